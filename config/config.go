@@ -1,61 +1,148 @@
-package config
-
-import (
-	"os"
-	"voucher_system/helper"
-
-	"github.com/joho/godotenv"
-)
-
-type Configuration struct {
-	AppName     string
-	Debug       bool
-	Port        string
-	DBConfig    DBConfig
-	RedisConfig RedisConfig
-	JwtKey      string
-	Migrate     bool
-}
-
-type DBConfig struct {
-	DBName         string
-	DBUsername     string
-	DBPassword     string
-	DBHost         string
-	DBTimeZone     string
-	DBMaxIdleConns int
-	DBMaxOpenConns int
-	DBMaxIdleTime  int
-	DBMaxLifeTime  int
-}
-
-type RedisConfig struct {
-	Url      string
-	Password string
-	Prefix   string
-}
-
-func ReadConfig() (Configuration, error) {
-	err := godotenv.Load()
-	if err != nil {
-		return Configuration{}, err
-	}
-	return Configuration{
-		AppName: os.Getenv("APP_NAME"),
-		Debug:   helper.StringToBool(os.Getenv("DEBUG")),
-		Port:    os.Getenv("PORT"),
-		JwtKey:  os.Getenv("JWT_KEY"),
-		Migrate:   helper.StringToBool(os.Getenv("MIGRATE")),
-		DBConfig: DBConfig{
-			DBName:         os.Getenv("DB_NAME"),
-			DBUsername:     os.Getenv("DB_USERNAME"),
-			DBPassword:     os.Getenv("DB_PASSWORD"),
-			DBHost:         os.Getenv("DB_HOST"),
-			DBTimeZone:     os.Getenv("DB_TIMEZONE"),
-			DBMaxIdleConns: helper.StringToInt(os.Getenv("DB_MAX_IDLE_CONNS")),
-			DBMaxOpenConns: helper.StringToInt(os.Getenv("DB_MAX_OPEN_CONNS")),
-			DBMaxIdleTime:  helper.StringToInt(os.Getenv("DB_MAX_IDLE_TIME")),
-			DBMaxLifeTime:  helper.StringToInt(os.Getenv("DB_MAX_LIFE_TIME")),
-		},
-	}, nil
-}
+package config
+
+import (
+	"os"
+	"voucher_system/helper"
+
+	"github.com/joho/godotenv"
+)
+
+type Configuration struct {
+	AppName     string
+	Debug       bool
+	Port        string
+	DBConfig    DBConfig
+	RedisConfig RedisConfig
+	JwtKey      string
+	TokenConfig TokenConfig
+	// StepUpMaxAge is how many minutes a login counts as recent for sensitive routes.
+	StepUpMaxAge int
+	// IdempotencyTTL is how many hours a response is replayed for a reused Idempotency-Key.
+	IdempotencyTTL int
+	Migrate        bool
+	// BaseCurrency is the currency reports are made in. FXRates converts other
+	// currencies into it, e.g. "SGD=11750,MYR=3550".
+	BaseCurrency string
+	FXRates      []string
+	QueueConfig  QueueConfig
+	MailConfig   MailConfig
+}
+
+type DBConfig struct {
+	DBName         string
+	DBUsername     string
+	DBPassword     string
+	DBHost         string
+	DBTimeZone     string
+	DBMaxIdleConns int
+	DBMaxOpenConns int
+	DBMaxIdleTime  int
+	DBMaxLifeTime  int
+}
+
+// TokenConfig selects the access token format. AcceptFormats lists every format the
+// middleware still accepts, e.g. "jwt,paseto-v4-public" while migrating.
+type TokenConfig struct {
+	Format          string
+	AcceptFormats   []string
+	PasetoSecretKey string
+	PasetoPublicKey string
+	PasetoLocalKey  string
+}
+
+// QueueConfig tunes the background job queue; zero values keep the queue's defaults.
+type QueueConfig struct {
+	Concurrency int
+	// VisibilityTimeout is how many seconds a task may run before it is retried.
+	VisibilityTimeout int
+	MaxAttempts       int
+}
+
+// MailConfig is the SMTP server used to send emails such as verification codes.
+type MailConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type RedisConfig struct {
+	Url      string
+	Password string
+	Prefix   string
+}
+
+func ReadConfig() (Configuration, error) {
+	err := godotenv.Load()
+	if err != nil {
+		return Configuration{}, err
+	}
+	return Configuration{
+		AppName:        os.Getenv("APP_NAME"),
+		Debug:          helper.StringToBool(os.Getenv("DEBUG")),
+		Port:           os.Getenv("PORT"),
+		JwtKey:         os.Getenv("JWT_KEY"),
+		StepUpMaxAge:   stepUpMaxAge(os.Getenv("STEP_UP_MAX_AGE")),
+		IdempotencyTTL: idempotencyTTL(os.Getenv("IDEMPOTENCY_TTL")),
+		Migrate:        helper.StringToBool(os.Getenv("MIGRATE")),
+		BaseCurrency:   os.Getenv("BASE_CURRENCY"),
+		FXRates:        helper.StringToSlice(os.Getenv("FX_RATES")),
+		DBConfig: DBConfig{
+			DBName:         os.Getenv("DB_NAME"),
+			DBUsername:     os.Getenv("DB_USERNAME"),
+			DBPassword:     os.Getenv("DB_PASSWORD"),
+			DBHost:         os.Getenv("DB_HOST"),
+			DBTimeZone:     os.Getenv("DB_TIMEZONE"),
+			DBMaxIdleConns: helper.StringToInt(os.Getenv("DB_MAX_IDLE_CONNS")),
+			DBMaxOpenConns: helper.StringToInt(os.Getenv("DB_MAX_OPEN_CONNS")),
+			DBMaxIdleTime:  helper.StringToInt(os.Getenv("DB_MAX_IDLE_TIME")),
+			DBMaxLifeTime:  helper.StringToInt(os.Getenv("DB_MAX_LIFE_TIME")),
+		},
+		TokenConfig: TokenConfig{
+			Format:          os.Getenv("TOKEN_FORMAT"),
+			AcceptFormats:   helper.StringToSlice(os.Getenv("TOKEN_ACCEPT_FORMATS")),
+			PasetoSecretKey: os.Getenv("PASETO_SECRET_KEY"),
+			PasetoPublicKey: os.Getenv("PASETO_PUBLIC_KEY"),
+			PasetoLocalKey:  os.Getenv("PASETO_LOCAL_KEY"),
+		},
+		RedisConfig: RedisConfig{
+			Url:      os.Getenv("REDIS_URL"),
+			Password: os.Getenv("REDIS_PASSWORD"),
+			Prefix:   os.Getenv("REDIS_PREFIX"),
+		},
+		QueueConfig: QueueConfig{
+			Concurrency:       helper.StringToInt(os.Getenv("QUEUE_CONCURRENCY")),
+			VisibilityTimeout: helper.StringToInt(os.Getenv("QUEUE_VISIBILITY_TIMEOUT")),
+			MaxAttempts:       helper.StringToInt(os.Getenv("QUEUE_MAX_ATTEMPTS")),
+		},
+		MailConfig: MailConfig{
+			Host:     os.Getenv("MAIL_HOST"),
+			Port:     mailPort(os.Getenv("MAIL_PORT")),
+			Username: os.Getenv("MAIL_USERNAME"),
+			Password: os.Getenv("MAIL_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		},
+	}, nil
+}
+
+func stepUpMaxAge(value string) int {
+	if minutes := helper.StringToInt(value); minutes > 0 {
+		return minutes
+	}
+	return 5
+}
+
+func idempotencyTTL(value string) int {
+	if hours := helper.StringToInt(value); hours > 0 {
+		return hours
+	}
+	return 24
+}
+
+func mailPort(value string) string {
+	if value == "" {
+		return "587"
+	}
+	return value
+}
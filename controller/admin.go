@@ -0,0 +1,294 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"voucher_system/database"
+	"voucher_system/helper"
+	pointsledger "voucher_system/repository/points_ledger"
+	"voucher_system/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AdminController struct {
+	service service.Service
+	log     *zap.Logger
+	Cacher  database.Cacher
+}
+
+func NewAdminController(service service.Service, log *zap.Logger, cacher database.Cacher) AdminController {
+	return AdminController{service: service, log: log, Cacher: cacher}
+}
+
+type UserStatusRequest struct {
+	Disabled *bool `json:"disabled" binding:"required" example:"true"`
+}
+
+type PointsAdjustmentRequest struct {
+	Points      int    `json:"points" binding:"required" example:"100"`
+	Description string `json:"description" binding:"required" example:"Compensation for order 1234"`
+}
+
+type UserRoleRequest struct {
+	Role string `json:"role" binding:"required" example:"admin"`
+}
+
+func adminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrSelfManagement),
+		errors.Is(err, service.ErrZeroAdjustment), errors.Is(err, pointsledger.ErrInsufficientPoints):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// SearchUsers godoc
+// @Summary Search users
+// @Description Paginated search of users by email or name
+// @Tags Admin
+// @Produce json
+// @Param q query string false "Part of the email or name"
+// @Param page query int false "Page, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} utils.ResponseOK{data=service.UserPage} "Users fetched successfully"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Security Authentication
+// @Router /admin/users [get]
+func (a *AdminController) SearchUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	result, err := a.service.Admin.SearchUsers(c.Query("q"), page, limit)
+	if err != nil {
+		a.log.Error("Failed to search users", zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to search users", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, result, "Users fetched successfully", http.StatusOK)
+}
+
+// GetUser godoc
+// @Summary Get a user
+// @Description Get a user with their redeem and usage counts
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.ResponseOK{data=repository.UserSummary} "User fetched successfully"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Security Authentication
+// @Router /admin/users/{id} [get]
+func (a *AdminController) GetUser(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := a.service.Admin.GetUserSummary(userID)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "User not found", adminErrorStatus(err))
+		return
+	}
+
+	helper.ResponseOK(c, summary, "User fetched successfully", http.StatusOK)
+}
+
+// SetUserStatus godoc
+// @Summary Disable or enable a user
+// @Description Disabled users cannot log in and their existing tokens are rejected
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param userStatusRequest body UserStatusRequest true "New status"
+// @Success 200 {object} utils.ResponseOK "Status updated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid input"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Security Authentication
+// @Router /admin/users/{id}/status [put]
+func (a *AdminController) SetUserStatus(c *gin.Context) {
+	adminID, userID, ok := a.adminAndTarget(c)
+	if !ok {
+		return
+	}
+
+	var req UserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.service.Admin.SetUserDisabled(adminID, userID, *req.Disabled); err != nil {
+		a.log.Error("Failed to change user status", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to change user status", adminErrorStatus(err))
+		return
+	}
+
+	if *req.Disabled {
+		a.revokeSession(userID)
+	}
+
+	helper.ResponseOK(c, gin.H{"id": userID, "disabled": *req.Disabled}, "User status updated successfully", http.StatusOK)
+}
+
+// SetUserRole godoc
+// @Summary Assign a role
+// @Description Assign the customer or admin role to a user. The user has to log in again.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param userRoleRequest body UserRoleRequest true "New role"
+// @Success 200 {object} utils.ResponseOK "Role updated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid role"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Security Authentication
+// @Router /admin/users/{id}/role [put]
+func (a *AdminController) SetUserRole(c *gin.Context) {
+	adminID, userID, ok := a.adminAndTarget(c)
+	if !ok {
+		return
+	}
+
+	var req UserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.service.Admin.SetUserRole(adminID, userID, req.Role); err != nil {
+		a.log.Error("Failed to change user role", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to change user role", adminErrorStatus(err))
+		return
+	}
+	a.revokeSession(userID)
+
+	helper.ResponseOK(c, gin.H{"id": userID, "role": req.Role}, "User role updated successfully", http.StatusOK)
+}
+
+// ResetUserPassword godoc
+// @Summary Force a password reset
+// @Description Replace the password with a temporary one, sign the user out and require a new password after the next login
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.ResponseOK "Temporary password"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Security Authentication
+// @Router /admin/users/{id}/reset-password [post]
+func (a *AdminController) ResetUserPassword(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	temporary, err := a.service.Admin.ResetPassword(userID)
+	if err != nil {
+		a.log.Error("Failed to reset password", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to reset password", adminErrorStatus(err))
+		return
+	}
+	a.revokeSession(userID)
+
+	helper.ResponseOK(c, gin.H{"id": userID, "temporary_password": temporary}, "Password reset successfully", http.StatusOK)
+}
+
+// AdjustPoints godoc
+// @Summary Adjust points
+// @Description Credit (positive) or debit (negative) points of a user. The balance cannot go below zero.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param pointsAdjustmentRequest body PointsAdjustmentRequest true "Adjustment"
+// @Success 200 {object} utils.ResponseOK{data=models.PointsLedgerEntry} "Points adjusted"
+// @Failure 400 {object} utils.ErrorResponse "Invalid adjustment"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Security Authentication
+// @Router /admin/users/{id}/points [post]
+func (a *AdminController) AdjustPoints(c *gin.Context) {
+	adminID, userID, ok := a.adminAndTarget(c)
+	if !ok {
+		return
+	}
+
+	var req PointsAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := a.service.Points.AdjustPoints(adminID, userID, req.Points, req.Description)
+	if err != nil {
+		a.log.Error("Failed to adjust points", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to adjust points", adminErrorStatus(err))
+		return
+	}
+
+	helper.ResponseOK(c, entry, "Points adjusted successfully", http.StatusOK)
+}
+
+func (a *AdminController) adminAndTarget(c *gin.Context) (int, int, bool) {
+	adminID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid user ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	return adminID, userID, true
+}
+
+func (a *AdminController) revokeSession(userID int) {
+	if err := a.Cacher.Delete(helper.IntToString(userID)); err != nil {
+		a.log.Error("Failed to revoke session", zap.Int("userID", userID), zap.Error(err))
+	}
+}
+
+// GetUsageReport godoc
+// @Summary Voucher usage report
+// @Description Voucher usage per currency and in the base currency, converted with the static exchange rates. Both dates are inclusive and default to the last 30 days.
+// @Tags Admin
+// @Produce json
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD"
+// @Success 200 {object} utils.ResponseOK{data=service.UsageReport} "Report fetched successfully"
+// @Failure 400 {object} utils.ErrorResponse "Invalid date"
+// @Security Authentication
+// @Router /admin/reports/usage [get]
+func (a *AdminController) GetUsageReport(c *gin.Context) {
+	today := time.Now()
+	from, err := time.Parse("2006-01-02", c.DefaultQuery("from", today.AddDate(0, 0, -29).Format("2006-01-02")))
+	if err != nil {
+		helper.ResponseError(c, "from must be in format YYYY-MM-DD", "Invalid date", http.StatusBadRequest)
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.DefaultQuery("to", today.Format("2006-01-02")))
+	if err != nil || to.Before(from) {
+		helper.ResponseError(c, "to must be in format YYYY-MM-DD and not before from", "Invalid date", http.StatusBadRequest)
+		return
+	}
+
+	report, err := a.service.Report.UsageReport(from, to.AddDate(0, 0, 1))
+	if err != nil {
+		a.log.Error("Failed to build usage report", zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to build usage report", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, report, "Report fetched successfully", http.StatusOK)
+}
@@ -1,23 +1,35 @@
-package controller
-
-import (
-	managementvoucherhandler "voucher_system/controller/management_voucher_handler"
-	"voucher_system/database"
-	"voucher_system/service"
-
-	"go.uber.org/zap"
-)
-
-type Controller struct {
-	User    AuthController
-	Manage  managementvoucherhandler.ManageVoucherHandler
-	Voucher VoucherController
-}
-
-func NewController(service service.Service, logger *zap.Logger, cacher database.Cacher) *Controller {
-	return &Controller{
-		User:    NewAuthController(service, logger, cacher),
-		Manage:  managementvoucherhandler.NewManagementVoucherHanlder(service, logger),
-		Voucher: *NewVoucherController(service, logger),
-	}
-}
+package controller
+
+import (
+	managementvoucherhandler "voucher_system/controller/management_voucher_handler"
+	"voucher_system/database"
+	"voucher_system/jobs"
+	"voucher_system/service"
+	"voucher_system/utils"
+
+	"go.uber.org/zap"
+)
+
+type Controller struct {
+	User        AuthController
+	Manage      managementvoucherhandler.ManageVoucherHandler
+	Voucher     VoucherController
+	Reservation ReservationController
+	Admin       AdminController
+	Points      PointsController
+	Tier        TierController
+	Job         JobController
+}
+
+func NewController(service service.Service, logger *zap.Logger, cacher database.Cacher, mailer utils.Mailer, scheduler *jobs.Scheduler, queue *jobs.Queue) *Controller {
+	return &Controller{
+		User:        NewAuthController(service, logger, cacher, mailer),
+		Manage:      managementvoucherhandler.NewManagementVoucherHanlder(service, logger),
+		Voucher:     *NewVoucherController(service, logger),
+		Reservation: NewReservationController(service, logger),
+		Admin:       NewAdminController(service, logger, cacher),
+		Points:      NewPointsController(service, logger),
+		Tier:        NewTierController(service, logger),
+		Job:         NewJobController(scheduler, queue, logger),
+	}
+}
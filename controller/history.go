@@ -1,51 +1,110 @@
-package controller
-
-import (
-	"net/http"
-	"strconv"
-	"voucher_system/helper"
-
-	"github.com/gin-gonic/gin"
-	"go.uber.org/zap"
-)
-
-func (c *VoucherController) GetRedeemHistoryByUser(ctx *gin.Context) {
-	userID, err := strconv.Atoi(ctx.Param("user_id"))
-	if err != nil {
-		helper.ResponseError(ctx, "Invalid user ID", err.Error(), http.StatusBadRequest)
-		return
-	}
-	redeems, err := c.service.History.GetRedeemHistoryByUser(userID)
-	if err != nil {
-		c.log.Error("Error fetching redeem history", zap.Error(err))
-		helper.ResponseError(ctx, "Failed to fetch history", err.Error(), http.StatusInternalServerError)
-		return
-	}
-	helper.ResponseOK(ctx, gin.H{"redeem_history": redeems}, "Redeem history fetched successfully", http.StatusOK)
-}
-
-func (c *VoucherController) GetUsageHistoryByUser(ctx *gin.Context) {
-	userID, err := strconv.Atoi(ctx.Param("user_id"))
-	if err != nil {
-		helper.ResponseError(ctx, "Invalid user ID", err.Error(), http.StatusBadRequest)
-		return
-	}
-	histories, err := c.service.History.GetUsageHistoryByUser(userID)
-	if err != nil {
-		c.log.Error("Error fetching usage history", zap.Error(err))
-		helper.ResponseError(ctx, "Failed to fetch usage history", err.Error(), http.StatusInternalServerError)
-		return
-	}
-	helper.ResponseOK(ctx, gin.H{"usage_history": histories}, "Usage history fetched successfully", http.StatusOK)
-}
-
-func (c *VoucherController) GetUsersByVoucherCode(ctx *gin.Context) {
-	voucherCode := ctx.Param("voucher_code")
-	redeems, err := c.service.History.GetUsersByVoucherCode(voucherCode)
-	if err != nil {
-		c.log.Error("Error fetching users by voucher", zap.Error(err))
-		helper.ResponseError(ctx, "Failed to fetch users", err.Error(), http.StatusInternalServerError)
-		return
-	}
-	helper.ResponseOK(ctx, gin.H{"users": redeems}, "Users fetched successfully", http.StatusOK)
-}
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"voucher_system/helper"
+	"voucher_system/repository"
+	"voucher_system/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ReverseUsageRequest struct {
+	UsageID int `json:"usage_id" example:"15"`
+	// UserID is required with OrderID, as order ids are only unique per user.
+	UserID  int    `json:"user_id" example:"1"`
+	OrderID string `json:"order_id" example:"INV-2024-0001"`
+	Reason  string `json:"reason" example:"Order cancelled by customer"`
+	// RestoreQuota gives the used unit back to the voucher, true by default.
+	RestoreQuota *bool `json:"restore_quota" example:"true"`
+}
+
+func (c *VoucherController) GetRedeemHistoryByUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		helper.ResponseError(ctx, "Invalid user ID", err.Error(), http.StatusBadRequest)
+		return
+	}
+	redeems, err := c.service.History.GetRedeemHistoryByUser(userID)
+	if err != nil {
+		c.log.Error("Error fetching redeem history", zap.Error(err))
+		helper.ResponseError(ctx, "Failed to fetch history", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	helper.ResponseOK(ctx, gin.H{"redeem_history": redeems}, "Redeem history fetched successfully", http.StatusOK)
+}
+
+func (c *VoucherController) GetUsageHistoryByUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		helper.ResponseError(ctx, "Invalid user ID", err.Error(), http.StatusBadRequest)
+		return
+	}
+	histories, err := c.service.History.GetUsageHistoryByUser(userID)
+	if err != nil {
+		c.log.Error("Error fetching usage history", zap.Error(err))
+		helper.ResponseError(ctx, "Failed to fetch usage history", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	helper.ResponseOK(ctx, gin.H{"usage_history": histories}, "Usage history fetched successfully", http.StatusOK)
+}
+
+func (c *VoucherController) GetUsersByVoucherCode(ctx *gin.Context) {
+	voucherCode := ctx.Param("voucher_code")
+	redeems, err := c.service.History.GetUsersByVoucherCode(voucherCode)
+	if err != nil {
+		c.log.Error("Error fetching users by voucher", zap.Error(err))
+		helper.ResponseError(ctx, "Failed to fetch users", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	helper.ResponseOK(ctx, gin.H{"users": redeems}, "Users fetched successfully", http.StatusOK)
+}
+
+// ReverseUsage godoc
+// @Summary Reverse a voucher usage
+// @Description Undo the voucher usage of a cancelled order, found by usage id or by order id and user id. The usage stays in the usage history marked as reversed, the points it earned that are not spent yet are taken back, the user keeps their redeem of the voucher and the used unit goes back to the quota unless restore_quota is false.
+// @Tags Vouchers
+// @Accept json
+// @Produce json
+// @Param request body ReverseUsageRequest true "Usage to reverse"
+// @Success 200 {object} utils.ResponseOK{data=models.History} "Voucher usage reversed"
+// @Failure 400 {object} utils.ErrorResponse "Neither usage_id nor order_id with user_id given"
+// @Failure 404 {object} utils.ErrorResponse "Usage not found"
+// @Failure 409 {object} utils.ErrorResponse "Usage already reversed"
+// @Security Authentication
+// @Router /admin/usages/reverse [post]
+func (c *VoucherController) ReverseUsage(ctx *gin.Context) {
+	var request ReverseUsageRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		helper.ResponseError(ctx, "Invalid input", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	restoreQuota := request.RestoreQuota == nil || *request.RestoreQuota
+	history, err := c.service.History.ReverseUsage(repository.UsageReversal{
+		UsageID:      request.UsageID,
+		UserID:       request.UserID,
+		OrderID:      request.OrderID,
+		Reason:       request.Reason,
+		RestoreQuota: restoreQuota,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrNoUsageToReverse):
+			status = http.StatusBadRequest
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, repository.ErrAlreadyReversed):
+			status = http.StatusConflict
+		}
+		c.log.Error("Error reversing voucher usage", zap.Error(err))
+		helper.ResponseError(ctx, "Failed to reverse voucher usage", err.Error(), status)
+		return
+	}
+	helper.ResponseOK(ctx, history, "Voucher usage reversed", http.StatusOK)
+}
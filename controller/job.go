@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"voucher_system/helper"
+	"voucher_system/jobs"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type JobController struct {
+	scheduler *jobs.Scheduler
+	queue     *jobs.Queue
+	log       *zap.Logger
+}
+
+func NewJobController(scheduler *jobs.Scheduler, queue *jobs.Queue, log *zap.Logger) JobController {
+	return JobController{scheduler: scheduler, queue: queue, log: log}
+}
+
+// ListJobs godoc
+// @Summary List background jobs
+// @Description List the scheduled background jobs with their schedule, next run on this instance and last run on any instance
+// @Tags Jobs
+// @Produce json
+// @Success 200 {object} utils.ResponseOK{data=[]jobs.JobStatus} "Jobs fetched successfully"
+// @Failure 500 {object} utils.ErrorResponse "Failed to fetch jobs"
+// @Security Authentication
+// @Router /admin/jobs [get]
+func (j *JobController) ListJobs(c *gin.Context) {
+	statuses, err := j.scheduler.Jobs()
+	if err != nil {
+		j.log.Error("Failed to list jobs", zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to fetch jobs", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, statuses, "Jobs fetched successfully", http.StatusOK)
+}
+
+// ListJobRuns godoc
+// @Summary List runs of a job
+// @Description List the latest runs of a background job, newest first
+// @Tags Jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Param limit query int false "Number of runs, 20 by default and at most 100"
+// @Success 200 {object} utils.ResponseOK{data=[]models.JobRun} "Job runs fetched successfully"
+// @Failure 404 {object} utils.ErrorResponse "Job not found"
+// @Security Authentication
+// @Router /admin/jobs/{name}/runs [get]
+func (j *JobController) ListJobRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		helper.ResponseError(c, "Invalid limit", "limit must be between 1 and 100", http.StatusBadRequest)
+		return
+	}
+
+	runs, err := j.scheduler.Runs(c.Param("name"), limit)
+	if errors.Is(err, jobs.ErrJobNotFound) {
+		helper.ResponseError(c, err.Error(), "Job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		j.log.Error("Failed to list job runs", zap.String("job", c.Param("name")), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to fetch job runs", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, runs, "Job runs fetched successfully", http.StatusOK)
+}
+
+// TriggerJob godoc
+// @Summary Run a job now
+// @Description Start a background job right away instead of waiting for its schedule. The job runs in the background; its outcome shows up in the job runs.
+// @Tags Jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 202 {object} utils.ResponseOK{data=models.JobRun} "Job started"
+// @Failure 404 {object} utils.ErrorResponse "Job not found"
+// @Failure 409 {object} utils.ErrorResponse "Job is already running"
+// @Security Authentication
+// @Router /admin/jobs/{name}/trigger [post]
+func (j *JobController) TriggerJob(c *gin.Context) {
+	name := c.Param("name")
+	run, err := j.scheduler.Trigger(name)
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound):
+		helper.ResponseError(c, err.Error(), "Job not found", http.StatusNotFound)
+		return
+	case errors.Is(err, jobs.ErrJobRunning):
+		helper.ResponseError(c, err.Error(), "Job is already running", http.StatusConflict)
+		return
+	case err != nil:
+		j.log.Error("Failed to trigger job", zap.String("job", name), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to start job", http.StatusInternalServerError)
+		return
+	}
+
+	j.log.Info("Job triggered", zap.String("job", name), zap.Int("runID", run.ID))
+	helper.ResponseOK(c, run, "Job started", http.StatusAccepted)
+}
+
+// GetQueueStats godoc
+// @Summary Show job queue sizes
+// @Description Count the queued tasks that are ready, waiting for a retry, running or dead-lettered
+// @Tags Jobs
+// @Produce json
+// @Success 200 {object} utils.ResponseOK{data=database.QueueLengths} "Queue stats fetched successfully"
+// @Failure 500 {object} utils.ErrorResponse "Failed to fetch queue stats"
+// @Security Authentication
+// @Router /admin/queue [get]
+func (j *JobController) GetQueueStats(c *gin.Context) {
+	stats, err := j.queue.Stats()
+	if err != nil {
+		j.log.Error("Failed to fetch queue stats", zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to fetch queue stats", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, stats, "Queue stats fetched successfully", http.StatusOK)
+}
+
+// ListDeadLetters godoc
+// @Summary List dead-lettered tasks
+// @Description List the queued tasks that failed on every attempt, oldest first
+// @Tags Jobs
+// @Produce json
+// @Param offset query int false "Tasks to skip"
+// @Param limit query int false "Number of tasks, 20 by default and at most 100"
+// @Success 200 {object} utils.ResponseOK{data=[]jobs.Task} "Dead letters fetched successfully"
+// @Failure 400 {object} utils.ErrorResponse "Invalid offset or limit"
+// @Security Authentication
+// @Router /admin/queue/dead-letters [get]
+func (j *JobController) ListDeadLetters(c *gin.Context) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		helper.ResponseError(c, "Invalid offset", "offset must not be negative", http.StatusBadRequest)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		helper.ResponseError(c, "Invalid limit", "limit must be between 1 and 100", http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := j.queue.DeadLetters(offset, limit)
+	if err != nil {
+		j.log.Error("Failed to list dead letters", zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to fetch dead letters", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, tasks, "Dead letters fetched successfully", http.StatusOK)
+}
+
+// RequeueDeadLetter godoc
+// @Summary Requeue a dead-lettered task
+// @Description Move a dead-lettered task back to the queue with its attempts reset
+// @Tags Jobs
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} utils.ResponseOK{data=jobs.Task} "Task requeued"
+// @Failure 404 {object} utils.ErrorResponse "Task not found"
+// @Security Authentication
+// @Router /admin/queue/dead-letters/{id}/requeue [post]
+func (j *JobController) RequeueDeadLetter(c *gin.Context) {
+	task, err := j.queue.Requeue(c.Param("id"))
+	if errors.Is(err, jobs.ErrTaskNotFound) {
+		helper.ResponseError(c, err.Error(), "Task not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		j.log.Error("Failed to requeue task", zap.String("taskID", c.Param("id")), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to requeue task", http.StatusInternalServerError)
+		return
+	}
+
+	j.log.Info("Task requeued", zap.String("taskID", task.ID), zap.String("type", task.Type))
+	helper.ResponseOK(c, task, "Task requeued", http.StatusOK)
+}
@@ -1,224 +1,563 @@
-package managementvoucherhandler
-
-import (
-	"net/http"
-	"strconv"
-	"voucher_system/helper"
-	"voucher_system/models"
-	"voucher_system/service"
-
-	"github.com/gin-gonic/gin"
-	"go.uber.org/zap"
-)
-
-type ManageVoucherHandler interface {
-	CreateVoucher(c *gin.Context)
-	SoftDeleteVoucher(c *gin.Context)
-	UpdateVoucher(c *gin.Context)
-	ShowRedeemPoints(c *gin.Context)
-	GetVouchersByQueryParams(c *gin.Context)
-	CreateRedeemVoucher(c *gin.Context)
-}
-
-type ManagementVoucherHandler struct {
-	service service.Service
-	log     *zap.Logger
-}
-
-func NewManagementVoucherHanlder(service service.Service, log *zap.Logger) ManageVoucherHandler {
-	return &ManagementVoucherHandler{service: service, log: log}
-}
-
-// CreateVoucher godoc
-// @Summary Create a new voucher
-// @Description Create a new voucher with provided details
-// @Tags Vouchers
-// @Accept json
-// @Produce json
-// @Param voucher body models.Voucher true "Voucher details"
-// @Success 200 {object} utils.ResponseOK{data=models.Voucher} "Created successfully"
-// @Failure 400 {object} utils.ErrorResponse "Invalid payload"
-// @Failure 500 {object} utils.ErrorResponse "Failed to create voucher"
-// @Security Authentication
-// @Security UserID
-// @Router /vouchers/create [post]
-func (mh *ManagementVoucherHandler) CreateVoucher(c *gin.Context) {
-
-	var voucher models.Voucher
-
-	err := c.ShouldBindJSON(&voucher)
-	if err != nil {
-		mh.log.Error("Invalid payload", zap.Error(err))
-		helper.ResponseError(c, "INVALID", "Invalid Payload"+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	err = mh.service.Manage.CreateVoucher(&voucher)
-	if err != nil {
-		mh.log.Error("Failed to create", zap.Error(err))
-		helper.ResponseError(c, "FAILED", "Failed to create Voucher", http.StatusBadRequest)
-		return
-	}
-
-	mh.log.Info("Create Voucher successfully")
-	helper.ResponseOK(c, voucher, "Created successfully", http.StatusOK)
-}
-
-// SoftDeleteVoucher godoc
-// @Summary Soft delete a voucher
-// @Description Soft delete a voucher by ID
-// @Tags Vouchers
-// @Param id path int true "Voucher ID"
-// @Success 200 {object} utils.ResponseOK "Deleted successfully"
-// @Failure 500 {object} utils.ErrorResponse "Failed to delete voucher"
-// @Security Authentication
-// @Security UserID
-// @Router /vouchers/{id} [delete]
-func (mh *ManagementVoucherHandler) SoftDeleteVoucher(c *gin.Context) {
-	id, _ := strconv.Atoi(c.Param("id"))
-
-	err := mh.service.Manage.SoftDeleteVoucher(id)
-	if err != nil {
-		mh.log.Error("Failed to Deleted", zap.Error(err))
-		helper.ResponseError(c, "FAILED", "Failed to deleted Voucher", http.StatusInternalServerError)
-		return
-	}
-
-	mh.log.Info("Deleted Voucher successfully")
-	helper.ResponseOK(c, id, "Deleted succesfully", http.StatusOK)
-}
-
-// UpdateVoucher godoc
-// @Summary Update a voucher
-// @Description Update a voucher by ID
-// @Tags Vouchers
-// @Accept json
-// @Produce json
-// @Param id path int true "Voucher ID"
-// @Param voucher body models.Voucher true "Updated voucher details"
-// @Success 200 {object} utils.ResponseOK{data=models.Voucher} "Updated successfully"
-// @Failure 400 {object} utils.ErrorResponse "Invalid payload"
-// @Failure 500 {object} utils.ErrorResponse "Failed to update voucher"
-// @Security Authentication
-// @Security UserID
-// @Router /vouchers/{id} [put]
-func (mh *ManagementVoucherHandler) UpdateVoucher(c *gin.Context) {
-
-	id, _ := strconv.Atoi(c.Param("id"))
-	voucher := models.Voucher{}
-
-	if err := c.ShouldBindJSON(&voucher); err != nil {
-		mh.log.Error("Invalid payload", zap.Error(err))
-		helper.ResponseError(c, "INVALID", "Invalid Payload"+err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	err := mh.service.Manage.UpdateVoucher(&voucher, id)
-	if err != nil {
-		mh.log.Error("Failed to Updated Voucher", zap.Error(err))
-		helper.ResponseError(c, "FAILED", "Failed to Updated Voucher", http.StatusInternalServerError)
-		return
-	}
-
-	mh.log.Info("Updated Voucher successfully")
-	helper.ResponseOK(c, id, "updated succesfully", http.StatusOK)
-}
-
-// ShowRedeemPoints godoc
-// @Summary Show redeem points
-// @Description Retrieve the list of redeem points
-// @Tags Vouchers
-// @Produce json
-// @Success 200 {object} utils.ResponseOK{data=[]models.Redeem} "Redeem points retrieved successfully"
-// @Failure 404 {object} utils.ErrorResponse "Redeem points not found"
-// @Security Authentication
-// @Security UserID
-// @Router /vouchers/redeem-points [get]
-func (mh *ManagementVoucherHandler) ShowRedeemPoints(c *gin.Context) {
-
-	voucher, err := mh.service.Manage.ShowRedeemPoints()
-	if err != nil {
-		mh.log.Error("Failed to Get Reedem Points List", zap.Error(err))
-		helper.ResponseError(c, "NOT FOUND", "Reedem Points List Not Found", http.StatusNotFound)
-		return
-	}
-
-	mh.log.Info("Redeem points retrieved successfully")
-	helper.ResponseOK(c, voucher, "Redeem points retrieved successfully", http.StatusOK)
-
-}
-
-// GetVouchersByQueryParams godoc
-// @Summary Get vouchers by query parameters
-// @Description Retrieve vouchers based on status, area, and voucher type
-// @Tags Vouchers
-// @Produce json
-// @Param status query string false "Voucher status"
-// @Param area query string false "Voucher area"
-// @Param voucher_type query string false "Voucher type"
-// @Success 200 {object} utils.ResponseOK{data=[]models.Voucher} "Vouchers retrieved successfully"
-// @Failure 404 {object} utils.ErrorResponse "Vouchers not found"
-// @Security Authentication
-// @Security UserID
-// @Router /vouchers [get]
-func (mh *ManagementVoucherHandler) GetVouchersByQueryParams(c *gin.Context) {
-
-	status := c.Query("status")
-	area := c.Query("area")
-	voucher_type := c.Query("voucher_type")
-
-	voucher, err := mh.service.Manage.GetVouchersByQueryParams(status, area, voucher_type)
-	if err != nil {
-		mh.log.Error("Failed to Get Voucher List", zap.Error(err))
-		helper.ResponseError(c, "NOT FOUND", "Voucher Not Found", http.StatusNotFound)
-		return
-	}
-
-	mh.log.Info("Voucher retrieved successfully")
-	helper.ResponseOK(c, voucher, "Voucher retrieved successfully", http.StatusOK)
-
-}
-
-type RedeemRequest struct {
-	VoucherID int `json:"voucher_id" binding:"required"`
-	UserID    int `json:"user_id" binding:"required"`
-	Points    int `json:"points" binding:"required"`
-}
-
-// CreateRedeemVoucher godoc
-// @Summary Create a redeem voucher
-// @Description Redeem a voucher using points
-// @Tags Vouchers
-// @Accept json
-// @Produce json
-// @Param redeemRequest body RedeemRequest true "Redeem request payload"
-// @Success 200 {object} utils.ResponseOK{data=models.Redeem} "Redeem created successfully"
-// @Failure 400 {object} utils.ErrorResponse "Invalid payload"
-// @Failure 500 {object} utils.ErrorResponse "Failed to create redeem voucher"
-// @Security Authentication
-// @Security UserID
-// @Router /vouchers/redeem [post]
-func (mh *ManagementVoucherHandler) CreateRedeemVoucher(c *gin.Context) {
-	var RedeemRequest RedeemRequest
-	err := c.ShouldBindJSON(&RedeemRequest)
-	if err != nil {
-		mh.log.Error("Invalid payload", zap.Error(err))
-		helper.ResponseError(c, "INVALID", "Invalid Payload: "+err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	redeem := models.Redeem{
-		VoucherID: RedeemRequest.VoucherID,
-		UserID:    RedeemRequest.UserID,
-	}
-
-	err = mh.service.Manage.CreateRedeemVoucher(&redeem, RedeemRequest.Points)
-	if err != nil {
-		mh.log.Error("Failed to create redeem voucher", zap.Error(err))
-		helper.ResponseError(c, "FAILED", "Failed to create redeem voucher: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	mh.log.Info("Create Redeem Voucher successfully")
-	helper.ResponseOK(c, redeem, "Created successfully", http.StatusOK)
-}
+package managementvoucherhandler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"voucher_system/helper"
+	"voucher_system/models"
+	managementvoucher "voucher_system/repository/management_voucher"
+	pointsledger "voucher_system/repository/points_ledger"
+	"voucher_system/service"
+	"voucher_system/utils/money"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ManageVoucherHandler interface {
+	CreateVoucher(c *gin.Context)
+	SoftDeleteVoucher(c *gin.Context)
+	UpdateVoucher(c *gin.Context)
+	GetVoucherVersions(c *gin.Context)
+	RevertVoucher(c *gin.Context)
+	SubmitVoucher(c *gin.Context)
+	ApproveVoucher(c *gin.Context)
+	RejectVoucher(c *gin.Context)
+	PauseVoucher(c *gin.Context)
+	ResumeVoucher(c *gin.Context)
+	GenerateVoucherCodes(c *gin.Context)
+	AssignVoucherCode(c *gin.Context)
+	ExportVoucherCodes(c *gin.Context)
+	ShowRedeemPoints(c *gin.Context)
+	GetVouchersByQueryParams(c *gin.Context)
+	CreateRedeemVoucher(c *gin.Context)
+}
+
+type ManagementVoucherHandler struct {
+	service service.Service
+	log     *zap.Logger
+}
+
+func NewManagementVoucherHanlder(service service.Service, log *zap.Logger) ManageVoucherHandler {
+	return &ManagementVoucherHandler{service: service, log: log}
+}
+
+// CreateVoucher godoc
+// @Summary Create a new voucher
+// @Description Create a new voucher with provided details
+// @Tags Vouchers
+// @Accept json
+// @Produce json
+// @Param voucher body models.Voucher true "Voucher details"
+// @Success 200 {object} utils.ResponseOK{data=models.Voucher} "Created successfully"
+// @Failure 400 {object} utils.ErrorResponse "Invalid payload, discount, limits or currency"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 409 {object} utils.ErrorResponse "Voucher code taken by a generated code (CODE_TAKEN)"
+// @Failure 500 {object} utils.ErrorResponse "Failed to create voucher"
+// @Security Authentication
+// @Security UserID
+// @Router /vouchers/create [post]
+func (mh *ManagementVoucherHandler) CreateVoucher(c *gin.Context) {
+
+	var voucher models.Voucher
+
+	err := c.ShouldBindJSON(&voucher)
+	if err != nil {
+		mh.log.Error("Invalid payload", zap.Error(err))
+		helper.ResponseError(c, "INVALID", "Invalid Payload"+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if voucher.DiscountType == "" {
+		helper.ResponseError(c, "INVALID", models.ErrMissingDiscountType.Error(), http.StatusBadRequest)
+		return
+	}
+
+	actorID, ok := actor(c)
+	if !ok {
+		return
+	}
+
+	err = mh.service.Manage.CreateVoucher(&voucher, actorID)
+	if errors.Is(err, models.ErrCodeTaken) {
+		helper.ResponseError(c, "CODE_TAKEN", err.Error(), http.StatusConflict)
+		return
+	}
+	if invalidVoucher(err) {
+		helper.ResponseError(c, "INVALID", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		mh.log.Error("Failed to create", zap.Error(err))
+		helper.ResponseError(c, "FAILED", "Failed to create Voucher", http.StatusBadRequest)
+		return
+	}
+
+	mh.log.Info("Create Voucher successfully")
+	helper.ResponseOK(c, voucher, "Created successfully", http.StatusOK)
+}
+
+// SoftDeleteVoucher godoc
+// @Summary Soft delete a voucher
+// @Description Soft delete a voucher by ID
+// @Tags Vouchers
+// @Param id path int true "Voucher ID"
+// @Success 200 {object} utils.ResponseOK "Deleted successfully"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 500 {object} utils.ErrorResponse "Failed to delete voucher"
+// @Security Authentication
+// @Security UserID
+// @Router /vouchers/{id} [delete]
+func (mh *ManagementVoucherHandler) SoftDeleteVoucher(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	actorID, ok := actor(c)
+	if !ok {
+		return
+	}
+
+	err := mh.service.Manage.SoftDeleteVoucher(id, actorID)
+	if err != nil {
+		mh.log.Error("Failed to Deleted", zap.Error(err))
+		helper.ResponseError(c, "FAILED", "Failed to deleted Voucher", http.StatusInternalServerError)
+		return
+	}
+
+	mh.log.Info("Deleted Voucher successfully")
+	helper.ResponseOK(c, id, "Deleted succesfully", http.StatusOK)
+}
+
+// UpdateVoucher godoc
+// @Summary Update a voucher
+// @Description Update a voucher by ID. A voucher that is past the draft stage goes back to pending approval and has to be approved by another admin before the new terms are used.
+// @Tags Vouchers
+// @Accept json
+// @Produce json
+// @Param id path int true "Voucher ID"
+// @Param voucher body models.Voucher true "Updated voucher details"
+// @Success 200 {object} utils.ResponseOK{data=models.Voucher} "Updated successfully"
+// @Failure 400 {object} utils.ErrorResponse "Invalid payload, discount, limits or currency"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 409 {object} utils.ErrorResponse "Voucher code taken by a generated code (CODE_TAKEN)"
+// @Failure 500 {object} utils.ErrorResponse "Failed to update voucher"
+// @Security Authentication
+// @Security UserID
+// @Router /vouchers/{id} [put]
+func (mh *ManagementVoucherHandler) UpdateVoucher(c *gin.Context) {
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	voucher := models.Voucher{}
+
+	if err := c.ShouldBindJSON(&voucher); err != nil {
+		mh.log.Error("Invalid payload", zap.Error(err))
+		helper.ResponseError(c, "INVALID", "Invalid Payload"+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	actorID, ok := actor(c)
+	if !ok {
+		return
+	}
+
+	err := mh.service.Manage.UpdateVoucher(&voucher, id, actorID)
+	if errors.Is(err, models.ErrCodeTaken) {
+		helper.ResponseError(c, "CODE_TAKEN", err.Error(), http.StatusConflict)
+		return
+	}
+	if invalidVoucher(err) {
+		helper.ResponseError(c, "INVALID", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		mh.log.Error("Failed to Updated Voucher", zap.Error(err))
+		helper.ResponseError(c, "FAILED", "Failed to Updated Voucher", http.StatusInternalServerError)
+		return
+	}
+
+	mh.log.Info("Updated Voucher successfully")
+	helper.ResponseOK(c, id, "updated succesfully", http.StatusOK)
+}
+
+// GetVoucherVersions godoc
+// @Summary List voucher versions
+// @Description List every recorded version of a voucher, newest first, with the snapshot of its terms and the fields each change touched. Usage history rows carry the voucher_version that was applied.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Voucher ID"
+// @Success 200 {object} utils.ResponseOK{data=[]models.VoucherVersion} "Voucher versions retrieved successfully"
+// @Failure 400 {object} utils.ErrorResponse "Invalid voucher ID"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 500 {object} utils.ErrorResponse "Failed to get voucher versions"
+// @Security Authentication
+// @Security UserID
+// @Router /admin/vouchers/{id}/versions [get]
+func (mh *ManagementVoucherHandler) GetVoucherVersions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, "INVALID", "Invalid voucher ID", http.StatusBadRequest)
+		return
+	}
+
+	versions, err := mh.service.Manage.FindVersions(id)
+	if err != nil {
+		mh.log.Error("Failed to get voucher versions", zap.Error(err))
+		helper.ResponseError(c, "FAILED", "Failed to get voucher versions", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, versions, "Voucher versions retrieved successfully", http.StatusOK)
+}
+
+// invalidVoucher reports whether err is a voucher that failed validation, which the
+// client has to fix rather than retry.
+func invalidVoucher(err error) bool {
+	return errors.Is(err, models.ErrInvalidDiscount) ||
+		errors.Is(err, models.ErrInvalidLimit) ||
+		errors.Is(err, money.ErrUnsupportedCurrency)
+}
+
+// RevertVoucher godoc
+// @Summary Revert a voucher to an earlier version
+// @Description Restore the terms a voucher had at the given version. The revert is recorded as a new version; the remaining quota is kept. Like an update, it sends a voucher past the draft stage back to pending approval.
+// @Tags Vouchers
+// @Produce json
+// @Param id path int true "Voucher ID"
+// @Param version path int true "Version to restore"
+// @Success 200 {object} utils.ResponseOK{data=models.VoucherVersion} "Voucher reverted successfully"
+// @Failure 400 {object} utils.ErrorResponse "Invalid voucher ID or version"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} utils.ErrorResponse "Voucher or version not found"
+// @Failure 409 {object} utils.ErrorResponse "Restored voucher code is already a generated code (CODE_TAKEN)"
+// @Failure 500 {object} utils.ErrorResponse "Failed to revert voucher"
+// @Security Authentication
+// @Security UserID
+// @Router /vouchers/{id}/versions/{version}/revert [post]
+func (mh *ManagementVoucherHandler) RevertVoucher(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, "INVALID", "Invalid voucher ID", http.StatusBadRequest)
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		helper.ResponseError(c, "INVALID", "Invalid version", http.StatusBadRequest)
+		return
+	}
+
+	actorID, ok := actor(c)
+	if !ok {
+		return
+	}
+
+	reverted, err := mh.service.Manage.RevertVoucher(id, version, actorID)
+	if errors.Is(err, models.ErrVersionNotFound) || errors.Is(err, managementvoucher.ErrVoucherNotFound) {
+		helper.ResponseError(c, "NOT FOUND", err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, models.ErrCodeTaken) {
+		helper.ResponseError(c, "CODE_TAKEN", err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		mh.log.Error("Failed to revert voucher", zap.Error(err))
+		helper.ResponseError(c, "FAILED", "Failed to revert voucher: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	mh.log.Info("Voucher reverted", zap.Int("voucherID", id), zap.Int("version", version))
+	helper.ResponseOK(c, reverted, "Voucher reverted successfully", http.StatusOK)
+}
+
+type StateChangeRequest struct {
+	Reason string `json:"reason" example:"Discount too high for this segment"`
+}
+
+// SubmitVoucher godoc
+// @Summary Submit a voucher for approval
+// @Description Move a draft voucher to pending approval. Another admin has to approve it before it can be used.
+// @Tags Vouchers
+// @Accept json
+// @Produce json
+// @Param id path int true "Voucher ID"
+// @Param request body StateChangeRequest false "Optional note for the reviewer"
+// @Success 200 {object} utils.ResponseOK{data=models.VoucherVersion} "Voucher submitted for approval"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} utils.ErrorResponse "Voucher not found"
+// @Failure 409 {object} utils.ErrorResponse "Voucher is not a draft (INVALID_TRANSITION)"
+// @Security Authentication
+// @Security UserID
+// @Router /vouchers/{id}/submit [post]
+func (mh *ManagementVoucherHandler) SubmitVoucher(c *gin.Context) {
+	mh.changeState(c, models.VersionSubmitted, "Voucher submitted for approval")
+}
+
+// ApproveVoucher godoc
+// @Summary Approve a voucher
+// @Description Approve a voucher pending approval. It goes live right away, or is scheduled until its start date. The approver must not be the admin who submitted it.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Voucher ID"
+// @Param request body StateChangeRequest false "Optional note"
+// @Success 200 {object} utils.ResponseOK{data=models.VoucherVersion} "Voucher approved"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 403 {object} utils.ErrorResponse "Approving a voucher you submitted (SELF_APPROVAL)"
+// @Failure 404 {object} utils.ErrorResponse "Voucher not found"
+// @Failure 409 {object} utils.ErrorResponse "Voucher is not pending approval (INVALID_TRANSITION)"
+// @Security Authentication
+// @Security UserID
+// @Router /admin/vouchers/{id}/approve [post]
+func (mh *ManagementVoucherHandler) ApproveVoucher(c *gin.Context) {
+	mh.changeState(c, models.VersionApproved, "Voucher approved")
+}
+
+// RejectVoucher godoc
+// @Summary Reject a voucher
+// @Description Send a voucher pending approval back to draft with the reason it was rejected.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Voucher ID"
+// @Param request body StateChangeRequest true "Why the voucher was rejected"
+// @Success 200 {object} utils.ResponseOK{data=models.VoucherVersion} "Voucher rejected"
+// @Failure 400 {object} utils.ErrorResponse "Missing reason"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 404 {object} utils.ErrorResponse "Voucher not found"
+// @Failure 409 {object} utils.ErrorResponse "Voucher is not pending approval (INVALID_TRANSITION)"
+// @Security Authentication
+// @Security UserID
+// @Router /admin/vouchers/{id}/reject [post]
+func (mh *ManagementVoucherHandler) RejectVoucher(c *gin.Context) {
+	mh.changeState(c, models.VersionRejected, "Voucher rejected")
+}
+
+// PauseVoucher godoc
+// @Summary Pause a voucher
+// @Description Stop a live or scheduled voucher right away. Paused vouchers cannot be validated, used, redeemed or listed; their dates are kept so they can be resumed.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Voucher ID"
+// @Param request body StateChangeRequest true "Why the voucher is paused"
+// @Success 200 {object} utils.ResponseOK{data=models.VoucherVersion} "Voucher paused"
+// @Failure 400 {object} utils.ErrorResponse "Missing reason"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 404 {object} utils.ErrorResponse "Voucher not found"
+// @Failure 409 {object} utils.ErrorResponse "Voucher is not live or scheduled (INVALID_TRANSITION)"
+// @Security Authentication
+// @Security UserID
+// @Router /admin/vouchers/{id}/pause [post]
+func (mh *ManagementVoucherHandler) PauseVoucher(c *gin.Context) {
+	mh.changeState(c, models.VersionPaused, "Voucher paused")
+}
+
+// ResumeVoucher godoc
+// @Summary Resume a paused voucher
+// @Description Put a paused voucher back on its original schedule: live within its dates, scheduled before its start date and ended after its end date.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Voucher ID"
+// @Param request body StateChangeRequest true "Why the voucher is resumed"
+// @Success 200 {object} utils.ResponseOK{data=models.VoucherVersion} "Voucher resumed"
+// @Failure 400 {object} utils.ErrorResponse "Missing reason"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 404 {object} utils.ErrorResponse "Voucher not found"
+// @Failure 409 {object} utils.ErrorResponse "Voucher is not paused (INVALID_TRANSITION)"
+// @Security Authentication
+// @Security UserID
+// @Router /admin/vouchers/{id}/resume [post]
+func (mh *ManagementVoucherHandler) ResumeVoucher(c *gin.Context) {
+	mh.changeState(c, models.VersionResumed, "Voucher resumed")
+}
+
+// reasonRequired are the lifecycle actions that must say why they were taken.
+var reasonRequired = map[string]bool{
+	models.VersionRejected: true,
+	models.VersionPaused:   true,
+	models.VersionResumed:  true,
+}
+
+// changeState applies a lifecycle action to the voucher in the path.
+func (mh *ManagementVoucherHandler) changeState(c *gin.Context, action, message string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, "INVALID", "Invalid voucher ID", http.StatusBadRequest)
+		return
+	}
+
+	var req StateChangeRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			helper.ResponseError(c, "INVALID", "Invalid Payload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if reasonRequired[action] && strings.TrimSpace(req.Reason) == "" {
+		helper.ResponseError(c, "INVALID", "A reason is required", http.StatusBadRequest)
+		return
+	}
+
+	actorID, ok := actor(c)
+	if !ok {
+		return
+	}
+
+	changed, err := mh.service.Manage.ChangeState(id, actorID, action, req.Reason)
+	switch {
+	case errors.Is(err, managementvoucher.ErrVoucherNotFound):
+		helper.ResponseError(c, "NOT FOUND", err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, models.ErrInvalidTransition):
+		helper.ResponseError(c, "INVALID_TRANSITION", err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, models.ErrSelfApproval):
+		helper.ResponseError(c, "SELF_APPROVAL", err.Error(), http.StatusForbidden)
+		return
+	case err != nil:
+		mh.log.Error("Failed to change voucher state", zap.String("action", action), zap.Error(err))
+		helper.ResponseError(c, "FAILED", "Failed to change voucher state: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	mh.log.Info(message, zap.Int("voucherID", id), zap.Int("actorID", actorID))
+	helper.ResponseOK(c, changed, message, http.StatusOK)
+}
+
+// actor returns the id of the admin making a change, which is recorded with the voucher
+// version. It responds with 401 when the request is not authenticated.
+func actor(c *gin.Context) (int, bool) {
+	actorID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, "UNAUTHORIZED", err.Error(), http.StatusUnauthorized)
+		return 0, false
+	}
+	return actorID, true
+}
+
+// ShowRedeemPoints godoc
+// @Summary Show redeem points
+// @Description Retrieve the list of redeem points
+// @Tags Vouchers
+// @Produce json
+// @Success 200 {object} utils.ResponseOK{data=[]models.Redeem} "Redeem points retrieved successfully"
+// @Failure 404 {object} utils.ErrorResponse "Redeem points not found"
+// @Security Authentication
+// @Security UserID
+// @Router /vouchers/redeem-points [get]
+func (mh *ManagementVoucherHandler) ShowRedeemPoints(c *gin.Context) {
+
+	voucher, err := mh.service.Manage.ShowRedeemPoints()
+	if err != nil {
+		mh.log.Error("Failed to Get Reedem Points List", zap.Error(err))
+		helper.ResponseError(c, "NOT FOUND", "Reedem Points List Not Found", http.StatusNotFound)
+		return
+	}
+
+	mh.log.Info("Redeem points retrieved successfully")
+	helper.ResponseOK(c, voucher, "Redeem points retrieved successfully", http.StatusOK)
+
+}
+
+// GetVouchersByQueryParams godoc
+// @Summary Get vouchers by query parameters
+// @Description Retrieve live and ended vouchers based on status, area, and voucher type
+// @Tags Vouchers
+// @Produce json
+// @Param status query string false "Voucher status"
+// @Param area query string false "Voucher area"
+// @Param voucher_type query string false "Voucher type"
+// @Success 200 {object} utils.ResponseOK{data=[]models.Voucher} "Vouchers retrieved successfully"
+// @Failure 404 {object} utils.ErrorResponse "Vouchers not found"
+// @Security Authentication
+// @Security UserID
+// @Router /vouchers [get]
+func (mh *ManagementVoucherHandler) GetVouchersByQueryParams(c *gin.Context) {
+
+	status := c.Query("status")
+	area := c.Query("area")
+	voucher_type := c.Query("voucher_type")
+
+	voucher, err := mh.service.Manage.GetVouchersByQueryParams(status, area, voucher_type)
+	if err != nil {
+		mh.log.Error("Failed to Get Voucher List", zap.Error(err))
+		helper.ResponseError(c, "NOT FOUND", "Voucher Not Found", http.StatusNotFound)
+		return
+	}
+
+	mh.log.Info("Voucher retrieved successfully")
+	helper.ResponseOK(c, voucher, "Voucher retrieved successfully", http.StatusOK)
+
+}
+
+type RedeemRequest struct {
+	VoucherID int `json:"voucher_id" binding:"required"`
+	UserID    int `json:"user_id" binding:"required"`
+	Points    int `json:"points" binding:"required"`
+}
+
+// CreateRedeemVoucher godoc
+// @Summary Create a redeem voucher
+// @Description Redeem a voucher, debiting its required points from the user's balance
+// @Tags Vouchers
+// @Accept json
+// @Produce json
+// @Param redeemRequest body RedeemRequest true "Redeem request payload"
+// @Param Idempotency-Key header string false "Retrying with the same key replays the first response instead of redeeming again"
+// @Success 200 {object} utils.ResponseOK{data=models.Redeem} "Redeem created successfully"
+// @Failure 400 {object} utils.ErrorResponse "Invalid payload or insufficient points"
+// @Failure 403 {object} utils.ErrorResponse "Redeeming for another user"
+// @Failure 409 {object} utils.ErrorResponse "Voucher is not live (VOUCHER_NOT_LIVE), redemption limit reached (REDEMPTION_LIMIT_REACHED), or Idempotency-Key reused for a different request or still in progress"
+// @Failure 500 {object} utils.ErrorResponse "Failed to create redeem voucher"
+// @Security Authentication
+// @Security UserID
+// @Router /vouchers/redeem [post]
+func (mh *ManagementVoucherHandler) CreateRedeemVoucher(c *gin.Context) {
+	var RedeemRequest RedeemRequest
+	err := c.ShouldBindJSON(&RedeemRequest)
+	if err != nil {
+		mh.log.Error("Invalid payload", zap.Error(err))
+		helper.ResponseError(c, "INVALID", "Invalid Payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if userID, err := helper.GetUserID(c); err == nil && userID != RedeemRequest.UserID && c.GetString("role") != models.RoleAdmin {
+		helper.ResponseError(c, "FORBIDDEN", "Cannot redeem a voucher for another user", http.StatusForbidden)
+		return
+	}
+
+	redeem := models.Redeem{
+		VoucherID: RedeemRequest.VoucherID,
+		UserID:    RedeemRequest.UserID,
+	}
+
+	err = mh.service.Manage.CreateRedeemVoucher(&redeem, RedeemRequest.Points)
+	if errors.Is(err, pointsledger.ErrInsufficientPoints) {
+		helper.ResponseError(c, "INSUFFICIENT_POINTS", "Failed to create redeem voucher: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, models.ErrVoucherNotLive) {
+		helper.ResponseError(c, "VOUCHER_NOT_LIVE", "Failed to create redeem voucher: "+err.Error(), http.StatusConflict)
+		return
+	}
+	var limitErr *models.LimitError
+	if errors.As(err, &limitErr) {
+		helper.ResponseError(c, limitErr.Code, "Failed to create redeem voucher: "+err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		mh.log.Error("Failed to create redeem voucher", zap.Error(err))
+		helper.ResponseError(c, "FAILED", "Failed to create redeem voucher: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	mh.log.Info("Create Redeem Voucher successfully")
+	helper.ResponseOK(c, redeem, "Created successfully", http.StatusOK)
+}
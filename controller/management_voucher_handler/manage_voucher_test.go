@@ -1,621 +1,962 @@
-package managementvoucherhandler_test
-
-import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"net/http/httptest"
-	"strings"
-	"testing"
-	"time"
-	managementvoucherhandler "voucher_system/controller/management_voucher_handler"
-	"voucher_system/models"
-	managementvoucher "voucher_system/repository/management_voucher"
-	"voucher_system/service"
-	managementvoucherservice "voucher_system/service/management_voucher_service"
-
-	"github.com/gin-gonic/gin"
-	"github.com/stretchr/testify/assert"
-	"go.uber.org/zap"
-)
-
-func TestSoftDeleteVoucher(t *testing.T) {
-	// Create a new No-op logger and mock service for each test
-	log := *zap.NewNop()
-
-	t.Run("Successfully delete voucher", func(t *testing.T) {
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		r := gin.Default() // Always create a new router for each test
-		r.DELETE("/voucher/:id", handler.SoftDeleteVoucher)
-
-		voucherID := 123
-
-		// Mock the service call to SoftDeleteVoucher to return no error (successful deletion)
-		mockService.On("SoftDeleteVoucher", voucherID).Return(nil)
-
-		// Create a request to delete the voucher with ID 123
-		req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/voucher/%d", voucherID), nil)
-		w := httptest.NewRecorder()
-
-		// Call the handler
-		r.ServeHTTP(w, req)
-
-		// Assert the response code is 200 (OK)
-		assert.Equal(t, http.StatusOK, w.Code)
-
-		// Assert that the service method was called with the correct voucher ID
-		mockService.AssertCalled(t, "SoftDeleteVoucher", voucherID)
-
-		// Check the response body
-		expectedResponse := `{"status":true,"data":123,"message":"Deleted succesfully"}`
-		assert.JSONEq(t, expectedResponse, w.Body.String())
-	})
-
-	t.Run("Fail to delete voucher due to service error", func(t *testing.T) {
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		r := gin.Default() // Always create a new router for each test
-		r.DELETE("/voucher/:id", handler.SoftDeleteVoucher)
-
-		voucherID := 123
-
-		// Mock the service call to SoftDeleteVoucher to return an error (deletion failed)
-		mockService.On("SoftDeleteVoucher", voucherID).Return(fmt.Errorf("failed to delete voucher"))
-
-		// Create a request to delete the voucher with ID 123
-		req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/voucher/%d", voucherID), nil)
-		w := httptest.NewRecorder()
-
-		// Call the handler
-		r.ServeHTTP(w, req)
-
-		// Assert the response code is 500 (Internal Server Error)
-		assert.Equal(t, http.StatusInternalServerError, w.Code)
-
-		// Assert that the service method was called with the correct voucher ID
-		mockService.AssertCalled(t, "SoftDeleteVoucher", voucherID)
-
-		// Check the response body
-		expectedResponse := `{"error_msg":"FAILED", "message":"Failed to deleted Voucher", "status":false}`
-		assert.JSONEq(t, expectedResponse, w.Body.String())
-	})
-}
-
-func TestCreateVoucher(t *testing.T) {
-	// Setup logger
-	log := *zap.NewNop()
-
-	t.Run("Successfully create voucher", func(t *testing.T) {
-		// Mock Service
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		// Router and Endpoint
-		r := gin.Default()
-		r.POST("/vouchers", handler.CreateVoucher)
-
-		// Mock Data
-		mockVoucher := models.Voucher{
-			VoucherName:     "Test Voucher",
-			VoucherCode:     "TEST123",
-			VoucherType:     "e-commerce",
-			VoucherCategory: "Discount",
-			DiscountValue:   10.0,
-			MinimumPurchase: 100.0,
-			PaymentMethods:  []string{"Credit Card"},
-			StartDate:       time.Now().Round(0),
-			EndDate:         time.Now().AddDate(0, 1, 0).Round(0),
-			ApplicableAreas: []string{"Jawa"},
-			Quota:           50,
-		}
-
-		// Mock Response
-		mockService.On("CreateVoucher", &mockVoucher).Return(nil)
-
-		// Create Request Body
-		body, _ := json.Marshal(mockVoucher)
-
-		// Perform Request
-		req := httptest.NewRequest(http.MethodPost, "/vouchers", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		w := httptest.NewRecorder()
-
-		// Call the Handler
-		r.ServeHTTP(w, req)
-
-		// Assert the Response
-		assert.Equal(t, http.StatusOK, w.Code)
-		mockService.AssertCalled(t, "CreateVoucher", &mockVoucher)
-
-		// Assert the JSON Response Body
-		expectedResponse := map[string]interface{}{
-			"status":  true,
-			"message": "Created succesfully",
-			"data": map[string]interface{}{
-				"voucher_name":     "Test Voucher",
-				"voucher_code":     "TEST123",
-				"voucher_type":     "e-commerce",
-				"voucher_category": "Discount",
-				"discount_value":   10.0,
-				"minimum_purchase": 100.0,
-				"payment_methods":  []interface{}{"Credit Card"},
-				"start_date":       mockVoucher.StartDate.Format(time.RFC3339Nano),
-				"end_date":         mockVoucher.EndDate.Format(time.RFC3339Nano),
-				"applicable_areas": []interface{}{"Jawa"},
-				"quota":            float64(mockVoucher.Quota),
-				"created_at":       "0001-01-01T00:00:00Z",
-				"updated_at":       "0001-01-01T00:00:00Z",
-			},
-		}
-
-		var actualResponse map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
-		assert.NoError(t, err)
-		assert.Equal(t, expectedResponse, actualResponse)
-	})
-
-	t.Run("Fail to create voucher due to service error", func(t *testing.T) {
-		// Mock Service
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		// Router and Endpoint
-		r := gin.Default()
-		r.POST("/vouchers", handler.CreateVoucher)
-
-		// Mock Data
-		mockVoucher := models.Voucher{
-			VoucherName:     "Test Voucher",
-			VoucherCode:     "TEST123",
-			VoucherType:     "e-commerce",
-			VoucherCategory: "Discount",
-			DiscountValue:   10.0,
-			MinimumPurchase: 100.0,
-			PaymentMethods:  []string{"Credit Card"},
-			StartDate:       time.Now().Round(0),
-			EndDate:         time.Now().AddDate(0, 1, 0).Round(0),
-			ApplicableAreas: []string{"Jawa"},
-			Quota:           50,
-		}
-
-		// Mock Response
-		mockService.On("CreateVoucher", &mockVoucher).Return(fmt.Errorf("failed to create voucher"))
-
-		// Create Request Body
-		body, _ := json.Marshal(mockVoucher)
-
-		// Perform Request
-		req := httptest.NewRequest(http.MethodPost, "/vouchers", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		w := httptest.NewRecorder()
-
-		// Call the Handler
-		r.ServeHTTP(w, req)
-
-		// Assert the Response
-		assert.Equal(t, http.StatusBadRequest, w.Code)
-		mockService.AssertCalled(t, "CreateVoucher", &mockVoucher)
-
-		// Assert the JSON Response Body
-		expectedResponse := `{"error_msg":"FAILED", "message":"Failed to create Voucher", "status":false}`
-		assert.JSONEq(t, expectedResponse, w.Body.String())
-	})
-}
-
-func TestUpdateVoucher(t *testing.T) {
-	// Setup logger
-	log := *zap.NewNop()
-
-	t.Run("Successfully update voucher", func(t *testing.T) {
-		// Mock Service
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		// Router and Endpoint
-		r := gin.Default()
-		r.PUT("/vouchers/:id", handler.UpdateVoucher)
-
-		// Mock Data
-		mockVoucher := models.Voucher{
-			VoucherName:     "Updated Voucher",
-			VoucherCode:     "TEST123",
-			VoucherType:     "e-commerce",
-			VoucherCategory: "Discount",
-			DiscountValue:   15.0,
-			MinimumPurchase: 150.0,
-			PaymentMethods:  []string{"Credit Card", "PayPal"},
-			StartDate:       time.Now().Round(0),
-			EndDate:         time.Now().AddDate(0, 1, 0).Round(0),
-			ApplicableAreas: []string{"Bali"},
-			Quota:           75,
-		}
-
-		// Mock Response
-		mockService.On("UpdateVoucher", &mockVoucher, 1).Return(nil)
-
-		// Create Request Body
-		body, _ := json.Marshal(mockVoucher)
-
-		// Perform Request
-		req := httptest.NewRequest(http.MethodPut, "/vouchers/1", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		w := httptest.NewRecorder()
-
-		// Call the Handler
-		r.ServeHTTP(w, req)
-
-		// Assert the Response
-		assert.Equal(t, http.StatusOK, w.Code)
-		mockService.AssertCalled(t, "UpdateVoucher", &mockVoucher, 1)
-
-		// Assert the JSON Response Body
-		expectedResponse := map[string]interface{}{
-			"status":  true,
-			"message": "updated succesfully",
-			"data":    float64(1), // ID of the updated voucher
-		}
-
-		var actualResponse map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
-		assert.NoError(t, err)
-		assert.Equal(t, expectedResponse, actualResponse)
-	})
-
-	t.Run("Fail to update voucher due to service error", func(t *testing.T) {
-		// Mock Service
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		// Router and Endpoint
-		r := gin.Default()
-		r.PUT("/vouchers/:id", handler.UpdateVoucher)
-
-		// Mock Data
-		mockVoucher := models.Voucher{
-			VoucherName:     "Updated Voucher",
-			VoucherCode:     "TEST123",
-			VoucherType:     "e-commerce",
-			VoucherCategory: "Discount",
-			DiscountValue:   15.0,
-			MinimumPurchase: 150.0,
-			PaymentMethods:  []string{"Credit Card", "PayPal"},
-			StartDate:       time.Now().Round(0),
-			EndDate:         time.Now().AddDate(0, 1, 0).Round(0),
-			ApplicableAreas: []string{"Bali"},
-			Quota:           75,
-		}
-
-		// Mock Response
-		mockService.On("UpdateVoucher", &mockVoucher, 1).Return(fmt.Errorf("failed to update voucher"))
-
-		// Create Request Body
-		body, _ := json.Marshal(mockVoucher)
-
-		// Perform Request
-		req := httptest.NewRequest(http.MethodPut, "/vouchers/1", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		w := httptest.NewRecorder()
-
-		// Call the Handler
-		r.ServeHTTP(w, req)
-
-		// Assert the Response
-		assert.Equal(t, http.StatusInternalServerError, w.Code)
-		mockService.AssertCalled(t, "UpdateVoucher", &mockVoucher, 1)
-
-		// Assert the JSON Response Body
-		expectedResponse := `{"error_msg":"FAILED", "message":"Failed to Updated Voucher", "status":false}`
-		assert.JSONEq(t, expectedResponse, w.Body.String())
-	})
-}
-
-func TestShowRedeemPoints(t *testing.T) {
-	// Setup logger
-	log := *zap.NewNop()
-
-	t.Run("Successfully retrieve redeem points", func(t *testing.T) {
-		// Mock Service
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		// Router and Endpoint
-		r := gin.Default()
-		r.GET("/vouchers/redeem-points", handler.ShowRedeemPoints)
-
-		// Mock Data
-		mockRedeemPoints := []managementvoucher.RedeemPoint{
-			{
-				VoucherName:    "Discount 10%",
-				PointsRequired: 100,
-				DiscountValue:  10.0,
-			},
-			{
-				VoucherName:    "Discount 20%",
-				PointsRequired: 200,
-				DiscountValue:  20.0,
-			},
-		}
-
-		// Mock Response
-		mockService.On("ShowRedeemPoints").Return(&mockRedeemPoints, nil)
-
-		// Perform Request
-		req := httptest.NewRequest(http.MethodGet, "/vouchers/redeem-points", nil)
-		w := httptest.NewRecorder()
-
-		// Call the Handler
-		r.ServeHTTP(w, req)
-
-		// Assert the Response
-		assert.Equal(t, http.StatusOK, w.Code)
-		mockService.AssertCalled(t, "ShowRedeemPoints")
-
-		// Assert the JSON Response Body
-		var actualResponse map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
-		assert.NoError(t, err)
-		assert.Equal(t, "Redeem points retrieved successfully", actualResponse["message"])
-		assert.True(t, actualResponse["status"].(bool))
-	})
-
-	t.Run("Fail to retrieve redeem points due to service error", func(t *testing.T) {
-		// Mock Service
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		// Router and Endpoint
-		r := gin.Default()
-		r.GET("/vouchers/redeem-points", handler.ShowRedeemPoints)
-
-		// Mock Response
-		mockService.On("ShowRedeemPoints").Return(nil, fmt.Errorf("failed to retrieve redeem points"))
-
-		// Perform Request
-		req := httptest.NewRequest(http.MethodGet, "/vouchers/redeem-points", nil)
-		w := httptest.NewRecorder()
-
-		// Call the Handler
-		r.ServeHTTP(w, req)
-
-		// Assert the Response
-		assert.Equal(t, http.StatusNotFound, w.Code)
-		mockService.AssertCalled(t, "ShowRedeemPoints")
-
-		// Assert the JSON Response Body
-		expectedResponse := `{"error_msg":"NOT FOUND", "message":"Reedem Points List Not Found", "status":false}`
-		assert.JSONEq(t, expectedResponse, w.Body.String())
-	})
-}
-
-func TestGetVouchersByQueryParams(t *testing.T) {
-	// Setup logger
-	log := *zap.NewNop()
-
-	t.Run("Successfully retrieve vouchers with query params", func(t *testing.T) {
-		// Mock Service
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		// Router and Endpoint
-		r := gin.Default()
-		r.GET("/vouchers", handler.GetVouchersByQueryParams)
-
-		// Mock Data
-		mockVouchers := []models.Voucher{
-			{
-				VoucherName: "Active Voucher 1",
-				VoucherType: "e-commerce",
-			},
-			{
-				VoucherName: "Active Voucher 2",
-				VoucherType: "e-commerce",
-			},
-		}
-
-		// Mock Response
-		mockService.On("GetVouchersByQueryParams", "active", "Jawa", "e-commerce").Return(&mockVouchers, nil)
-
-		// Perform Request
-		req := httptest.NewRequest(http.MethodGet, "/vouchers?status=active&area=Jawa&voucher_type=e-commerce", nil)
-		w := httptest.NewRecorder()
-
-		// Call the Handler
-		r.ServeHTTP(w, req)
-
-		// Assert the Response
-		assert.Equal(t, http.StatusOK, w.Code)
-		mockService.AssertCalled(t, "GetVouchersByQueryParams", "active", "Jawa", "e-commerce")
-
-		// Assert the JSON Response Body
-		var actualResponse map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
-		assert.NoError(t, err)
-		assert.Equal(t, "Voucher retrieved successfully", actualResponse["message"])
-		assert.True(t, actualResponse["status"].(bool))
-	})
-
-	t.Run("Fail to retrieve vouchers due to service error", func(t *testing.T) {
-		// Mock Service
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		// Router and Endpoint
-		r := gin.Default()
-		r.GET("/vouchers", handler.GetVouchersByQueryParams)
-
-		// Mock Response
-		mockService.On("GetVouchersByQueryParams", "expired", "", "").Return(nil, fmt.Errorf("failed to retrieve vouchers"))
-
-		// Perform Request
-		req := httptest.NewRequest(http.MethodGet, "/vouchers?status=expired", nil)
-		w := httptest.NewRecorder()
-
-		// Call the Handler
-		r.ServeHTTP(w, req)
-
-		// Assert the Response
-		assert.Equal(t, http.StatusNotFound, w.Code)
-		mockService.AssertCalled(t, "GetVouchersByQueryParams", "expired", "", "")
-
-		// Assert the JSON Response Body
-		expectedResponse := `{"error_msg":"NOT FOUND", "message":"Voucher Not Found", "status":false}`
-		assert.JSONEq(t, expectedResponse, w.Body.String())
-	})
-}
-
-func TestCreateRedeemVoucher(t *testing.T) {
-	log := *zap.NewNop() // Setup logger
-
-	t.Run("Successfully create redeem voucher", func(t *testing.T) {
-		// Mock Service
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		// Router and Endpoint
-		r := gin.Default()
-		r.POST("/redeem", handler.CreateRedeemVoucher)
-
-		// Input Payload
-		payload := `{"voucher_id": 1, "user_id": 2, "points": 100}`
-
-		// Mock Response
-		mockRedeem := models.Redeem{
-			VoucherID: 1,
-			UserID:    2,
-		}
-		mockService.On("CreateRedeemVoucher", &mockRedeem, 100).Return(nil)
-
-		// Perform Request
-		req := httptest.NewRequest(http.MethodPost, "/redeem", strings.NewReader(payload))
-		req.Header.Set("Content-Type", "application/json")
-		w := httptest.NewRecorder()
-
-		// Call the Handler
-		r.ServeHTTP(w, req)
-
-		// Assert the Response
-		assert.Equal(t, http.StatusOK, w.Code)
-		mockService.AssertCalled(t, "CreateRedeemVoucher", &mockRedeem, 100)
-
-		// Assert the JSON Response Body
-		var actualResponse map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
-		assert.NoError(t, err)
-		assert.Equal(t, "Created successfully", actualResponse["message"])
-		assert.True(t, actualResponse["status"].(bool))
-	})
-
-	t.Run("Fail to create redeem voucher due to invalid payload", func(t *testing.T) {
-		// Mock Service
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		// Router and Endpoint
-		r := gin.Default()
-		r.POST("/redeem", handler.CreateRedeemVoucher)
-
-		// Invalid Payload
-		payload := `{"voucher_id": 1, "user_id": "invalid", "points": 100}`
-
-		// Perform Request
-		req := httptest.NewRequest(http.MethodPost, "/redeem", strings.NewReader(payload))
-		req.Header.Set("Content-Type", "application/json")
-		w := httptest.NewRecorder()
-
-		// Call the Handler
-		r.ServeHTTP(w, req)
-
-		// Assert the Response
-		assert.Equal(t, http.StatusBadRequest, w.Code)
-		mockService.AssertNotCalled(t, "CreateRedeemVoucher")
-
-		// Assert the JSON Response Body
-		var actualResponse map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
-		assert.NoError(t, err)
-		assert.Equal(t, "Invalid Payload: json: cannot unmarshal string into Go struct field .user_id of type int", actualResponse["message"])
-		assert.False(t, actualResponse["status"].(bool))
-	})
-
-	t.Run("Fail to create redeem voucher due to service error", func(t *testing.T) {
-		// Mock Service
-		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
-		service := service.Service{
-			Manage: mockService,
-		}
-		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
-
-		// Router and Endpoint
-		r := gin.Default()
-		r.POST("/redeem", handler.CreateRedeemVoucher)
-
-		// Input Payload
-		payload := `{"voucher_id": 1, "user_id": 2, "points": 100}`
-
-		// Mock Response
-		mockRedeem := models.Redeem{
-			VoucherID: 1,
-			UserID:    2,
-		}
-		mockService.On("CreateRedeemVoucher", &mockRedeem, 100).Return(fmt.Errorf("service error"))
-
-		// Perform Request
-		req := httptest.NewRequest(http.MethodPost, "/redeem", strings.NewReader(payload))
-		req.Header.Set("Content-Type", "application/json")
-		w := httptest.NewRecorder()
-
-		// Call the Handler
-		r.ServeHTTP(w, req)
-
-		// Assert the Response
-		assert.Equal(t, http.StatusInternalServerError, w.Code)
-		mockService.AssertCalled(t, "CreateRedeemVoucher", &mockRedeem, 100)
-
-		// Assert the JSON Response Body
-		var actualResponse map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
-		assert.NoError(t, err)
-		assert.Equal(t, "Failed to create redeem voucher: service error", actualResponse["message"])
-		assert.False(t, actualResponse["status"].(bool))
-	})
-}
+package managementvoucherhandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	managementvoucherhandler "voucher_system/controller/management_voucher_handler"
+	"voucher_system/models"
+	managementvoucher "voucher_system/repository/management_voucher"
+	"voucher_system/service"
+	managementvoucherservice "voucher_system/service/management_voucher_service"
+	"voucher_system/utils/money"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// asUser authenticates every request as userID, like the auth middleware does.
+func asUser(userID int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("userID", fmt.Sprint(userID))
+	}
+}
+
+func TestSoftDeleteVoucher(t *testing.T) {
+	// Create a new No-op logger and mock service for each test
+	log := *zap.NewNop()
+
+	t.Run("Successfully delete voucher", func(t *testing.T) {
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		r := gin.Default() // Always create a new router for each test
+		r.Use(asUser(1))
+		r.DELETE("/voucher/:id", handler.SoftDeleteVoucher)
+
+		voucherID := 123
+
+		// Mock the service call to SoftDeleteVoucher to return no error (successful deletion)
+		mockService.On("SoftDeleteVoucher", voucherID, 1).Return(nil)
+
+		// Create a request to delete the voucher with ID 123
+		req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/voucher/%d", voucherID), nil)
+		w := httptest.NewRecorder()
+
+		// Call the handler
+		r.ServeHTTP(w, req)
+
+		// Assert the response code is 200 (OK)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// Assert that the service method was called with the correct voucher ID
+		mockService.AssertCalled(t, "SoftDeleteVoucher", voucherID, 1)
+
+		// Check the response body
+		expectedResponse := `{"status":true,"data":123,"message":"Deleted succesfully"}`
+		assert.JSONEq(t, expectedResponse, w.Body.String())
+	})
+
+	t.Run("Fail to delete voucher due to service error", func(t *testing.T) {
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		r := gin.Default() // Always create a new router for each test
+		r.Use(asUser(1))
+		r.DELETE("/voucher/:id", handler.SoftDeleteVoucher)
+
+		voucherID := 123
+
+		// Mock the service call to SoftDeleteVoucher to return an error (deletion failed)
+		mockService.On("SoftDeleteVoucher", voucherID, 1).Return(fmt.Errorf("failed to delete voucher"))
+
+		// Create a request to delete the voucher with ID 123
+		req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/voucher/%d", voucherID), nil)
+		w := httptest.NewRecorder()
+
+		// Call the handler
+		r.ServeHTTP(w, req)
+
+		// Assert the response code is 500 (Internal Server Error)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+		// Assert that the service method was called with the correct voucher ID
+		mockService.AssertCalled(t, "SoftDeleteVoucher", voucherID, 1)
+
+		// Check the response body
+		expectedResponse := `{"error_msg":"FAILED", "message":"Failed to deleted Voucher", "status":false}`
+		assert.JSONEq(t, expectedResponse, w.Body.String())
+	})
+}
+
+func TestCreateVoucher(t *testing.T) {
+	// Setup logger
+	log := *zap.NewNop()
+
+	t.Run("Successfully create voucher", func(t *testing.T) {
+		// Mock Service
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		// Router and Endpoint
+		r := gin.Default()
+		r.Use(asUser(1))
+		r.POST("/vouchers", handler.CreateVoucher)
+
+		// Mock Data
+		mockVoucher := models.Voucher{
+			VoucherName:     "Test Voucher",
+			VoucherCode:     "TEST123",
+			VoucherType:     "e-commerce",
+			VoucherCategory: "Discount",
+			DiscountValue:   money.New(10),
+			MinimumPurchase: money.New(100),
+			PaymentMethods:  []string{"Credit Card"},
+			StartDate:       time.Now().Round(0),
+			EndDate:         time.Now().AddDate(0, 1, 0).Round(0),
+			ApplicableAreas: []string{"Jawa"},
+			Quota:           50,
+		}
+
+		// Mock Response
+		mockService.On("CreateVoucher", &mockVoucher, 1).Return(nil)
+
+		// Create Request Body
+		body, _ := json.Marshal(mockVoucher)
+
+		// Perform Request
+		req := httptest.NewRequest(http.MethodPost, "/vouchers", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Call the Handler
+		r.ServeHTTP(w, req)
+
+		// Assert the Response
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertCalled(t, "CreateVoucher", &mockVoucher, 1)
+
+		// Assert the JSON Response Body
+		expectedResponse := map[string]interface{}{
+			"status":  true,
+			"message": "Created succesfully",
+			"data": map[string]interface{}{
+				"voucher_name":     "Test Voucher",
+				"voucher_code":     "TEST123",
+				"voucher_type":     "e-commerce",
+				"voucher_category": "Discount",
+				"discount_value":   10.0,
+				"minimum_purchase": 100.0,
+				"payment_methods":  []interface{}{"Credit Card"},
+				"start_date":       mockVoucher.StartDate.Format(time.RFC3339Nano),
+				"end_date":         mockVoucher.EndDate.Format(time.RFC3339Nano),
+				"applicable_areas": []interface{}{"Jawa"},
+				"quota":            float64(mockVoucher.Quota),
+				"created_at":       "0001-01-01T00:00:00Z",
+				"updated_at":       "0001-01-01T00:00:00Z",
+			},
+		}
+
+		var actualResponse map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedResponse, actualResponse)
+	})
+
+	t.Run("Fail to create voucher due to service error", func(t *testing.T) {
+		// Mock Service
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		// Router and Endpoint
+		r := gin.Default()
+		r.Use(asUser(1))
+		r.POST("/vouchers", handler.CreateVoucher)
+
+		// Mock Data
+		mockVoucher := models.Voucher{
+			VoucherName:     "Test Voucher",
+			VoucherCode:     "TEST123",
+			VoucherType:     "e-commerce",
+			VoucherCategory: "Discount",
+			DiscountValue:   money.New(10),
+			MinimumPurchase: money.New(100),
+			PaymentMethods:  []string{"Credit Card"},
+			StartDate:       time.Now().Round(0),
+			EndDate:         time.Now().AddDate(0, 1, 0).Round(0),
+			ApplicableAreas: []string{"Jawa"},
+			Quota:           50,
+		}
+
+		// Mock Response
+		mockService.On("CreateVoucher", &mockVoucher, 1).Return(fmt.Errorf("failed to create voucher"))
+
+		// Create Request Body
+		body, _ := json.Marshal(mockVoucher)
+
+		// Perform Request
+		req := httptest.NewRequest(http.MethodPost, "/vouchers", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Call the Handler
+		r.ServeHTTP(w, req)
+
+		// Assert the Response
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertCalled(t, "CreateVoucher", &mockVoucher, 1)
+
+		// Assert the JSON Response Body
+		expectedResponse := `{"error_msg":"FAILED", "message":"Failed to create Voucher", "status":false}`
+		assert.JSONEq(t, expectedResponse, w.Body.String())
+	})
+}
+
+func TestCreateVoucher_MissingDiscountType(t *testing.T) {
+	log := *zap.NewNop()
+
+	mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+	handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+	r := gin.Default()
+	r.Use(asUser(1))
+	r.POST("/vouchers", handler.CreateVoucher)
+
+	body, _ := json.Marshal(models.Voucher{
+		VoucherName:     "Test Voucher",
+		VoucherCode:     "TEST123",
+		VoucherType:     "e-commerce",
+		VoucherCategory: "Discount",
+		DiscountValue:   money.New(10),
+		MinimumPurchase: money.New(100),
+		PaymentMethods:  []string{"Credit Card"},
+		StartDate:       time.Now(),
+		EndDate:         time.Now().AddDate(0, 1, 0),
+		ApplicableAreas: []string{"Jawa"},
+		Quota:           50,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/vouchers", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), models.ErrMissingDiscountType.Error())
+	mockService.AssertNotCalled(t, "CreateVoucher")
+}
+
+func TestCreateVoucher_FreeShippingWithoutValue(t *testing.T) {
+	log := *zap.NewNop()
+
+	mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+	handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+	r := gin.Default()
+	r.Use(asUser(1))
+	r.POST("/vouchers", handler.CreateVoucher)
+
+	mockService.On("CreateVoucher", mock.Anything, 1).Return(nil)
+
+	body := `{"voucher_name": "Free Shipping", "voucher_code": "SHIPFREE", "voucher_type": "e-commerce",
+		"voucher_category": "Free Shipping", "discount_type": "free_shipping", "discount_value": 0,
+		"minimum_purchase": 100, "payment_methods": ["Credit Card"], "start_date": "2024-12-01T00:00:00Z",
+		"end_date": "2024-12-31T00:00:00Z", "applicable_areas": ["Jawa"], "quota": 50}`
+	req := httptest.NewRequest(http.MethodPost, "/vouchers", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateVoucher_InvalidDiscount(t *testing.T) {
+	log := *zap.NewNop()
+
+	mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+	handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+	r := gin.Default()
+	r.Use(asUser(1))
+	r.PUT("/vouchers/:id", handler.UpdateVoucher)
+
+	voucher := models.Voucher{
+		VoucherName:     "Updated Voucher",
+		VoucherCode:     "TEST123",
+		VoucherType:     "e-commerce",
+		VoucherCategory: "Discount",
+		DiscountValue:   money.New(500),
+		MinimumPurchase: money.New(150),
+		PaymentMethods:  []string{"Credit Card"},
+		StartDate:       time.Now(),
+		EndDate:         time.Now().AddDate(0, 1, 0),
+		ApplicableAreas: []string{"Bali"},
+		Quota:           75,
+	}
+	mockService.On("UpdateVoucher", mock.Anything, 1, 1).Return(models.ErrInvalidDiscount)
+
+	body, _ := json.Marshal(voucher)
+	req := httptest.NewRequest(http.MethodPut, "/vouchers/1", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), models.ErrInvalidDiscount.Error())
+}
+
+func TestUpdateVoucher(t *testing.T) {
+	// Setup logger
+	log := *zap.NewNop()
+
+	t.Run("Successfully update voucher", func(t *testing.T) {
+		// Mock Service
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		// Router and Endpoint
+		r := gin.Default()
+		r.Use(asUser(1))
+		r.PUT("/vouchers/:id", handler.UpdateVoucher)
+
+		// Mock Data
+		mockVoucher := models.Voucher{
+			VoucherName:     "Updated Voucher",
+			VoucherCode:     "TEST123",
+			VoucherType:     "e-commerce",
+			VoucherCategory: "Discount",
+			DiscountValue:   money.New(15),
+			MinimumPurchase: money.New(150),
+			PaymentMethods:  []string{"Credit Card", "PayPal"},
+			StartDate:       time.Now().Round(0),
+			EndDate:         time.Now().AddDate(0, 1, 0).Round(0),
+			ApplicableAreas: []string{"Bali"},
+			Quota:           75,
+		}
+
+		// Mock Response
+		mockService.On("UpdateVoucher", &mockVoucher, 1, 1).Return(nil)
+
+		// Create Request Body
+		body, _ := json.Marshal(mockVoucher)
+
+		// Perform Request
+		req := httptest.NewRequest(http.MethodPut, "/vouchers/1", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Call the Handler
+		r.ServeHTTP(w, req)
+
+		// Assert the Response
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertCalled(t, "UpdateVoucher", &mockVoucher, 1, 1)
+
+		// Assert the JSON Response Body
+		expectedResponse := map[string]interface{}{
+			"status":  true,
+			"message": "updated succesfully",
+			"data":    float64(1), // ID of the updated voucher
+		}
+
+		var actualResponse map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedResponse, actualResponse)
+	})
+
+	t.Run("Fail to update voucher due to service error", func(t *testing.T) {
+		// Mock Service
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		// Router and Endpoint
+		r := gin.Default()
+		r.Use(asUser(1))
+		r.PUT("/vouchers/:id", handler.UpdateVoucher)
+
+		// Mock Data
+		mockVoucher := models.Voucher{
+			VoucherName:     "Updated Voucher",
+			VoucherCode:     "TEST123",
+			VoucherType:     "e-commerce",
+			VoucherCategory: "Discount",
+			DiscountValue:   money.New(15),
+			MinimumPurchase: money.New(150),
+			PaymentMethods:  []string{"Credit Card", "PayPal"},
+			StartDate:       time.Now().Round(0),
+			EndDate:         time.Now().AddDate(0, 1, 0).Round(0),
+			ApplicableAreas: []string{"Bali"},
+			Quota:           75,
+		}
+
+		// Mock Response
+		mockService.On("UpdateVoucher", &mockVoucher, 1, 1).Return(fmt.Errorf("failed to update voucher"))
+
+		// Create Request Body
+		body, _ := json.Marshal(mockVoucher)
+
+		// Perform Request
+		req := httptest.NewRequest(http.MethodPut, "/vouchers/1", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Call the Handler
+		r.ServeHTTP(w, req)
+
+		// Assert the Response
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockService.AssertCalled(t, "UpdateVoucher", &mockVoucher, 1, 1)
+
+		// Assert the JSON Response Body
+		expectedResponse := `{"error_msg":"FAILED", "message":"Failed to Updated Voucher", "status":false}`
+		assert.JSONEq(t, expectedResponse, w.Body.String())
+	})
+}
+
+func TestShowRedeemPoints(t *testing.T) {
+	// Setup logger
+	log := *zap.NewNop()
+
+	t.Run("Successfully retrieve redeem points", func(t *testing.T) {
+		// Mock Service
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		// Router and Endpoint
+		r := gin.Default()
+		r.GET("/vouchers/redeem-points", handler.ShowRedeemPoints)
+
+		// Mock Data
+		mockRedeemPoints := []managementvoucher.RedeemPoint{
+			{
+				VoucherName:    "Discount 10%",
+				PointsRequired: 100,
+				DiscountValue:  money.New(10),
+			},
+			{
+				VoucherName:    "Discount 20%",
+				PointsRequired: 200,
+				DiscountValue:  money.New(20),
+			},
+		}
+
+		// Mock Response
+		mockService.On("ShowRedeemPoints").Return(&mockRedeemPoints, nil)
+
+		// Perform Request
+		req := httptest.NewRequest(http.MethodGet, "/vouchers/redeem-points", nil)
+		w := httptest.NewRecorder()
+
+		// Call the Handler
+		r.ServeHTTP(w, req)
+
+		// Assert the Response
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertCalled(t, "ShowRedeemPoints")
+
+		// Assert the JSON Response Body
+		var actualResponse map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, "Redeem points retrieved successfully", actualResponse["message"])
+		assert.True(t, actualResponse["status"].(bool))
+	})
+
+	t.Run("Fail to retrieve redeem points due to service error", func(t *testing.T) {
+		// Mock Service
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		// Router and Endpoint
+		r := gin.Default()
+		r.GET("/vouchers/redeem-points", handler.ShowRedeemPoints)
+
+		// Mock Response
+		mockService.On("ShowRedeemPoints").Return(nil, fmt.Errorf("failed to retrieve redeem points"))
+
+		// Perform Request
+		req := httptest.NewRequest(http.MethodGet, "/vouchers/redeem-points", nil)
+		w := httptest.NewRecorder()
+
+		// Call the Handler
+		r.ServeHTTP(w, req)
+
+		// Assert the Response
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertCalled(t, "ShowRedeemPoints")
+
+		// Assert the JSON Response Body
+		expectedResponse := `{"error_msg":"NOT FOUND", "message":"Reedem Points List Not Found", "status":false}`
+		assert.JSONEq(t, expectedResponse, w.Body.String())
+	})
+}
+
+func TestGetVouchersByQueryParams(t *testing.T) {
+	// Setup logger
+	log := *zap.NewNop()
+
+	t.Run("Successfully retrieve vouchers with query params", func(t *testing.T) {
+		// Mock Service
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		// Router and Endpoint
+		r := gin.Default()
+		r.GET("/vouchers", handler.GetVouchersByQueryParams)
+
+		// Mock Data
+		mockVouchers := []models.Voucher{
+			{
+				VoucherName: "Active Voucher 1",
+				VoucherType: "e-commerce",
+			},
+			{
+				VoucherName: "Active Voucher 2",
+				VoucherType: "e-commerce",
+			},
+		}
+
+		// Mock Response
+		mockService.On("GetVouchersByQueryParams", "active", "Jawa", "e-commerce").Return(&mockVouchers, nil)
+
+		// Perform Request
+		req := httptest.NewRequest(http.MethodGet, "/vouchers?status=active&area=Jawa&voucher_type=e-commerce", nil)
+		w := httptest.NewRecorder()
+
+		// Call the Handler
+		r.ServeHTTP(w, req)
+
+		// Assert the Response
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertCalled(t, "GetVouchersByQueryParams", "active", "Jawa", "e-commerce")
+
+		// Assert the JSON Response Body
+		var actualResponse map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, "Voucher retrieved successfully", actualResponse["message"])
+		assert.True(t, actualResponse["status"].(bool))
+	})
+
+	t.Run("Fail to retrieve vouchers due to service error", func(t *testing.T) {
+		// Mock Service
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		// Router and Endpoint
+		r := gin.Default()
+		r.GET("/vouchers", handler.GetVouchersByQueryParams)
+
+		// Mock Response
+		mockService.On("GetVouchersByQueryParams", "expired", "", "").Return(nil, fmt.Errorf("failed to retrieve vouchers"))
+
+		// Perform Request
+		req := httptest.NewRequest(http.MethodGet, "/vouchers?status=expired", nil)
+		w := httptest.NewRecorder()
+
+		// Call the Handler
+		r.ServeHTTP(w, req)
+
+		// Assert the Response
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertCalled(t, "GetVouchersByQueryParams", "expired", "", "")
+
+		// Assert the JSON Response Body
+		expectedResponse := `{"error_msg":"NOT FOUND", "message":"Voucher Not Found", "status":false}`
+		assert.JSONEq(t, expectedResponse, w.Body.String())
+	})
+}
+
+func TestCreateRedeemVoucher(t *testing.T) {
+	log := *zap.NewNop() // Setup logger
+
+	t.Run("Successfully create redeem voucher", func(t *testing.T) {
+		// Mock Service
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		// Router and Endpoint
+		r := gin.Default()
+		r.POST("/redeem", handler.CreateRedeemVoucher)
+
+		// Input Payload
+		payload := `{"voucher_id": 1, "user_id": 2, "points": 100}`
+
+		// Mock Response
+		mockRedeem := models.Redeem{
+			VoucherID: 1,
+			UserID:    2,
+		}
+		mockService.On("CreateRedeemVoucher", &mockRedeem, 100).Return(nil)
+
+		// Perform Request
+		req := httptest.NewRequest(http.MethodPost, "/redeem", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Call the Handler
+		r.ServeHTTP(w, req)
+
+		// Assert the Response
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertCalled(t, "CreateRedeemVoucher", &mockRedeem, 100)
+
+		// Assert the JSON Response Body
+		var actualResponse map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, "Created successfully", actualResponse["message"])
+		assert.True(t, actualResponse["status"].(bool))
+	})
+
+	t.Run("Fail to create redeem voucher due to invalid payload", func(t *testing.T) {
+		// Mock Service
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		// Router and Endpoint
+		r := gin.Default()
+		r.POST("/redeem", handler.CreateRedeemVoucher)
+
+		// Invalid Payload
+		payload := `{"voucher_id": 1, "user_id": "invalid", "points": 100}`
+
+		// Perform Request
+		req := httptest.NewRequest(http.MethodPost, "/redeem", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Call the Handler
+		r.ServeHTTP(w, req)
+
+		// Assert the Response
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "CreateRedeemVoucher")
+
+		// Assert the JSON Response Body
+		var actualResponse map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, "Invalid Payload: json: cannot unmarshal string into Go struct field .user_id of type int", actualResponse["message"])
+		assert.False(t, actualResponse["status"].(bool))
+	})
+
+	t.Run("Fail to create redeem voucher due to service error", func(t *testing.T) {
+		// Mock Service
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		service := service.Service{
+			Manage: mockService,
+		}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service, &log)
+
+		// Router and Endpoint
+		r := gin.Default()
+		r.POST("/redeem", handler.CreateRedeemVoucher)
+
+		// Input Payload
+		payload := `{"voucher_id": 1, "user_id": 2, "points": 100}`
+
+		// Mock Response
+		mockRedeem := models.Redeem{
+			VoucherID: 1,
+			UserID:    2,
+		}
+		mockService.On("CreateRedeemVoucher", &mockRedeem, 100).Return(fmt.Errorf("service error"))
+
+		// Perform Request
+		req := httptest.NewRequest(http.MethodPost, "/redeem", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		// Call the Handler
+		r.ServeHTTP(w, req)
+
+		// Assert the Response
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockService.AssertCalled(t, "CreateRedeemVoucher", &mockRedeem, 100)
+
+		// Assert the JSON Response Body
+		var actualResponse map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, "Failed to create redeem voucher: service error", actualResponse["message"])
+		assert.False(t, actualResponse["status"].(bool))
+	})
+}
+
+func TestRevertVoucher(t *testing.T) {
+	log := *zap.NewNop()
+
+	t.Run("Successfully revert voucher", func(t *testing.T) {
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+		r := gin.Default()
+		r.Use(asUser(7))
+		r.POST("/vouchers/:id/versions/:version/revert", handler.RevertVoucher)
+
+		reverted := &models.VoucherVersion{VoucherID: 3, Version: 5, Action: models.VersionReverted, ActorID: 7}
+		mockService.On("RevertVoucher", 3, 2, 7).Return(reverted, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/vouchers/3/versions/2/revert", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertCalled(t, "RevertVoucher", 3, 2, 7)
+
+		var actualResponse map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(5), actualResponse["data"].(map[string]interface{})["version"])
+	})
+
+	t.Run("Unknown version", func(t *testing.T) {
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+		r := gin.Default()
+		r.Use(asUser(7))
+		r.POST("/vouchers/:id/versions/:version/revert", handler.RevertVoucher)
+
+		mockService.On("RevertVoucher", 3, 9, 7).Return(nil, models.ErrVersionNotFound)
+
+		req := httptest.NewRequest(http.MethodPost, "/vouchers/3/versions/9/revert", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRevertVoucher_CodeTaken(t *testing.T) {
+	log := *zap.NewNop()
+	mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+	handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+	r := gin.Default()
+	r.Use(asUser(7))
+	r.POST("/vouchers/:id/versions/:version/revert", handler.RevertVoucher)
+
+	mockService.On("RevertVoucher", 3, 2, 7).Return(nil, models.ErrCodeTaken)
+
+	req := httptest.NewRequest(http.MethodPost, "/vouchers/3/versions/2/revert", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "CODE_TAKEN")
+}
+
+func TestGenerateVoucherCodes_ReportsCodesStoredBeforeFailure(t *testing.T) {
+	log := *zap.NewNop()
+	mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+	handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+	r := gin.Default()
+	r.POST("/admin/vouchers/:id/codes", handler.GenerateVoucherCodes)
+
+	req := models.GenerateCodesRequest{Count: 50000, Length: 8}
+	mockService.On("GenerateCodes", 3, req).Return(20000, fmt.Errorf("connection reset"))
+
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/vouchers/3/codes", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var actualResponse map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &actualResponse))
+	assert.Equal(t, float64(20000), actualResponse["data"].(map[string]interface{})["generated"])
+}
+
+func TestApproveVoucher(t *testing.T) {
+	log := *zap.NewNop()
+
+	t.Run("Submitter cannot approve", func(t *testing.T) {
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+		r := gin.Default()
+		r.Use(asUser(7))
+		r.POST("/admin/vouchers/:id/approve", handler.ApproveVoucher)
+
+		mockService.On("ChangeState", 3, 7, models.VersionApproved, "").Return(nil, models.ErrSelfApproval)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/vouchers/3/approve", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "SELF_APPROVAL")
+	})
+
+	t.Run("Rejecting requires a reason", func(t *testing.T) {
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+		r := gin.Default()
+		r.Use(asUser(8))
+		r.POST("/admin/vouchers/:id/reject", handler.RejectVoucher)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/vouchers/3/reject", strings.NewReader(`{"reason": " "}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ChangeState")
+	})
+}
+
+func TestPauseVoucher(t *testing.T) {
+	log := *zap.NewNop()
+
+	t.Run("Pausing requires a reason", func(t *testing.T) {
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+		r := gin.Default()
+		r.Use(asUser(8))
+		r.POST("/admin/vouchers/:id/pause", handler.PauseVoucher)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/vouchers/3/pause", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ChangeState")
+	})
+
+	t.Run("Voucher paused", func(t *testing.T) {
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+		r := gin.Default()
+		r.Use(asUser(8))
+		r.POST("/admin/vouchers/:id/pause", handler.PauseVoucher)
+
+		mockService.On("ChangeState", 3, 8, models.VersionPaused, "fraud spike").
+			Return(&models.VoucherVersion{VoucherID: 3, Version: 4, Action: models.VersionPaused}, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/vouchers/3/pause", strings.NewReader(`{"reason": "fraud spike"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Voucher paused")
+	})
+}
+
+func TestExportVoucherCodes(t *testing.T) {
+	log := *zap.NewNop()
+
+	t.Run("Codes exported as CSV", func(t *testing.T) {
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+		r := gin.Default()
+		r.GET("/admin/vouchers/:id/codes/export", handler.ExportVoucherCodes)
+
+		userID := 5
+		assignedAt := time.Date(2024, 7, 1, 9, 30, 0, 0, time.UTC)
+		mockService.On("FindCodes", 3, "").Return([]models.VoucherCode{
+			{Code: "SUMMER-7KX9QD4M", State: models.CodeAssigned, UserID: &userID, AssignedAt: &assignedAt},
+			{Code: "SUMMER-P2WZ8HAN", State: models.CodeUnused},
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/vouchers/3/codes/export", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "voucher-3-codes.csv")
+		assert.Equal(t, "code,state,user_id,assigned_at,used_at\n"+
+			"SUMMER-7KX9QD4M,assigned,5,2024-07-01T09:30:00Z,\n"+
+			"SUMMER-P2WZ8HAN,unused,,,\n", w.Body.String())
+	})
+
+	t.Run("Invalid state", func(t *testing.T) {
+		mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+		handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+		r := gin.Default()
+		r.GET("/admin/vouchers/:id/codes/export", handler.ExportVoucherCodes)
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/vouchers/3/codes/export?state=lost", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "FindCodes")
+	})
+}
+
+func TestGetVoucherVersions(t *testing.T) {
+	log := *zap.NewNop()
+
+	mockService := &managementvoucherservice.ManagementVoucherServiceMock{}
+	handler := managementvoucherhandler.NewManagementVoucherHanlder(service.Service{Manage: mockService}, &log)
+
+	r := gin.Default()
+	r.GET("/admin/vouchers/:id/versions", handler.GetVoucherVersions)
+
+	mockService.On("FindVersions", 3).Return([]models.VoucherVersion{
+		{VoucherID: 3, Version: 2, Action: models.VersionUpdated},
+		{VoucherID: 3, Version: 1, Action: models.VersionCreated},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/vouchers/3/versions", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"version":2`)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,181 @@
+package managementvoucherhandler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"voucher_system/helper"
+	"voucher_system/models"
+	managementvoucher "voucher_system/repository/management_voucher"
+	"voucher_system/utils/codegen"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AssignCodeRequest struct {
+	UserID int `json:"user_id" binding:"required" example:"1"`
+}
+
+// GenerateVoucherCodes godoc
+// @Summary Generate unique codes for a voucher
+// @Description Generate single-use codes for a campaign voucher. Each code is Prefix, then Length characters from Alphabet and, with check_digit, a Luhn mod N check character. Codes never collide with stored codes or with other vouchers' own codes.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Voucher ID"
+// @Param request body models.GenerateCodesRequest true "Code format and number of codes"
+// @Success 201 {object} utils.ResponseOK{data=object{generated=int}} "Voucher codes generated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid payload or code format"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 404 {object} utils.ErrorResponse "Voucher not found"
+// @Failure 422 {object} utils.ErrorResponse "Code format has too few combinations (CODE_SPACE_TOO_SMALL)"
+// @Failure 500 {object} utils.ErrorResponse{data=object{generated=int}} "Failed to generate voucher codes; generated is how many were stored before the failure"
+// @Security Authentication
+// @Security UserID
+// @Router /admin/vouchers/{id}/codes [post]
+func (mh *ManagementVoucherHandler) GenerateVoucherCodes(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, "INVALID", "Invalid voucher ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.GenerateCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, "INVALID", "Invalid Payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created, err := mh.service.Manage.GenerateCodes(id, req)
+	switch {
+	case errors.Is(err, codegen.ErrInvalidFormat):
+		helper.ResponseError(c, "INVALID", err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, codegen.ErrSpaceTooSmall):
+		helper.ResponseError(c, "CODE_SPACE_TOO_SMALL", err.Error(), http.StatusUnprocessableEntity)
+		return
+	case errors.Is(err, managementvoucher.ErrVoucherNotFound):
+		helper.ResponseError(c, "NOT FOUND", err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		mh.log.Error("Failed to generate voucher codes", zap.Int("generated", created), zap.Error(err))
+		helper.ResponseErrorData(c, "FAILED", "Failed to generate voucher codes: "+err.Error(), gin.H{"generated": created}, http.StatusInternalServerError)
+		return
+	}
+
+	mh.log.Info("Voucher codes generated", zap.Int("voucherID", id), zap.Int("count", created))
+	helper.ResponseOK(c, gin.H{"generated": created}, "Voucher codes generated", http.StatusCreated)
+}
+
+// AssignVoucherCode godoc
+// @Summary Assign a voucher code to a user
+// @Description Hand the next unused code of a campaign voucher to a user, who can then use it in place of the voucher code.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Voucher ID"
+// @Param request body AssignCodeRequest true "User to assign the code to"
+// @Success 200 {object} utils.ResponseOK{data=models.VoucherCode} "Voucher code assigned"
+// @Failure 400 {object} utils.ErrorResponse "Invalid payload"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 409 {object} utils.ErrorResponse "No unused codes left (NO_UNUSED_CODES)"
+// @Failure 500 {object} utils.ErrorResponse "Failed to assign voucher code"
+// @Security Authentication
+// @Security UserID
+// @Router /admin/vouchers/{id}/codes/assign [post]
+func (mh *ManagementVoucherHandler) AssignVoucherCode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, "INVALID", "Invalid voucher ID", http.StatusBadRequest)
+		return
+	}
+
+	var req AssignCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, "INVALID", "Invalid Payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code, err := mh.service.Manage.AssignCode(id, req.UserID)
+	if errors.Is(err, models.ErrNoUnusedCodes) {
+		helper.ResponseError(c, "NO_UNUSED_CODES", err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		mh.log.Error("Failed to assign voucher code", zap.Error(err))
+		helper.ResponseError(c, "FAILED", "Failed to assign voucher code: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	mh.log.Info("Voucher code assigned", zap.Int("voucherID", id), zap.Int("userID", req.UserID))
+	helper.ResponseOK(c, code, "Voucher code assigned", http.StatusOK)
+}
+
+// ExportVoucherCodes godoc
+// @Summary Export the codes of a voucher as CSV
+// @Description Download the generated codes of a voucher with their state, owner and dates, optionally limited to one state.
+// @Tags Admin
+// @Produce text/csv
+// @Param id path int true "Voucher ID"
+// @Param state query string false "Only export codes in this state" Enums(unused, assigned, used)
+// @Success 200 {file} file "CSV with the columns code, state, user_id, assigned_at and used_at"
+// @Failure 400 {object} utils.ErrorResponse "Invalid voucher ID or state"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 500 {object} utils.ErrorResponse "Failed to export voucher codes"
+// @Security Authentication
+// @Security UserID
+// @Router /admin/vouchers/{id}/codes/export [get]
+func (mh *ManagementVoucherHandler) ExportVoucherCodes(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, "INVALID", "Invalid voucher ID", http.StatusBadRequest)
+		return
+	}
+
+	state := c.Query("state")
+	switch state {
+	case "", models.CodeUnused, models.CodeAssigned, models.CodeUsed:
+	default:
+		helper.ResponseError(c, "INVALID", "Invalid state", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := mh.service.Manage.FindCodes(id, state)
+	if err != nil {
+		mh.log.Error("Failed to export voucher codes", zap.Error(err))
+		helper.ResponseError(c, "FAILED", "Failed to export voucher codes", http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="voucher-%d-codes.csv"`, id))
+	c.Header("Content-Type", "text/csv")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"code", "state", "user_id", "assigned_at", "used_at"})
+	for _, code := range codes {
+		_ = w.Write([]string{code.Code, code.State, optionalInt(code.UserID), optionalTime(code.AssignedAt), optionalTime(code.UsedAt)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		mh.log.Error("Failed to write voucher codes", zap.Int("voucherID", id), zap.Error(err))
+	}
+}
+
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func optionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,299 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"voucher_system/helper"
+	"voucher_system/models"
+	"voucher_system/service"
+	"voucher_system/utils/money"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type PointsController struct {
+	service service.Service
+	log     *zap.Logger
+}
+
+func NewPointsController(service service.Service, log *zap.Logger) PointsController {
+	return PointsController{service: service, log: log}
+}
+
+type OrderEventRequest struct {
+	OrderID       string       `json:"order_id" binding:"required" example:"INV-2024-0001"`
+	UserID        int          `json:"user_id" binding:"required" example:"1"`
+	Amount        money.Amount `json:"amount" binding:"required,gt=0" swaggertype:"number" example:"250000"`
+	Currency      string       `json:"currency" example:"IDR"`
+	PaymentMethod string       `json:"payment_method" example:"Credit Card"`
+	Area          string       `json:"area" example:"Jawa"`
+	OrderDate     time.Time    `json:"order_date" example:"2024-12-03T10:00:00Z"`
+}
+
+func earningErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidEarningRule), errors.Is(err, money.ErrNoRate):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrAlreadyEarned):
+		return http.StatusConflict
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// GetBalance godoc
+// @Summary Get points balance
+// @Description Get the points balance of the logged in user
+// @Tags Points
+// @Produce json
+// @Success 200 {object} utils.ResponseOK{data=models.PointsAccount} "Balance fetched successfully"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Security Authentication
+// @Router /points/balance [get]
+func (p *PointsController) GetBalance(c *gin.Context) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	account, err := p.service.Points.GetBalance(userID)
+	if err != nil {
+		p.log.Error("Failed to fetch points balance", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to fetch points balance", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, account, "Balance fetched successfully", http.StatusOK)
+}
+
+// GetLedger godoc
+// @Summary Get points history
+// @Description Paginated points ledger of the logged in user, newest first
+// @Tags Points
+// @Produce json
+// @Param page query int false "Page, starting at 1"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} utils.ResponseOK{data=service.LedgerPage} "Ledger fetched successfully"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Security Authentication
+// @Router /points/ledger [get]
+func (p *PointsController) GetLedger(c *gin.Context) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	result, err := p.service.Points.GetLedger(userID, page, limit)
+	if err != nil {
+		p.log.Error("Failed to fetch points ledger", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to fetch points ledger", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, result, "Ledger fetched successfully", http.StatusOK)
+}
+
+// GetExpiringPoints godoc
+// @Summary Get expiring points
+// @Description Points of the logged in user that expire in the coming days, oldest first
+// @Tags Points
+// @Produce json
+// @Param days query int false "Days to look ahead, 30 by default"
+// @Success 200 {object} utils.ResponseOK{data=service.ExpiringPoints} "Expiring points fetched successfully"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Security Authentication
+// @Router /points/expiring [get]
+func (p *PointsController) GetExpiringPoints(c *gin.Context) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+
+	result, err := p.service.Points.GetExpiringPoints(userID, days)
+	if err != nil {
+		p.log.Error("Failed to fetch expiring points", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to fetch expiring points", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, result, "Expiring points fetched successfully", http.StatusOK)
+}
+
+// ListEarningRules godoc
+// @Summary List earning rules
+// @Description List all points earning rules, including disabled ones
+// @Tags Points
+// @Produce json
+// @Success 200 {object} utils.ResponseOK{data=[]models.EarningRule} "Rules fetched successfully"
+// @Security Authentication
+// @Router /admin/earning-rules [get]
+func (p *PointsController) ListEarningRules(c *gin.Context) {
+	rules, err := p.service.Earning.ListRules()
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Failed to fetch earning rules", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, rules, "Rules fetched successfully", http.StatusOK)
+}
+
+// CreateEarningRule godoc
+// @Summary Create an earning rule
+// @Description Base rules give 1 point per spend_per_point spent, multiplier rules scale the base points and bonus rules add bonus_points
+// @Tags Points
+// @Accept json
+// @Produce json
+// @Param rule body models.EarningRule true "Earning rule"
+// @Success 201 {object} utils.ResponseOK{data=models.EarningRule} "Rule created"
+// @Failure 400 {object} utils.ErrorResponse "Invalid rule"
+// @Security Authentication
+// @Router /admin/earning-rules [post]
+func (p *PointsController) CreateEarningRule(c *gin.Context) {
+	var rule models.EarningRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+	rule.ID = 0
+
+	if err := p.service.Earning.CreateRule(&rule); err != nil {
+		p.log.Error("Failed to create earning rule", zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to create earning rule", earningErrorStatus(err))
+		return
+	}
+
+	helper.ResponseOK(c, rule, "Rule created successfully", http.StatusCreated)
+}
+
+// UpdateEarningRule godoc
+// @Summary Update an earning rule
+// @Description Replace an earning rule
+// @Tags Points
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Param rule body models.EarningRule true "Earning rule"
+// @Success 200 {object} utils.ResponseOK{data=models.EarningRule} "Rule updated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid rule"
+// @Failure 404 {object} utils.ErrorResponse "Rule not found"
+// @Security Authentication
+// @Router /admin/earning-rules/{id} [put]
+func (p *PointsController) UpdateEarningRule(c *gin.Context) {
+	ruleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid rule ID", http.StatusBadRequest)
+		return
+	}
+
+	var rule models.EarningRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+	rule.ID = ruleID
+
+	if err := p.service.Earning.UpdateRule(&rule); err != nil {
+		p.log.Error("Failed to update earning rule", zap.Int("ruleID", ruleID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to update earning rule", earningErrorStatus(err))
+		return
+	}
+
+	helper.ResponseOK(c, rule, "Rule updated successfully", http.StatusOK)
+}
+
+// DeleteEarningRule godoc
+// @Summary Delete an earning rule
+// @Description Delete an earning rule. Points already earned are kept.
+// @Tags Points
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Success 200 {object} utils.ResponseOK "Rule deleted"
+// @Failure 404 {object} utils.ErrorResponse "Rule not found"
+// @Security Authentication
+// @Router /admin/earning-rules/{id} [delete]
+func (p *PointsController) DeleteEarningRule(c *gin.Context) {
+	ruleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid rule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := p.service.Earning.DeleteRule(ruleID); err != nil {
+		helper.ResponseError(c, err.Error(), "Failed to delete earning rule", earningErrorStatus(err))
+		return
+	}
+
+	helper.ResponseOK(c, gin.H{"id": ruleID}, "Rule deleted successfully", http.StatusOK)
+}
+
+// PostOrderEvent godoc
+// @Summary Report an order
+// @Description Credit the points an order placed outside the voucher system earns. Each order_id earns points once.
+// @Tags Points
+// @Accept json
+// @Produce json
+// @Param orderEventRequest body OrderEventRequest true "Order"
+// @Success 200 {object} utils.ResponseOK{data=models.PointsLedgerEntry} "Points earned"
+// @Failure 400 {object} utils.ErrorResponse "Invalid input"
+// @Failure 409 {object} utils.ErrorResponse "Order already earned points"
+// @Security Authentication
+// @Router /admin/points/order-events [post]
+func (p *PointsController) PostOrderEvent(c *gin.Context) {
+	var req OrderEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if !req.Amount.IsPositive() {
+		helper.ResponseError(c, "amount must be above zero", "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if req.OrderDate.IsZero() {
+		req.OrderDate = time.Now()
+	}
+	currency := money.DefaultCurrency
+	if req.Currency != "" {
+		parsed, err := money.ParseCurrency(req.Currency)
+		if err != nil {
+			helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+			return
+		}
+		currency = parsed
+	}
+
+	entry, err := p.service.Earning.Earn(service.EarningEvent{
+		UserID:        req.UserID,
+		Amount:        req.Amount,
+		Currency:      currency,
+		PaymentMethod: req.PaymentMethod,
+		Area:          req.Area,
+		At:            req.OrderDate,
+		Reference:     "order:" + req.OrderID,
+		Description:   fmt.Sprintf("Order %s", req.OrderID),
+	})
+	if err != nil {
+		p.log.Error("Failed to earn points for order", zap.String("orderID", req.OrderID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to earn points", earningErrorStatus(err))
+		return
+	}
+	if entry == nil {
+		helper.ResponseOK(c, nil, "No points earned", http.StatusOK)
+		return
+	}
+
+	helper.ResponseOK(c, entry, "Points earned successfully", http.StatusOK)
+}
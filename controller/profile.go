@@ -0,0 +1,282 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"voucher_system/helper"
+	"voucher_system/service"
+	"voucher_system/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type UpdateProfileRequest struct {
+	Name string `json:"name" binding:"required" example:"John Doe"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"password1234"`
+	NewPassword     string `json:"new_password" binding:"required,min=8" example:"newpassword1234"`
+}
+
+type ChangeEmailRequest struct {
+	Password string `json:"password" binding:"required" example:"password1234"`
+	NewEmail string `json:"new_email" binding:"required,email" example:"john.new@example.com"`
+}
+
+type VerifyEmailRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required" example:"password1234"`
+}
+
+type pendingEmailChange struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+func emailChangeKey(userID int) string {
+	return "email_change_" + helper.IntToString(userID)
+}
+
+func profileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidPassword):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrWeakPassword):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrEmailTaken):
+		return http.StatusConflict
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// UpdateProfile godoc
+// @Summary Update own profile
+// @Description Change the name of the authenticated user
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param updateProfileRequest body UpdateProfileRequest true "New profile"
+// @Success 200 {object} utils.ResponseOK{data=models.User} "Profile updated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid input"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Security Authentication
+// @Router /users/me [put]
+func (a *AuthController) UpdateProfile(c *gin.Context) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	user, err := a.Service.User.UpdateProfile(userID, req.Name)
+	if err != nil {
+		a.log.Error("Failed to update profile", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to update profile", profileErrorStatus(err))
+		return
+	}
+
+	helper.ResponseOK(c, user, "Profile updated successfully", http.StatusOK)
+}
+
+// ChangePassword godoc
+// @Summary Change own password
+// @Description Change the password after confirming the current one. Every other session is signed out and a new token is returned.
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param changePasswordRequest body ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} utils.ResponseOK{data=utils.LoginResponse} "Password changed"
+// @Failure 400 {object} utils.ErrorResponse "Invalid input"
+// @Failure 403 {object} utils.ErrorResponse "Current password is incorrect"
+// @Security Authentication
+// @Router /users/me/password [put]
+func (a *AuthController) ChangePassword(c *gin.Context) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	err = a.Service.User.ChangePassword(userID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		a.log.Warn("Failed to change password", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to change password", profileErrorStatus(err))
+		return
+	}
+
+	// Replacing the stored session revokes every token issued before the change.
+	userIDstr := helper.IntToString(userID)
+	token, err := utils.GenerateJWT(userID)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Failed to generate jwt", http.StatusInternalServerError)
+		return
+	}
+	if err := a.Cacher.SaveToken(userIDstr, token); err != nil {
+		helper.ResponseError(c, err.Error(), "Failed to save token", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, gin.H{
+		"id":    userIDstr,
+		"token": token,
+	}, "Password changed successfully", http.StatusOK)
+}
+
+// RequestEmailChange godoc
+// @Summary Request an email change
+// @Description Start changing the email. A verification code is sent to the new address and must be confirmed within an hour.
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param changeEmailRequest body ChangeEmailRequest true "Password and new email"
+// @Success 202 {object} utils.ResponseOK "Verification code sent"
+// @Failure 400 {object} utils.ErrorResponse "Invalid input"
+// @Failure 403 {object} utils.ErrorResponse "Password is incorrect"
+// @Failure 409 {object} utils.ErrorResponse "Email is already registered"
+// @Failure 500 {object} utils.ErrorResponse "Failed to send verification code"
+// @Security Authentication
+// @Router /users/me/email [post]
+func (a *AuthController) RequestEmailChange(c *gin.Context) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Service.User.CheckEmailChange(userID, req.Password, req.NewEmail); err != nil {
+		a.log.Warn("Email change rejected", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to change email", profileErrorStatus(err))
+		return
+	}
+
+	code := utils.GenerateToken()
+	pending, _ := json.Marshal(pendingEmailChange{Email: req.NewEmail, Code: code})
+	if err := a.Cacher.Set(emailChangeKey(userID), string(pending)); err != nil {
+		helper.ResponseError(c, err.Error(), "Failed to save verification code", http.StatusInternalServerError)
+		return
+	}
+
+	body := "Use this code to confirm your new email address: " + code + "\n\nIf you did not ask to change your email, you can ignore this message."
+	if err := a.Mailer.Send(req.NewEmail, "Confirm your new email address", body); err != nil {
+		a.log.Error("Failed to send email verification code", zap.Int("userID", userID), zap.Error(err))
+		_ = a.Cacher.Delete(emailChangeKey(userID))
+		helper.ResponseError(c, "Failed to send verification code", "Failed to change email", http.StatusInternalServerError)
+		return
+	}
+
+	a.log.Info("Email verification code sent", zap.Int("userID", userID))
+	helper.ResponseOK(c, nil, "Verification code sent to the new email", http.StatusAccepted)
+}
+
+// VerifyEmailChange godoc
+// @Summary Confirm an email change
+// @Description Confirm the new email with the code sent by RequestEmailChange
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param verifyEmailRequest body VerifyEmailRequest true "Verification code"
+// @Success 200 {object} utils.ResponseOK{data=models.User} "Email changed"
+// @Failure 400 {object} utils.ErrorResponse "Invalid or expired code"
+// @Failure 409 {object} utils.ErrorResponse "Email is already registered"
+// @Security Authentication
+// @Router /users/me/email/verify [post]
+func (a *AuthController) VerifyEmailChange(c *gin.Context) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	var pending pendingEmailChange
+	stored, err := a.Cacher.Get(emailChangeKey(userID))
+	if err != nil || json.Unmarshal([]byte(stored), &pending) != nil || pending.Code != req.Code {
+		helper.ResponseError(c, "Invalid or expired verification code", "Failed to change email", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Service.User.ChangeEmail(userID, pending.Email); err != nil {
+		a.log.Error("Failed to change email", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to change email", profileErrorStatus(err))
+		return
+	}
+	_ = a.Cacher.Delete(emailChangeKey(userID))
+
+	user, err := a.Service.User.GetUserByID(userID)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "User not found", http.StatusNotFound)
+		return
+	}
+
+	helper.ResponseOK(c, user, "Email changed successfully", http.StatusOK)
+}
+
+// DeleteAccount godoc
+// @Summary Delete own account
+// @Description Delete the authenticated account after confirming the password and sign out its session
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param deleteAccountRequest body DeleteAccountRequest true "Password confirmation"
+// @Success 200 {object} utils.ResponseOK "Account deleted"
+// @Failure 400 {object} utils.ErrorResponse "Invalid input"
+// @Failure 403 {object} utils.ErrorResponse "Password is incorrect"
+// @Security Authentication
+// @Router /users/me [delete]
+func (a *AuthController) DeleteAccount(c *gin.Context) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Service.User.DeleteAccount(userID, req.Password); err != nil {
+		a.log.Warn("Failed to delete account", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to delete account", profileErrorStatus(err))
+		return
+	}
+
+	if err := a.Cacher.Delete(helper.IntToString(userID)); err != nil {
+		a.log.Error("Failed to remove session of deleted account", zap.Int("userID", userID), zap.Error(err))
+	}
+
+	helper.ResponseOK(c, nil, "Account deleted successfully", http.StatusOK)
+}
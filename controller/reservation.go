@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"voucher_system/helper"
+	"voucher_system/repository"
+	"voucher_system/service"
+	"voucher_system/utils/money"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ReservationController struct {
+	service service.Service
+	log     *zap.Logger
+}
+
+func NewReservationController(service service.Service, log *zap.Logger) ReservationController {
+	return ReservationController{service: service, log: log}
+}
+
+type ReservationRequest struct {
+	OrderID           string       `json:"order_id" binding:"required" example:"INV-2024-0001"`
+	VoucherCode       string       `json:"voucher_code" binding:"required" example:"DESCERIA100"`
+	TransactionAmount money.Amount `json:"transaction_amount" binding:"required,gt=0" swaggertype:"number" example:"250000"`
+	Currency          string       `json:"currency" example:"IDR"`
+	PaymentMethod     string       `json:"payment_method" example:"Credit Card"`
+	Area              string       `json:"area" example:"Jawa"`
+	// TTLSeconds is how long the quota is held, 900 by default and at most 3600.
+	TTLSeconds int `json:"ttl_seconds" example:"900"`
+}
+
+func reservationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrOrderReserved),
+		errors.Is(err, repository.ErrReservationNotHeld),
+		errors.Is(err, repository.ErrReservationExpired),
+		errors.Is(err, repository.ErrQuotaExceeded):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// Reserve godoc
+// @Summary Reserve a voucher
+// @Description Validate a voucher for an order and hold one unit of its quota while the order is paid. The hold ends when it is confirmed, released or expires.
+// @Tags Reservations
+// @Accept json
+// @Produce json
+// @Param request body ReservationRequest true "Order to reserve the voucher for"
+// @Param Idempotency-Key header string false "Retrying with the same key replays the first response instead of reserving again"
+// @Success 201 {object} utils.ResponseOK{data=models.VoucherReservation} "Voucher reserved"
+// @Failure 400 {object} utils.ErrorResponse "Invalid payload or voucher not valid for the order"
+// @Failure 409 {object} utils.ErrorResponse "Order already reserved, quota used up or a per-user limit reached"
+// @Security Authentication
+// @Router /vouchers/reservations [post]
+func (r *ReservationController) Reserve(c *gin.Context) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var request ReservationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.ResponseError(c, "Invalid input", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	currency, err := money.ParseCurrency(request.Currency)
+	if err != nil {
+		helper.ResponseError(c, "Invalid currency", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := r.service.Reservation.Reserve(service.ReservationRequest{
+		UserID:            userID,
+		OrderID:           request.OrderID,
+		VoucherCode:       request.VoucherCode,
+		TransactionAmount: request.TransactionAmount,
+		Currency:          currency,
+		PaymentMethod:     request.PaymentMethod,
+		Area:              request.Area,
+		TTL:               time.Duration(request.TTLSeconds) * time.Second,
+	})
+	if err != nil {
+		r.log.Error("Failed to reserve voucher", zap.Int("userID", userID), zap.String("orderID", request.OrderID), zap.Error(err))
+		if responseLimitError(c, err) {
+			return
+		}
+		helper.ResponseError(c, "Failed to reserve voucher", err.Error(), reservationErrorStatus(err))
+		return
+	}
+
+	helper.ResponseOK(c, reservation, "Voucher reserved", http.StatusCreated)
+}
+
+// ConfirmReservation godoc
+// @Summary Confirm a reservation
+// @Description Record the voucher usage of a held reservation once its order is paid
+// @Tags Reservations
+// @Produce json
+// @Param id path int true "Reservation ID"
+// @Param Idempotency-Key header string false "Retrying with the same key replays the first response"
+// @Success 200 {object} utils.ResponseOK{data=models.VoucherReservation} "Reservation confirmed"
+// @Failure 404 {object} utils.ErrorResponse "Reservation not found"
+// @Failure 409 {object} utils.ErrorResponse "Reservation expired or no longer held"
+// @Security Authentication
+// @Router /vouchers/reservations/{id}/confirm [post]
+func (r *ReservationController) ConfirmReservation(c *gin.Context) {
+	userID, reservationID, ok := r.reservationParams(c)
+	if !ok {
+		return
+	}
+
+	reservation, err := r.service.Reservation.Confirm(userID, reservationID)
+	if err != nil {
+		r.log.Error("Failed to confirm reservation", zap.Int("reservationID", reservationID), zap.Error(err))
+		helper.ResponseError(c, "Failed to confirm reservation", err.Error(), reservationErrorStatus(err))
+		return
+	}
+
+	helper.ResponseOK(c, reservation, "Reservation confirmed", http.StatusOK)
+}
+
+// ReleaseReservation godoc
+// @Summary Release a reservation
+// @Description Cancel a held reservation and give its unit back to the voucher quota
+// @Tags Reservations
+// @Produce json
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} utils.ResponseOK{data=models.VoucherReservation} "Reservation released"
+// @Failure 404 {object} utils.ErrorResponse "Reservation not found"
+// @Failure 409 {object} utils.ErrorResponse "Reservation no longer held"
+// @Security Authentication
+// @Router /vouchers/reservations/{id}/release [post]
+func (r *ReservationController) ReleaseReservation(c *gin.Context) {
+	userID, reservationID, ok := r.reservationParams(c)
+	if !ok {
+		return
+	}
+
+	reservation, err := r.service.Reservation.Release(userID, reservationID)
+	if err != nil {
+		r.log.Error("Failed to release reservation", zap.Int("reservationID", reservationID), zap.Error(err))
+		helper.ResponseError(c, "Failed to release reservation", err.Error(), reservationErrorStatus(err))
+		return
+	}
+
+	helper.ResponseOK(c, reservation, "Reservation released", http.StatusOK)
+}
+
+// reservationParams reads the authenticated user and the :id parameter and writes the
+// error response itself when either is missing.
+func (r *ReservationController) reservationParams(c *gin.Context) (int, int, bool) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	reservationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid reservation ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, reservationID, true
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+	"voucher_system/helper"
+	"voucher_system/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TierController struct {
+	service service.Service
+	log     *zap.Logger
+}
+
+func NewTierController(service service.Service, log *zap.Logger) TierController {
+	return TierController{service: service, log: log}
+}
+
+// ListTiers godoc
+// @Summary List membership tiers
+// @Description List the tiers from the lowest to the highest with the rolling spend or points each needs
+// @Tags Tiers
+// @Produce json
+// @Success 200 {object} utils.ResponseOK{data=[]models.Tier} "Tiers fetched successfully"
+// @Security Authentication
+// @Router /tiers [get]
+func (t *TierController) ListTiers(c *gin.Context) {
+	tiers, err := t.service.Tier.ListTiers()
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Failed to fetch tiers", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, tiers, "Tiers fetched successfully", http.StatusOK)
+}
+
+// GetMyTier godoc
+// @Summary Get my tier
+// @Description Current tier of the logged in user, the spend and points counting towards it, the next tier and past tier changes
+// @Tags Tiers
+// @Produce json
+// @Success 200 {object} utils.ResponseOK{data=service.TierStatus} "Tier fetched successfully"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Security Authentication
+// @Router /users/me/tier [get]
+func (t *TierController) GetMyTier(c *gin.Context) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status, err := t.service.Tier.GetTierStatus(userID)
+	if err != nil {
+		t.log.Error("Failed to fetch tier status", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to fetch tier", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, status, "Tier fetched successfully", http.StatusOK)
+}
+
+// RecalculateTiers godoc
+// @Summary Recalculate tiers
+// @Description Run the nightly tier recalculation now
+// @Tags Tiers
+// @Produce json
+// @Success 200 {object} utils.ResponseOK "Tiers recalculated"
+// @Security Authentication
+// @Router /admin/tiers/recalculate [post]
+func (t *TierController) RecalculateTiers(c *gin.Context) {
+	changed, err := t.service.Tier.RecalculateTiers(time.Now())
+	if err != nil {
+		t.log.Error("Failed to recalculate tiers", zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to recalculate tiers", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, gin.H{"changed": changed}, "Tiers recalculated successfully", http.StatusOK)
+}
@@ -1,107 +1,259 @@
-package controller
-
-import (
-	"net/http"
-	"voucher_system/database"
-	"voucher_system/helper"
-	"voucher_system/models"
-	"voucher_system/service"
-	"voucher_system/utils"
-
-	"github.com/gin-gonic/gin"
-	"go.uber.org/zap"
-)
-
-type AuthController struct {
-	Service service.Service
-	log     *zap.Logger
-	Cacher  database.Cacher
-}
-
-func NewAuthController(service service.Service, log *zap.Logger, cacher database.Cacher) AuthController {
-	return AuthController{Service: service, log: log, Cacher: cacher}
-}
-
-type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email" example:"john.doe@example.com"`
-	Password string `json:"password" binding:"required" example:"password1234"`
-}
-
-// Login godoc
-// @Summary Login user
-// @Description Authenticate user with email and password
-// @Tags Authentication
-// @Accept json
-// @Produce json
-// @Param loginRequest body LoginRequest true "Login request payload"
-// @Success 200 {object} utils.ResponseOK{data=utils.LoginResponse} "Successful login"
-// @Failure 400 {object} utils.ErrorResponse "Invalid input"
-// @Failure 401 {object} utils.ErrorResponse "Invalid email or password"
-// @Failure 500 {object} utils.ErrorResponse "Failed to save token"
-// @Router /login [post]
-func (a *AuthController) Login(c *gin.Context) {
-
-	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
-		return
-	}
-
-	user, err := a.Service.User.Login(req.Email)
-	if err != nil {
-		helper.ResponseError(c, "User not found", "Invalid email or password", http.StatusUnauthorized)
-		return
-	}
-
-	if !utils.CheckPassword(req.Password, user.Password) {
-		c.Set("login_failed", true) // Set login_failed flag
-		a.log.Warn("Login failed", zap.String("email", req.Email))
-		helper.ResponseError(c, "Invalid email or password", "Unauthorized", http.StatusUnauthorized)
-		return
-	
-	}
-	userIDstr := helper.IntToString(user.ID)
-	token, err := utils.GenerateJWT(user.ID)
-	if err != nil {
-		helper.ResponseError(c, err.Error(), "Failed to generate jwt", http.StatusBadRequest)
-		return 
-	}
-	err = a.Cacher.SaveToken(userIDstr, token)
-	if err != nil {
-		helper.ResponseError(c, err.Error(), "Failed to save token", http.StatusInternalServerError)
-		return
-	}
-
-	helper.ResponseOK(c, gin.H{
-		"id":    userIDstr,
-		"token": token,
-	}, "Login Success", http.StatusOK)
-}
-
-// Register godoc
-// @Summary Register a new user
-// @Description Register a new user with email and password
-// @Tags Authentication
-// @Accept json
-// @Produce json
-// @Param registerRequest body models.User true "User registration request payload"
-// @Success 201 {object} utils.ResponseOK "User registered successfully"
-// @Failure 400 {object} utils.ErrorResponse "Invalid input"
-// @Failure 500 {object} utils.ErrorResponse "Failed to register user"
-// @Router /register [post]
-func (a *AuthController) Register(c *gin.Context) {
-	var req models.User
-	if err := c.ShouldBindJSON(&req); err != nil {
-		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
-		return
-	}
-
-	req.Password = utils.HashPassword(req.Password)
-
-	err := a.Service.User.Register(req)
-	if err != nil {
-		helper.ResponseError(c, err.Error(), "Failed to register", http.StatusInternalServerError)
-		return
-	}
-	helper.ResponseOK(c, nil, "register success", http.StatusCreated)
-}
+package controller
+
+import (
+	"net/http"
+	"voucher_system/database"
+	"voucher_system/helper"
+	"voucher_system/models"
+	"voucher_system/service"
+	"voucher_system/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AuthController struct {
+	Service service.Service
+	log     *zap.Logger
+	Cacher  database.Cacher
+	Mailer  utils.Mailer
+}
+
+func NewAuthController(service service.Service, log *zap.Logger, cacher database.Cacher, mailer utils.Mailer) AuthController {
+	return AuthController{Service: service, log: log, Cacher: cacher, Mailer: mailer}
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email" example:"john.doe@example.com"`
+	Password string `json:"password" binding:"required" example:"password1234"`
+}
+
+// Login godoc
+// @Summary Login user
+// @Description Authenticate user with email and password
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param loginRequest body LoginRequest true "Login request payload"
+// @Success 200 {object} utils.ResponseOK{data=utils.LoginResponse} "Successful login"
+// @Failure 400 {object} utils.ErrorResponse "Invalid input"
+// @Failure 401 {object} utils.ErrorResponse "Invalid email or password"
+// @Failure 403 {object} utils.ErrorResponse "Account is disabled"
+// @Failure 500 {object} utils.ErrorResponse "Failed to save token"
+// @Router /login [post]
+func (a *AuthController) Login(c *gin.Context) {
+
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	user, err := a.Service.User.Login(req.Email)
+	if err != nil {
+		helper.ResponseError(c, "User not found", "Invalid email or password", http.StatusUnauthorized)
+		return
+	}
+
+	if !utils.CheckPassword(req.Password, user.Password) {
+		c.Set("login_failed", true) // Set login_failed flag
+		a.log.Warn("Login failed", zap.String("email", req.Email))
+		helper.ResponseError(c, "Invalid email or password", "Unauthorized", http.StatusUnauthorized)
+		return
+	
+	}
+	if user.Disabled {
+		a.log.Warn("Login to disabled account", zap.String("email", req.Email))
+		helper.ResponseError(c, "Account is disabled", "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	userIDstr := helper.IntToString(user.ID)
+	token, err := utils.GenerateJWT(user.ID)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Failed to generate jwt", http.StatusBadRequest)
+		return 
+	}
+	err = a.Cacher.SaveToken(userIDstr, token)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Failed to save token", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, gin.H{
+		"id":                      userIDstr,
+		"token":                   token,
+		"password_reset_required": user.PasswordResetRequired,
+	}, "Login Success", http.StatusOK)
+}
+
+// Register godoc
+// @Summary Register a new user
+// @Description Register a new user with email and password
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param registerRequest body models.User true "User registration request payload"
+// @Success 201 {object} utils.ResponseOK "User registered successfully"
+// @Failure 400 {object} utils.ErrorResponse "Invalid input"
+// @Failure 409 {object} utils.ErrorResponse "Email is already registered"
+// @Failure 500 {object} utils.ErrorResponse "Failed to register user"
+// @Router /register [post]
+func (a *AuthController) Register(c *gin.Context) {
+	var req models.User
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	req.Password = utils.HashPassword(req.Password)
+	req.Role = models.RoleCustomer
+	req.Disabled = false
+	req.PasswordResetRequired = false
+
+	err := a.Service.User.Register(req)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Failed to register", profileErrorStatus(err))
+		return
+	}
+	helper.ResponseOK(c, nil, "register success", http.StatusCreated)
+}
+
+type IntrospectRequest struct {
+	Token         string `json:"token" form:"token" binding:"required"`
+	TokenTypeHint string `json:"token_type_hint" form:"token_type_hint"`
+}
+
+// IntrospectResponse follows RFC 7662. Only Active is set for inactive tokens.
+type IntrospectResponse struct {
+	Active    bool   `json:"active"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+// Introspect godoc
+// @Summary Introspect a token
+// @Description Report whether a token is active (valid signature, not expired, not revoked) and who it belongs to
+// @Tags Authentication
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Param introspectRequest body IntrospectRequest true "Token to introspect"
+// @Success 200 {object} IntrospectResponse "Introspection result"
+// @Failure 400 {object} utils.ErrorResponse "Invalid input"
+// @Failure 500 {object} utils.ErrorResponse "Failed to check session"
+// @Security Authentication
+// @Router /introspect [post]
+func (a *AuthController) Introspect(c *gin.Context) {
+	var req IntrospectRequest
+	if err := c.ShouldBind(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := utils.VerifyToken(req.Token)
+	if err != nil {
+		a.log.Info("Introspected token is not valid", zap.Error(err))
+		c.JSON(http.StatusOK, IntrospectResponse{Active: false})
+		return
+	}
+
+	active, err := a.Cacher.IsActiveToken(claims.Subject, req.Token)
+	if err != nil {
+		a.log.Error("Failed to check session", zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Failed to check session", http.StatusInternalServerError)
+		return
+	}
+	if !active {
+		c.JSON(http.StatusOK, IntrospectResponse{Active: false})
+		return
+	}
+
+	c.JSON(http.StatusOK, IntrospectResponse{
+		Active:    true,
+		Subject:   claims.Subject,
+		TokenType: "Bearer",
+		IssuedAt:  claims.IssuedAt.Unix(),
+		ExpiresAt: claims.ExpiresAt.Unix(),
+	})
+}
+
+// UserInfo godoc
+// @Summary Current user profile
+// @Description Return the profile of the user the token was issued for
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} utils.ResponseOK{data=models.User} "User profile"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Security Authentication
+// @Router /userinfo [get]
+func (a *AuthController) UserInfo(c *gin.Context) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := a.Service.User.GetUserByID(userID)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "User not found", http.StatusNotFound)
+		return
+	}
+
+	helper.ResponseOK(c, user, "User profile fetched successfully", http.StatusOK)
+}
+
+type ReauthRequest struct {
+	Password string `json:"password" binding:"required" example:"password1234"`
+}
+
+// Reauthenticate godoc
+// @Summary Confirm the password again
+// @Description Step-up authentication for sensitive routes. Returns a new token with a fresh auth_time that replaces the current session.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param reauthRequest body ReauthRequest true "Password confirmation"
+// @Success 200 {object} utils.ResponseOK{data=utils.LoginResponse} "Reauthenticated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid input"
+// @Failure 403 {object} utils.ErrorResponse "Password is incorrect"
+// @Security Authentication
+// @Router /reauth [post]
+func (a *AuthController) Reauthenticate(c *gin.Context) {
+	userID, err := helper.GetUserID(c)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ReauthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.ResponseError(c, err.Error(), "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Service.User.ConfirmPassword(userID, req.Password); err != nil {
+		c.Set("login_failed", true)
+		a.log.Warn("Reauthentication failed", zap.Int("userID", userID), zap.Error(err))
+		helper.ResponseError(c, err.Error(), "Reauthentication failed", profileErrorStatus(err))
+		return
+	}
+
+	userIDstr := helper.IntToString(userID)
+	token, err := utils.GenerateJWT(userID)
+	if err != nil {
+		helper.ResponseError(c, err.Error(), "Failed to generate jwt", http.StatusInternalServerError)
+		return
+	}
+	if err := a.Cacher.SaveToken(userIDstr, token); err != nil {
+		helper.ResponseError(c, err.Error(), "Failed to save token", http.StatusInternalServerError)
+		return
+	}
+
+	helper.ResponseOK(c, gin.H{
+		"id":    userIDstr,
+		"token": token,
+	}, "Reauthentication success", http.StatusOK)
+}
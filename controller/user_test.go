@@ -0,0 +1,257 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"voucher_system/config"
+	"voucher_system/controller"
+	"voucher_system/database"
+	"voucher_system/models"
+	"voucher_system/service"
+	"voucher_system/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockUserService struct {
+	mock.Mock
+}
+
+func (m *MockUserService) Login(email string) (models.User, error) {
+	args := m.Called(email)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *MockUserService) Register(user models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserService) GetUserByID(userID int) (models.User, error) {
+	args := m.Called(userID)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *MockUserService) UpdateProfile(userID int, name string) (models.User, error) {
+	args := m.Called(userID, name)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *MockUserService) ChangePassword(userID int, currentPassword, newPassword string) error {
+	args := m.Called(userID, currentPassword, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserService) CheckEmailChange(userID int, password, newEmail string) error {
+	args := m.Called(userID, password, newEmail)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ChangeEmail(userID int, newEmail string) error {
+	args := m.Called(userID, newEmail)
+	return args.Error(0)
+}
+
+func (m *MockUserService) DeleteAccount(userID int, password string) error {
+	args := m.Called(userID, password)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ConfirmPassword(userID int, password string) error {
+	args := m.Called(userID, password)
+	return args.Error(0)
+}
+
+// fakeMailer records the emails it is asked to send.
+type fakeMailer struct {
+	to, subject, body string
+	err               error
+}
+
+func (m *fakeMailer) Send(to, subject, body string) error {
+	m.to, m.subject, m.body = to, subject, body
+	return m.err
+}
+
+func setupAuthController(t *testing.T, userService service.UserService) (controller.AuthController, database.Cacher) {
+	mr := miniredis.RunT(t)
+	cfg := config.Configuration{
+		JwtKey:      "test-secret",
+		RedisConfig: config.RedisConfig{Url: mr.Addr()},
+	}
+	assert.NoError(t, utils.InitTokenProvider(cfg))
+
+	cacher := database.NewCacher(cfg, 60)
+	return controller.NewAuthController(service.Service{User: userService}, zap.NewNop(), cacher, &fakeMailer{}), cacher
+}
+
+func TestAuthController_Introspect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	introspect := func(ctl controller.AuthController, token string) controller.IntrospectResponse {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(`{"token":"`+token+`"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		ctl.Introspect(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp controller.IntrospectResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	ctl, cacher := setupAuthController(t, new(MockUserService))
+	token, err := utils.GenerateJWT(5)
+	assert.NoError(t, err)
+
+	t.Run("Token without a session is inactive", func(t *testing.T) {
+		assert.False(t, introspect(ctl, token).Active)
+	})
+
+	t.Run("Token matching the session is active", func(t *testing.T) {
+		assert.NoError(t, cacher.SaveToken("5", token))
+		resp := introspect(ctl, token)
+		assert.True(t, resp.Active)
+		assert.Equal(t, "5", resp.Subject)
+		assert.NotZero(t, resp.ExpiresAt)
+	})
+
+	t.Run("Garbage token is inactive", func(t *testing.T) {
+		assert.False(t, introspect(ctl, "not-a-token").Active)
+	})
+}
+
+func TestAuthController_UserInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Returns the profile of the token subject", func(t *testing.T) {
+		userService := new(MockUserService)
+		ctl, _ := setupAuthController(t, userService)
+		userService.On("GetUserByID", 5).Return(models.User{ID: 5, Name: "John Doe", Email: "john.doe@example.com"}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+		c.Set("userID", "5")
+
+		ctl.UserInfo(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "john.doe@example.com")
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		userService := new(MockUserService)
+		ctl, _ := setupAuthController(t, userService)
+		userService.On("GetUserByID", 9).Return(models.User{}, errors.New("record not found"))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+		c.Set("userID", "9")
+
+		ctl.UserInfo(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAuthController_ChangePassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	changePassword := func(ctl controller.AuthController, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPut, "/users/me/password", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("userID", "5")
+		ctl.ChangePassword(c)
+		return w
+	}
+
+	t.Run("Replaces the stored session", func(t *testing.T) {
+		userService := new(MockUserService)
+		ctl, cacher := setupAuthController(t, userService)
+		assert.NoError(t, cacher.SaveToken("5", "old-session"))
+		userService.On("ChangePassword", 5, "password1234", "newpassword1234").Return(nil)
+
+		w := changePassword(ctl, `{"current_password":"password1234","new_password":"newpassword1234"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		active, err := cacher.IsActiveToken("5", "old-session")
+		assert.NoError(t, err)
+		assert.False(t, active)
+	})
+
+	t.Run("Wrong current password keeps the session", func(t *testing.T) {
+		userService := new(MockUserService)
+		ctl, cacher := setupAuthController(t, userService)
+		assert.NoError(t, cacher.SaveToken("5", "old-session"))
+		userService.On("ChangePassword", 5, "wrong", "newpassword1234").Return(service.ErrInvalidPassword)
+
+		w := changePassword(ctl, `{"current_password":"wrong","new_password":"newpassword1234"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		active, _ := cacher.IsActiveToken("5", "old-session")
+		assert.True(t, active)
+	})
+}
+
+func TestAuthController_RequestEmailChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	requestChange := func(ctl controller.AuthController) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/users/me/email", strings.NewReader(`{"password":"password1234","new_email":"new@example.com"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("userID", "5")
+		ctl.RequestEmailChange(c)
+		return w
+	}
+
+	t.Run("Sends the code to the new email", func(t *testing.T) {
+		userService := new(MockUserService)
+		ctl, cacher := setupAuthController(t, userService)
+		mailer := &fakeMailer{}
+		ctl.Mailer = mailer
+		userService.On("CheckEmailChange", 5, "password1234", "new@example.com").Return(nil)
+
+		w := requestChange(ctl)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, "new@example.com", mailer.to)
+		var pending struct {
+			Code string `json:"code"`
+		}
+		stored, err := cacher.Get("email_change_5")
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal([]byte(stored), &pending))
+		assert.NotEmpty(t, pending.Code)
+		assert.Contains(t, mailer.body, pending.Code)
+	})
+
+	t.Run("Send failure drops the code", func(t *testing.T) {
+		userService := new(MockUserService)
+		ctl, cacher := setupAuthController(t, userService)
+		ctl.Mailer = &fakeMailer{err: errors.New("connection refused")}
+		userService.On("CheckEmailChange", 5, "password1234", "new@example.com").Return(nil)
+
+		w := requestChange(ctl)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		_, err := cacher.Get("email_change_5")
+		assert.Error(t, err)
+	})
+}
@@ -1,17 +1,105 @@
-package database
-
-import (
-	"voucher_system/models"
-
-	"gorm.io/gorm"
-)
-
-func Migrate(db *gorm.DB) error {
-	err := db.AutoMigrate(
-		&models.Voucher{},
-		&models.Redeem{},
-		&models.History{},
-	)
-
-	return err
-}
+package database
+
+import (
+	"voucher_system/models"
+
+	"gorm.io/gorm"
+)
+
+func Migrate(db *gorm.DB) error {
+	if err := dropReplacedConstraints(db); err != nil {
+		return err
+	}
+
+	err := db.AutoMigrate(
+		&models.Tier{},
+		&models.User{},
+		&models.Voucher{},
+		&models.Redeem{},
+		&models.History{},
+		&models.PointsAccount{},
+		&models.PointsLedgerEntry{},
+		&models.PointsLot{},
+		&models.EarningRule{},
+		&models.TierChange{},
+		&models.VoucherTierPrice{},
+		&models.VoucherReservation{},
+		&models.JobRun{},
+		&models.VoucherVersion{},
+		&models.VoucherCode{},
+	)
+	if err != nil {
+		return err
+	}
+	if err := widenAmountColumns(db); err != nil {
+		return err
+	}
+
+	return backfillDiscountTypes(db)
+}
+
+// amountColumns hold money.Amount values. They keep all four decimals an Amount carries,
+// so Postgres never rounds a stored amount.
+var amountColumns = []struct{ table, column string }{
+	{"vouchers", "discount_value"},
+	{"vouchers", "max_discount"},
+	{"vouchers", "minimum_purchase"},
+	{"histories", "transaction_amount"},
+	{"histories", "benefit_value"},
+	{"voucher_reservations", "transaction_amount"},
+	{"voucher_reservations", "benefit_value"},
+	{"tiers", "min_spend"},
+	{"tier_changes", "rolling_spend"},
+	{"earning_rules", "spend_per_point"},
+	{"earning_rules", "minimum_amount"},
+}
+
+// widenAmountColumns changes amount columns created with two decimals to numeric(20,4).
+// AutoMigrate leaves the scale of an existing numeric column alone.
+func widenAmountColumns(db *gorm.DB) error {
+	for _, c := range amountColumns {
+		var narrow int64
+		err := db.Raw(`SELECT COUNT(*) FROM information_schema.columns
+			WHERE table_schema = CURRENT_SCHEMA() AND table_name = ? AND column_name = ?
+				AND (numeric_precision <> 20 OR numeric_scale <> 4)`, c.table, c.column).Scan(&narrow).Error
+		if err != nil {
+			return err
+		}
+		if narrow == 0 {
+			continue
+		}
+		if err := db.Exec(`ALTER TABLE ` + c.table + ` ALTER COLUMN ` + c.column + ` TYPE numeric(20,4)`).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropReplacedConstraints removes constraints that the models now declare differently,
+// so AutoMigrate can create their replacements.
+func dropReplacedConstraints(db *gorm.DB) error {
+	statements := []string{
+		// The email of soft-deleted users may be registered again; see models.User.
+		`ALTER TABLE IF EXISTS users DROP CONSTRAINT IF EXISTS users_email_key`,
+		`ALTER TABLE IF EXISTS users DROP CONSTRAINT IF EXISTS uni_users_email`,
+		// Released and expired orders may be reserved again; see models.VoucherReservation.
+		`DROP INDEX IF EXISTS idx_reservation_order`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillDiscountTypes sets discount_type on vouchers created before it existed.
+// Discount values above 100 cannot be percentages, so those are fixed amounts.
+func backfillDiscountTypes(db *gorm.DB) error {
+	return db.Exec(`UPDATE vouchers SET discount_type = CASE
+			WHEN voucher_category = 'Free Shipping' THEN 'free_shipping'
+			WHEN discount_value > 100 THEN 'fixed_amount'
+			ELSE 'percentage'
+		END
+		WHERE discount_type IS NULL OR discount_type = ''`).Error
+}
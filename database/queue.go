@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// A queue is kept in four keys: a ready list, a delayed sorted set of messages waiting
+// for a retry, an in-flight sorted set of messages being processed scored by when they
+// become visible again, and a dead-letter list. The scripts below move a message between
+// them atomically and only when it is still where the caller saw it, so instances sharing
+// the queue never both act on the same message.
+
+var dequeueScript = redis.NewScript(`
+local message = redis.call("LPOP", KEYS[1])
+if message then
+	redis.call("ZADD", KEYS[2], ARGV[1], message)
+end
+return message`)
+
+var retryScript = redis.NewScript(`
+if redis.call("ZREM", KEYS[1], ARGV[1]) == 1 then
+	redis.call("ZADD", KEYS[2], ARGV[3], ARGV[2])
+	return 1
+end
+return 0`)
+
+var deadLetterScript = redis.NewScript(`
+if redis.call("ZREM", KEYS[1], ARGV[1]) == 1 then
+	redis.call("RPUSH", KEYS[2], ARGV[2])
+	return 1
+end
+return 0`)
+
+var promoteScript = redis.NewScript(`
+local due = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+local moved = 0
+for _, message in ipairs(due) do
+	if redis.call("ZREM", KEYS[1], message) == 1 then
+		redis.call("RPUSH", KEYS[2], message)
+		moved = moved + 1
+	end
+end
+return moved`)
+
+var requeueScript = redis.NewScript(`
+if redis.call("LREM", KEYS[1], 1, ARGV[1]) == 1 then
+	redis.call("RPUSH", KEYS[2], ARGV[2])
+	return 1
+end
+return 0`)
+
+// QueueLengths counts the messages in each part of a queue.
+type QueueLengths struct {
+	Ready    int64 `json:"ready"`
+	Delayed  int64 `json:"delayed"`
+	InFlight int64 `json:"in_flight"`
+	Dead     int64 `json:"dead"`
+}
+
+func (c *Cacher) queueKey(queue, part string) string {
+	return c.prefix + "_queue_" + queue + "_" + part
+}
+
+// Enqueue appends message to the ready list of queue.
+func (c *Cacher) Enqueue(queue string, message string) error {
+	return c.rdb.RPush(context.Background(), c.queueKey(queue, "ready"), message).Err()
+}
+
+// Dequeue takes the next ready message of queue and keeps it in flight until visibleAt.
+// It returns an empty message when the queue is empty.
+func (c *Cacher) Dequeue(queue string, visibleAt time.Time) (string, error) {
+	keys := []string{c.queueKey(queue, "ready"), c.queueKey(queue, "inflight")}
+	message, err := dequeueScript.Run(context.Background(), c.rdb, keys, visibleAt.UnixMilli()).Text()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return message, err
+}
+
+// Ack removes a processed message from flight.
+func (c *Cacher) Ack(queue string, message string) error {
+	return c.rdb.ZRem(context.Background(), c.queueKey(queue, "inflight"), message).Err()
+}
+
+// Retry replaces the in-flight message with next, delayed until at. It reports false
+// when message was no longer in flight.
+func (c *Cacher) Retry(queue string, message string, next string, at time.Time) (bool, error) {
+	keys := []string{c.queueKey(queue, "inflight"), c.queueKey(queue, "delayed")}
+	moved, err := retryScript.Run(context.Background(), c.rdb, keys, message, next, at.UnixMilli()).Int()
+	return moved == 1, err
+}
+
+// DeadLetter replaces the in-flight message with dead on the dead-letter list. It reports
+// false when message was no longer in flight.
+func (c *Cacher) DeadLetter(queue string, message string, dead string) (bool, error) {
+	keys := []string{c.queueKey(queue, "inflight"), c.queueKey(queue, "dead")}
+	moved, err := deadLetterScript.Run(context.Background(), c.rdb, keys, message, dead).Int()
+	return moved == 1, err
+}
+
+// PromoteDue moves up to limit delayed messages of queue that are due by now to the ready
+// list and returns how many it moved.
+func (c *Cacher) PromoteDue(queue string, now time.Time, limit int) (int, error) {
+	keys := []string{c.queueKey(queue, "delayed"), c.queueKey(queue, "ready")}
+	return promoteScript.Run(context.Background(), c.rdb, keys, now.UnixMilli(), limit).Int()
+}
+
+// ExpiredInFlight returns up to limit in-flight messages of queue whose visibility
+// timeout passed by now.
+func (c *Cacher) ExpiredInFlight(queue string, now time.Time, limit int) ([]string, error) {
+	return c.rdb.ZRangeByScore(context.Background(), c.queueKey(queue, "inflight"), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: int64(limit),
+	}).Result()
+}
+
+// DeadLetters returns the dead-letter messages of queue from start to stop, inclusive.
+func (c *Cacher) DeadLetters(queue string, start, stop int64) ([]string, error) {
+	return c.rdb.LRange(context.Background(), c.queueKey(queue, "dead"), start, stop).Result()
+}
+
+// RequeueDeadLetter replaces the dead message with next on the ready list. It reports
+// false when message was not on the dead-letter list.
+func (c *Cacher) RequeueDeadLetter(queue string, message string, next string) (bool, error) {
+	keys := []string{c.queueKey(queue, "dead"), c.queueKey(queue, "ready")}
+	moved, err := requeueScript.Run(context.Background(), c.rdb, keys, message, next).Int()
+	return moved == 1, err
+}
+
+// QueueLengths counts the messages in each part of queue.
+func (c *Cacher) QueueLengths(queue string) (QueueLengths, error) {
+	ctx := context.Background()
+	pipe := c.rdb.Pipeline()
+	ready := pipe.LLen(ctx, c.queueKey(queue, "ready"))
+	delayed := pipe.ZCard(ctx, c.queueKey(queue, "delayed"))
+	inFlight := pipe.ZCard(ctx, c.queueKey(queue, "inflight"))
+	dead := pipe.LLen(ctx, c.queueKey(queue, "dead"))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return QueueLengths{}, err
+	}
+	return QueueLengths{Ready: ready.Val(), Delayed: delayed.Val(), InFlight: inFlight.Val(), Dead: dead.Val()}, nil
+}
@@ -1,142 +1,179 @@
-package database
-
-import (
-	"context"
-	"fmt"
-	"time"
-	"voucher_system/config"
-
-	"github.com/go-redis/redis/v8"
-)
-
-type Cacher struct {
-	rdb      *redis.Client
-	expiracy time.Duration
-	prefix   string
-}
-
-func newRedisClient(url, password string, dbIndex int) *redis.Client {
-	return redis.NewClient(&redis.Options{
-		Addr:     url,
-		Password: password,
-		DB:       dbIndex,
-	})
-}
-func NewCacher(cfg config.Configuration, expiracy int) Cacher {
-	cache := Cacher{
-		rdb:      newRedisClient(cfg.RedisConfig.Url, cfg.RedisConfig.Password, 0),
-		expiracy: time.Duration(expiracy) * time.Second,
-		prefix:   cfg.RedisConfig.Prefix,
-	}
-
-	return cache
-}
-
-func (c *Cacher) Push(name string, value []byte) error {
-	return c.rdb.RPush(context.Background(), c.prefix+"_"+name, value).Err()
-}
-
-func (c *Cacher) Pop(name string) (string, error) {
-	return c.rdb.LPop(context.Background(), c.prefix+"_"+name).Result()
-}
-
-func (c *Cacher) GetLength(name string) int64 {
-	return c.rdb.LLen(context.Background(), c.prefix+"_"+name).Val()
-}
-
-func (c *Cacher) Set(name string, value string) error {
-	return c.rdb.Set(context.Background(), c.prefix+"_"+name, value, c.expiracy).Err()
-}
-
-func (c *Cacher) SaveToken(name string, value string) error {
-	return c.rdb.Set(context.Background(), c.prefix+"_"+name, value, 20*time.Hour).Err()
-}
-
-func (c *Cacher) Get(name string) (string, error) {
-	result, err := c.rdb.Get(context.Background(), c.prefix+"_"+name).Result()
-    if err != nil {
-        fmt.Println("error getting token from Redis: ", err)
-    }
-    return result, err
-}
-
-func (c *Cacher) Delete(name string) error {
-	return c.rdb.Del(context.Background(), c.prefix+"_"+name).Err()
-}
-
-func (c *Cacher) DeleteByKey(key string) error {
-	return c.rdb.Del(context.Background(), key).Err()
-}
-
-func (c *Cacher) PrintKeys() {
-	var cursor uint64
-	for {
-		var keys []string
-		var err error
-		keys, cursor, err = c.rdb.Scan(context.Background(), cursor, "", 0).Result()
-		if err != nil {
-			panic(err)
-		}
-
-		for _, key := range keys {
-			fmt.Println("key", key)
-		}
-
-		if cursor == 0 { // no more keys
-			break
-		}
-	}
-}
-
-func (c *Cacher) GetKeys() []string {
-	var cursor uint64
-	var result []string
-	for {
-		var keys []string
-		var err error
-		keys, cursor, err = c.rdb.Scan(context.Background(), cursor, "", 0).Result()
-		if err != nil {
-			panic(err)
-		}
-
-		result = append(result, keys...)
-
-		if cursor == 0 { // no more keys
-			break
-		}
-	}
-
-	return result
-}
-
-func (c *Cacher) GetKeysByPattern(pattern string) []string {
-	var cursor uint64
-	var result []string
-	for {
-		var keys []string
-		var err error
-		keys, cursor, err = c.rdb.Scan(context.Background(), cursor, pattern, 0).Result()
-		if err != nil {
-			panic(err)
-		}
-
-		result = append(result, keys...)
-
-		if cursor == 0 { // no more keys
-			break
-		}
-	}
-
-	return result
-}
-
-// Pub and Sub
-func (c *Cacher) Publish(channelName string, message string) error {
-	return c.rdb.Publish(context.Background(), channelName, message).Err()
-}
-
-func (c *Cacher) Subcribe(channelName string) (*redis.Message, error) {
-	subscriber := c.rdb.Subscribe(context.Background(), channelName)
-	message, err := subscriber.ReceiveMessage(context.Background())
-	return message, err
-}
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"voucher_system/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type Cacher struct {
+	rdb      *redis.Client
+	expiracy time.Duration
+	prefix   string
+}
+
+func newRedisClient(url, password string, dbIndex int) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     url,
+		Password: password,
+		DB:       dbIndex,
+	})
+}
+func NewCacher(cfg config.Configuration, expiracy int) Cacher {
+	cache := Cacher{
+		rdb:      newRedisClient(cfg.RedisConfig.Url, cfg.RedisConfig.Password, 0),
+		expiracy: time.Duration(expiracy) * time.Second,
+		prefix:   cfg.RedisConfig.Prefix,
+	}
+
+	return cache
+}
+
+func (c *Cacher) Push(name string, value []byte) error {
+	return c.rdb.RPush(context.Background(), c.prefix+"_"+name, value).Err()
+}
+
+func (c *Cacher) Pop(name string) (string, error) {
+	return c.rdb.LPop(context.Background(), c.prefix+"_"+name).Result()
+}
+
+func (c *Cacher) GetLength(name string) int64 {
+	return c.rdb.LLen(context.Background(), c.prefix+"_"+name).Val()
+}
+
+func (c *Cacher) Set(name string, value string) error {
+	return c.rdb.Set(context.Background(), c.prefix+"_"+name, value, c.expiracy).Err()
+}
+
+// SetIfAbsent stores value under name for ttl unless something is stored there already,
+// and reports whether it did.
+func (c *Cacher) SetIfAbsent(name string, value string, ttl time.Duration) (bool, error) {
+	return c.rdb.SetNX(context.Background(), c.prefix+"_"+name, value, ttl).Result()
+}
+
+// SetWithTTL is like Set but with its own expiry instead of the cacher's.
+func (c *Cacher) SetWithTTL(name string, value string, ttl time.Duration) error {
+	return c.rdb.Set(context.Background(), c.prefix+"_"+name, value, ttl).Err()
+}
+
+var deleteIfValue = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// DeleteIfValue deletes name only while it still holds value, so a lock is only released
+// by its owner, and reports whether it did.
+func (c *Cacher) DeleteIfValue(name string, value string) (bool, error) {
+	deleted, err := deleteIfValue.Run(context.Background(), c.rdb, []string{c.prefix + "_" + name}, value).Int()
+	return deleted == 1, err
+}
+
+func (c *Cacher) SaveToken(name string, value string) error {
+	return c.rdb.Set(context.Background(), c.prefix+"_"+name, value, 20*time.Hour).Err()
+}
+
+// IsActiveToken reports whether token is the session saved by Login for userID.
+// A missing session is reported as inactive, not as an error.
+func (c *Cacher) IsActiveToken(userID string, token string) (bool, error) {
+	storedToken, err := c.rdb.Get(context.Background(), c.prefix+"_"+userID).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return storedToken != "" && storedToken == token, nil
+}
+
+func (c *Cacher) Get(name string) (string, error) {
+	result, err := c.rdb.Get(context.Background(), c.prefix+"_"+name).Result()
+    if err != nil {
+        fmt.Println("error getting token from Redis: ", err)
+    }
+    return result, err
+}
+
+func (c *Cacher) Delete(name string) error {
+	return c.rdb.Del(context.Background(), c.prefix+"_"+name).Err()
+}
+
+func (c *Cacher) DeleteByKey(key string) error {
+	return c.rdb.Del(context.Background(), key).Err()
+}
+
+func (c *Cacher) PrintKeys() {
+	var cursor uint64
+	for {
+		var keys []string
+		var err error
+		keys, cursor, err = c.rdb.Scan(context.Background(), cursor, "", 0).Result()
+		if err != nil {
+			panic(err)
+		}
+
+		for _, key := range keys {
+			fmt.Println("key", key)
+		}
+
+		if cursor == 0 { // no more keys
+			break
+		}
+	}
+}
+
+func (c *Cacher) GetKeys() []string {
+	var cursor uint64
+	var result []string
+	for {
+		var keys []string
+		var err error
+		keys, cursor, err = c.rdb.Scan(context.Background(), cursor, "", 0).Result()
+		if err != nil {
+			panic(err)
+		}
+
+		result = append(result, keys...)
+
+		if cursor == 0 { // no more keys
+			break
+		}
+	}
+
+	return result
+}
+
+func (c *Cacher) GetKeysByPattern(pattern string) []string {
+	var cursor uint64
+	var result []string
+	for {
+		var keys []string
+		var err error
+		keys, cursor, err = c.rdb.Scan(context.Background(), cursor, pattern, 0).Result()
+		if err != nil {
+			panic(err)
+		}
+
+		result = append(result, keys...)
+
+		if cursor == 0 { // no more keys
+			break
+		}
+	}
+
+	return result
+}
+
+// Pub and Sub
+func (c *Cacher) Publish(channelName string, message string) error {
+	return c.rdb.Publish(context.Background(), channelName, message).Err()
+}
+
+func (c *Cacher) Subcribe(channelName string) (*redis.Message, error) {
+	subscriber := c.rdb.Subscribe(context.Background(), channelName)
+	message, err := subscriber.ReceiveMessage(context.Background())
+	return message, err
+}
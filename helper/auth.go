@@ -0,0 +1,17 @@
+package helper
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUserID returns the id of the user authenticated by the middleware.
+func GetUserID(c *gin.Context) (int, error) {
+	userID, err := strconv.Atoi(c.GetString("userID"))
+	if err != nil {
+		return 0, errors.New("user is not authenticated")
+	}
+	return userID, nil
+}
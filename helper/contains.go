@@ -1,26 +1,40 @@
-package helper
-
-import "strconv"
-
-func Contains(slice []string, str string) bool {
-	for _, item := range slice {
-		if item == str {
-			return true
-		}
-	}
-	return false
-}
-
-func StringToBool(str string) bool {
-	convBool, _ := strconv.ParseBool(str)
-	return convBool
-}
-
-func StringToInt(num string) int {
-	convInt, _ := strconv.Atoi(num)
-	return convInt
-}
-func IntToString(num int) string {
-	convStr := strconv.Itoa(num)
-	return convStr
-}
\ No newline at end of file
+package helper
+
+import (
+	"strconv"
+	"strings"
+)
+
+func Contains(slice []string, str string) bool {
+	for _, item := range slice {
+		if item == str {
+			return true
+		}
+	}
+	return false
+}
+
+func StringToBool(str string) bool {
+	convBool, _ := strconv.ParseBool(str)
+	return convBool
+}
+
+func StringToInt(num string) int {
+	convInt, _ := strconv.Atoi(num)
+	return convInt
+}
+func IntToString(num int) string {
+	convStr := strconv.Itoa(num)
+	return convStr
+}
+
+// StringToSlice splits a comma separated value, dropping empty items.
+func StringToSlice(str string) []string {
+	var result []string
+	for _, item := range strings.Split(str, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
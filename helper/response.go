@@ -1,25 +1,35 @@
-package helper
-
-import (
-	"github.com/gin-gonic/gin"
-)
-
-type HTTPResponse struct {
-	ErrorMsg string      `json:"error_msg,omitempty"`
-	Message  string      `json:"message,omitempty"`
-	Data     interface{} `json:"data,omitempty"`
-}
-
-func ResponseOK(c *gin.Context, data interface{}, message string, httpStatusCode int) {
-	c.JSON(httpStatusCode, HTTPResponse{
-		Message: message,
-		Data:    data,
-	})
-}
-
-func ResponseError(c *gin.Context, errorMsg string, message string, httpStatusCode int) {
-	c.JSON(httpStatusCode, HTTPResponse{
-		ErrorMsg: errorMsg,
-		Message:  message,
-	})
-}
+package helper
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+type HTTPResponse struct {
+	ErrorMsg string      `json:"error_msg,omitempty"`
+	Message  string      `json:"message,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+func ResponseOK(c *gin.Context, data interface{}, message string, httpStatusCode int) {
+	c.JSON(httpStatusCode, HTTPResponse{
+		Message: message,
+		Data:    data,
+	})
+}
+
+func ResponseError(c *gin.Context, errorMsg string, message string, httpStatusCode int) {
+	c.JSON(httpStatusCode, HTTPResponse{
+		ErrorMsg: errorMsg,
+		Message:  message,
+	})
+}
+
+// ResponseErrorData is ResponseError for failures that still produced data the client
+// needs, such as how much of the work was done.
+func ResponseErrorData(c *gin.Context, errorMsg string, message string, data interface{}, httpStatusCode int) {
+	c.JSON(httpStatusCode, HTTPResponse{
+		ErrorMsg: errorMsg,
+		Message:  message,
+		Data:     data,
+	})
+}
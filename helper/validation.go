@@ -0,0 +1,25 @@
+package helper
+
+import (
+	"reflect"
+	"voucher_system/utils/money"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(amountSign, money.Amount{})
+	}
+}
+
+// amountSign lets binding tags check money.Amount fields, which are structs the
+// validator cannot compare on its own. Amounts are validated by their sign: required
+// rejects zero, gt=0 asks for a positive amount and gte=0 rejects negative ones.
+func amountSign(field reflect.Value) interface{} {
+	if amount, ok := field.Interface().(money.Amount); ok {
+		return amount.Cmp(money.Amount{})
+	}
+	return nil
+}
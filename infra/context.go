@@ -1,70 +1,114 @@
-package infra
-
-import (
-	"voucher_system/config"
-	"voucher_system/controller"
-	"voucher_system/database"
-	"voucher_system/helper"
-	"voucher_system/middleware"
-	"voucher_system/repository"
-	"voucher_system/service"
-	"voucher_system/utils"
-
-	"go.uber.org/zap"
-	"gorm.io/gorm"
-)
-
-type ServiceContext struct {
-	Cfg config.Configuration
-	DB  *gorm.DB
-	Ctl controller.Controller
-	Log *zap.Logger
-	Cacher     database.Cacher
-	Middleware middleware.Middleware
-}
-
-func NewServiceContext() (*ServiceContext, error) {
-
-	handlerError := func(err error) (*ServiceContext, error) {
-		return nil, err
-	}
-
-	// instance config
-	config, err := config.ReadConfig()
-	if err != nil {
-		handlerError(err)
-	}
-
-	if err := utils.InitJwtKey(config); err != nil {
-		return handlerError(err)
-	}
-
-	// instance looger
-	log, err := helper.InitZapLogger()
-	if err != nil {
-		handlerError(err)
-	}
-
-	// instance database
-	db, err := database.InitDB(config)
-	if err != nil {
-		handlerError(err)
-	}
-
-	rdb := database.NewCacher(config, 60*60)
-
-	middleware := middleware.NewMiddleware(log, rdb)
-
-	// instance repository
-	repository := repository.NewRepository(db, log)
-
-	// instance service
-	service := service.NewService(repository, log)
-
-	// instance controller
-	Ctl := controller.NewController(service, log, rdb)
-
-
-
-	return &ServiceContext{Cfg: config, DB: db, Ctl: *Ctl, Log: log, Cacher: rdb, Middleware: middleware}, nil
-}
+package infra
+
+import (
+	"context"
+	"time"
+	"voucher_system/config"
+	"voucher_system/controller"
+	"voucher_system/database"
+	"voucher_system/helper"
+	"voucher_system/jobs"
+	"voucher_system/middleware"
+	"voucher_system/repository"
+	"voucher_system/service"
+	"voucher_system/utils"
+	"voucher_system/utils/money"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ServiceContext struct {
+	Cfg config.Configuration
+	DB  *gorm.DB
+	Ctl controller.Controller
+	Log *zap.Logger
+	Cacher     database.Cacher
+	Middleware middleware.Middleware
+	Queue      *jobs.Queue
+	stopJobs   context.CancelFunc
+}
+
+func NewServiceContext() (*ServiceContext, error) {
+
+	handlerError := func(err error) (*ServiceContext, error) {
+		return nil, err
+	}
+
+	// instance config
+	config, err := config.ReadConfig()
+	if err != nil {
+		handlerError(err)
+	}
+
+	if err := utils.InitTokenProvider(config); err != nil {
+		return handlerError(err)
+	}
+
+	// instance looger
+	log, err := helper.InitZapLogger()
+	if err != nil {
+		handlerError(err)
+	}
+
+	// instance database
+	db, err := database.InitDB(config)
+	if err != nil {
+		handlerError(err)
+	}
+
+	rdb := database.NewCacher(config, 60*60)
+
+	// instance repository
+	repository := repository.NewRepository(db, log)
+
+	middleware := middleware.NewMiddleware(log, rdb, repository.User)
+
+	rates, err := money.ParseRates(config.BaseCurrency, config.FXRates)
+	if err != nil {
+		return handlerError(err)
+	}
+
+	// instance service
+	service := service.NewService(repository, log, rates)
+
+	// background jobs
+	queue := jobs.NewQueue("default", &rdb, log, jobs.QueueOptions{
+		Concurrency:       config.QueueConfig.Concurrency,
+		VisibilityTimeout: time.Duration(config.QueueConfig.VisibilityTimeout) * time.Second,
+		MaxAttempts:       config.QueueConfig.MaxAttempts,
+	})
+	queue.Handle(jobs.TaskVoucherEvent, jobs.PublishVoucherEvent(&rdb))
+
+	scheduler := jobs.NewScheduler(&rdb, repository.JobRun, log)
+	schedules := []struct {
+		name, spec string
+		job        jobs.Func
+	}{
+		{"points-expiry", "0 * * * *", jobs.ExpirePoints(service.Points, log)},
+		{"tier-recalculation", "0 2 * * *", jobs.RecalculateTiers(service.Tier, log)},
+		{"reservation-expiry", "* * * * *", jobs.ExpireReservations(service.Reservation, log)},
+		{"voucher-status-sync", "* * * * *", jobs.SyncVoucherStatus(service.Manage, queue, log)},
+	}
+	for _, s := range schedules {
+		if err := scheduler.Register(s.name, s.spec, 0, s.job); err != nil {
+			return handlerError(err)
+		}
+	}
+
+	// instance controller
+	Ctl := controller.NewController(service, log, rdb, utils.NewMailer(config.MailConfig), scheduler, queue)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	scheduler.Start(jobsCtx)
+	queue.Start(jobsCtx)
+
+	return &ServiceContext{Cfg: config, DB: db, Ctl: *Ctl, Log: log, Cacher: rdb, Middleware: middleware, Queue: queue, stopJobs: stopJobs}, nil
+}
+
+// Shutdown stops scheduling jobs and waits for the queued tasks that are running to
+// finish, or for ctx to be done.
+func (s *ServiceContext) Shutdown(ctx context.Context) error {
+	s.stopJobs()
+	return s.Queue.Shutdown(ctx)
+}
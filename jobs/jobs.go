@@ -0,0 +1,9 @@
+package jobs
+
+import (
+	"context"
+)
+
+// Func is a unit of background work. Returning an error only logs it; the next run
+// tries again.
+type Func func(ctx context.Context) error
@@ -0,0 +1,90 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"voucher_system/models"
+	"voucher_system/service"
+	managementvoucherservice "voucher_system/service/management_voucher_service"
+
+	"go.uber.org/zap"
+)
+
+// ExpirePoints writes off the points lots that reached their expiry date.
+func ExpirePoints(points service.PointsService, log *zap.Logger) Func {
+	return func(ctx context.Context) error {
+		expired, err := points.ExpirePoints(time.Now())
+		if expired > 0 {
+			log.Info("Expired points", zap.Int("points", expired))
+		}
+		return err
+	}
+}
+
+// RecalculateTiers moves users between tiers based on their rolling spend and points.
+func RecalculateTiers(tiers service.TierService, log *zap.Logger) Func {
+	return func(ctx context.Context) error {
+		changed, err := tiers.RecalculateTiers(time.Now())
+		log.Info("Recalculated tiers", zap.Int("changed", changed))
+		return err
+	}
+}
+
+// ExpireReservations returns the quota held by voucher reservations that ran out.
+func ExpireReservations(reservations service.ReservationService, log *zap.Logger) Func {
+	return func(ctx context.Context) error {
+		expired, err := reservations.ExpireReservations(time.Now())
+		if expired > 0 {
+			log.Info("Expired voucher reservations", zap.Int("reservations", expired))
+		}
+		return err
+	}
+}
+
+// VoucherEventsChannel is the Redis channel voucher status events are published on.
+const VoucherEventsChannel = "voucher_events"
+
+// TaskVoucherEvent delivers a models.VoucherStatusEvent to VoucherEventsChannel.
+const TaskVoucherEvent = "voucher.event"
+
+// Publisher sends a message to subscribers of a channel; database.Cacher is one.
+type Publisher interface {
+	Publish(channelName string, message string) error
+}
+
+// Enqueuer adds a task to a work queue; Queue is one.
+type Enqueuer interface {
+	Enqueue(taskType string, payload interface{}) (*Task, error)
+}
+
+// SyncVoucherStatus activates vouchers whose start date arrived and deactivates those
+// whose end date passed, queueing a TaskVoucherEvent for each so a failed publish is
+// retried instead of lost.
+func SyncVoucherStatus(vouchers managementvoucherservice.ManageVoucherService, queue Enqueuer, log *zap.Logger) Func {
+	return func(ctx context.Context) error {
+		events, err := vouchers.SyncStatus(time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			log.Info("Voucher status changed", zap.String("event", event.Event), zap.Int("voucherID", event.VoucherID))
+			if _, err := queue.Enqueue(TaskVoucherEvent, event); err != nil {
+				log.Error("Failed to queue voucher event", zap.String("event", event.Event), zap.Int("voucherID", event.VoucherID), zap.Error(err))
+			}
+		}
+		return nil
+	}
+}
+
+// PublishVoucherEvent handles TaskVoucherEvent tasks.
+func PublishVoucherEvent(publisher Publisher) TaskHandler {
+	return Typed(func(ctx context.Context, event models.VoucherStatusEvent) error {
+		message, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		return publisher.Publish(VoucherEventsChannel, string(message))
+	})
+}
@@ -0,0 +1,74 @@
+package jobs_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"voucher_system/jobs"
+	"voucher_system/models"
+	managementvoucherservice "voucher_system/service/management_voucher_service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type recordingPublisher struct {
+	messages []string
+	err      error
+}
+
+func (p *recordingPublisher) Publish(channelName string, message string) error {
+	p.messages = append(p.messages, channelName+" "+message)
+	return p.err
+}
+
+type recordingQueue struct {
+	payloads []interface{}
+	err      error
+}
+
+func (q *recordingQueue) Enqueue(taskType string, payload interface{}) (*jobs.Task, error) {
+	q.payloads = append(q.payloads, payload)
+	return &jobs.Task{Type: taskType}, q.err
+}
+
+func TestSyncVoucherStatus(t *testing.T) {
+	t.Run("Queues an event per changed voucher", func(t *testing.T) {
+		vouchers := new(managementvoucherservice.ManagementVoucherServiceMock)
+		queue := &recordingQueue{}
+		events := []models.VoucherStatusEvent{
+			{Event: models.VoucherActivated, VoucherID: 1, VoucherCode: "NEWYEAR"},
+			{Event: models.VoucherExpired, VoucherID: 2, VoucherCode: "XMAS"},
+		}
+		vouchers.On("SyncStatus", mock.AnythingOfType("time.Time")).Return(events, nil)
+
+		err := jobs.SyncVoucherStatus(vouchers, queue, zap.NewNop())(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{events[0], events[1]}, queue.payloads)
+	})
+
+	t.Run("Queue failures do not fail the run", func(t *testing.T) {
+		vouchers := new(managementvoucherservice.ManagementVoucherServiceMock)
+		queue := &recordingQueue{err: errors.New("redis down")}
+		vouchers.On("SyncStatus", mock.AnythingOfType("time.Time")).
+			Return([]models.VoucherStatusEvent{{Event: models.VoucherActivated, VoucherID: 1}}, nil)
+
+		err := jobs.SyncVoucherStatus(vouchers, queue, zap.NewNop())(context.Background())
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestPublishVoucherEvent(t *testing.T) {
+	publisher := &recordingPublisher{}
+	event := models.VoucherStatusEvent{Event: models.VoucherExpired, VoucherID: 2, VoucherCode: "XMAS"}
+	payload, _ := json.Marshal(event)
+
+	err := jobs.PublishVoucherEvent(publisher)(context.Background(), jobs.Task{Type: jobs.TaskVoucherEvent, Payload: payload})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{jobs.VoucherEventsChannel + " " + string(payload)}, publisher.messages)
+}
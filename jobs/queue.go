@@ -0,0 +1,387 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"voucher_system/database"
+
+	"go.uber.org/zap"
+)
+
+var ErrTaskNotFound = errors.New("task not found")
+
+// Task is one unit of work on a Queue. Payload holds the JSON of the value it was
+// enqueued with.
+type Task struct {
+	ID         string          `json:"id" example:"3f1c9a7e0b2d4c58a6e1f0b9d8c7a6e5"`
+	Type       string          `json:"type" example:"voucher.event"`
+	Payload    json.RawMessage `json:"payload" swaggertype:"object"`
+	Attempts   int             `json:"attempts" example:"5"`
+	LastError  string          `json:"last_error,omitempty" example:"connection refused"`
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+	FailedAt   *time.Time      `json:"failed_at,omitempty"`
+}
+
+// TaskHandler processes a task. Returning an error retries the task with backoff until it
+// runs out of attempts and lands on the dead-letter list.
+type TaskHandler func(ctx context.Context, task Task) error
+
+// Typed adapts fn to a TaskHandler that receives the payload decoded into T.
+func Typed[T any](fn func(ctx context.Context, payload T) error) TaskHandler {
+	return func(ctx context.Context, task Task) error {
+		var payload T
+		if err := json.Unmarshal(task.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", task.Type, err)
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// QueueStore keeps the messages of a queue; database.Cacher is one.
+type QueueStore interface {
+	Enqueue(queue string, message string) error
+	Dequeue(queue string, visibleAt time.Time) (string, error)
+	Ack(queue string, message string) error
+	Retry(queue string, message string, next string, at time.Time) (bool, error)
+	DeadLetter(queue string, message string, dead string) (bool, error)
+	PromoteDue(queue string, now time.Time, limit int) (int, error)
+	ExpiredInFlight(queue string, now time.Time, limit int) ([]string, error)
+	DeadLetters(queue string, start, stop int64) ([]string, error)
+	RequeueDeadLetter(queue string, message string, next string) (bool, error)
+	QueueLengths(queue string) (database.QueueLengths, error)
+}
+
+// QueueOptions tunes a Queue; zero fields take the defaults noted on them.
+type QueueOptions struct {
+	// Concurrency is the number of workers, 4 by default.
+	Concurrency int
+	// VisibilityTimeout is how long a task may run before it is handed to another
+	// worker, 1 minute by default. It also bounds the handler's context.
+	VisibilityTimeout time.Duration
+	// MaxAttempts is how often a task runs before it is dead-lettered, 5 by default.
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry, 1 second by default; each later
+	// retry waits twice as long up to MaxBackoff, 5 minutes by default.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// PollInterval is how long an idle worker waits before looking again, 1 second by
+	// default.
+	PollInterval time.Duration
+}
+
+func (o QueueOptions) withDefaults() QueueOptions {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	if o.VisibilityTimeout <= 0 {
+		o.VisibilityTimeout = time.Minute
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 5 * time.Minute
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	return o
+}
+
+// Queue is a Redis-backed work queue shared by every instance. Tasks are delivered at
+// least once: a task whose worker does not finish within the visibility timeout is
+// retried elsewhere, so handlers must be safe to run twice.
+type Queue struct {
+	name  string
+	store QueueStore
+	log   *zap.Logger
+	opts  QueueOptions
+
+	mu       sync.RWMutex
+	handlers map[string]TaskHandler
+
+	wg       sync.WaitGroup
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewQueue(name string, store QueueStore, log *zap.Logger, opts QueueOptions) *Queue {
+	return &Queue{
+		name:     name,
+		store:    store,
+		log:      log,
+		opts:     opts.withDefaults(),
+		handlers: make(map[string]TaskHandler),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Handle sets the handler of taskType.
+func (q *Queue) Handle(taskType string, handler TaskHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[taskType] = handler
+}
+
+// Enqueue adds a taskType task carrying payload as JSON.
+func (q *Queue) Enqueue(taskType string, payload interface{}) (*Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	task := &Task{ID: id, Type: taskType, Payload: data, EnqueuedAt: time.Now()}
+	message, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.store.Enqueue(q.name, string(message)); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Start runs the workers and the loop that brings back due retries and timed-out tasks
+// until ctx is done or Shutdown is called.
+func (q *Queue) Start(ctx context.Context) {
+	for i := 0; i < q.opts.Concurrency; i++ {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+	q.wg.Add(1)
+	go q.maintain(ctx)
+	q.log.Info("Queue started", zap.String("queue", q.name), zap.Int("workers", q.opts.Concurrency))
+}
+
+// Shutdown stops taking new tasks and waits for the running ones to finish, or for ctx
+// to be done. Tasks cut off by ctx are retried once their visibility timeout passes.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.stopOnce.Do(func() { close(q.stop) })
+
+	drained := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		q.log.Info("Queue drained", zap.String("queue", q.name))
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats counts the tasks in each part of the queue.
+func (q *Queue) Stats() (database.QueueLengths, error) {
+	return q.store.QueueLengths(q.name)
+}
+
+// DeadLetters returns up to limit dead-lettered tasks, oldest first, skipping offset.
+func (q *Queue) DeadLetters(offset, limit int) ([]Task, error) {
+	messages, err := q.store.DeadLetters(q.name, int64(offset), int64(offset+limit-1))
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]Task, 0, len(messages))
+	for _, message := range messages {
+		var task Task
+		if err := json.Unmarshal([]byte(message), &task); err != nil {
+			q.log.Error("Skipping unreadable dead letter", zap.String("queue", q.name), zap.Error(err))
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// Requeue moves the dead-lettered task id back to the ready list with its attempts reset.
+func (q *Queue) Requeue(id string) (*Task, error) {
+	const pageSize = 100
+	for start := int64(0); ; start += pageSize {
+		messages, err := q.store.DeadLetters(q.name, start, start+pageSize-1)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			var task Task
+			if json.Unmarshal([]byte(message), &task) != nil || task.ID != id {
+				continue
+			}
+
+			task.Attempts, task.FailedAt = 0, nil
+			next, err := json.Marshal(task)
+			if err != nil {
+				return nil, err
+			}
+			moved, err := q.store.RequeueDeadLetter(q.name, message, string(next))
+			if err != nil {
+				return nil, err
+			}
+			if !moved {
+				return nil, ErrTaskNotFound
+			}
+			return &task, nil
+		}
+		if len(messages) < pageSize {
+			return nil, ErrTaskNotFound
+		}
+	}
+}
+
+func (q *Queue) work(ctx context.Context) {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		message, err := q.store.Dequeue(q.name, time.Now().Add(q.opts.VisibilityTimeout))
+		if err != nil {
+			q.log.Error("Failed to dequeue task", zap.String("queue", q.name), zap.Error(err))
+		}
+		if err != nil || message == "" {
+			q.idle(ctx)
+			continue
+		}
+		q.process(message)
+	}
+}
+
+// idle waits one poll interval unless the queue is stopping.
+func (q *Queue) idle(ctx context.Context) {
+	timer := time.NewTimer(q.opts.PollInterval)
+	defer timer.Stop()
+	select {
+	case <-q.stop:
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// process runs the task in message. It does not use the queue's context so a shutdown
+// lets the task finish instead of cancelling it.
+func (q *Queue) process(message string) {
+	var task Task
+	if err := json.Unmarshal([]byte(message), &task); err != nil {
+		q.log.Error("Dead-lettering unreadable task", zap.String("queue", q.name), zap.Error(err))
+		if _, err := q.store.DeadLetter(q.name, message, message); err != nil {
+			q.log.Error("Failed to dead-letter task", zap.String("queue", q.name), zap.Error(err))
+		}
+		return
+	}
+
+	q.mu.RLock()
+	handler, ok := q.handlers[task.Type]
+	q.mu.RUnlock()
+
+	err := fmt.Errorf("no handler for task type %s", task.Type)
+	if ok {
+		ctx, cancel := context.WithTimeout(context.Background(), q.opts.VisibilityTimeout)
+		err = runSafely(ctx, func(ctx context.Context) error { return handler(ctx, task) })
+		cancel()
+	}
+
+	if err == nil {
+		if err := q.store.Ack(q.name, message); err != nil {
+			q.log.Error("Failed to acknowledge task", zap.String("queue", q.name), zap.String("taskID", task.ID), zap.Error(err))
+		}
+		return
+	}
+	q.fail(message, task, err)
+}
+
+// fail counts a failed attempt of task and either schedules a retry with exponential
+// backoff or moves it to the dead-letter list.
+func (q *Queue) fail(message string, task Task, cause error) {
+	now := time.Now()
+	task.Attempts++
+	task.LastError = cause.Error()
+
+	if task.Attempts >= q.opts.MaxAttempts {
+		task.FailedAt = &now
+		dead, err := json.Marshal(task)
+		if err == nil {
+			_, err = q.store.DeadLetter(q.name, message, string(dead))
+		}
+		if err != nil {
+			q.log.Error("Failed to dead-letter task", zap.String("queue", q.name), zap.String("taskID", task.ID), zap.Error(err))
+			return
+		}
+		q.log.Warn("Task dead-lettered", zap.String("queue", q.name), zap.String("taskID", task.ID), zap.String("type", task.Type), zap.Error(cause))
+		return
+	}
+
+	next, err := json.Marshal(task)
+	if err == nil {
+		_, err = q.store.Retry(q.name, message, string(next), now.Add(q.backoff(task.Attempts)))
+	}
+	if err != nil {
+		q.log.Error("Failed to schedule task retry", zap.String("queue", q.name), zap.String("taskID", task.ID), zap.Error(err))
+		return
+	}
+	q.log.Info("Task will be retried", zap.String("queue", q.name), zap.String("taskID", task.ID), zap.Int("attempts", task.Attempts), zap.Error(cause))
+}
+
+// backoff is the delay before the retry that follows the attempts-th failed attempt.
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.opts.BaseBackoff
+	for i := 1; i < attempts && delay < q.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > q.opts.MaxBackoff {
+		return q.opts.MaxBackoff
+	}
+	return delay
+}
+
+// maintain moves due retries to the ready list and fails tasks whose visibility timeout
+// passed, once per poll interval.
+func (q *Queue) maintain(ctx context.Context) {
+	defer q.wg.Done()
+	const batchSize = 100
+	for {
+		now := time.Now()
+		if _, err := q.store.PromoteDue(q.name, now, batchSize); err != nil {
+			q.log.Error("Failed to promote delayed tasks", zap.String("queue", q.name), zap.Error(err))
+		}
+
+		expired, err := q.store.ExpiredInFlight(q.name, now, batchSize)
+		if err != nil {
+			q.log.Error("Failed to find timed-out tasks", zap.String("queue", q.name), zap.Error(err))
+		}
+		for _, message := range expired {
+			var task Task
+			if err := json.Unmarshal([]byte(message), &task); err != nil {
+				_, _ = q.store.DeadLetter(q.name, message, message)
+				continue
+			}
+			q.fail(message, task, errors.New("visibility timeout expired"))
+		}
+
+		select {
+		case <-q.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-time.After(q.opts.PollInterval):
+		}
+	}
+}
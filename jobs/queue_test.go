@@ -0,0 +1,136 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+	"voucher_system/config"
+	"voucher_system/database"
+	"voucher_system/jobs"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+func setupQueue(t *testing.T, opts jobs.QueueOptions) (*jobs.Queue, *database.Cacher) {
+	mr := miniredis.RunT(t)
+	cacher := database.NewCacher(config.Configuration{RedisConfig: config.RedisConfig{Url: mr.Addr(), Prefix: "test"}}, 60)
+
+	opts.PollInterval = 5 * time.Millisecond
+	opts.BaseBackoff = time.Millisecond
+	queue := jobs.NewQueue("test", &cacher, zap.NewNop(), opts)
+	t.Cleanup(func() { _ = queue.Shutdown(context.Background()) })
+	return queue, &cacher
+}
+
+func queueLengths(t *testing.T, queue *jobs.Queue) database.QueueLengths {
+	stats, err := queue.Stats()
+	assert.NoError(t, err)
+	return stats
+}
+
+func TestQueue_ProcessesTasks(t *testing.T) {
+	queue, _ := setupQueue(t, jobs.QueueOptions{Concurrency: 2})
+	received := make(chan string, 1)
+	queue.Handle("greet", jobs.Typed(func(ctx context.Context, payload greeting) error {
+		received <- payload.Name
+		return nil
+	}))
+
+	_, err := queue.Enqueue("greet", greeting{Name: "Ayu"})
+	assert.NoError(t, err)
+	queue.Start(context.Background())
+
+	select {
+	case name := <-received:
+		assert.Equal(t, "Ayu", name)
+	case <-time.After(time.Second):
+		t.Fatal("task was not processed")
+	}
+	assert.Eventually(t, func() bool { return queueLengths(t, queue) == database.QueueLengths{} }, time.Second, 5*time.Millisecond)
+}
+
+func TestQueue_RetriesThenDeadLetters(t *testing.T) {
+	queue, _ := setupQueue(t, jobs.QueueOptions{MaxAttempts: 3})
+	var attempts atomic.Int32
+	queue.Handle("greet", func(ctx context.Context, task jobs.Task) error {
+		attempts.Add(1)
+		return errors.New("mail server down")
+	})
+
+	task, err := queue.Enqueue("greet", greeting{Name: "Ayu"})
+	assert.NoError(t, err)
+	queue.Start(context.Background())
+
+	assert.Eventually(t, func() bool { return queueLengths(t, queue).Dead == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	dead, err := queue.DeadLetters(0, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, dead, 1) {
+		assert.Equal(t, task.ID, dead[0].ID)
+		assert.Equal(t, 3, dead[0].Attempts)
+		assert.Equal(t, "mail server down", dead[0].LastError)
+		assert.NotNil(t, dead[0].FailedAt)
+	}
+
+	requeued, err := queue.Requeue(task.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, requeued.Attempts)
+	assert.Eventually(t, func() bool { return attempts.Load() == 6 }, time.Second, 5*time.Millisecond)
+
+	_, err = queue.Requeue("missing")
+	assert.ErrorIs(t, err, jobs.ErrTaskNotFound)
+}
+
+func TestQueue_RetriesTimedOutTasks(t *testing.T) {
+	queue, cacher := setupQueue(t, jobs.QueueOptions{})
+	received := make(chan struct{}, 1)
+	queue.Handle("greet", func(ctx context.Context, task jobs.Task) error {
+		received <- struct{}{}
+		return nil
+	})
+
+	_, err := queue.Enqueue("greet", greeting{Name: "Ayu"})
+	assert.NoError(t, err)
+	// A worker that took the task and died before its visibility timeout passed.
+	message, err := cacher.Dequeue("test", time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, message)
+
+	queue.Start(context.Background())
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("timed-out task was not retried")
+	}
+}
+
+func TestQueue_ShutdownDrainsRunningTasks(t *testing.T) {
+	queue, _ := setupQueue(t, jobs.QueueOptions{Concurrency: 1})
+	started := make(chan struct{})
+	var finished atomic.Bool
+	queue.Handle("greet", func(ctx context.Context, task jobs.Task) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	})
+
+	_, err := queue.Enqueue("greet", greeting{Name: "Ayu"})
+	assert.NoError(t, err)
+	queue.Start(context.Background())
+	<-started
+
+	assert.NoError(t, queue.Shutdown(context.Background()))
+	assert.True(t, finished.Load())
+	assert.Equal(t, database.QueueLengths{}, queueLengths(t, queue))
+}
@@ -0,0 +1,42 @@
+package jobs_test
+
+import (
+	"testing"
+	"time"
+	"voucher_system/jobs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	from := time.Date(2024, time.December, 6, 10, 17, 30, 0, time.UTC) // a Friday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"@every 90s", time.Date(2024, time.December, 6, 10, 19, 0, 0, time.UTC)},
+		{"* * * * *", time.Date(2024, time.December, 6, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.December, 6, 10, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.December, 6, 11, 0, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, time.December, 7, 2, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2024, time.December, 9, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 25 * 7", time.Date(2024, time.December, 8, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.spec, func(t *testing.T) {
+			schedule, err := jobs.ParseSchedule(tc.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, schedule.Next(from))
+		})
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "5-1 * * * *", "*/0 * * * *", "@every 10ms", "@weekly"} {
+		_, err := jobs.ParseSchedule(spec)
+		assert.Error(t, err, spec)
+	}
+}
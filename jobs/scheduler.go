@@ -0,0 +1,287 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+
+	"go.uber.org/zap"
+)
+
+// DefaultJobTimeout bounds a run when the job is registered without its own timeout.
+const DefaultJobTimeout = 10 * time.Minute
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobRunning  = errors.New("job is already running")
+	ErrJobExists   = errors.New("job is already registered")
+)
+
+// Locker keeps a named lock in a store shared by every instance; database.Cacher is one.
+type Locker interface {
+	SetIfAbsent(name string, value string, ttl time.Duration) (bool, error)
+	DeleteIfValue(name string, value string) (bool, error)
+}
+
+// JobStatus describes a registered job for the admin API.
+type JobStatus struct {
+	Name     string         `json:"name" example:"points-expiry"`
+	Schedule string         `json:"schedule" example:"0 * * * *"`
+	Timeout  string         `json:"timeout" example:"10m0s"`
+	NextRun  time.Time      `json:"next_run"`
+	LastRun  *models.JobRun `json:"last_run,omitempty"`
+}
+
+type scheduledJob struct {
+	name     string
+	spec     string
+	schedule Schedule
+	timeout  time.Duration
+	run      Func
+	next     time.Time
+}
+
+// Scheduler runs registered jobs on their schedules. Every instance runs the same
+// scheduler; a Redis lock per scheduled tick makes sure only one of them runs each tick,
+// a lock per job keeps manual triggers from overlapping, and every run is recorded in the
+// job run history.
+type Scheduler struct {
+	locker   Locker
+	runs     repository.JobRunRepository
+	log      *zap.Logger
+	instance string
+
+	mu   sync.Mutex
+	ctx  context.Context
+	jobs map[string]*scheduledJob
+}
+
+func NewScheduler(locker Locker, runs repository.JobRunRepository, log *zap.Logger) *Scheduler {
+	hostname, _ := os.Hostname()
+	return &Scheduler{
+		locker:   locker,
+		runs:     runs,
+		log:      log,
+		instance: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		ctx:      context.Background(),
+		jobs:     make(map[string]*scheduledJob),
+	}
+}
+
+// Register adds job under name to run on spec (see ParseSchedule). A zero timeout means
+// DefaultJobTimeout; it also bounds how long the job's locks are held. Jobs must be
+// registered before Start.
+func (s *Scheduler) Register(name, spec string, timeout time.Duration, job Func) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	if timeout <= 0 {
+		timeout = DefaultJobTimeout
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("job %s: %w", name, ErrJobExists)
+	}
+	s.jobs[name] = &scheduledJob{name: name, spec: spec, schedule: schedule, timeout: timeout, run: job}
+	return nil
+}
+
+// Start runs every registered job on its schedule until ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	jobs := make([]*scheduledJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	s.mu.Unlock()
+
+	for _, job := range jobs {
+		go s.loop(ctx, job)
+	}
+	s.log.Info("Scheduler started", zap.String("instance", s.instance), zap.Int("jobs", len(jobs)))
+}
+
+func (s *Scheduler) loop(ctx context.Context, job *scheduledJob) {
+	for {
+		next := job.schedule.Next(time.Now())
+		if next.IsZero() {
+			s.log.Warn("Job has no next run", zap.String("job", job.name))
+			return
+		}
+		s.mu.Lock()
+		job.next = next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// The tick lock is left to expire rather than released, so an instance whose
+		// clock runs behind cannot run the same tick again once this run has finished.
+		_, run, err := s.begin(job, models.JobTriggerSchedule, tickLockName(job.name, next))
+		if errors.Is(err, ErrJobRunning) {
+			s.log.Debug("Job tick was run by another instance", zap.String("job", job.name), zap.Time("tick", next))
+			continue
+		}
+		if err != nil {
+			s.log.Error("Failed to start job", zap.String("job", job.name), zap.Error(err))
+			continue
+		}
+		s.execute(ctx, job, run)
+	}
+}
+
+// Trigger starts name right away in the background and returns its run, unless a run of
+// the job is already in progress on any instance.
+func (s *Scheduler) Trigger(name string) (*models.JobRun, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[name]
+	ctx := s.ctx
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	lock := lockName(job.name)
+	token, run, err := s.begin(job, models.JobTriggerManual, lock)
+	if err != nil {
+		return nil, err
+	}
+
+	started := *run
+	go func() {
+		defer s.unlock(job, lock, token)
+		s.execute(ctx, job, run)
+	}()
+	return &started, nil
+}
+
+// Jobs lists the registered jobs by name with their next and last run.
+func (s *Scheduler) Jobs() ([]JobStatus, error) {
+	s.mu.Lock()
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		statuses = append(statuses, JobStatus{Name: job.name, Schedule: job.spec, Timeout: job.timeout.String(), NextRun: job.next})
+	}
+	s.mu.Unlock()
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	for i := range statuses {
+		runs, err := s.runs.FindRecent(statuses[i].Name, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) > 0 {
+			statuses[i].LastRun = &runs[0]
+		}
+	}
+	return statuses, nil
+}
+
+// Runs returns the latest runs of name, newest first.
+func (s *Scheduler) Runs(name string, limit int) ([]models.JobRun, error) {
+	s.mu.Lock()
+	_, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return s.runs.FindRecent(name, limit)
+}
+
+// begin takes lock for the job's timeout and records the start of a run. It returns
+// ErrJobRunning when lock is already held.
+func (s *Scheduler) begin(job *scheduledJob, trigger, lock string) (string, *models.JobRun, error) {
+	token, err := randomID()
+	if err != nil {
+		return "", nil, err
+	}
+	acquired, err := s.locker.SetIfAbsent(lock, token, job.timeout)
+	if err != nil {
+		return "", nil, err
+	}
+	if !acquired {
+		return "", nil, ErrJobRunning
+	}
+
+	run := &models.JobRun{
+		Job:       job.name,
+		Trigger:   trigger,
+		Instance:  s.instance,
+		Status:    models.JobRunRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.runs.Start(run); err != nil {
+		s.unlock(job, lock, token)
+		return "", nil, err
+	}
+	return token, run, nil
+}
+
+// execute runs job within its timeout and records the outcome.
+func (s *Scheduler) execute(ctx context.Context, job *scheduledJob, run *models.JobRun) {
+	runCtx, cancel := context.WithTimeout(ctx, job.timeout)
+	defer cancel()
+
+	err := runSafely(runCtx, job.run)
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Status = models.JobRunSucceeded
+	if err != nil {
+		run.Status = models.JobRunFailed
+		run.Error = err.Error()
+		s.log.Error("Job failed", zap.String("job", job.name), zap.String("trigger", run.Trigger), zap.Error(err))
+	}
+
+	if err := s.runs.Finish(run); err != nil {
+		s.log.Error("Failed to record job run", zap.String("job", job.name), zap.Int("runID", run.ID), zap.Error(err))
+	}
+}
+
+func (s *Scheduler) unlock(job *scheduledJob, lock, token string) {
+	if _, err := s.locker.DeleteIfValue(lock, token); err != nil {
+		s.log.Error("Failed to release job lock", zap.String("job", job.name), zap.Error(err))
+	}
+}
+
+// runSafely turns a panic in job into an error so one bad run does not stop the scheduler.
+func runSafely(ctx context.Context, job Func) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return job(ctx)
+}
+
+func lockName(job string) string {
+	return "job_lock_" + job
+}
+
+func tickLockName(job string, tick time.Time) string {
+	return fmt.Sprintf("job_lock_%s_%d", job, tick.Unix())
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
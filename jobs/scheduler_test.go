@@ -0,0 +1,205 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"voucher_system/jobs"
+	"voucher_system/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type memoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]string
+}
+
+func (l *memoryLocker) SetIfAbsent(name string, value string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.locks[name]; ok {
+		return false, nil
+	}
+	l.locks[name] = value
+	return true, nil
+}
+
+func (l *memoryLocker) DeleteIfValue(name string, value string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locks[name] != value {
+		return false, nil
+	}
+	delete(l.locks, name)
+	return true, nil
+}
+
+func (l *memoryLocker) held(name string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.locks[name]
+	return ok
+}
+
+type memoryRuns struct {
+	mu       sync.Mutex
+	runs     []models.JobRun
+	finished chan models.JobRun
+}
+
+func (r *memoryRuns) Start(run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run.ID = len(r.runs) + 1
+	r.runs = append(r.runs, *run)
+	return nil
+}
+
+func (r *memoryRuns) Finish(run *models.JobRun) error {
+	r.mu.Lock()
+	r.runs[run.ID-1] = *run
+	r.mu.Unlock()
+	r.finished <- *run
+	return nil
+}
+
+func (r *memoryRuns) FindRecent(job string, limit int) ([]models.JobRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var runs []models.JobRun
+	for i := len(r.runs) - 1; i >= 0 && len(runs) < limit; i-- {
+		if r.runs[i].Job == job {
+			runs = append(runs, r.runs[i])
+		}
+	}
+	return runs, nil
+}
+
+func newTestScheduler() (*jobs.Scheduler, *memoryLocker, *memoryRuns) {
+	locker := &memoryLocker{locks: map[string]string{}}
+	runs := &memoryRuns{finished: make(chan models.JobRun, 10)}
+	return jobs.NewScheduler(locker, runs, zap.NewNop()), locker, runs
+}
+
+func waitForRun(t *testing.T, runs *memoryRuns) models.JobRun {
+	select {
+	case run := <-runs.finished:
+		return run
+	case <-time.After(time.Second):
+		t.Fatal("job run did not finish")
+		return models.JobRun{}
+	}
+}
+
+func TestScheduler_Trigger(t *testing.T) {
+	t.Run("Runs the job and records the outcome", func(t *testing.T) {
+		scheduler, locker, runs := newTestScheduler()
+		assert.NoError(t, scheduler.Register("sync", "@daily", 0, func(ctx context.Context) error {
+			return errors.New("database unavailable")
+		}))
+
+		run, err := scheduler.Trigger("sync")
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.JobTriggerManual, run.Trigger)
+		assert.Equal(t, models.JobRunRunning, run.Status)
+
+		finished := waitForRun(t, runs)
+		assert.Equal(t, models.JobRunFailed, finished.Status)
+		assert.Equal(t, "database unavailable", finished.Error)
+		assert.NotNil(t, finished.FinishedAt)
+		assert.Eventually(t, func() bool { return !locker.held("job_lock_sync") }, time.Second, time.Millisecond)
+	})
+
+	t.Run("Refuses while another run holds the lock", func(t *testing.T) {
+		scheduler, locker, _ := newTestScheduler()
+		assert.NoError(t, scheduler.Register("sync", "@daily", 0, func(ctx context.Context) error { return nil }))
+		locker.locks["job_lock_sync"] = "other-instance"
+
+		_, err := scheduler.Trigger("sync")
+
+		assert.ErrorIs(t, err, jobs.ErrJobRunning)
+		assert.Equal(t, "other-instance", locker.locks["job_lock_sync"])
+	})
+
+	t.Run("Recovers from a panicking job", func(t *testing.T) {
+		scheduler, _, runs := newTestScheduler()
+		assert.NoError(t, scheduler.Register("sync", "@daily", 0, func(ctx context.Context) error { panic("boom") }))
+
+		_, err := scheduler.Trigger("sync")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "job panicked: boom", waitForRun(t, runs).Error)
+	})
+
+	t.Run("Unknown job", func(t *testing.T) {
+		scheduler, _, _ := newTestScheduler()
+
+		_, err := scheduler.Trigger("missing")
+
+		assert.ErrorIs(t, err, jobs.ErrJobNotFound)
+	})
+}
+
+func TestScheduler_Jobs(t *testing.T) {
+	scheduler, _, runs := newTestScheduler()
+	assert.NoError(t, scheduler.Register("tier-recalculation", "0 2 * * *", 0, func(ctx context.Context) error { return nil }))
+	assert.NoError(t, scheduler.Register("points-expiry", "0 * * * *", time.Minute, func(ctx context.Context) error { return nil }))
+	assert.ErrorIs(t, scheduler.Register("points-expiry", "0 * * * *", 0, nil), jobs.ErrJobExists)
+
+	_, err := scheduler.Trigger("points-expiry")
+	assert.NoError(t, err)
+	waitForRun(t, runs)
+
+	statuses, err := scheduler.Jobs()
+
+	assert.NoError(t, err)
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, "points-expiry", statuses[0].Name)
+		assert.Equal(t, "1m0s", statuses[0].Timeout)
+		assert.Equal(t, models.JobRunSucceeded, statuses[0].LastRun.Status)
+		assert.Nil(t, statuses[1].LastRun)
+	}
+}
+
+func TestScheduler_Start(t *testing.T) {
+	scheduler, locker, runs := newTestScheduler()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, scheduler.Register("tick", "@every 1s", 0, func(ctx context.Context) error { return nil }))
+
+	scheduler.Start(ctx)
+
+	select {
+	case run := <-runs.finished:
+		assert.Equal(t, models.JobTriggerSchedule, run.Trigger)
+		// The tick stays locked after the run so no other instance runs it again.
+		assert.True(t, locker.held(fmt.Sprintf("job_lock_tick_%d", run.StartedAt.Truncate(time.Second).Unix())))
+		assert.False(t, locker.held("job_lock_tick"))
+	case <-time.After(3 * time.Second):
+		t.Fatal("scheduled job did not run")
+	}
+}
+
+func TestScheduler_Start_SkipsTickTakenByAnotherInstance(t *testing.T) {
+	scheduler, locker, runs := newTestScheduler()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, scheduler.Register("tick", "@every 1s", 0, func(ctx context.Context) error { return nil }))
+	next := time.Now().Add(time.Second).Truncate(time.Second)
+	locker.locks[fmt.Sprintf("job_lock_tick_%d", next.Unix())] = "other-instance"
+
+	scheduler.Start(ctx)
+
+	select {
+	case run := <-runs.finished:
+		assert.True(t, run.StartedAt.After(next), "ran the tick taken by another instance")
+	case <-time.After(4 * time.Second):
+		t.Fatal("scheduled job did not run")
+	}
+}
@@ -1,40 +1,63 @@
-package main
-
-import (
-	"log"
-	"voucher_system/infra"
-	"voucher_system/router"
-
-	_ "voucher_system/docs"
-)
-
-// @title Voucher System API
-// @version 1.0
-// @description API for managing vouchers
-// @termsOfService http://example.com/terms/
-// @contact.name API Support
-// @contact.url http://example.com/support
-// @contact.email support@example.com
-// @license.name MIT
-// @license.url https://opensource.org/licenses/MIT
-// @host localhost:8080
-// @BasePath /
-// @securityDefinitions.apikey Authentication
-// @in header
-// @name Authorization
-// @securityDefinitions.apikey UserID
-// @in header
-// @name User-ID
-
-func main() {
-	ctx, err := infra.NewServiceContext()
-	if err != nil {
-		log.Fatal("can't init service context %w", err)
-	}
-
-	r := router.NewRoutes(*ctx)
-
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("failed to run server: %v", err)
-	}
-}
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+	"voucher_system/infra"
+	"voucher_system/router"
+
+	_ "voucher_system/docs"
+)
+
+// @title Voucher System API
+// @version 1.0
+// @description API for managing vouchers
+// @termsOfService http://example.com/terms/
+// @contact.name API Support
+// @contact.url http://example.com/support
+// @contact.email support@example.com
+// @license.name MIT
+// @license.url https://opensource.org/licenses/MIT
+// @host localhost:8080
+// @BasePath /
+// @securityDefinitions.apikey Authentication
+// @in header
+// @name Authorization
+// @securityDefinitions.apikey UserID
+// @in header
+// @name User-ID
+
+func main() {
+	ctx, err := infra.NewServiceContext()
+	if err != nil {
+		log.Fatal("can't init service context %w", err)
+	}
+
+	r := router.NewRoutes(*ctx)
+	server := &http.Server{Addr: ":8080", Handler: r}
+
+	stop, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("failed to run server: %v", err)
+		}
+	}()
+	<-stop.Done()
+
+	// Stop taking requests first, then let the queued tasks that are running finish.
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to shut down server: %v", err)
+	}
+	if err := ctx.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to drain job queue: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"voucher_system/config"
+	"voucher_system/database"
+	"voucher_system/middleware"
+	"voucher_system/models"
+	"voucher_system/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type fakeUsers map[int]models.User
+
+func (f fakeUsers) GetUserByID(userID int) (models.User, error) {
+	user, ok := f[userID]
+	if !ok {
+		return models.User{}, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+var testUsers = fakeUsers{
+	3: {ID: 3, Role: models.RoleCustomer},
+	7: {ID: 7, Role: models.RoleAdmin},
+	8: {ID: 8, Role: models.RoleCustomer, Disabled: true},
+}
+
+func setupMiddleware(t *testing.T) (middleware.Middleware, database.Cacher) {
+	mr := miniredis.RunT(t)
+	cfg := config.Configuration{
+		JwtKey:      "test-secret",
+		RedisConfig: config.RedisConfig{Url: mr.Addr(), Prefix: "test"},
+	}
+	assert.NoError(t, utils.InitJwtKey(cfg))
+
+	cacher := database.NewCacher(cfg, 60)
+	return middleware.NewMiddleware(zap.NewNop(), cacher, testUsers), cacher
+}
+
+func performRequest(handler gin.HandlerFunc, headers map[string]string, extra ...gin.HandlerFunc) (*httptest.ResponseRecorder, string) {
+	gin.SetMode(gin.TestMode)
+	var userID string
+	r := gin.New()
+	handlers := append([]gin.HandlerFunc{handler}, extra...)
+	r.GET("/", append(handlers, func(c *gin.Context) {
+		userID = c.GetString("userID")
+		c.Status(http.StatusOK)
+	})...)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, userID
+}
+
+func TestAuthenticator(t *testing.T) {
+	t.Run("Missing token is rejected in every mode", func(t *testing.T) {
+		m, _ := setupMiddleware(t)
+		for _, mode := range []middleware.AuthMode{middleware.AuthStateless, middleware.AuthStateful, middleware.AuthOpaque} {
+			w, _ := performRequest(m.Authenticator(mode), nil)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, mode.String())
+		}
+	})
+
+	t.Run("Stateless accepts a valid JWT without a session", func(t *testing.T) {
+		m, _ := setupMiddleware(t)
+		token, err := utils.GenerateJWT(7)
+		assert.NoError(t, err)
+
+		w, userID := performRequest(m.Authenticator(middleware.AuthStateless), map[string]string{"Authorization": "Bearer " + token})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "7", userID)
+	})
+
+	t.Run("Stateless rejects a tampered JWT", func(t *testing.T) {
+		m, _ := setupMiddleware(t)
+		token, _ := utils.GenerateJWT(7)
+
+		w, _ := performRequest(m.Authenticator(middleware.AuthStateless), map[string]string{"Authorization": token + "x"})
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Stateful requires the JWT to match the saved session", func(t *testing.T) {
+		m, cacher := setupMiddleware(t)
+		token, _ := utils.GenerateJWT(7)
+
+		w, _ := performRequest(m.Authenticator(middleware.AuthStateful), map[string]string{"Authorization": "Bearer " + token})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		assert.NoError(t, cacher.SaveToken("7", token))
+		w, userID := performRequest(m.Authenticator(middleware.AuthStateful), map[string]string{"Authorization": "Bearer " + token})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "7", userID)
+
+		assert.NoError(t, cacher.SaveToken("7", "another-session"))
+		w, _ = performRequest(m.Authenticator(middleware.AuthStateful), map[string]string{"Authorization": "Bearer " + token})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Opaque compares the raw token for the User-ID header", func(t *testing.T) {
+		m, cacher := setupMiddleware(t)
+		assert.NoError(t, cacher.SaveToken("3", "opaque-token"))
+
+		w, userID := performRequest(m.Authenticator(middleware.AuthOpaque), map[string]string{"Authorization": "opaque-token", "User-ID": "3"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "3", userID)
+
+		w, _ = performRequest(m.Authenticator(middleware.AuthOpaque), map[string]string{"Authorization": "opaque-token", "User-ID": "4"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w, _ = performRequest(m.Authenticator(middleware.AuthOpaque), map[string]string{"Authorization": "opaque-token"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAuthenticatorAccountChecks(t *testing.T) {
+	t.Run("Disabled account is rejected with a valid token", func(t *testing.T) {
+		m, _ := setupMiddleware(t)
+		token, _ := utils.GenerateJWT(8)
+
+		w, _ := performRequest(m.Authenticator(middleware.AuthStateless), map[string]string{"Authorization": token})
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Unknown account is rejected", func(t *testing.T) {
+		m, _ := setupMiddleware(t)
+		token, _ := utils.GenerateJWT(99)
+
+		w, _ := performRequest(m.Authenticator(middleware.AuthStateless), map[string]string{"Authorization": token})
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestRequireRole(t *testing.T) {
+	m, _ := setupMiddleware(t)
+	adminToken, _ := utils.GenerateJWT(7)
+	customerToken, _ := utils.GenerateJWT(3)
+
+	w, _ := performRequest(m.Authenticator(middleware.AuthStateless), map[string]string{"Authorization": adminToken}, m.RequireRole(models.RoleAdmin))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = performRequest(m.Authenticator(middleware.AuthStateless), map[string]string{"Authorization": customerToken}, m.RequireRole(models.RoleAdmin))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRequireRecentAuth(t *testing.T) {
+	m, _ := setupMiddleware(t)
+	withAuthTime := func(at time.Time) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("userID", "7")
+			c.Set("authTime", at)
+		}
+	}
+
+	t.Run("Recent login passes", func(t *testing.T) {
+		w, _ := performRequest(withAuthTime(time.Now().Add(-time.Minute)), nil, m.RequireRecentAuth(5*time.Minute))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Old login needs a step-up", func(t *testing.T) {
+		w, _ := performRequest(withAuthTime(time.Now().Add(-10*time.Minute)), nil, m.RequireRecentAuth(5*time.Minute))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), middleware.StepUpRequired)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), "insufficient_user_authentication")
+	})
+
+	t.Run("Missing auth time needs a step-up", func(t *testing.T) {
+		w, _ := performRequest(withAuthTime(time.Time{}), nil, m.RequireRecentAuth(5*time.Minute))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Fresh token passes after Authenticator", func(t *testing.T) {
+		token, _ := utils.GenerateJWT(7)
+		w, _ := performRequest(m.Authenticator(middleware.AuthStateless), map[string]string{"Authorization": token}, m.RequireRecentAuth(5*time.Minute))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
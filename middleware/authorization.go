@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"voucher_system/helper"
+	"voucher_system/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// passwordChangePath stays reachable while a forced password reset is pending.
+const passwordChangePath = "/users/me/password"
+
+// StepUpRequired is the error_msg clients get when a route needs a fresh login,
+// they should call POST /reauth and retry with the returned token.
+const StepUpRequired = "STEP_UP_REQUIRED"
+
+// UserFinder loads the account behind an authenticated token.
+type UserFinder interface {
+	GetUserByID(userID int) (models.User, error)
+}
+
+// checkAccount blocks disabled and deleted accounts, even when their token is still
+// valid, and writes the error response itself.
+func (m *Middleware) checkAccount(c *gin.Context, userID string) bool {
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		m.log.Warn("Token subject is not a user id", zap.String("userID", userID))
+		helper.ResponseError(c, "Invalid token", "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+
+	user, err := m.users.GetUserByID(id)
+	if err != nil {
+		m.log.Warn("Account of token not found", zap.Int("userID", id), zap.Error(err))
+		helper.ResponseError(c, "Account not found", "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+
+	if user.Disabled {
+		m.log.Warn("Disabled account tried to access", zap.Int("userID", id))
+		helper.ResponseError(c, "Account is disabled", "Forbidden", http.StatusForbidden)
+		return false
+	}
+
+	if user.PasswordResetRequired && c.FullPath() != passwordChangePath {
+		helper.ResponseError(c, "Password must be changed before continuing", "Forbidden", http.StatusForbidden)
+		return false
+	}
+
+	c.Set("role", user.Role)
+	return true
+}
+
+// RequireRole only lets users with one of roles through. It must run after Authenticator.
+func (m *Middleware) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		m.log.Warn("Role not allowed", zap.String("userID", c.GetString("userID")), zap.String("role", role))
+		helper.ResponseError(c, "You do not have access to this resource", "Forbidden", http.StatusForbidden)
+		c.Abort()
+	}
+}
+
+// RequireRecentAuth only lets requests through when the user authenticated with their
+// password less than maxAge ago. It must run after Authenticator. Tokens without an
+// auth_time, such as opaque tokens, always need a step-up.
+func (m *Middleware) RequireRecentAuth(maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authTime, _ := c.Get("authTime")
+		if at, ok := authTime.(time.Time); ok && !at.IsZero() && time.Since(at) <= maxAge {
+			c.Next()
+			return
+		}
+
+		m.log.Warn("Step-up authentication required", zap.String("userID", c.GetString("userID")), zap.String("path", c.FullPath()))
+		c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_user_authentication", error_description="A more recent authentication is required", max_age=%d`, int(maxAge.Seconds())))
+		helper.ResponseError(c, StepUpRequired, "Please confirm your password to continue", http.StatusUnauthorized)
+		c.Abort()
+	}
+}
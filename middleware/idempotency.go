@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+	"voucher_system/helper"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// IdempotencyKeyHeader is the request header clients use to make a retried request safe.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotentRecord is what is stored for a key: the fingerprint of the first request
+// and, once it has finished, its response. A zero Status means it is still running.
+type idempotentRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// responseRecorder keeps a copy of everything the handler writes.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes requests carrying an Idempotency-Key header safe to retry. The first
+// request with a key runs normally and its response is kept for ttl; repeating the key
+// with the same method, URL and body replays that response without running the handler
+// again, while repeating it with a different request is rejected with 409. Keys are
+// scoped to the authenticated user, so it must run after Authenticator. Requests without
+// the header are not affected.
+func (m *Middleware) Idempotency(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			m.log.Error("Failed to read request body", zap.Error(err))
+			helper.ResponseError(c, "Failed to read request body", "Bad request", http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		name := "idempotency_" + c.GetString("userID") + "_" + key
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.Path+"?"+c.Request.URL.RawQuery, body)
+
+		pending, _ := json.Marshal(idempotentRecord{Fingerprint: fingerprint})
+		reserved, err := m.Cacher.SetIfAbsent(name, string(pending), ttl)
+		if err != nil {
+			m.log.Error("Failed to reserve idempotency key", zap.Error(err))
+			helper.ResponseError(c, "Failed to check Idempotency-Key", "Server error", http.StatusInternalServerError)
+			c.Abort()
+			return
+		}
+		if !reserved {
+			m.replay(c, name, fingerprint)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not final, so the client may retry them with the same key.
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			if err := m.Cacher.Delete(name); err != nil {
+				m.log.Error("Failed to release idempotency key", zap.Error(err))
+			}
+			return
+		}
+
+		done, _ := json.Marshal(idempotentRecord{
+			Fingerprint: fingerprint,
+			Status:      c.Writer.Status(),
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		})
+		if err := m.Cacher.SetWithTTL(name, string(done), ttl); err != nil {
+			m.log.Error("Failed to store idempotent response", zap.Error(err))
+		}
+	}
+}
+
+// replay answers a request whose key is already taken with the stored response, or with
+// 409 when the key belongs to a different request or the first one is still running.
+func (m *Middleware) replay(c *gin.Context, name, fingerprint string) {
+	stored, err := m.Cacher.Get(name)
+	if err != nil {
+		// The key expired between the reservation and now; let the client retry.
+		helper.ResponseError(c, "Request with this Idempotency-Key is still being processed", "Conflict", http.StatusConflict)
+		return
+	}
+
+	var record idempotentRecord
+	if err := json.Unmarshal([]byte(stored), &record); err != nil {
+		m.log.Error("Failed to decode idempotent response", zap.Error(err))
+		helper.ResponseError(c, "Failed to check Idempotency-Key", "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case record.Fingerprint != fingerprint:
+		m.log.Warn("Idempotency-Key reused with a different request", zap.String("userID", c.GetString("userID")))
+		helper.ResponseError(c, "Idempotency-Key was already used for a different request", "Conflict", http.StatusConflict)
+	case record.Status == 0:
+		helper.ResponseError(c, "Request with this Idempotency-Key is still being processed", "Conflict", http.StatusConflict)
+	default:
+		m.log.Info("Replaying idempotent response", zap.String("userID", c.GetString("userID")))
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(record.Status, record.ContentType, record.Body)
+	}
+}
+
+// requestFingerprint identifies a request by its URL and exact body. The URL is used
+// rather than the route so that the same key on another resource is a different request.
+func requestFingerprint(method, url string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + url + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
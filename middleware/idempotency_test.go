@@ -0,0 +1,106 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func idempotentRouter(t *testing.T, status int) (*gin.Engine, *int) {
+	m, _ := setupMiddleware(t)
+	calls := 0
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	handlers := []gin.HandlerFunc{func(c *gin.Context) {
+		c.Set("userID", c.GetHeader("X-Test-User"))
+		c.Next()
+	}, m.Idempotency(time.Hour), func(c *gin.Context) {
+		calls++
+		c.JSON(status, gin.H{"call": calls, "id": c.Param("id")})
+	}}
+	r.POST("/vouchers/", handlers...)
+	r.POST("/reservations/:id/confirm", handlers...)
+	return r, &calls
+}
+
+func postIdempotent(r *gin.Engine, user, key, body string) *httptest.ResponseRecorder {
+	return postIdempotentTo(r, "/vouchers/", user, key, body)
+}
+
+func postIdempotentTo(r *gin.Engine, path, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("X-Test-User", user)
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	t.Run("Replays the stored response for a repeated key", func(t *testing.T) {
+		r, calls := idempotentRouter(t, http.StatusCreated)
+
+		first := postIdempotent(r, "3", "key-1", `{"voucher_code":"A"}`)
+		second := postIdempotent(r, "3", "key-1", `{"voucher_code":"A"}`)
+
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.JSONEq(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	})
+
+	t.Run("Rejects a reused key with a different body", func(t *testing.T) {
+		r, calls := idempotentRouter(t, http.StatusCreated)
+
+		postIdempotent(r, "3", "key-1", `{"voucher_code":"A"}`)
+		w := postIdempotent(r, "3", "key-1", `{"voucher_code":"B"}`)
+
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Rejects a reused key on another resource of the same route", func(t *testing.T) {
+		r, calls := idempotentRouter(t, http.StatusOK)
+
+		postIdempotentTo(r, "/reservations/5/confirm", "3", "key-1", `{}`)
+		w := postIdempotentTo(r, "/reservations/6/confirm", "3", "key-1", `{}`)
+
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Keys are scoped to the user", func(t *testing.T) {
+		r, calls := idempotentRouter(t, http.StatusCreated)
+
+		postIdempotent(r, "3", "key-1", `{}`)
+		postIdempotent(r, "7", "key-1", `{}`)
+
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("Requests without a key always run", func(t *testing.T) {
+		r, calls := idempotentRouter(t, http.StatusCreated)
+
+		postIdempotent(r, "3", "", `{}`)
+		postIdempotent(r, "3", "", `{}`)
+
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("Server errors release the key for a retry", func(t *testing.T) {
+		r, calls := idempotentRouter(t, http.StatusInternalServerError)
+
+		postIdempotent(r, "3", "key-1", `{}`)
+		postIdempotent(r, "3", "key-1", `{}`)
+
+		assert.Equal(t, 2, *calls)
+	})
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+)
+
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// JobRun is one run of a scheduled background job on one instance.
+type JobRun struct {
+	ID         int        `gorm:"primaryKey;autoIncrement" json:"id" example:"31"`
+	Job        string     `gorm:"type:varchar(100);not null;index:idx_job_runs_job_started,priority:1" json:"job" example:"points-expiry"`
+	Trigger    string     `gorm:"type:varchar(20);not null;check:trigger in ('schedule', 'manual')" json:"trigger" example:"schedule"`
+	Instance   string     `gorm:"type:varchar(255);not null" json:"instance" example:"api-7f9c-1"`
+	Status     string     `gorm:"type:varchar(20);not null;check:status in ('running', 'succeeded', 'failed')" json:"status" example:"succeeded"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	StartedAt  time.Time  `gorm:"type:timestamp with time zone;not null;index:idx_job_runs_job_started,priority:2" json:"started_at"`
+	FinishedAt *time.Time `gorm:"type:timestamp with time zone" json:"finished_at,omitempty"`
+}
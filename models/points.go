@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+	"voucher_system/utils/money"
+)
+
+// PointsValidityMonths is how long credited points can be spent before they expire.
+const PointsValidityMonths = 12
+
+const (
+	LedgerEarn   = "earn"
+	LedgerSpend  = "spend"
+	LedgerAdjust = "adjust"
+	LedgerExpire = "expire"
+)
+
+// PointsAccount holds the current points balance of a user. The balance is only
+// changed together with a PointsLedgerEntry explaining the change.
+type PointsAccount struct {
+	ID        int       `gorm:"primaryKey;autoIncrement" json:"-"`
+	UserID    int       `gorm:"not null;uniqueIndex" json:"user_id"`
+	Balance   int       `gorm:"not null;default:0;check:balance >= 0" json:"balance"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	User      User      `gorm:"foreignKey:UserID;references:ID" swaggerignore:"true" json:"-"`
+}
+
+// PointsLedgerEntry is an append-only record of a points movement. Points is
+// positive for credits and negative for debits. Each non-empty reference is earned at
+// most once.
+type PointsLedgerEntry struct {
+	ID           int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       int        `gorm:"not null;index" json:"user_id"`
+	EntryType    string     `gorm:"type:varchar(10);not null;uniqueIndex:idx_ledger_earn_reference,where:entry_type = 'earn' AND reference <> '';check:entry_type in ('earn', 'spend', 'adjust', 'expire')" json:"entry_type"`
+	Points       int        `gorm:"not null" json:"points"`
+	BalanceAfter int        `gorm:"not null" json:"balance_after"`
+	Reference    string     `gorm:"type:varchar(100);index;uniqueIndex:idx_ledger_earn_reference" json:"reference,omitempty"`
+	Description  string     `gorm:"type:text" json:"description,omitempty"`
+	ExpiresAt    *time.Time `gorm:"type:timestamp with time zone" json:"expires_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	User         User       `gorm:"foreignKey:UserID;references:ID" swaggerignore:"true" json:"-"`
+}
+
+// PointsLot tracks what is left of one credit. Debits consume the lots that expire
+// first, and whatever remains of a lot when it expires is written off.
+type PointsLot struct {
+	ID            int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID        int        `gorm:"not null;index:idx_points_lots_user_expiry,priority:1" json:"-"`
+	LedgerEntryID int        `gorm:"not null" json:"ledger_entry_id"`
+	Points        int        `gorm:"not null" json:"points"`
+	Remaining     int        `gorm:"not null;check:remaining >= 0" json:"remaining"`
+	ExpiresAt     *time.Time `gorm:"type:timestamp with time zone;index:idx_points_lots_user_expiry,priority:2" json:"expires_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+const (
+	EarnRuleBase       = "base"
+	EarnRuleMultiplier = "multiplier"
+	EarnRuleBonus      = "bonus"
+)
+
+// EarningRule describes how many points a purchase earns. Base rules convert the amount
+// spent into points, multiplier rules scale the base points and bonus rules add a fixed
+// amount, e.g. for a campaign. PaymentMethod, Area, MinimumAmount and the dates narrow
+// down which purchases a rule applies to; empty means any. SpendPerPoint and
+// MinimumAmount are in the base currency.
+type EarningRule struct {
+	ID            int          `gorm:"primaryKey;autoIncrement" json:"id" swaggerignore:"true"`
+	Name          string       `gorm:"type:varchar(255);not null;uniqueIndex" json:"name" binding:"required" example:"1 point per 10.000"`
+	RuleType      string       `gorm:"type:varchar(20);not null;check:rule_type in ('base', 'multiplier', 'bonus')" json:"rule_type" binding:"required" example:"base"`
+	SpendPerPoint money.Amount `gorm:"type:numeric(20,4);default:0" json:"spend_per_point,omitempty" swaggertype:"number" example:"10000"`
+	Multiplier    float64      `gorm:"type:numeric(6,2);default:0" json:"multiplier,omitempty" example:"2"`
+	BonusPoints   int          `gorm:"default:0" json:"bonus_points,omitempty" example:"50"`
+	PaymentMethod string       `gorm:"type:varchar(50)" json:"payment_method,omitempty" example:"Credit Card"`
+	Area          string       `gorm:"type:varchar(50)" json:"area,omitempty" example:"Jawa"`
+	MinimumAmount money.Amount `gorm:"type:numeric(20,4);default:0" json:"minimum_amount,omitempty" swaggertype:"number" example:"100000"`
+	StartDate     *time.Time   `gorm:"type:timestamp with time zone" json:"start_date,omitempty" example:"2024-12-01T00:00:00Z"`
+	EndDate       *time.Time   `gorm:"type:timestamp with time zone" json:"end_date,omitempty" example:"2024-12-31T00:00:00Z"`
+	Disabled      bool         `gorm:"not null;default:false" json:"disabled"`
+	CreatedAt     time.Time    `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt     time.Time    `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+}
+
+func EarningRuleSeed() []EarningRule {
+	return []EarningRule{
+		{
+			Name:          "1 point per 10.000 spent",
+			RuleType:      EarnRuleBase,
+			SpendPerPoint: money.New(10000),
+		},
+	}
+}
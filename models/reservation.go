@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+	"voucher_system/utils/money"
+)
+
+const (
+	ReservationHeld      = "held"
+	ReservationConfirmed = "confirmed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+// VoucherReservation holds one unit of a voucher's quota for an order while it is being
+// paid. The unit is taken from the quota when the reservation is made; confirming it
+// records the usage, while releasing it or letting it expire gives the unit back. An
+// order has at most one held or confirmed reservation; once released or expired it may
+// be reserved again.
+type VoucherReservation struct {
+	ID                int            `gorm:"primaryKey;autoIncrement" json:"id" example:"12"`
+	VoucherID         int            `gorm:"not null;index" json:"voucher_id" example:"3"`
+	VoucherVersion    int            `gorm:"not null;default:0" json:"voucher_version" example:"2"`
+	UserID            int            `gorm:"not null;uniqueIndex:idx_reservation_active_order,where:status IN ('held'\\,'confirmed')" json:"user_id" example:"1"`
+	OrderID           string         `gorm:"type:varchar(100);not null;uniqueIndex:idx_reservation_active_order" json:"order_id" example:"INV-2024-0001"`
+	TransactionAmount money.Amount   `gorm:"type:numeric(20,4);not null" json:"transaction_amount" swaggertype:"number" example:"250000"`
+	BenefitValue      money.Amount   `gorm:"type:numeric(20,4);not null" json:"benefit_value" swaggertype:"number" example:"25000"`
+	Currency          money.Currency `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency" swaggertype:"string" example:"IDR"`
+	PaymentMethod     string         `gorm:"type:varchar(50)" json:"payment_method" example:"Credit Card"`
+	Area              string         `gorm:"type:varchar(50)" json:"area" example:"Jawa"`
+	Status            string         `gorm:"type:varchar(20);not null;index;check:status in ('held', 'confirmed', 'released', 'expired')" json:"status" example:"held"`
+	ExpiresAt         time.Time      `gorm:"type:timestamp with time zone;not null;index" json:"expires_at"`
+	HistoryID         *int           `json:"history_id,omitempty"`
+	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	User              User           `gorm:"foreignKey:UserID;references:ID" swaggerignore:"true" json:"-"`
+	Voucher           Voucher        `gorm:"foreignKey:VoucherID;references:ID" swaggerignore:"true" json:"-"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+	"voucher_system/utils/money"
+)
+
+const (
+	TierSilver   = "Silver"
+	TierGold     = "Gold"
+	TierPlatinum = "Platinum"
+)
+
+// TierWindowMonths is the rolling window spend and earned points are summed over when
+// tiers are recalculated.
+const TierWindowMonths = 12
+
+const (
+	TierUpgrade   = "upgrade"
+	TierDowngrade = "downgrade"
+)
+
+// Tier is a membership level. A user reaches a tier by meeting either MinSpend, in the
+// base currency, or MinPoints within the rolling window; a higher Rank is a better tier.
+type Tier struct {
+	ID        int          `gorm:"primaryKey;autoIncrement" json:"-"`
+	Name      string       `gorm:"type:varchar(50);not null;uniqueIndex" json:"name" example:"Gold"`
+	Rank      int          `gorm:"not null;uniqueIndex" json:"rank" example:"2"`
+	MinSpend  money.Amount `gorm:"type:numeric(20,4);not null;default:0" json:"min_spend" swaggertype:"number" example:"5000000"`
+	MinPoints int          `gorm:"not null;default:0" json:"min_points" example:"500"`
+	CreatedAt time.Time    `gorm:"autoCreateTime" json:"-"`
+	UpdatedAt time.Time    `gorm:"autoUpdateTime" json:"-"`
+}
+
+// TierChange records a user moving between tiers. FromTier is empty for the first
+// assignment.
+type TierChange struct {
+	ID            int          `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID        int          `gorm:"not null;index" json:"user_id"`
+	FromTier      string       `gorm:"type:varchar(50)" json:"from_tier"`
+	ToTier        string       `gorm:"type:varchar(50);not null" json:"to_tier"`
+	Direction     string       `gorm:"type:varchar(10);not null;check:direction in ('upgrade', 'downgrade')" json:"direction"`
+	RollingSpend  money.Amount `gorm:"type:numeric(20,4);not null" json:"rolling_spend" swaggertype:"number"`
+	RollingPoints int          `gorm:"not null" json:"rolling_points"`
+	CreatedAt     time.Time    `gorm:"autoCreateTime" json:"created_at"`
+	User          User         `gorm:"foreignKey:UserID;references:ID" swaggerignore:"true" json:"-"`
+}
+
+// VoucherTierPrice overrides Voucher.PointsRequired for users of one tier.
+type VoucherTierPrice struct {
+	ID             int    `gorm:"primaryKey;autoIncrement" json:"-"`
+	VoucherID      int    `gorm:"not null;uniqueIndex:idx_voucher_tier_price" json:"-"`
+	Tier           string `gorm:"type:varchar(50);not null;uniqueIndex:idx_voucher_tier_price" json:"tier" example:"Gold"`
+	PointsRequired int    `gorm:"not null;check:points_required >= 0" json:"points_required" example:"180"`
+}
+
+func TierSeed() []Tier {
+	return []Tier{
+		{Name: TierSilver, Rank: 1},
+		{Name: TierGold, Rank: 2, MinSpend: money.New(5000000), MinPoints: 500},
+		{Name: TierPlatinum, Rank: 3, MinSpend: money.New(20000000), MinPoints: 2000},
+	}
+}
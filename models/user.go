@@ -1,22 +1,40 @@
-package models
-
-import (
-	"voucher_system/utils"
-)
-
-type User struct {
-	ID       int    `gorm:"primaryKey;autoIncrement" json:"id,omitempty" swaggerignore:"true"`
-	Name     string `json:"name,omitempty" gorm:"type:varchar(255);not null" binding:"required"`
-	Email    string `json:"email,omitempty" gorm:"type:varchar(255);unique;not null" binding:"required,email"`
-	Password string `json:"password,omitempty" gorm:"type:varchar(255);not null" binding:"required,min=8"`
-}
-
-func UserSeed() []User {
-	return []User{
-		{Name: "John Doe", Email: "john.doe@example.com", Password: utils.HashPassword("password1234")},
-		{Name: "Jane Smith", Email: "jane.smith@example.com", Password: utils.HashPassword("password1245")},
-		{Name: "Alice Johnson", Email: "alice.johnson@example.com", Password: utils.HashPassword("password1256")},
-		{Name: "Bob Brown", Email: "bob.brown@example.com", Password: utils.HashPassword("password1278")},
-		{Name: "Charlie Davis", Email: "charlie.davis@example.com", Password: utils.HashPassword("password1298")},
-	}
-}
+package models
+
+import (
+	"voucher_system/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+)
+
+// User is an account. Emails are unique among accounts that are not deleted, so the
+// email of a deleted account can be registered again.
+type User struct {
+	ID                    int            `gorm:"primaryKey;autoIncrement" json:"id,omitempty" swaggerignore:"true"`
+	Name                  string         `json:"name,omitempty" gorm:"type:varchar(255);not null" binding:"required"`
+	Email                 string         `json:"email,omitempty" gorm:"type:varchar(255);not null;uniqueIndex:idx_users_email,where:deleted_at IS NULL" binding:"required,email"`
+	Password              string         `json:"password,omitempty" gorm:"type:varchar(255);not null" binding:"required,min=8"`
+	Role                  string         `json:"role,omitempty" gorm:"type:varchar(20);not null;default:'customer';check:role in ('customer', 'admin')" swaggerignore:"true"`
+	Disabled              bool           `json:"disabled" gorm:"not null;default:false" swaggerignore:"true"`
+	PasswordResetRequired bool           `json:"password_reset_required,omitempty" gorm:"not null;default:false" swaggerignore:"true"`
+	Tier                  string         `json:"tier,omitempty" gorm:"type:varchar(50);not null;default:''" swaggerignore:"true"`
+	DeletedAt             gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
+}
+
+func IsValidRole(role string) bool {
+	return role == RoleCustomer || role == RoleAdmin
+}
+
+func UserSeed() []User {
+	return []User{
+		{Name: "John Doe", Email: "john.doe@example.com", Password: utils.HashPassword("password1234"), Role: RoleAdmin},
+		{Name: "Jane Smith", Email: "jane.smith@example.com", Password: utils.HashPassword("password1245")},
+		{Name: "Alice Johnson", Email: "alice.johnson@example.com", Password: utils.HashPassword("password1256")},
+		{Name: "Bob Brown", Email: "bob.brown@example.com", Password: utils.HashPassword("password1278")},
+		{Name: "Charlie Davis", Email: "charlie.davis@example.com", Password: utils.HashPassword("password1298")},
+	}
+}
@@ -1,231 +1,470 @@
-package models
-
-import (
-	"encoding/json"
-	"time"
-
-	"gorm.io/gorm"
-)
-
-type Voucher struct {
-	ID              int             `gorm:"primaryKey;autoIncrement" json:"id,omitempty" swaggerignore:"true"`
-	VoucherName     string          `gorm:"type:varchar(255);not null" json:"voucher_name,omitempty" binding:"required" example:"PROMO GAJIAN"`
-	VoucherCode     string          `gorm:"type:varchar(50);unique;not null" json:"voucher_code,omitempty" binding:"required" example:"DESCERIA100"`
-	VoucherType     string          `gorm:"type:varchar(20);not null;check:voucher_type in ('e-commerce', 'redeem points')" json:"voucher_type,omitempty" binding:"required" example:"redeem points"`
-	PointsRequired  int             `gorm:"default:0" json:"points_required,omitempty" example:"220"`
-	Description     string          `gorm:"type:text;not null" json:"description,omitempty" example:"10% off for purchases above 200.000"`
-	VoucherCategory string          `gorm:"type:varchar(20);not null;check:voucher_category in ('Free Shipping', 'Discount')" json:"voucher_category,omitempty" binding:"required" example:"Free Shipping"`
-	DiscountValue   float64         `gorm:"type:numeric(10,2);not null" json:"discount_value,omitempty" binding:"required" example:"10.0"`
-	MinimumPurchase float64         `gorm:"type:numeric(10,2);default:0" json:"minimum_purchase,omitempty" binding:"required" example:"200000.0"`
-	PaymentMethods  []string        `gorm:"type:jsonb" json:"payment_methods,omitempty" binding:"required" swaggertype:"array,string" example:"Credit Card"`
-	StartDate       time.Time       `gorm:"type:timestamp with time zone;not null" json:"start_date,omitempty" binding:"required" example:"2024-12-01T00:00:00Z"`
-	EndDate         time.Time       `gorm:"type:timestamp with time zone;not null" json:"end_date,omitempty" binding:"required" example:"2024-12-07T00:00:00Z"`
-	ApplicableAreas []string        `gorm:"type:jsonb" json:"applicable_areas,omitempty" binding:"required" swaggertype:"array,string" example:"Jawa"`
-	Quota           int             `gorm:"default:0" json:"quota,omitempty" binding:"required" example:"50"`
-	Status          bool            `gorm:"type:boolean" json:"status,omitempty" example:"true"`
-	CreatedAt       time.Time       `gorm:"autoCreateTime" json:"created_at,omitempty" swaggerignore:"true"`
-	UpdatedAt       time.Time       `gorm:"autoUpdateTime" json:"updated_at,omitempty" swaggerignore:"true"`
-	DeletedAt       *gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty" swaggerignore:"true"`
-}
-
-func (v *Voucher) BeforeSave(tx *gorm.DB) (err error) {
-	currentDate := time.Now()
-	v.Status = currentDate.After(v.StartDate) && currentDate.Before(v.EndDate)
-	// Marshal PaymentMethods and ApplicableAreas to JSON before saving
-	if len(v.PaymentMethods) > 0 {
-		// Marshal to JSON to ensure proper formatting
-		paymentMethodsJSON, err := json.Marshal(v.PaymentMethods)
-		if err != nil {
-			return err
-		}
-		v.PaymentMethods = nil // Clear the original array to use the marshaled value
-		v.PaymentMethods = []string{string(paymentMethodsJSON)}
-	}
-
-	if len(v.ApplicableAreas) > 0 {
-		// Marshal to JSON to ensure proper formatting
-		applicableAreasJSON, err := json.Marshal(v.ApplicableAreas)
-		if err != nil {
-			return err
-		}
-		v.ApplicableAreas = nil // Clear the original array to use the marshaled value
-		v.ApplicableAreas = []string{string(applicableAreasJSON)}
-	}
-
-	return nil
-}
-
-type Redeem struct {
-	ID         int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID     int       `gorm:"not null" json:"user_id"`
-	VoucherID  int       `gorm:"not null" json:"voucher_id"`
-	RedeemDate time.Time `gorm:"default:current_date" json:"redeem_date"`
-	User       User      `gorm:"foreignKey:UserID;references:ID" swaggerignore:"true" json:"-"`
-	Voucher    Voucher   `gorm:"foreignKey:VoucherID;references:ID" swaggerignore:"true" json:"-"`
-}
-
-type History struct {
-	ID                int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID            int       `gorm:"not null" json:"user_id"`
-	VoucherID         int       `gorm:"not null" json:"voucher_id"`
-	UsageDate         time.Time `gorm:"default:current_date" json:"usage_date"`
-	TransactionAmount float64   `gorm:"type:numeric(10,2);not null" json:"transaction_amount"`
-	BenefitValue      float64   `gorm:"type:numeric(10,2);not null" json:"benefit_value"`
-	User              User      `gorm:"foreignKey:UserID;references:ID" swaggerignore:"true" json:"-"`
-	Voucher           Voucher   `gorm:"foreignKey:VoucherID;references:ID" swaggerignore:"true" json:"-"`
-}
-
-func VoucherSeed() []Voucher {
-	return []Voucher{
-		{
-			VoucherName:     "10% Discount",
-			VoucherCode:     "DISCOUNT10",
-			VoucherType:     "e-commerce",
-			PointsRequired:  0,
-			Description:     "10% off for purchases above $100",
-			VoucherCategory: "Discount",
-			DiscountValue:   10.0,
-			MinimumPurchase: 100.0,
-			PaymentMethods:  []string{"Credit Card", "PayPal"},
-			StartDate:       time.Now().AddDate(0, 0, -5), // StartDate 5 days ago
-			EndDate:         time.Now().AddDate(0, 0, -1), // EndDate 1 day ago
-			ApplicableAreas: []string{"US", "Canada"},
-			Quota:           100,
-		},
-		{
-			VoucherName:     "Free Shipping",
-			VoucherCode:     "FREESHIP50",
-			VoucherType:     "e-commerce",
-			PointsRequired:  0,
-			Description:     "Free shipping for orders above $50",
-			VoucherCategory: "Free Shipping",
-			DiscountValue:   0.0,
-			MinimumPurchase: 50.0,
-			PaymentMethods:  []string{"All"},
-			StartDate:       time.Now(),
-			EndDate:         time.Now().AddDate(0, 2, 0), // 2 months valid
-			ApplicableAreas: []string{"Worldwide"},
-			Quota:           200,
-		},
-		{
-			VoucherName:     "Redeem 500 Points",
-			VoucherCode:     "POINTS500",
-			VoucherType:     "redeem points",
-			PointsRequired:  500,
-			Description:     "Redeem 500 points for a $20 discount",
-			VoucherCategory: "Discount",
-			DiscountValue:   20.0,
-			MinimumPurchase: 0.0,
-			PaymentMethods:  []string{"Credit Card"},
-			StartDate:       time.Now(),
-			EndDate:         time.Now().AddDate(0, 3, 0), // 3 months valid
-			ApplicableAreas: []string{"US"},
-			Quota:           150,
-		},
-		{
-			VoucherName:     "5% Discount",
-			VoucherCode:     "DISCOUNT5",
-			VoucherType:     "e-commerce",
-			PointsRequired:  0,
-			Description:     "5% discount on all purchases",
-			VoucherCategory: "Discount",
-			DiscountValue:   5.0,
-			MinimumPurchase: 0.0,
-			PaymentMethods:  []string{"PayPal"},
-			StartDate:       time.Now(),
-			EndDate:         time.Now().AddDate(0, 1, 0), // 1 month valid
-			ApplicableAreas: []string{"Europe"},
-			Quota:           500,
-		},
-		{
-			VoucherName:     "Black Friday Sale",
-			VoucherCode:     "BLACKFRIDAY",
-			VoucherType:     "e-commerce",
-			PointsRequired:  0,
-			Description:     "20% off for Black Friday",
-			VoucherCategory: "Discount",
-			DiscountValue:   20.0,
-			MinimumPurchase: 200.0,
-			PaymentMethods:  []string{"Credit Card", "Bank Transfer"},
-			StartDate:       time.Now(),
-			EndDate:         time.Now().AddDate(0, 0, 7), // 1 week valid
-			ApplicableAreas: []string{"Worldwide"},
-			Quota:           300,
-		},
-		{
-			VoucherName:     "Holiday Free Shipping",
-			VoucherCode:     "HOLIDAYSHIP",
-			VoucherType:     "e-commerce",
-			PointsRequired:  0,
-			Description:     "Free shipping during the holiday season",
-			VoucherCategory: "Free Shipping",
-			DiscountValue:   0.0,
-			MinimumPurchase: 75.0,
-			PaymentMethods:  []string{"All"},
-			StartDate:       time.Now(),
-			EndDate:         time.Now().AddDate(0, 1, 0), // 1 month valid
-			ApplicableAreas: []string{"US", "Canada"},
-			Quota:           400,
-		},
-		{
-			VoucherName:     "Cyber Monday Special",
-			VoucherCode:     "CYBERMON",
-			VoucherType:     "e-commerce",
-			PointsRequired:  0,
-			Description:     "15% off for Cyber Monday",
-			VoucherCategory: "Discount",
-			DiscountValue:   15.0,
-			MinimumPurchase: 150.0,
-			PaymentMethods:  []string{"Credit Card"},
-			StartDate:       time.Now(),
-			EndDate:         time.Now().AddDate(0, 0, 5), // 5 days valid
-			ApplicableAreas: []string{"Worldwide"},
-			Quota:           100,
-		},
-		{
-			VoucherName:     "Student Discount",
-			VoucherCode:     "STUDENT15",
-			VoucherType:     "e-commerce",
-			PointsRequired:  0,
-			Description:     "15% discount for students",
-			VoucherCategory: "Discount",
-			DiscountValue:   15.0,
-			MinimumPurchase: 0.0,
-			PaymentMethods:  []string{"Credit Card", "PayPal"},
-			StartDate:       time.Now(),
-			EndDate:         time.Now().AddDate(0, 2, 0), // 2 months valid
-			ApplicableAreas: []string{"Europe"},
-			Quota:           200,
-		},
-		{
-			VoucherName:     "New Year Sale",
-			VoucherCode:     "NEWYEAR50",
-			VoucherType:     "e-commerce",
-			PointsRequired:  0,
-			Description:     "Flat $50 off for the New Year sale",
-			VoucherCategory: "Discount",
-			DiscountValue:   50.0,
-			MinimumPurchase: 300.0,
-			PaymentMethods:  []string{"All"},
-			StartDate:       time.Now(),
-			EndDate:         time.Now().AddDate(0, 1, 0), // 1 month valid
-			ApplicableAreas: []string{"US"},
-			Quota:           150,
-		},
-		{
-			VoucherName:     "Valentine's Free Shipping",
-			VoucherCode:     "VALSHIP",
-			VoucherType:     "e-commerce",
-			PointsRequired:  0,
-			Description:     "Free shipping for Valentine's Day",
-			VoucherCategory: "Free Shipping",
-			DiscountValue:   0.0,
-			MinimumPurchase: 100.0,
-			PaymentMethods:  []string{"Credit Card", "PayPal"},
-			StartDate:       time.Now(),
-			EndDate:         time.Now().AddDate(0, 1, 14), // 1 month 14 days valid
-			ApplicableAreas: []string{"Worldwide"},
-			Quota:           300,
-		},
-	}
-}
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"voucher_system/utils/money"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DiscountPercentage   = "percentage"
+	DiscountFixedAmount  = "fixed_amount"
+	DiscountFreeShipping = "free_shipping"
+)
+
+var (
+	ErrInvalidDiscount     = errors.New("percentage discounts must be between 0 and 100 and other discounts cannot be negative")
+	ErrMissingDiscountType = errors.New("discount_type is required when creating a voucher")
+	ErrInvalidLimit        = errors.New("usage and redemption limits cannot be negative")
+	ErrInvalidTransition   = errors.New("voucher cannot make this state transition")
+	ErrSelfApproval        = errors.New("voucher must be approved by another admin than the one who submitted it")
+	ErrVoucherNotLive      = errors.New("voucher is not live")
+)
+
+// Lifecycle states of a voucher. New vouchers start as drafts and only live vouchers can
+// be validated or used. Scheduled vouchers go live and published vouchers end on their
+// own once their start or end date is reached.
+const (
+	VoucherDraft           = "draft"
+	VoucherPendingApproval = "pending_approval"
+	VoucherScheduled       = "scheduled"
+	VoucherLive            = "live"
+	VoucherPaused          = "paused"
+	VoucherEnded           = "ended"
+)
+
+// Events emitted when the status sync flips a voucher's Status.
+const (
+	VoucherActivated   = "voucher.activated"
+	VoucherExpired     = "voucher.expired"
+	VoucherDeactivated = "voucher.deactivated"
+)
+
+// VoucherStatusEvent records that a voucher became active or inactive because its start
+// or end date was reached.
+type VoucherStatusEvent struct {
+	Event       string    `json:"event"`
+	VoucherID   int       `json:"voucher_id"`
+	VoucherCode string    `json:"voucher_code"`
+	At          time.Time `json:"at"`
+}
+
+// Codes of the usage limits a user can run into.
+const (
+	LimitUsesPerUser        = "USER_USAGE_LIMIT_REACHED"
+	LimitUsesPerUserPerDay  = "DAILY_USAGE_LIMIT_REACHED"
+	LimitUsesPerUserPerWeek = "WEEKLY_USAGE_LIMIT_REACHED"
+	LimitRedemptionsPerUser = "REDEMPTION_LIMIT_REACHED"
+)
+
+// LimitError is returned when a user has used up one of a voucher's per-user limits.
+type LimitError struct {
+	Code  string
+	Limit int
+}
+
+func (e *LimitError) Error() string {
+	switch e.Code {
+	case LimitUsesPerUserPerDay:
+		return fmt.Sprintf("voucher can be used %d time(s) per day", e.Limit)
+	case LimitUsesPerUserPerWeek:
+		return fmt.Sprintf("voucher can be used %d time(s) per week", e.Limit)
+	case LimitRedemptionsPerUser:
+		return fmt.Sprintf("voucher can be redeemed %d time(s) per user", e.Limit)
+	}
+	return fmt.Sprintf("voucher can be used %d time(s) per user", e.Limit)
+}
+
+// UsageCounts is how often a user already used a voucher, in total and within the
+// current day and week.
+type UsageCounts struct {
+	Total    int
+	Today    int
+	ThisWeek int
+}
+
+type Voucher struct {
+	ID              int            `gorm:"primaryKey;autoIncrement" json:"id,omitempty" swaggerignore:"true"`
+	VoucherName     string         `gorm:"type:varchar(255);not null" json:"voucher_name,omitempty" binding:"required" example:"PROMO GAJIAN"`
+	VoucherCode     string         `gorm:"type:varchar(50);unique;not null" json:"voucher_code,omitempty" binding:"required" example:"DESCERIA100"`
+	VoucherType     string         `gorm:"type:varchar(20);not null;check:voucher_type in ('e-commerce', 'redeem points')" json:"voucher_type,omitempty" binding:"required" example:"redeem points"`
+	PointsRequired  int            `gorm:"default:0" json:"points_required,omitempty" example:"220"`
+	Description     string         `gorm:"type:text;not null" json:"description,omitempty" example:"10% off for purchases above 200.000"`
+	VoucherCategory string         `gorm:"type:varchar(20);not null;check:voucher_category in ('Free Shipping', 'Discount')" json:"voucher_category,omitempty" binding:"required" example:"Free Shipping"`
+	DiscountValue   money.Amount   `gorm:"type:numeric(20,4);not null" json:"discount_value,omitempty" binding:"gte=0" swaggertype:"number" example:"10.0"`
+	DiscountType    string         `gorm:"type:varchar(20);check:discount_type in ('percentage', 'fixed_amount', 'free_shipping')" json:"discount_type,omitempty" binding:"omitempty,oneof=percentage fixed_amount free_shipping" example:"percentage"`
+	MaxDiscount     money.Amount   `gorm:"type:numeric(20,4);not null;default:0" json:"max_discount,omitempty" swaggertype:"number" example:"50000"`
+	MinimumPurchase money.Amount   `gorm:"type:numeric(20,4);default:0" json:"minimum_purchase,omitempty" binding:"required,gt=0" swaggertype:"number" example:"200000.0"`
+	Currency        money.Currency `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency,omitempty" swaggertype:"string" example:"IDR"`
+	PaymentMethods  []string       `gorm:"type:jsonb" json:"payment_methods,omitempty" binding:"required" swaggertype:"array,string" example:"Credit Card"`
+	StartDate       time.Time      `gorm:"type:timestamp with time zone;not null" json:"start_date,omitempty" binding:"required" example:"2024-12-01T00:00:00Z"`
+	EndDate         time.Time      `gorm:"type:timestamp with time zone;not null" json:"end_date,omitempty" binding:"required" example:"2024-12-07T00:00:00Z"`
+	ApplicableAreas []string       `gorm:"type:jsonb" json:"applicable_areas,omitempty" binding:"required" swaggertype:"array,string" example:"Jawa"`
+	Quota           int            `gorm:"default:0" json:"quota,omitempty" binding:"required" example:"50"`
+	// Per-user limits; zero means unlimited, except MaxRedemptionsPerUser where zero
+	// keeps the default of a single redemption.
+	MaxUsesPerUser        int `gorm:"not null;default:0" json:"max_uses_per_user,omitempty" example:"5"`
+	MaxUsesPerUserPerDay  int `gorm:"not null;default:0" json:"max_uses_per_user_per_day,omitempty" example:"1"`
+	MaxUsesPerUserPerWeek int `gorm:"not null;default:0" json:"max_uses_per_user_per_week,omitempty" example:"3"`
+	MaxRedemptionsPerUser int `gorm:"not null;default:0" json:"max_redemptions_per_user,omitempty" example:"1"`
+	// RemainingUses is filled in when a voucher is validated for a user and is nil when
+	// none of the per-user usage limits apply.
+	RemainingUses *int   `gorm:"-" json:"remaining_uses,omitempty" swaggerignore:"true"`
+	Status        bool   `gorm:"type:boolean" json:"status,omitempty" example:"true"`
+	MinimumTier   string `gorm:"type:varchar(50)" json:"minimum_tier,omitempty" example:"Gold"`
+	// State is the voucher's lifecycle state. Vouchers that existed before the workflow
+	// are live; SubmittedBy is the user who last submitted it for approval. Both only
+	// change through the workflow actions.
+	State       string `gorm:"type:varchar(20);not null;default:'live';index;check:state in ('draft', 'pending_approval', 'scheduled', 'live', 'paused', 'ended')" json:"state,omitempty" swaggerignore:"true"`
+	SubmittedBy int    `gorm:"not null;default:0" json:"submitted_by,omitempty" swaggerignore:"true"`
+	// Version is the number of the latest VoucherVersion and is maintained by the
+	// repository; it is ignored on create and update.
+	Version    int                `gorm:"not null;default:0" json:"version,omitempty" swaggerignore:"true"`
+	TierPrices []VoucherTierPrice `gorm:"foreignKey:VoucherID" json:"tier_prices,omitempty"`
+	CreatedAt  time.Time          `gorm:"autoCreateTime" json:"created_at,omitempty" swaggerignore:"true"`
+	UpdatedAt  time.Time          `gorm:"autoUpdateTime" json:"updated_at,omitempty" swaggerignore:"true"`
+	DeletedAt  *gorm.DeletedAt    `gorm:"index" json:"deleted_at,omitempty" swaggerignore:"true"`
+}
+
+// ValidateCurrency normalises Currency and checks that it is supported. An empty currency
+// passes so the column default applies on create and partial updates keep the old one.
+func (v *Voucher) ValidateCurrency() error {
+	if v.Currency == "" {
+		return nil
+	}
+	currency, err := money.ParseCurrency(string(v.Currency))
+	if err != nil {
+		return err
+	}
+	v.Currency = currency
+	return nil
+}
+
+// ValidateDiscount checks DiscountValue and MaxDiscount against DiscountType. An empty
+// type passes so partial updates can leave it out; new vouchers must set one.
+func (v *Voucher) ValidateDiscount() error {
+	if v.DiscountValue.IsNegative() || v.MaxDiscount.IsNegative() {
+		return ErrInvalidDiscount
+	}
+	if v.DiscountType == DiscountPercentage && v.DiscountValue.GreaterThan(money.New(100)) {
+		return ErrInvalidDiscount
+	}
+	return nil
+}
+
+// ValidateLimits checks that none of the per-user limits is negative.
+func (v *Voucher) ValidateLimits() error {
+	if v.MaxUsesPerUser < 0 || v.MaxUsesPerUserPerDay < 0 || v.MaxUsesPerUserPerWeek < 0 || v.MaxRedemptionsPerUser < 0 {
+		return ErrInvalidLimit
+	}
+	return nil
+}
+
+// HasUsageLimits reports whether any per-user usage limit is set.
+func (v *Voucher) HasUsageLimits() bool {
+	return v.MaxUsesPerUser > 0 || v.MaxUsesPerUserPerDay > 0 || v.MaxUsesPerUserPerWeek > 0
+}
+
+// CheckUsageLimits returns a *LimitError for the first usage limit the counts reached.
+func (v *Voucher) CheckUsageLimits(counts UsageCounts) error {
+	switch {
+	case v.MaxUsesPerUser > 0 && counts.Total >= v.MaxUsesPerUser:
+		return &LimitError{Code: LimitUsesPerUser, Limit: v.MaxUsesPerUser}
+	case v.MaxUsesPerUserPerDay > 0 && counts.Today >= v.MaxUsesPerUserPerDay:
+		return &LimitError{Code: LimitUsesPerUserPerDay, Limit: v.MaxUsesPerUserPerDay}
+	case v.MaxUsesPerUserPerWeek > 0 && counts.ThisWeek >= v.MaxUsesPerUserPerWeek:
+		return &LimitError{Code: LimitUsesPerUserPerWeek, Limit: v.MaxUsesPerUserPerWeek}
+	}
+	return nil
+}
+
+// RemainingUsesFor returns how many more times the user may use the voucher under the
+// tightest of its limits, or nil when it has none.
+func (v *Voucher) RemainingUsesFor(counts UsageCounts) *int {
+	var remaining *int
+	for _, limit := range []struct{ max, used int }{
+		{v.MaxUsesPerUser, counts.Total},
+		{v.MaxUsesPerUserPerDay, counts.Today},
+		{v.MaxUsesPerUserPerWeek, counts.ThisWeek},
+	} {
+		if limit.max <= 0 {
+			continue
+		}
+		left := limit.max - limit.used
+		if left < 0 {
+			left = 0
+		}
+		if remaining == nil || left < *remaining {
+			remaining = &left
+		}
+	}
+	return remaining
+}
+
+// RedemptionLimit is how many times one user may redeem the voucher.
+func (v *Voucher) RedemptionLimit() int {
+	if v.MaxRedemptionsPerUser > 0 {
+		return v.MaxRedemptionsPerUser
+	}
+	return 1
+}
+
+// NextState returns the state the lifecycle action moves the voucher to at now, or
+// ErrInvalidTransition when the action is not allowed in its current state. Approved and
+// resumed vouchers are scheduled until their start date and end right away when their
+// end date already passed; pausing leaves the dates alone.
+func (v *Voucher) NextState(action string, now time.Time) (string, error) {
+	switch {
+	case action == VersionSubmitted && v.State == VoucherDraft:
+		return VoucherPendingApproval, nil
+	case action == VersionRejected && v.State == VoucherPendingApproval:
+		return VoucherDraft, nil
+	case action == VersionApproved && v.State == VoucherPendingApproval,
+		action == VersionResumed && v.State == VoucherPaused:
+		switch {
+		case !now.Before(v.EndDate):
+			return VoucherEnded, nil
+		case now.Before(v.StartDate):
+			return VoucherScheduled, nil
+		}
+		return VoucherLive, nil
+	case action == VersionPaused && (v.State == VoucherLive || v.State == VoucherScheduled):
+		return VoucherPaused, nil
+	}
+	return "", ErrInvalidTransition
+}
+
+func (v *Voucher) BeforeSave(tx *gorm.DB) (err error) {
+	if err := v.ValidateDiscount(); err != nil {
+		return err
+	}
+	if err := v.ValidateLimits(); err != nil {
+		return err
+	}
+	if err := v.ValidateCurrency(); err != nil {
+		return err
+	}
+
+	currentDate := time.Now()
+	v.Status = currentDate.After(v.StartDate) && currentDate.Before(v.EndDate)
+	// Marshal PaymentMethods and ApplicableAreas to JSON before saving
+	if len(v.PaymentMethods) > 0 {
+		// Marshal to JSON to ensure proper formatting
+		paymentMethodsJSON, err := json.Marshal(v.PaymentMethods)
+		if err != nil {
+			return err
+		}
+		v.PaymentMethods = nil // Clear the original array to use the marshaled value
+		v.PaymentMethods = []string{string(paymentMethodsJSON)}
+	}
+
+	if len(v.ApplicableAreas) > 0 {
+		// Marshal to JSON to ensure proper formatting
+		applicableAreasJSON, err := json.Marshal(v.ApplicableAreas)
+		if err != nil {
+			return err
+		}
+		v.ApplicableAreas = nil // Clear the original array to use the marshaled value
+		v.ApplicableAreas = []string{string(applicableAreasJSON)}
+	}
+
+	return nil
+}
+
+type Redeem struct {
+	ID         int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     int       `gorm:"not null" json:"user_id"`
+	VoucherID  int       `gorm:"not null" json:"voucher_id"`
+	RedeemDate time.Time `gorm:"default:current_date" json:"redeem_date"`
+	User       User      `gorm:"foreignKey:UserID;references:ID" swaggerignore:"true" json:"-"`
+	Voucher    Voucher   `gorm:"foreignKey:VoucherID;references:ID" swaggerignore:"true" json:"-"`
+}
+
+type History struct {
+	ID                int            `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID            int            `gorm:"not null" json:"user_id"`
+	VoucherID         int            `gorm:"not null" json:"voucher_id"`
+	UsageDate         time.Time      `gorm:"default:current_date" json:"usage_date"`
+	TransactionAmount money.Amount   `gorm:"type:numeric(20,4);not null" json:"transaction_amount" swaggertype:"number"`
+	BenefitValue      money.Amount   `gorm:"type:numeric(20,4);not null" json:"benefit_value" swaggertype:"number"`
+	Currency          money.Currency `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency" swaggertype:"string"`
+	OrderID           string         `gorm:"type:varchar(100);index" json:"order_id,omitempty"`
+	// ReversedAt is set when the order was cancelled and the usage undone. Reversed
+	// usages stay in the history but no longer count as spend.
+	ReversedAt     *time.Time `gorm:"type:timestamp with time zone" json:"reversed_at,omitempty"`
+	ReversalReason string     `gorm:"type:text" json:"reversal_reason,omitempty"`
+	QuotaRestored  bool       `gorm:"not null;default:false" json:"quota_restored,omitempty"`
+	// VoucherVersion is the version of the voucher whose terms were applied.
+	VoucherVersion int     `gorm:"not null;default:0" json:"voucher_version,omitempty"`
+	User           User    `gorm:"foreignKey:UserID;references:ID" swaggerignore:"true" json:"-"`
+	Voucher        Voucher `gorm:"foreignKey:VoucherID;references:ID" swaggerignore:"true" json:"-"`
+}
+
+func VoucherSeed() []Voucher {
+	return []Voucher{
+		{
+			VoucherName:     "10% Discount",
+			VoucherCode:     "DISCOUNT10",
+			VoucherType:     "e-commerce",
+			PointsRequired:  0,
+			Description:     "10% off for purchases above $100",
+			VoucherCategory: "Discount",
+			DiscountType:    DiscountPercentage,
+			DiscountValue:   money.New(10),
+			MinimumPurchase: money.New(100),
+			PaymentMethods:  []string{"Credit Card", "PayPal"},
+			StartDate:       time.Now().AddDate(0, 0, -5), // StartDate 5 days ago
+			EndDate:         time.Now().AddDate(0, 0, -1), // EndDate 1 day ago
+			ApplicableAreas: []string{"US", "Canada"},
+			Quota:           100,
+		},
+		{
+			VoucherName:     "Free Shipping",
+			VoucherCode:     "FREESHIP50",
+			VoucherType:     "e-commerce",
+			PointsRequired:  0,
+			Description:     "Free shipping for orders above $50",
+			VoucherCategory: "Free Shipping",
+			DiscountType:    DiscountFreeShipping,
+			DiscountValue:   money.New(0),
+			MinimumPurchase: money.New(50),
+			PaymentMethods:  []string{"All"},
+			StartDate:       time.Now(),
+			EndDate:         time.Now().AddDate(0, 2, 0), // 2 months valid
+			ApplicableAreas: []string{"Worldwide"},
+			Quota:           200,
+		},
+		{
+			VoucherName:     "Redeem 500 Points",
+			VoucherCode:     "POINTS500",
+			VoucherType:     "redeem points",
+			PointsRequired:  500,
+			Description:     "Redeem 500 points for a $20 discount",
+			VoucherCategory: "Discount",
+			DiscountType:    DiscountPercentage,
+			DiscountValue:   money.New(20),
+			MinimumPurchase: money.New(0),
+			PaymentMethods:  []string{"Credit Card"},
+			StartDate:       time.Now(),
+			EndDate:         time.Now().AddDate(0, 3, 0), // 3 months valid
+			ApplicableAreas: []string{"US"},
+			Quota:           150,
+		},
+		{
+			VoucherName:     "5% Discount",
+			VoucherCode:     "DISCOUNT5",
+			VoucherType:     "e-commerce",
+			PointsRequired:  0,
+			Description:     "5% discount on all purchases",
+			VoucherCategory: "Discount",
+			DiscountType:    DiscountPercentage,
+			DiscountValue:   money.New(5),
+			MinimumPurchase: money.New(0),
+			PaymentMethods:  []string{"PayPal"},
+			StartDate:       time.Now(),
+			EndDate:         time.Now().AddDate(0, 1, 0), // 1 month valid
+			ApplicableAreas: []string{"Europe"},
+			Quota:           500,
+		},
+		{
+			VoucherName:     "Black Friday Sale",
+			VoucherCode:     "BLACKFRIDAY",
+			VoucherType:     "e-commerce",
+			PointsRequired:  0,
+			Description:     "20% off for Black Friday",
+			VoucherCategory: "Discount",
+			DiscountType:    DiscountPercentage,
+			DiscountValue:   money.New(20),
+			MinimumPurchase: money.New(200),
+			PaymentMethods:  []string{"Credit Card", "Bank Transfer"},
+			StartDate:       time.Now(),
+			EndDate:         time.Now().AddDate(0, 0, 7), // 1 week valid
+			ApplicableAreas: []string{"Worldwide"},
+			Quota:           300,
+		},
+		{
+			VoucherName:     "Holiday Free Shipping",
+			VoucherCode:     "HOLIDAYSHIP",
+			VoucherType:     "e-commerce",
+			PointsRequired:  0,
+			Description:     "Free shipping during the holiday season",
+			VoucherCategory: "Free Shipping",
+			DiscountType:    DiscountFreeShipping,
+			DiscountValue:   money.New(0),
+			MinimumPurchase: money.New(75),
+			PaymentMethods:  []string{"All"},
+			StartDate:       time.Now(),
+			EndDate:         time.Now().AddDate(0, 1, 0), // 1 month valid
+			ApplicableAreas: []string{"US", "Canada"},
+			Quota:           400,
+		},
+		{
+			VoucherName:     "Cyber Monday Special",
+			VoucherCode:     "CYBERMON",
+			VoucherType:     "e-commerce",
+			PointsRequired:  0,
+			Description:     "15% off for Cyber Monday",
+			VoucherCategory: "Discount",
+			DiscountType:    DiscountPercentage,
+			DiscountValue:   money.New(15),
+			MinimumPurchase: money.New(150),
+			PaymentMethods:  []string{"Credit Card"},
+			StartDate:       time.Now(),
+			EndDate:         time.Now().AddDate(0, 0, 5), // 5 days valid
+			ApplicableAreas: []string{"Worldwide"},
+			Quota:           100,
+		},
+		{
+			VoucherName:     "Student Discount",
+			VoucherCode:     "STUDENT15",
+			VoucherType:     "e-commerce",
+			PointsRequired:  0,
+			Description:     "15% discount for students",
+			VoucherCategory: "Discount",
+			DiscountType:    DiscountPercentage,
+			DiscountValue:   money.New(15),
+			MinimumPurchase: money.New(0),
+			PaymentMethods:  []string{"Credit Card", "PayPal"},
+			StartDate:       time.Now(),
+			EndDate:         time.Now().AddDate(0, 2, 0), // 2 months valid
+			ApplicableAreas: []string{"Europe"},
+			Quota:           200,
+		},
+		{
+			VoucherName:     "New Year Sale",
+			VoucherCode:     "NEWYEAR50",
+			VoucherType:     "e-commerce",
+			PointsRequired:  0,
+			Description:     "Flat $50 off for the New Year sale",
+			VoucherCategory: "Discount",
+			DiscountType:    DiscountFixedAmount,
+			DiscountValue:   money.New(50),
+			MinimumPurchase: money.New(300),
+			PaymentMethods:  []string{"All"},
+			StartDate:       time.Now(),
+			EndDate:         time.Now().AddDate(0, 1, 0), // 1 month valid
+			ApplicableAreas: []string{"US"},
+			Quota:           150,
+		},
+		{
+			VoucherName:     "Valentine's Free Shipping",
+			VoucherCode:     "VALSHIP",
+			VoucherType:     "e-commerce",
+			PointsRequired:  0,
+			Description:     "Free shipping for Valentine's Day",
+			VoucherCategory: "Free Shipping",
+			DiscountType:    DiscountFreeShipping,
+			DiscountValue:   money.New(0),
+			MinimumPurchase: money.New(100),
+			PaymentMethods:  []string{"Credit Card", "PayPal"},
+			StartDate:       time.Now(),
+			EndDate:         time.Now().AddDate(0, 1, 14), // 1 month 14 days valid
+			ApplicableAreas: []string{"Worldwide"},
+			Quota:           300,
+		},
+	}
+}
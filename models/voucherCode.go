@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	CodeUnused   = "unused"
+	CodeAssigned = "assigned"
+	CodeUsed     = "used"
+)
+
+var (
+	ErrNoUnusedCodes = errors.New("voucher has no unused codes left")
+	ErrCodeUsed      = errors.New("voucher code has already been used")
+	// ErrCodeNotReservable is returned when a generated code is used to reserve a voucher;
+	// generated codes can only be used directly.
+	ErrCodeNotReservable = errors.New("generated voucher codes cannot be reserved")
+	// ErrCodeTaken is returned when a voucher is given a code that was already generated
+	// for a campaign voucher.
+	ErrCodeTaken = errors.New("voucher code is already taken by a generated code")
+)
+
+// VoucherCode is one of the single-use codes generated for a campaign voucher. A code
+// starts out unused, is assigned to one user and becomes used when that user applies
+// it, at which point it takes one unit of the voucher's quota like its own code would.
+type VoucherCode struct {
+	ID         int        `gorm:"primaryKey;autoIncrement" json:"id" example:"1204"`
+	VoucherID  int        `gorm:"not null;index:idx_voucher_code_state" json:"voucher_id" example:"3"`
+	Code       string     `gorm:"type:varchar(80);not null;uniqueIndex" json:"code" example:"SUMMER-7KX9QD4M"`
+	State      string     `gorm:"type:varchar(10);not null;default:'unused';index:idx_voucher_code_state;check:state in ('unused', 'assigned', 'used')" json:"state" example:"assigned"`
+	UserID     *int       `gorm:"index" json:"user_id,omitempty" example:"1"`
+	HistoryID  *int       `json:"history_id,omitempty"`
+	AssignedAt *time.Time `json:"assigned_at,omitempty"`
+	UsedAt     *time.Time `json:"used_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	Voucher    Voucher    `gorm:"foreignKey:VoucherID;references:ID" swaggerignore:"true" json:"-"`
+}
+
+// GenerateCodesRequest is the format and number of codes to generate for a voucher. An
+// empty alphabet uses codegen.DefaultAlphabet.
+type GenerateCodesRequest struct {
+	Count      int    `json:"count" binding:"required,min=1,max=100000" example:"50000"`
+	Alphabet   string `json:"alphabet" example:"23456789ABCDEFGHJKMNPQRSTWXYZ"`
+	Length     int    `json:"length" binding:"required,min=1,max=32" example:"8"`
+	Prefix     string `json:"prefix" binding:"max=40" example:"SUMMER-"`
+	CheckDigit bool   `json:"check_digit" example:"true"`
+}
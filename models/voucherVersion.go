@@ -0,0 +1,82 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	VersionCreated  = "created"
+	VersionUpdated  = "updated"
+	VersionDeleted  = "deleted"
+	VersionReverted = "reverted"
+	// Lifecycle actions; see Voucher.NextState.
+	VersionSubmitted = "submitted"
+	VersionApproved  = "approved"
+	VersionRejected  = "rejected"
+	VersionPaused    = "paused"
+	VersionResumed   = "resumed"
+)
+
+var ErrVersionNotFound = errors.New("voucher version not found")
+
+// FieldChange is the old and new value of one voucher field. A nil value means the field
+// was empty.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// VoucherVersion is a snapshot of a voucher's terms taken after every change. Versions
+// are numbered per voucher starting at 1; vouchers created before versioning existed
+// stay at version 0 until they are first changed.
+type VoucherVersion struct {
+	ID        int       `gorm:"primaryKey;autoIncrement" json:"id" example:"41"`
+	VoucherID int       `gorm:"not null;uniqueIndex:idx_voucher_version" json:"voucher_id" example:"3"`
+	Version   int       `gorm:"not null;uniqueIndex:idx_voucher_version" json:"version" example:"2"`
+	Action    string    `gorm:"type:varchar(20);not null;check:action in ('created', 'updated', 'deleted', 'reverted', 'submitted', 'approved', 'rejected', 'paused', 'resumed')" json:"action" example:"updated"`
+	ActorID   int       `gorm:"not null;default:0" json:"actor_id,omitempty" example:"1"`
+	Reason    string    `gorm:"type:text" json:"reason,omitempty" example:"Discount too high for this segment"`
+	Snapshot  RawJSON   `gorm:"type:jsonb;not null" json:"snapshot" swaggertype:"object"`
+	Changes   RawJSON   `gorm:"type:jsonb" json:"changes,omitempty" swaggertype:"object"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// RawJSON is a jsonb column that is passed through to API responses as is.
+type RawJSON json.RawMessage
+
+func (j RawJSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j *RawJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append(RawJSON(nil), v...)
+	case string:
+		*j = RawJSON(v)
+	default:
+		return fmt.Errorf("cannot scan %T into RawJSON", value)
+	}
+	return nil
+}
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+func (j *RawJSON) UnmarshalJSON(data []byte) error {
+	*j = append(RawJSON(nil), data...)
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"time"
+	"voucher_system/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type EarningRuleRepository interface {
+	FindAll() ([]models.EarningRule, error)
+	FindActive(at time.Time) ([]models.EarningRule, error)
+	Create(rule *models.EarningRule) error
+	Update(rule *models.EarningRule) error
+	Delete(ruleID int) error
+}
+
+type earningRuleRepository struct {
+	DB  *gorm.DB
+	log *zap.Logger
+}
+
+func NewEarningRuleRepository(db *gorm.DB, log *zap.Logger) EarningRuleRepository {
+	return &earningRuleRepository{DB: db, log: log}
+}
+
+func (r *earningRuleRepository) FindAll() ([]models.EarningRule, error) {
+	var rules []models.EarningRule
+	err := r.DB.Order("id").Find(&rules).Error
+	if err != nil {
+		r.log.Error("Error fetching earning rules", zap.Error(err))
+	}
+	return rules, err
+}
+
+// FindActive returns the enabled rules whose date range includes at.
+func (r *earningRuleRepository) FindActive(at time.Time) ([]models.EarningRule, error) {
+	var rules []models.EarningRule
+	err := r.DB.
+		Where("disabled = ?", false).
+		Where("start_date IS NULL OR start_date <= ?", at).
+		Where("end_date IS NULL OR end_date >= ?", at).
+		Order("id").
+		Find(&rules).Error
+	if err != nil {
+		r.log.Error("Error fetching active earning rules", zap.Error(err))
+	}
+	return rules, err
+}
+
+func (r *earningRuleRepository) Create(rule *models.EarningRule) error {
+	return r.DB.Create(rule).Error
+}
+
+func (r *earningRuleRepository) Update(rule *models.EarningRule) error {
+	result := r.DB.Model(&models.EarningRule{}).
+		Where("id = ?", rule.ID).
+		Select("*").
+		Omit("id", "created_at").
+		Updates(rule)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *earningRuleRepository) Delete(ruleID int) error {
+	result := r.DB.Delete(&models.EarningRule{}, ruleID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -1,40 +1,147 @@
-package repository
-
-import (
-	"errors"
-	"voucher_system/models"
-
-	"go.uber.org/zap"
-	"gorm.io/gorm"
-)
-
-type HistoryRepository interface {
-	CreateHistory(history *models.History) error
-	FindUsageHistoryByUser(userID int) ([]models.History, error)
-}
-
-type historyRepository struct {
-	DB  *gorm.DB
-	log *zap.Logger
-}
-
-func NewHistoryRepository(db *gorm.DB, log *zap.Logger) HistoryRepository {
-	return &historyRepository{DB: db, log: log}
-}
-
-func (r *historyRepository) CreateHistory(history *models.History) error {
-	return r.DB.Create(history).Error
-}
-
-func (r *historyRepository) FindUsageHistoryByUser(userID int) ([]models.History, error) {
-	var histories []models.History
-	err := r.DB.Where("user_id = ?", userID).Find(&histories).Error
-	if err != nil {
-		r.log.Error("Error fetching usage voucher by user", zap.Error(err))
-	}
-
-	if len(histories) == 0 {
-		return nil, errors.New("no voucher usage history found")
-	}
-	return histories, nil
-}
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"voucher_system/models"
+	pointsledger "voucher_system/repository/points_ledger"
+	"voucher_system/utils/money"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrAlreadyReversed = errors.New("voucher usage is already reversed")
+
+// UsageReversal undoes one voucher usage, found by UsageID or else by the OrderID of
+// UserID.
+type UsageReversal struct {
+	UsageID int
+	UserID  int
+	OrderID string
+	Reason  string
+	// RestoreQuota gives the used unit back to the voucher.
+	RestoreQuota bool
+	At           time.Time
+}
+
+// UsageTotal sums the voucher usages of one currency.
+type UsageTotal struct {
+	Currency          money.Currency `json:"currency" swaggertype:"string"`
+	Usages            int            `json:"usages"`
+	TransactionAmount money.Amount   `json:"transaction_amount" swaggertype:"number"`
+	BenefitValue      money.Amount   `json:"benefit_value" swaggertype:"number"`
+}
+
+type HistoryRepository interface {
+	CreateHistory(history *models.History) error
+	FindUsageHistoryByUser(userID int) ([]models.History, error)
+	SumByCurrency(from, to time.Time) ([]UsageTotal, error)
+	Reverse(reversal UsageReversal) (*models.History, error)
+}
+
+type historyRepository struct {
+	DB  *gorm.DB
+	log *zap.Logger
+}
+
+func NewHistoryRepository(db *gorm.DB, log *zap.Logger) HistoryRepository {
+	return &historyRepository{DB: db, log: log}
+}
+
+func (r *historyRepository) CreateHistory(history *models.History) error {
+	return r.DB.Create(history).Error
+}
+
+func (r *historyRepository) FindUsageHistoryByUser(userID int) ([]models.History, error) {
+	var histories []models.History
+	err := r.DB.Where("user_id = ?", userID).Find(&histories).Error
+	if err != nil {
+		r.log.Error("Error fetching usage voucher by user", zap.Error(err))
+	}
+
+	if len(histories) == 0 {
+		return nil, errors.New("no voucher usage history found")
+	}
+	return histories, nil
+}
+
+// SumByCurrency totals the usages between from and to, one row per currency. Reversed
+// usages are left out.
+func (r *historyRepository) SumByCurrency(from, to time.Time) ([]UsageTotal, error) {
+	var totals []UsageTotal
+	err := r.DB.Model(&models.History{}).
+		Select(`currency, COUNT(*) AS usages,
+			COALESCE(SUM(transaction_amount), 0) AS transaction_amount,
+			COALESCE(SUM(benefit_value), 0) AS benefit_value`).
+		Where("usage_date >= ? AND usage_date < ? AND reversed_at IS NULL", from, to).
+		Group("currency").
+		Order("currency").
+		Scan(&totals).Error
+	if err != nil {
+		r.log.Error("Error summing voucher usage by currency", zap.Error(err))
+		return nil, err
+	}
+	return totals, nil
+}
+
+// Reverse marks a usage as reversed, takes back the points it earned that are not spent
+// yet and, if asked, gives its unit back to the voucher quota. The user keeps their
+// redeem of the voucher, so they can use it again.
+func (r *historyRepository) Reverse(reversal UsageReversal) (*models.History, error) {
+	var (
+		history       models.History
+		pointsRevoked int
+	)
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		if reversal.UsageID != 0 {
+			query = query.Where("id = ?", reversal.UsageID)
+		} else {
+			query = query.Where("order_id = ? AND user_id = ?", reversal.OrderID, reversal.UserID).Order("reversed_at IS NOT NULL, id DESC")
+		}
+		if err := query.First(&history).Error; err != nil {
+			return err
+		}
+		if history.ReversedAt != nil {
+			return ErrAlreadyReversed
+		}
+
+		history.ReversedAt = &reversal.At
+		history.ReversalReason = reversal.Reason
+		history.QuotaRestored = reversal.RestoreQuota
+		err := tx.Model(&history).
+			Select("reversed_at", "reversal_reason", "quota_restored").
+			Updates(&history).Error
+		if err != nil {
+			return fmt.Errorf("failed to reverse voucher usage: %w", err)
+		}
+
+		if reversal.RestoreQuota {
+			err := tx.Model(&models.Voucher{}).
+				Where("id = ?", history.VoucherID).
+				Update("quota", gorm.Expr("quota + 1")).Error
+			if err != nil {
+				return fmt.Errorf("failed to update voucher quota: %w", err)
+			}
+		}
+
+		revoked, err := pointsledger.RevokeEarn(tx, fmt.Sprintf("history:%d", history.ID), fmt.Sprintf("Points earned on order %s reversed", history.OrderID))
+		if err != nil {
+			return fmt.Errorf("failed to revoke earned points: %w", err)
+		}
+		pointsRevoked = revoked
+
+		redeem := models.Redeem{UserID: history.UserID, VoucherID: history.VoucherID}
+		return tx.Where(&redeem).FirstOrCreate(&redeem).Error
+	})
+	if err != nil {
+		r.log.Error("Error reversing voucher usage", zap.Int("usageID", reversal.UsageID), zap.String("orderID", reversal.OrderID), zap.Error(err))
+		return nil, err
+	}
+
+	r.log.Info("Voucher usage reversed", zap.Int("usageID", history.ID), zap.Bool("quotaRestored", reversal.RestoreQuota), zap.Int("pointsRevoked", pointsRevoked))
+	return &history, nil
+}
@@ -1,69 +1,169 @@
-package repository_test
-
-import (
-	"testing"
-	"voucher_system/repository"
-
-	"github.com/DATA-DOG/go-sqlmock"
-	"github.com/stretchr/testify/assert"
-	"go.uber.org/zap"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
-)
-
-func setupTestDB() (*gorm.DB, sqlmock.Sqlmock) {
-	db, mock, _ := sqlmock.New()
-	dialector := postgres.New(postgres.Config{
-		Conn:       db,
-		DriverName: "postgres",
-	})
-	gormDB, _ := gorm.Open(dialector, &gorm.Config{})
-	return gormDB, mock
-}
-
-func TestFindUsageHistoryByUser(t *testing.T) {
-	db, mock := setupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-	logger := zap.NewNop()
-	repo := repository.NewHistoryRepository(db, logger)
-
-	userID := 123
-	rows := sqlmock.NewRows([]string{"id", "user_id", "voucher_id", "transaction_amount", "benefit_value", "usage_date"}).
-		AddRow(1, userID, 456, 100.0, 10.0, "2024-12-01").
-		AddRow(2, userID, 457, 200.0, 20.0, "2024-12-02")
-
-	mock.ExpectQuery(`SELECT \* FROM "histories" WHERE user_id = \$1`).
-		WithArgs(userID).
-		WillReturnRows(rows)
-
-	histories, err := repo.FindUsageHistoryByUser(userID)
-
-	assert.NoError(t, err)
-	assert.Len(t, histories, 2)
-}
-
-
-// func TestCreateHistory(t *testing.T) {
-// 	db, mock := setupTestDB()
-// 	defer func() { _ = mock.ExpectationsWereMet() }()
-// 	logger := zap.NewNop()
-// 	repo := repository.NewHistoryRepository(db, logger)
-
-// 	history := &models.History{
-// 		ID:                1,
-// 		UserID:            123,
-// 		VoucherID:         456,
-// 		TransactionAmount: 100.0,
-// 		BenefitValue:      10.0,
-// 		UsageDate:         time.Now(),
-// 	}
-
-// 	mock.ExpectExec(`INSERT INTO "histories" \("id","user_id","voucher_id","transaction_amount","benefit_value","usage_date"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6\)`).
-// 		WithArgs(history.ID, history.UserID, history.VoucherID, history.TransactionAmount, history.BenefitValue, sqlmock.AnyArg()).
-// 		WillReturnResult(sqlmock.NewResult(1, 1))
-
-// 	err := repo.CreateHistory(history)
-
-// 	assert.NoError(t, err)
-// }
-
+package repository_test
+
+import (
+	"testing"
+	"time"
+	"voucher_system/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupTestDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, _ := sqlmock.New()
+	dialector := postgres.New(postgres.Config{
+		Conn:       db,
+		DriverName: "postgres",
+	})
+	gormDB, _ := gorm.Open(dialector, &gorm.Config{})
+	return gormDB, mock
+}
+
+func TestFindUsageHistoryByUser(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+	logger := zap.NewNop()
+	repo := repository.NewHistoryRepository(db, logger)
+
+	userID := 123
+	rows := sqlmock.NewRows([]string{"id", "user_id", "voucher_id", "transaction_amount", "benefit_value", "usage_date"}).
+		AddRow(1, userID, 456, 100.0, 10.0, "2024-12-01").
+		AddRow(2, userID, 457, 200.0, 20.0, "2024-12-02")
+
+	mock.ExpectQuery(`SELECT \* FROM "histories" WHERE user_id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(rows)
+
+	histories, err := repo.FindUsageHistoryByUser(userID)
+
+	assert.NoError(t, err)
+	assert.Len(t, histories, 2)
+}
+
+
+// func TestCreateHistory(t *testing.T) {
+// 	db, mock := setupTestDB()
+// 	defer func() { _ = mock.ExpectationsWereMet() }()
+// 	logger := zap.NewNop()
+// 	repo := repository.NewHistoryRepository(db, logger)
+
+// 	history := &models.History{
+// 		ID:                1,
+// 		UserID:            123,
+// 		VoucherID:         456,
+// 		TransactionAmount: 100.0,
+// 		BenefitValue:      10.0,
+// 		UsageDate:         time.Now(),
+// 	}
+
+// 	mock.ExpectExec(`INSERT INTO "histories" \("id","user_id","voucher_id","transaction_amount","benefit_value","usage_date"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6\)`).
+// 		WithArgs(history.ID, history.UserID, history.VoucherID, history.TransactionAmount, history.BenefitValue, sqlmock.AnyArg()).
+// 		WillReturnResult(sqlmock.NewResult(1, 1))
+
+// 	err := repo.CreateHistory(history)
+
+// 	assert.NoError(t, err)
+// }
+
+
+func TestReverseUsage(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+	logger := zap.NewNop()
+	repo := repository.NewHistoryRepository(db, logger)
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "user_id", "voucher_id", "transaction_amount", "benefit_value", "order_id", "usage_date"}).
+		AddRow(15, 1, 4, "100", "10", "INV-1", now)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "histories" WHERE order_id = \$1 AND user_id = \$2 ORDER BY reversed_at IS NOT NULL, id DESC,"histories"."id" LIMIT \$3 FOR UPDATE`).
+		WithArgs("INV-1", 1, 1).
+		WillReturnRows(rows)
+	mock.ExpectExec(`UPDATE "histories" SET "reversed_at"=\$1,"reversal_reason"=\$2,"quota_restored"=\$3 WHERE "id" = \$4`).
+		WithArgs(now, "cancelled", true, 15).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "vouchers" SET "quota"=quota \+ 1`).
+		WithArgs(sqlmock.AnyArg(), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "points_ledger_entries" WHERE entry_type = \$1 AND reference = \$2`).
+		WithArgs("earn", "history:15", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "redeems" WHERE "redeems"."user_id" = \$1 AND "redeems"."voucher_id" = \$2`).
+		WithArgs(1, 4, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "voucher_id"}).AddRow(3, 1, 4))
+	mock.ExpectCommit()
+
+	history, err := repo.Reverse(repository.UsageReversal{OrderID: "INV-1", UserID: 1, Reason: "cancelled", RestoreQuota: true, At: now})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 15, history.ID)
+	assert.True(t, history.QuotaRestored)
+	assert.Equal(t, &now, history.ReversedAt)
+}
+
+func TestReverseUsage_RevokesEarnedPoints(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+	repo := repository.NewHistoryRepository(db, zap.NewNop())
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "histories" WHERE id = \$1`).
+		WithArgs(15, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "voucher_id", "order_id"}).AddRow(15, 1, 4, "INV-1"))
+	mock.ExpectExec(`UPDATE "histories" SET "reversed_at"=\$1`).
+		WithArgs(now, "cancelled", false, 15).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "points_ledger_entries" WHERE entry_type = \$1 AND reference = \$2`).
+		WithArgs("earn", "history:15", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "entry_type", "points", "reference"}).AddRow(30, 1, "earn", 12, "history:15"))
+	mock.ExpectQuery(`INSERT INTO "points_accounts" .* ON CONFLICT \("user_id"\) DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "points_accounts" WHERE user_id = \$1 .* FOR UPDATE`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "balance"}).AddRow(2, 1, 20))
+	mock.ExpectQuery(`SELECT \* FROM "points_lots" WHERE ledger_entry_id = \$1`).
+		WithArgs(30, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "ledger_entry_id", "points", "remaining"}).AddRow(7, 1, 30, 12, 8))
+	mock.ExpectExec(`UPDATE "points_lots" SET "remaining"=\$1 WHERE id = \$2`).
+		WithArgs(0, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "points_accounts" SET "balance"=\$1`).
+		WithArgs(12, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "points_ledger_entries"`).
+		WithArgs(1, "adjust", -8, 12, "history:15", sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(31))
+	mock.ExpectQuery(`SELECT \* FROM "redeems"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "voucher_id"}).AddRow(3, 1, 4))
+	mock.ExpectCommit()
+
+	history, err := repo.Reverse(repository.UsageReversal{UsageID: 15, Reason: "cancelled", At: now})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 15, history.ID)
+}
+
+func TestReverseUsage_AlreadyReversed(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+	logger := zap.NewNop()
+	repo := repository.NewHistoryRepository(db, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "voucher_id", "reversed_at"}).
+		AddRow(15, 1, 4, time.Now())
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "histories" WHERE id = \$1`).
+		WithArgs(15, 1).
+		WillReturnRows(rows)
+	mock.ExpectRollback()
+
+	_, err := repo.Reverse(repository.UsageReversal{UsageID: 15, RestoreQuota: true, At: time.Now()})
+
+	assert.ErrorIs(t, err, repository.ErrAlreadyReversed)
+}
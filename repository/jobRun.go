@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"voucher_system/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type JobRunRepository interface {
+	Start(run *models.JobRun) error
+	Finish(run *models.JobRun) error
+	FindRecent(job string, limit int) ([]models.JobRun, error)
+}
+
+type jobRunRepository struct {
+	DB  *gorm.DB
+	log *zap.Logger
+}
+
+func NewJobRunRepository(db *gorm.DB, log *zap.Logger) JobRunRepository {
+	return &jobRunRepository{DB: db, log: log}
+}
+
+// Start records run as it begins.
+func (r *jobRunRepository) Start(run *models.JobRun) error {
+	if err := r.DB.Create(run).Error; err != nil {
+		r.log.Error("Error recording job run", zap.String("job", run.Job), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Finish stores the outcome of run.
+func (r *jobRunRepository) Finish(run *models.JobRun) error {
+	err := r.DB.Model(run).
+		Select("status", "error", "finished_at").
+		Updates(run).Error
+	if err != nil {
+		r.log.Error("Error finishing job run", zap.String("job", run.Job), zap.Int("runID", run.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// FindRecent returns the latest runs of job, newest first.
+func (r *jobRunRepository) FindRecent(job string, limit int) ([]models.JobRun, error) {
+	var runs []models.JobRun
+	err := r.DB.Where("job = ?", job).
+		Order("started_at DESC, id DESC").
+		Limit(limit).
+		Find(&runs).Error
+	if err != nil {
+		r.log.Error("Error fetching job runs", zap.String("job", job), zap.Error(err))
+		return nil, err
+	}
+	return runs, nil
+}
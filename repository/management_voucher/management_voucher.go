@@ -1,227 +1,587 @@
-package managementvoucher
-
-import (
-	"encoding/json"
-	"fmt"
-	"time"
-	"voucher_system/models"
-
-	"go.uber.org/zap"
-	"gorm.io/gorm"
-)
-
-type ManagementVoucherInterface interface {
-	CreateVoucher(voucher *models.Voucher) error
-	SoftDeleteVoucher(voucherID int) error
-	UpdateVoucher(voucher *models.Voucher, voucherID int) error
-	ShowRedeemPoints() (*[]RedeemPoint, error)
-	GetVouchersByQueryParams(status, area, voucher_type string) (*[]models.Voucher, error)
-	CreateRedeemVoucher(redeem *models.Redeem, points int) error
-}
-
-type ManagementVoucherRepo struct {
-	DB  *gorm.DB
-	Log *zap.Logger
-}
-
-func NewManagementVoucherRepo(db *gorm.DB, log *zap.Logger) ManagementVoucherInterface {
-	return &ManagementVoucherRepo{DB: db, Log: log}
-}
-
-func (m *ManagementVoucherRepo) CreateVoucher(voucher *models.Voucher) error {
-	err := m.DB.Create(voucher).Error
-	if err != nil {
-		m.Log.Error("Error from repo creating voucher:", zap.Error(err))
-		return err
-	}
-
-	return nil
-}
-
-func (m *ManagementVoucherRepo) SoftDeleteVoucher(voucherID int) error {
-
-	err := m.DB.Delete(&models.Voucher{}, voucherID).Error
-	if err != nil {
-		m.Log.Error("Error from repo soft deleting voucher:", zap.Error(err))
-		return err
-	}
-
-	return nil
-}
-
-func (m *ManagementVoucherRepo) UpdateVoucher(voucher *models.Voucher, voucherID int) error {
-
-	result := m.DB.Model(&voucher).
-		Where("id = ?", voucherID).
-		Updates(voucher)
-
-	if result.Error != nil {
-		return result.Error
-	}
-
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("no record found with shipping_id %d", voucherID)
-	}
-
-	return nil
-}
-
-type RedeemPoint struct {
-	VoucherName    string  `json:"voucher_name"`
-	PointsRequired int     `json:"points_required"`
-	DiscountValue  float64 `json:"discount_value"`
-}
-
-func (m *ManagementVoucherRepo) ShowRedeemPoints() (*[]RedeemPoint, error) {
-
-	voucher := []RedeemPoint{}
-
-	query := m.DB.
-		Table("vouchers as v").
-		Select(`v.voucher_name, v.discount_value, v.points_required`).
-		Where("voucher_type = ? AND start_date <= NOW() AND end_date >= NOW()", "redeem points")
-
-	err := query.Find(&voucher).Error
-	if err != nil {
-		return nil, err
-	}
-
-	return &voucher, nil
-}
-
-func (m *ManagementVoucherRepo) GetVouchersByQueryParams(status, area, voucher_type string) (*[]models.Voucher, error) {
-
-	var rawVouchers []struct {
-		models.Voucher
-		RawPaymentMethods  []byte `gorm:"column:payment_methods"`
-		RawApplicableAreas []byte `gorm:"column:applicable_areas"`
-	}
-
-	query := m.DB.Model(&models.Voucher{})
-
-	if area != "" {
-		query = query.Where("applicable_areas @> ?", fmt.Sprintf(`["%s"]`, area))
-	}
-
-	if status != "" {
-		if status == "active" {
-			query = query.Where("start_date <= NOW() AND end_date >= NOW()")
-		} else if status == "non-active" {
-			query = query.Where("end_date < NOW()")
-		}
-	}
-
-	if voucher_type != "" {
-		query = query.Where("voucher_type = ?", voucher_type)
-	}
-
-	err := query.Find(&rawVouchers).Error
-	if err != nil {
-		return nil, err
-	}
-
-	vouchers := make([]models.Voucher, 0, len(rawVouchers))
-	for _, rawVoucher := range rawVouchers {
-		v := rawVoucher.Voucher
-
-		if len(rawVoucher.RawPaymentMethods) > 0 {
-			if err := json.Unmarshal(rawVoucher.RawPaymentMethods, &v.PaymentMethods); err != nil {
-				return nil, err
-			}
-		}
-
-		if len(rawVoucher.RawApplicableAreas) > 0 {
-			if err := json.Unmarshal(rawVoucher.RawApplicableAreas, &v.ApplicableAreas); err != nil {
-				return nil, err
-			}
-		}
-		vouchers = append(vouchers, v)
-
-	}
-
-	return &vouchers, nil
-}
-
-func (m *ManagementVoucherRepo) CreateRedeemVoucher(redeem *models.Redeem, points int) error {
-
-	tx := m.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			m.Log.Panic("Transaction rolled back due to panic", zap.Any("reason", r))
-		}
-	}()
-
-	err := tx.Model(&models.Redeem{}).
-		Where("user_id = ? AND voucher_id = ?", redeem.UserID, redeem.VoucherID).
-		First(&models.Redeem{}).Error
-
-	if err == nil {
-		tx.Rollback()
-		return fmt.Errorf("user_id %d already claimed voucher_id %d", redeem.UserID, redeem.VoucherID)
-	}
-
-	var voucher struct {
-		Quota          int
-		PointsRequired int
-		StartDate      time.Time
-		EndDate        time.Time
-	}
-
-	today := time.Now()
-
-	err = tx.Model(&models.Voucher{}).
-		Where("id = ?", redeem.VoucherID).
-		Select("quota, points_required, start_date, end_date").
-		Scan(&voucher).Error
-	if err != nil {
-		tx.Rollback()
-		m.Log.Error("Failed to fetch voucher data: ", zap.Error(err))
-		return err
-	}
-	fmt.Println(voucher.PointsRequired)
-
-	if voucher.Quota <= 0 {
-		tx.Rollback()
-		return fmt.Errorf("quota for voucher ID %d is not sufficient", redeem.VoucherID)
-	}
-
-	if points != voucher.PointsRequired {
-		tx.Rollback()
-		return fmt.Errorf("required points (%d) do not match provided points (%d)", voucher.PointsRequired, points)
-	}
-
-	if voucher.StartDate.After(today) {
-		tx.Rollback()
-		return fmt.Errorf("voucher cannot be used before its start date: %s", voucher.StartDate.Format("2006-01-02"))
-	}
-
-	if voucher.EndDate.Before(today) {
-		tx.Rollback()
-		return fmt.Errorf("voucher expired")
-	}
-
-	err = tx.Create(redeem).Error
-	if err != nil {
-		tx.Rollback()
-		m.Log.Error("Failed to create redeem: ", zap.Error(err))
-		return err
-	}
-
-	err = tx.Model(&models.Voucher{}).
-		Where("id = ?", redeem.VoucherID).
-		UpdateColumn("quota", gorm.Expr("quota - ?", 1)).Error
-	if err != nil {
-		tx.Rollback()
-		m.Log.Error("Failed to decrement voucher quota: ", zap.Error(err))
-		return err
-	}
-
-	err = tx.Commit().Error
-	if err != nil {
-		m.Log.Error("Failed to commit transaction: ", zap.Error(err))
-		return err
-	}
-
-	return nil
-}
+package managementvoucher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"voucher_system/models"
+	pointsledger "voucher_system/repository/points_ledger"
+	"voucher_system/repository/tier"
+	"voucher_system/utils/codegen"
+	"voucher_system/utils/money"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ManagementVoucherInterface interface {
+	CreateVoucher(voucher *models.Voucher, actorID int) error
+	SoftDeleteVoucher(voucherID, actorID int) error
+	UpdateVoucher(voucher *models.Voucher, voucherID, actorID int) error
+	FindVersions(voucherID int) ([]models.VoucherVersion, error)
+	RevertVoucher(voucherID, version, actorID int) (*models.VoucherVersion, error)
+	ChangeState(voucherID, actorID int, action, reason string, now time.Time) (*models.VoucherVersion, error)
+	GenerateCodes(voucherID int, format codegen.Format, count int) (int, error)
+	AssignCode(voucherID, userID int, now time.Time) (*models.VoucherCode, error)
+	FindCodes(voucherID int, state string) ([]models.VoucherCode, error)
+	ShowRedeemPoints() (*[]RedeemPoint, error)
+	GetVouchersByQueryParams(status, area, voucher_type string) (*[]models.Voucher, error)
+	CreateRedeemVoucher(redeem *models.Redeem, points int) error
+	SyncStatus(now time.Time) ([]models.VoucherStatusEvent, error)
+}
+
+type ManagementVoucherRepo struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewManagementVoucherRepo(db *gorm.DB, log *zap.Logger) ManagementVoucherInterface {
+	return &ManagementVoucherRepo{DB: db, Log: log}
+}
+
+// CreateVoucher stores the voucher as a draft and records it as version 1.
+func (m *ManagementVoucherRepo) CreateVoucher(voucher *models.Voucher, actorID int) error {
+	voucher.State = models.VoucherDraft
+	voucher.SubmittedBy = 0
+	voucher.Version = 0
+	err := m.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkCodeFree(tx, voucher.VoucherCode); err != nil {
+			return err
+		}
+		if err := tx.Create(voucher).Error; err != nil {
+			return err
+		}
+
+		version, err := recordVersion(tx, voucher.ID, models.VersionCreated, actorID, "", nil)
+		if err != nil {
+			return err
+		}
+		voucher.Version = version.Version
+		return nil
+	})
+	if err != nil {
+		m.Log.Error("Error from repo creating voucher:", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// SoftDeleteVoucher deletes the voucher and records its final state as a new version.
+func (m *ManagementVoucherRepo) SoftDeleteVoucher(voucherID, actorID int) error {
+
+	err := m.DB.Transaction(func(tx *gorm.DB) error {
+		voucher, err := lockLiveVoucher(tx, voucherID)
+		if err != nil {
+			return err
+		}
+		before, err := snapshotOf(voucher)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&models.Voucher{}, voucherID).Error; err != nil {
+			return err
+		}
+
+		_, err = recordVersion(tx, voucherID, models.VersionDeleted, actorID, "", before)
+		return err
+	})
+	if err != nil {
+		m.Log.Error("Error from repo soft deleting voucher:", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// UpdateVoucher applies the non-zero fields of voucher and records the result as a new
+// version together with the fields that changed. Vouchers past the draft stage go back
+// for approval; see requireApproval.
+func (m *ManagementVoucherRepo) UpdateVoucher(voucher *models.Voucher, voucherID, actorID int) error {
+
+	return m.DB.Transaction(func(tx *gorm.DB) error {
+		current, err := lockLiveVoucher(tx, voucherID)
+		if errors.Is(err, ErrVoucherNotFound) {
+			return fmt.Errorf("no record found with shipping_id %d", voucherID)
+		}
+		if err != nil {
+			return err
+		}
+		before, err := snapshotOf(current)
+		if err != nil {
+			return err
+		}
+		if voucher.VoucherCode != "" && voucher.VoucherCode != current.VoucherCode {
+			if err := checkCodeFree(tx, voucher.VoucherCode); err != nil {
+				return err
+			}
+		}
+		if voucher.DiscountType == "" {
+			voucher.DiscountType = current.DiscountType
+		}
+		// Updates leaves zero fields alone, so check the discount the voucher ends up with.
+		merged := *current
+		merged.DiscountType = voucher.DiscountType
+		if !voucher.DiscountValue.IsZero() {
+			merged.DiscountValue = voucher.DiscountValue
+		}
+		if !voucher.MaxDiscount.IsZero() {
+			merged.MaxDiscount = voucher.MaxDiscount
+		}
+		if err := merged.ValidateDiscount(); err != nil {
+			return err
+		}
+
+		result := tx.Model(&voucher).
+			Omit("TierPrices", "State", "SubmittedBy", "Version").
+			Where("id = ?", voucherID).
+			Updates(voucher)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("no record found with shipping_id %d", voucherID)
+		}
+
+		if voucher.TierPrices != nil {
+			if err := replaceTierPrices(tx, voucherID, voucher.TierPrices); err != nil {
+				return err
+			}
+		}
+		if err := requireApproval(tx, current, actorID); err != nil {
+			return err
+		}
+
+		version, err := recordVersion(tx, voucherID, models.VersionUpdated, actorID, "", before)
+		if err != nil {
+			return err
+		}
+		voucher.Version = version.Version
+		return nil
+	})
+}
+
+// requireApproval sends a voucher whose terms were changed back to pending approval with
+// actorID as its submitter, so another admin has to approve the new terms before they
+// can be used. Drafts stay drafts until they are submitted.
+func requireApproval(tx *gorm.DB, voucher *models.Voucher, actorID int) error {
+	if voucher.State == models.VoucherDraft {
+		return nil
+	}
+	return tx.Model(&models.Voucher{}).
+		Where("id = ?", voucher.ID).
+		UpdateColumns(map[string]interface{}{"state": models.VoucherPendingApproval, "submitted_by": actorID}).Error
+}
+
+func replaceTierPrices(tx *gorm.DB, voucherID int, prices []models.VoucherTierPrice) error {
+	err := tx.Where("voucher_id = ?", voucherID).Delete(&models.VoucherTierPrice{}).Error
+	if err != nil {
+		return err
+	}
+	if len(prices) == 0 {
+		return nil
+	}
+
+	for i := range prices {
+		prices[i].ID = 0
+		prices[i].VoucherID = voucherID
+	}
+	return tx.Create(&prices).Error
+}
+
+// FindVersions returns the recorded versions of a voucher, newest first.
+func (m *ManagementVoucherRepo) FindVersions(voucherID int) ([]models.VoucherVersion, error) {
+	var versions []models.VoucherVersion
+	err := m.DB.Where("voucher_id = ?", voucherID).Order("version DESC").Find(&versions).Error
+	if err != nil {
+		m.Log.Error("Error from repo fetching voucher versions:", zap.Error(err))
+		return nil, err
+	}
+	return versions, nil
+}
+
+// RevertVoucher restores the terms of an earlier version and records them as a new
+// version. The quota is kept as it is; see revertColumns. Like an update, the revert
+// sends a voucher past the draft stage back for approval.
+func (m *ManagementVoucherRepo) RevertVoucher(voucherID, version, actorID int) (*models.VoucherVersion, error) {
+	var reverted *models.VoucherVersion
+	err := m.DB.Transaction(func(tx *gorm.DB) error {
+		var target models.VoucherVersion
+		err := tx.Where("voucher_id = ? AND version = ?", voucherID, version).First(&target).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrVersionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		current, err := lockLiveVoucher(tx, voucherID)
+		if err != nil {
+			return err
+		}
+		before, err := snapshotOf(current)
+		if err != nil {
+			return err
+		}
+
+		var restored models.Voucher
+		if err := json.Unmarshal(target.Snapshot, &restored); err != nil {
+			return err
+		}
+		if restored.VoucherCode != current.VoucherCode {
+			if err := checkCodeFree(tx, restored.VoucherCode); err != nil {
+				return err
+			}
+		}
+		err = tx.Model(&restored).
+			Select(revertColumns).
+			Where("id = ?", voucherID).
+			Updates(&restored).Error
+		if err != nil {
+			return err
+		}
+		if err := replaceTierPrices(tx, voucherID, restored.TierPrices); err != nil {
+			return err
+		}
+		if err := requireApproval(tx, current, actorID); err != nil {
+			return err
+		}
+
+		reverted, err = recordVersion(tx, voucherID, models.VersionReverted, actorID, "", before)
+		return err
+	})
+	if err != nil {
+		m.Log.Error("Error from repo reverting voucher:", zap.Error(err))
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+// ChangeState applies a lifecycle action to the voucher and records it as a new version
+// with reason. Approvals must come from someone other than the submitter.
+func (m *ManagementVoucherRepo) ChangeState(voucherID, actorID int, action, reason string, now time.Time) (*models.VoucherVersion, error) {
+	var changed *models.VoucherVersion
+	err := m.DB.Transaction(func(tx *gorm.DB) error {
+		voucher, err := lockLiveVoucher(tx, voucherID)
+		if err != nil {
+			return err
+		}
+		state, err := voucher.NextState(action, now)
+		if err != nil {
+			return err
+		}
+		if action == models.VersionApproved && voucher.SubmittedBy == actorID {
+			return models.ErrSelfApproval
+		}
+		before, err := snapshotOf(voucher)
+		if err != nil {
+			return err
+		}
+
+		columns := map[string]interface{}{"state": state, "updated_at": now}
+		if action == models.VersionSubmitted {
+			columns["submitted_by"] = actorID
+		}
+		err = tx.Model(&models.Voucher{}).Where("id = ?", voucherID).UpdateColumns(columns).Error
+		if err != nil {
+			return err
+		}
+
+		changed, err = recordVersion(tx, voucherID, action, actorID, reason, before)
+		return err
+	})
+	if err != nil {
+		m.Log.Error("Error from repo changing voucher state:", zap.String("action", action), zap.Error(err))
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+type RedeemPoint struct {
+	VoucherName    string       `json:"voucher_name"`
+	PointsRequired int          `json:"points_required"`
+	DiscountValue  money.Amount `json:"discount_value" swaggertype:"number"`
+}
+
+func (m *ManagementVoucherRepo) ShowRedeemPoints() (*[]RedeemPoint, error) {
+
+	voucher := []RedeemPoint{}
+
+	query := m.DB.
+		Table("vouchers as v").
+		Select(`v.voucher_name, v.discount_value, v.points_required`).
+		Where("voucher_type = ? AND state = ? AND start_date <= NOW() AND end_date >= NOW()", "redeem points", models.VoucherLive)
+
+	err := query.Find(&voucher).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &voucher, nil
+}
+
+// GetVouchersByQueryParams lists vouchers for customers, so only published vouchers are
+// included; drafts, vouchers waiting for approval and paused vouchers stay hidden
+// whatever the filters.
+func (m *ManagementVoucherRepo) GetVouchersByQueryParams(status, area, voucher_type string) (*[]models.Voucher, error) {
+
+	var rawVouchers []struct {
+		models.Voucher
+		RawPaymentMethods  []byte `gorm:"column:payment_methods"`
+		RawApplicableAreas []byte `gorm:"column:applicable_areas"`
+	}
+
+	query := m.DB.Model(&models.Voucher{}).Where("state IN ?", []string{models.VoucherLive, models.VoucherEnded})
+
+	if area != "" {
+		query = query.Where("applicable_areas @> ?", fmt.Sprintf(`["%s"]`, area))
+	}
+
+	if status != "" {
+		if status == "active" {
+			query = query.Where("state = ? AND start_date <= NOW() AND end_date >= NOW()", models.VoucherLive)
+		} else if status == "non-active" {
+			query = query.Where("end_date < NOW()")
+		}
+	}
+
+	if voucher_type != "" {
+		query = query.Where("voucher_type = ?", voucher_type)
+	}
+
+	err := query.Find(&rawVouchers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	vouchers := make([]models.Voucher, 0, len(rawVouchers))
+	for _, rawVoucher := range rawVouchers {
+		v := rawVoucher.Voucher
+
+		if len(rawVoucher.RawPaymentMethods) > 0 {
+			if err := json.Unmarshal(rawVoucher.RawPaymentMethods, &v.PaymentMethods); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rawVoucher.RawApplicableAreas) > 0 {
+			if err := json.Unmarshal(rawVoucher.RawApplicableAreas, &v.ApplicableAreas); err != nil {
+				return nil, err
+			}
+		}
+		vouchers = append(vouchers, v)
+
+	}
+
+	return &vouchers, nil
+}
+
+func (m *ManagementVoucherRepo) CreateRedeemVoucher(redeem *models.Redeem, points int) error {
+
+	tx := m.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			m.Log.Panic("Transaction rolled back due to panic", zap.Any("reason", r))
+		}
+	}()
+
+	var voucher models.Voucher
+
+	today := time.Now()
+
+	// Lock the voucher before counting, so concurrent redeems of the same voucher queue
+	// up here and each sees the redeems committed before it.
+	err := tx.Model(&models.Voucher{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", redeem.VoucherID).
+		Select("quota, points_required, start_date, end_date, minimum_tier, max_redemptions_per_user, state").
+		Scan(&voucher).Error
+	if err != nil {
+		tx.Rollback()
+		m.Log.Error("Failed to fetch voucher data: ", zap.Error(err))
+		return err
+	}
+
+	var redeemed int64
+	err = tx.Model(&models.Redeem{}).
+		Where("user_id = ? AND voucher_id = ?", redeem.UserID, redeem.VoucherID).
+		Count(&redeemed).Error
+	if err != nil {
+		tx.Rollback()
+		m.Log.Error("Failed to count redeems: ", zap.Error(err))
+		return err
+	}
+
+	if voucher.State != models.VoucherLive {
+		tx.Rollback()
+		return models.ErrVoucherNotLive
+	}
+
+	if limit := voucher.RedemptionLimit(); int(redeemed) >= limit {
+		tx.Rollback()
+		return &models.LimitError{Code: models.LimitRedemptionsPerUser, Limit: limit}
+	}
+	fmt.Println(voucher.PointsRequired)
+
+	if voucher.Quota <= 0 {
+		tx.Rollback()
+		return fmt.Errorf("quota for voucher ID %d is not sufficient", redeem.VoucherID)
+	}
+
+	userTier, err := tier.UserTier(tx, redeem.UserID)
+	if err != nil {
+		tx.Rollback()
+		m.Log.Error("Failed to fetch user tier: ", zap.Error(err))
+		return err
+	}
+
+	eligible, err := tier.Meets(tx, userTier, voucher.MinimumTier)
+	if err != nil {
+		tx.Rollback()
+		m.Log.Error("Failed to check user tier: ", zap.Error(err))
+		return err
+	}
+	if !eligible {
+		tx.Rollback()
+		return fmt.Errorf("voucher requires the %s tier", voucher.MinimumTier)
+	}
+
+	pointsRequired, err := tier.PointsPrice(tx, redeem.VoucherID, userTier, voucher.PointsRequired)
+	if err != nil {
+		tx.Rollback()
+		m.Log.Error("Failed to fetch tier price: ", zap.Error(err))
+		return err
+	}
+
+	if points != pointsRequired {
+		tx.Rollback()
+		return fmt.Errorf("required points (%d) do not match provided points (%d)", pointsRequired, points)
+	}
+
+	if voucher.StartDate.After(today) {
+		tx.Rollback()
+		return fmt.Errorf("voucher cannot be used before its start date: %s", voucher.StartDate.Format("2006-01-02"))
+	}
+
+	if voucher.EndDate.Before(today) {
+		tx.Rollback()
+		return fmt.Errorf("voucher expired")
+	}
+
+	err = tx.Create(redeem).Error
+	if err != nil {
+		tx.Rollback()
+		m.Log.Error("Failed to create redeem: ", zap.Error(err))
+		return err
+	}
+
+	if pointsRequired > 0 {
+		err = pointsledger.ApplyEntry(tx, &models.PointsLedgerEntry{
+			UserID:      redeem.UserID,
+			EntryType:   models.LedgerSpend,
+			Points:      -pointsRequired,
+			Reference:   fmt.Sprintf("redeem:%d", redeem.ID),
+			Description: fmt.Sprintf("Redeem voucher %d", redeem.VoucherID),
+		})
+		if err != nil {
+			tx.Rollback()
+			m.Log.Error("Failed to debit points: ", zap.Error(err))
+			return err
+		}
+	}
+
+	err = tx.Model(&models.Voucher{}).
+		Where("id = ?", redeem.VoucherID).
+		UpdateColumn("quota", gorm.Expr("quota - ?", 1)).Error
+	if err != nil {
+		tx.Rollback()
+		m.Log.Error("Failed to decrement voucher quota: ", zap.Error(err))
+		return err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		m.Log.Error("Failed to commit transaction: ", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// SyncStatus sets Status on every voucher whose start or end date passed since it was
+// last saved and returns one event per voucher it changed. The updates only match rows
+// whose status still differs, so when several instances sync at once each change is
+// reported by exactly one of them. Scheduled vouchers whose start date passed go live
+// and published vouchers whose end date passed end.
+func (m *ManagementVoucherRepo) SyncStatus(now time.Time) ([]models.VoucherStatusEvent, error) {
+	type statusRow struct {
+		ID          int
+		VoucherCode string
+		Status      bool
+		EndDate     time.Time
+	}
+	var changed []statusRow
+
+	err := m.DB.Transaction(func(tx *gorm.DB) error {
+		var activated, deactivated []statusRow
+		err := tx.Raw(`
+			UPDATE vouchers SET status = true, updated_at = ?
+			WHERE deleted_at IS NULL AND status IS NOT TRUE AND start_date < ? AND end_date > ?
+			RETURNING id, voucher_code, status, end_date`, now, now, now).
+			Scan(&activated).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Raw(`
+			UPDATE vouchers SET status = false, updated_at = ?
+			WHERE deleted_at IS NULL AND status IS TRUE AND (start_date >= ? OR end_date <= ?)
+			RETURNING id, voucher_code, status, end_date`, now, now, now).
+			Scan(&deactivated).Error
+		if err != nil {
+			return err
+		}
+
+		changed = append(activated, deactivated...)
+
+		err = tx.Exec(`
+			UPDATE vouchers SET state = ?, updated_at = ?
+			WHERE deleted_at IS NULL AND state = ? AND start_date <= ? AND end_date > ?`,
+			models.VoucherLive, now, models.VoucherScheduled, now, now).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(`
+			UPDATE vouchers SET state = ?, updated_at = ?
+			WHERE deleted_at IS NULL AND state IN ? AND end_date <= ?`,
+			models.VoucherEnded, now, []string{models.VoucherScheduled, models.VoucherLive, models.VoucherPaused}, now).Error
+	})
+	if err != nil {
+		m.Log.Error("Failed to sync voucher status: ", zap.Error(err))
+		return nil, err
+	}
+
+	events := make([]models.VoucherStatusEvent, 0, len(changed))
+	for _, voucher := range changed {
+		event := models.VoucherStatusEvent{VoucherID: voucher.ID, VoucherCode: voucher.VoucherCode, At: now}
+		switch {
+		case voucher.Status:
+			event.Event = models.VoucherActivated
+		case !voucher.EndDate.After(now):
+			event.Event = models.VoucherExpired
+		default:
+			event.Event = models.VoucherDeactivated
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
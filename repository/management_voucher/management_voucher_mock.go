@@ -1,48 +1,103 @@
-package managementvoucher
-
-import (
-	"voucher_system/models"
-
-	"github.com/stretchr/testify/mock"
-)
-
-type ManagementVoucherRepoMock struct {
-	mock.Mock
-}
-
-func (m *ManagementVoucherRepoMock) CreateVoucher(voucher *models.Voucher) error {
-
-	args := m.Called(voucher)
-	return args.Error(0)
-}
-
-func (m *ManagementVoucherRepoMock) SoftDeleteVoucher(voucherID int) error {
-	args := m.Called(voucherID)
-	return args.Error(0)
-}
-
-func (m *ManagementVoucherRepoMock) UpdateVoucher(voucher *models.Voucher, voucherID int) error {
-	args := m.Called(voucher, voucherID)
-	return args.Error(0)
-}
-
-func (m *ManagementVoucherRepoMock) ShowRedeemPoints() (*[]RedeemPoint, error) {
-	args := m.Called()
-	if points := args.Get(0); points != nil {
-		return points.(*[]RedeemPoint), args.Error(1)
-	}
-	return nil, args.Error(1)
-}
-
-func (m *ManagementVoucherRepoMock) GetVouchersByQueryParams(status, area, voucher_type string) (*[]models.Voucher, error) {
-	args := m.Called(status, area, voucher_type)
-	if vouchers := args.Get(0); vouchers != nil {
-		return vouchers.(*[]models.Voucher), args.Error(1)
-	}
-	return nil, args.Error(1)
-}
-
-func (m *ManagementVoucherRepoMock) CreateRedeemVoucher(redeem *models.Redeem, points int) error {
-	args := m.Called(redeem, points)
-	return args.Error(0)
-}
+package managementvoucher
+
+import (
+	"time"
+	"voucher_system/models"
+	"voucher_system/utils/codegen"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ManagementVoucherRepoMock struct {
+	mock.Mock
+}
+
+func (m *ManagementVoucherRepoMock) CreateVoucher(voucher *models.Voucher, actorID int) error {
+
+	args := m.Called(voucher, actorID)
+	return args.Error(0)
+}
+
+func (m *ManagementVoucherRepoMock) SoftDeleteVoucher(voucherID, actorID int) error {
+	args := m.Called(voucherID, actorID)
+	return args.Error(0)
+}
+
+func (m *ManagementVoucherRepoMock) UpdateVoucher(voucher *models.Voucher, voucherID, actorID int) error {
+	args := m.Called(voucher, voucherID, actorID)
+	return args.Error(0)
+}
+
+func (m *ManagementVoucherRepoMock) FindVersions(voucherID int) ([]models.VoucherVersion, error) {
+	args := m.Called(voucherID)
+	if versions := args.Get(0); versions != nil {
+		return versions.([]models.VoucherVersion), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherRepoMock) RevertVoucher(voucherID, version, actorID int) (*models.VoucherVersion, error) {
+	args := m.Called(voucherID, version, actorID)
+	if reverted := args.Get(0); reverted != nil {
+		return reverted.(*models.VoucherVersion), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherRepoMock) ShowRedeemPoints() (*[]RedeemPoint, error) {
+	args := m.Called()
+	if points := args.Get(0); points != nil {
+		return points.(*[]RedeemPoint), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherRepoMock) GetVouchersByQueryParams(status, area, voucher_type string) (*[]models.Voucher, error) {
+	args := m.Called(status, area, voucher_type)
+	if vouchers := args.Get(0); vouchers != nil {
+		return vouchers.(*[]models.Voucher), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherRepoMock) CreateRedeemVoucher(redeem *models.Redeem, points int) error {
+	args := m.Called(redeem, points)
+	return args.Error(0)
+}
+
+func (m *ManagementVoucherRepoMock) SyncStatus(now time.Time) ([]models.VoucherStatusEvent, error) {
+	args := m.Called(now)
+	if events := args.Get(0); events != nil {
+		return events.([]models.VoucherStatusEvent), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherRepoMock) ChangeState(voucherID, actorID int, action, reason string, now time.Time) (*models.VoucherVersion, error) {
+	args := m.Called(voucherID, actorID, action, reason, now)
+	if changed := args.Get(0); changed != nil {
+		return changed.(*models.VoucherVersion), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherRepoMock) GenerateCodes(voucherID int, format codegen.Format, count int) (int, error) {
+	args := m.Called(voucherID, format, count)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *ManagementVoucherRepoMock) AssignCode(voucherID, userID int, now time.Time) (*models.VoucherCode, error) {
+	args := m.Called(voucherID, userID, now)
+	if code := args.Get(0); code != nil {
+		return code.(*models.VoucherCode), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherRepoMock) FindCodes(voucherID int, state string) ([]models.VoucherCode, error) {
+	args := m.Called(voucherID, state)
+	if codes := args.Get(0); codes != nil {
+		return codes.([]models.VoucherCode), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
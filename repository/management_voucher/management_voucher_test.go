@@ -1,507 +1,1205 @@
-package managementvoucher_test
-
-import (
-	"fmt"
-	"testing"
-	"time"
-	"voucher_system/models"
-	managementvoucher "voucher_system/repository/management_voucher"
-
-	"github.com/DATA-DOG/go-sqlmock"
-	"github.com/stretchr/testify/assert"
-	"go.uber.org/zap"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
-)
-
-func setupTestDB() (*gorm.DB, sqlmock.Sqlmock) {
-	db, mock, _ := sqlmock.New()
-	dialector := postgres.New(postgres.Config{
-		Conn:       db,
-		DriverName: "postgres",
-	})
-	gormDB, _ := gorm.Open(dialector, &gorm.Config{})
-	return gormDB, mock
-}
-
-func TestCreateVoucher(t *testing.T) {
-	db, mock := setupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-
-	log := *zap.NewNop()
-
-	customerRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
-
-	t.Run("Succesfully create a voucher", func(t *testing.T) {
-
-		voucher := &models.Voucher{
-			VoucherName:     "Promo December",
-			VoucherCode:     "DESC2024",
-			VoucherType:     "e-commerce",
-			PointsRequired:  0,
-			Description:     "Get more discount on december",
-			VoucherCategory: "discount",
-			DiscountValue:   10,
-			MinimumPurchase: 200000,
-			PaymentMethods:  []string{"Credit Card", "PayPal"},
-			StartDate:       time.Now().AddDate(0, 0, -5),
-			EndDate:         time.Now().AddDate(0, 0, -1),
-			ApplicableAreas: []string{"US", "Canada"},
-			Quota:           100,
-			Status:          false,
-			CreatedAt:       time.Now().AddDate(0, 0, 1),
-			UpdatedAt:       time.Now().AddDate(0, 0, -1),
-		}
-
-		mock.ExpectBegin()
-		mock.ExpectQuery(`INSERT INTO "vouchers"`).
-			WithArgs(
-				voucher.VoucherName,
-				voucher.VoucherCode,
-				voucher.VoucherType,
-				voucher.PointsRequired,
-				voucher.Description,
-				voucher.VoucherCategory,
-				voucher.DiscountValue,
-				voucher.MinimumPurchase,
-				sqlmock.AnyArg(),
-				sqlmock.AnyArg(),
-				sqlmock.AnyArg(),
-				sqlmock.AnyArg(),
-				voucher.Quota,
-				voucher.Status,
-				sqlmock.AnyArg(),
-				sqlmock.AnyArg(),
-				sqlmock.AnyArg(),
-			).
-			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
-
-		mock.ExpectCommit()
-		err := customerRepo.CreateVoucher(voucher)
-		assert.NoError(t, err)
-		assert.Equal(t, 1, voucher.ID)
-		assert.NotEmpty(t, voucher.VoucherName)
-	})
-
-	t.Run("Failed to create a voucher", func(t *testing.T) {
-		voucher := &models.Voucher{
-			VoucherName:     "Promo December",
-			VoucherCode:     "DESC2024",
-			VoucherType:     "e-commerce",
-			PointsRequired:  0,
-			Description:     "Get more discount on december",
-			VoucherCategory: "discount",
-			DiscountValue:   10,
-			MinimumPurchase: 200000,
-			PaymentMethods:  []string{"Credit Card", "PayPal"},
-			StartDate:       time.Now().AddDate(0, 0, -5),
-			EndDate:         time.Now().AddDate(0, 0, -1),
-			ApplicableAreas: []string{"US", "Canada"},
-			Quota:           100,
-			Status:          false,
-			CreatedAt:       time.Now().AddDate(0, 0, 1),
-			UpdatedAt:       time.Now().AddDate(0, 0, -1),
-		}
-
-		mock.ExpectBegin()
-		mock.ExpectQuery(`INSERT INTO "vouchers"`).
-			WithArgs(
-				voucher.VoucherName,
-				voucher.VoucherCode,
-				voucher.VoucherType,
-				voucher.PointsRequired,
-				voucher.Description,
-				voucher.VoucherCategory,
-				voucher.DiscountValue,
-				voucher.MinimumPurchase,
-				sqlmock.AnyArg(),
-				sqlmock.AnyArg(),
-				sqlmock.AnyArg(),
-				sqlmock.AnyArg(),
-				voucher.Quota,
-				voucher.Status,
-				sqlmock.AnyArg(),
-				sqlmock.AnyArg(),
-				sqlmock.AnyArg(),
-			).
-			WillReturnError(fmt.Errorf("database error"))
-
-		mock.ExpectRollback()
-		err := customerRepo.CreateVoucher(voucher)
-		assert.Error(t, err)
-		assert.EqualError(t, err, "database error")
-	})
-}
-
-func TestSoftDeleteVoucher(t *testing.T) {
-	db, mock := setupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-
-	log := *zap.NewNop()
-
-	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
-
-	t.Run("Successfully soft delete a voucher", func(t *testing.T) {
-		voucherID := 1
-
-		mock.ExpectBegin()
-		mock.ExpectExec(`UPDATE "vouchers" SET "deleted_at"=`).
-			WithArgs(sqlmock.AnyArg(), voucherID).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectCommit()
-
-		err := voucherRepo.SoftDeleteVoucher(voucherID)
-		assert.NoError(t, err)
-	})
-
-	t.Run("Failed to soft delete a voucher", func(t *testing.T) {
-		voucherID := 2
-
-		mock.ExpectBegin()
-		mock.ExpectExec(`UPDATE "vouchers" SET "deleted_at"=`).
-			WithArgs(sqlmock.AnyArg(), voucherID).
-			WillReturnError(fmt.Errorf("database error"))
-		mock.ExpectRollback()
-
-		err := voucherRepo.SoftDeleteVoucher(voucherID)
-		assert.Error(t, err)
-		assert.EqualError(t, err, "database error")
-	})
-
-}
-
-func TestUpdateVoucher(t *testing.T) {
-	db, mock := setupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-
-	log := *zap.NewNop()
-	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
-
-	t.Run("Successfully update a voucher", func(t *testing.T) {
-		voucherID := 1
-		voucher := &models.Voucher{
-			VoucherName:     "Promo Updated",
-			VoucherCode:     "UPDATED2024",
-			VoucherType:     "e-commerce",
-			PointsRequired:  10,
-			Description:     "Updated discount",
-			VoucherCategory: "discount",
-			DiscountValue:   15,
-			MinimumPurchase: 250000,
-			Quota:           50,
-		}
-
-		mock.ExpectBegin()
-		mock.ExpectExec(`UPDATE "vouchers" SET`).
-			WithArgs(
-				voucher.VoucherName,
-				voucher.VoucherCode,
-				voucher.VoucherType,
-				voucher.PointsRequired,
-				voucher.Description,
-				voucher.VoucherCategory,
-				voucher.DiscountValue,
-				voucher.MinimumPurchase,
-				voucher.Quota,
-				sqlmock.AnyArg(), // updated_at
-				voucherID,
-			).
-			WillReturnResult(sqlmock.NewResult(1, int64(voucherID)))
-		mock.ExpectCommit()
-
-		err := voucherRepo.UpdateVoucher(voucher, voucherID)
-		assert.NoError(t, err)
-	})
-
-	t.Run("Failed to update due to no matching record", func(t *testing.T) {
-		voucherID := 2
-		voucher := &models.Voucher{
-			VoucherName: "Promo Not Found",
-		}
-
-		mock.ExpectBegin()
-		mock.ExpectExec(`UPDATE "vouchers" SET`).
-			WithArgs(voucher.VoucherName, sqlmock.AnyArg(), voucherID).
-			WillReturnResult(sqlmock.NewResult(0, 0)) // No rows affected
-		mock.ExpectCommit()
-
-		err := voucherRepo.UpdateVoucher(voucher, voucherID)
-		assert.Error(t, err)
-		assert.EqualError(t, err, "no record found with shipping_id 2")
-	})
-
-	t.Run("Failed to update due to database error", func(t *testing.T) {
-		voucherID := 3
-		voucher := &models.Voucher{
-			VoucherName: "Promo Error",
-		}
-
-		mock.ExpectBegin()
-		mock.ExpectExec(`UPDATE "vouchers" SET`).
-			WithArgs(voucher.VoucherName, sqlmock.AnyArg(), voucherID).
-			WillReturnError(fmt.Errorf("database error"))
-		mock.ExpectRollback()
-
-		err := voucherRepo.UpdateVoucher(voucher, voucherID)
-		assert.Error(t, err)
-		assert.EqualError(t, err, "database error")
-	})
-}
-
-func TestShowRedeemPoints(t *testing.T) {
-	db, mock := setupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-
-	log := *zap.NewNop()
-	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
-
-	t.Run("Successfully show redeem points", func(t *testing.T) {
-
-		mockRows := sqlmock.NewRows([]string{"voucher_name", "discount_value", "points_required"}).
-			AddRow("Promo A", 20.0, 50).
-			AddRow("Promo B", 15.0, 30)
-
-		mock.ExpectQuery(`SELECT v.voucher_name, v.discount_value, v.points_required FROM vouchers as v WHERE`).
-			WithArgs("redeem points").
-			WillReturnRows(mockRows)
-
-		result, err := voucherRepo.ShowRedeemPoints()
-		assert.NoError(t, err)
-		assert.NotNil(t, result)
-		assert.Len(t, *result, 2)
-
-		assert.Equal(t, "Promo A", (*result)[0].VoucherName)
-		assert.Equal(t, 20.0, (*result)[0].DiscountValue)
-		assert.Equal(t, 50, (*result)[0].PointsRequired)
-
-		assert.Equal(t, "Promo B", (*result)[1].VoucherName)
-		assert.Equal(t, 15.0, (*result)[1].DiscountValue)
-		assert.Equal(t, 30, (*result)[1].PointsRequired)
-	})
-
-	t.Run("Failed to show redeem points due to database error", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT v.voucher_name, v.discount_value, v.points_required FROM vouchers as v WHERE`).
-			WithArgs("redeem points").
-			WillReturnError(fmt.Errorf("database error"))
-
-		result, err := voucherRepo.ShowRedeemPoints()
-		assert.Error(t, err)
-		assert.Nil(t, result)
-		assert.EqualError(t, err, "database error")
-	})
-}
-
-func TestGetVouchersByQueryParams(t *testing.T) {
-	db, mock := setupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-
-	log := *zap.NewNop()
-	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
-
-	t.Run("Successfully fetch vouchers with all query parameters", func(t *testing.T) {
-		rawPaymentMethods := `["Credit Card","PayPal"]`
-		rawApplicableAreas := `["US","Canada"]`
-
-		mockRows := sqlmock.NewRows([]string{
-			"id", "voucher_name", "voucher_code", "voucher_type", "points_required",
-			"description", "voucher_category", "discount_value", "minimum_purchase",
-			"payment_methods", "start_date", "end_date", "applicable_areas", "quota",
-			"status", "created_at", "updated_at",
-		}).
-			AddRow(1, "Promo A", "CODE123", "e-commerce", 0, "Description A", "discount", 10.0, 200000,
-				rawPaymentMethods, time.Now(), time.Now().AddDate(0, 0, 1), rawApplicableAreas, 100, true, time.Now(), time.Now()).
-			AddRow(2, "Promo B", "CODE456", "retail", 0, "Description B", "voucher", 15.0, 150000,
-				rawPaymentMethods, time.Now(), time.Now().AddDate(0, 0, 1), rawApplicableAreas, 200, true, time.Now(), time.Now())
-
-		mock.ExpectQuery(`SELECT (.+) FROM "vouchers"`).
-			WillReturnRows(mockRows)
-
-		status := "active"
-		area := "US"
-		voucherType := "e-commerce"
-
-		result, err := voucherRepo.GetVouchersByQueryParams(status, area, voucherType)
-		assert.NoError(t, err)
-		assert.NotNil(t, result)
-		assert.Len(t, *result, 2)
-
-		// Validate first voucher
-		assert.Equal(t, "Promo A", (*result)[0].VoucherName)
-		assert.Equal(t, "CODE123", (*result)[0].VoucherCode)
-		assert.Equal(t, []string{"Credit Card", "PayPal"}, (*result)[0].PaymentMethods)
-		assert.Equal(t, []string{"US", "Canada"}, (*result)[0].ApplicableAreas)
-
-		// Validate second voucher
-		assert.Equal(t, "Promo B", (*result)[1].VoucherName)
-		assert.Equal(t, "CODE456", (*result)[1].VoucherCode)
-	})
-
-	t.Run("No vouchers match query parameters", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT (.+) FROM "vouchers"`).
-			WillReturnRows(sqlmock.NewRows([]string{
-				"id", "voucher_name", "voucher_code", "voucher_type", "points_required",
-				"description", "voucher_category", "discount_value", "minimum_purchase",
-				"payment_methods", "start_date", "end_date", "applicable_areas", "quota",
-				"status", "created_at", "updated_at",
-			}))
-
-		status := "non-active"
-		area := "Europe"
-		voucherType := "retail"
-
-		result, err := voucherRepo.GetVouchersByQueryParams(status, area, voucherType)
-		assert.NoError(t, err)
-		assert.NotNil(t, result)
-		assert.Len(t, *result, 0)
-	})
-
-	t.Run("Database error while fetching vouchers", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT (.+) FROM "vouchers"`).
-			WillReturnError(fmt.Errorf("database error"))
-
-		status := "active"
-		area := "Asia"
-		voucherType := "redeem points"
-
-		result, err := voucherRepo.GetVouchersByQueryParams(status, area, voucherType)
-		assert.Error(t, err)
-		assert.Nil(t, result)
-		assert.EqualError(t, err, "database error")
-	})
-}
-
-func TestCreateRedeemVoucher(t *testing.T) {
-	db, mock := setupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-
-	log := *zap.NewNop()
-	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
-
-	today := time.Now()
-
-	t.Run("Successfully create redeem voucher", func(t *testing.T) {
-		redeem := &models.Redeem{
-			UserID:    1,
-			VoucherID: 100,
-		}
-		points := 50
-
-		mock.ExpectBegin()
-		mock.ExpectQuery(`SELECT \* FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2 ORDER BY "redeems"."id" LIMIT \$3`).
-			WithArgs(redeem.UserID, redeem.VoucherID, 1).
-			WillReturnRows(sqlmock.NewRows(nil)) // Tidak ada baris ditemukan
-
-		// Mock fetch voucher data
-		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date FROM "vouchers" WHERE id = \$1`).
-			WithArgs(redeem.VoucherID).
-			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date"}).
-				AddRow(10, 50, today.AddDate(0, 0, -5), today.AddDate(0, 0, 5)))
-
-		// Mock create redeem
-		mock.ExpectQuery(`INSERT INTO "redeems"`).
-			WithArgs(
-				redeem.UserID,
-				redeem.VoucherID,
-			).
-			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
-			// WillReturnResult(sqlmock.NewResult(1, 1))
-
-		// Mock decrement quota
-		mock.ExpectExec(`UPDATE "vouchers" SET "quota"=quota - \$1 WHERE id = \$2 AND "vouchers"."deleted_at" IS NULL`).
-			WithArgs(1, redeem.VoucherID).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-
-		mock.ExpectCommit()
-
-		err := voucherRepo.CreateRedeemVoucher(redeem, points)
-		assert.NoError(t, err)
-	})
-
-	t.Run("Redeem already exists", func(t *testing.T) {
-		redeem := &models.Redeem{
-			UserID:    2,
-			VoucherID: 101,
-		}
-
-		mock.ExpectBegin()
-		mock.ExpectQuery(`SELECT \* FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2 ORDER BY "redeems"."id" LIMIT \$3`).
-			WithArgs(redeem.UserID, redeem.VoucherID, 1).
-			WillReturnRows(sqlmock.NewRows([]string{"user_id", "voucher_id"}).
-				AddRow(redeem.UserID, redeem.VoucherID))
-
-		mock.ExpectRollback()
-
-		err := voucherRepo.CreateRedeemVoucher(redeem, 50)
-		assert.Error(t, err)
-		assert.EqualError(t, err, fmt.Sprintf("user_id %d already claimed voucher_id %d", redeem.UserID, redeem.VoucherID))
-	})
-
-	t.Run("Insufficient quota", func(t *testing.T) {
-		redeem := &models.Redeem{
-			UserID:    3,
-			VoucherID: 102,
-		}
-
-		mock.ExpectBegin()
-		mock.ExpectQuery(`SELECT \* FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2 ORDER BY "redeems"."id" LIMIT \$3`).
-			WithArgs(redeem.UserID, redeem.VoucherID, 1).
-			WillReturnError(gorm.ErrRecordNotFound)
-
-		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date FROM "vouchers"`).
-			WithArgs(redeem.VoucherID).
-			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date"}).
-				AddRow(0, 50, today.AddDate(0, 0, -5), today.AddDate(0, 0, 5)))
-
-		mock.ExpectRollback()
-
-		err := voucherRepo.CreateRedeemVoucher(redeem, 50)
-		assert.Error(t, err)
-		assert.EqualError(t, err, fmt.Sprintf("quota for voucher ID %d is not sufficient", redeem.VoucherID))
-	})
-
-	t.Run("Points mismatch", func(t *testing.T) {
-		redeem := &models.Redeem{
-			UserID:    4,
-			VoucherID: 103,
-		}
-
-		mock.ExpectBegin()
-		mock.ExpectQuery(`SELECT \* FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2 ORDER BY "redeems"."id" LIMIT \$3`).
-			WithArgs(redeem.UserID, redeem.VoucherID, 1).
-			WillReturnError(gorm.ErrRecordNotFound)
-
-		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date FROM "vouchers"`).
-			WithArgs(redeem.VoucherID).
-			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date"}).
-				AddRow(10, 100, today.AddDate(0, 0, -5), today.AddDate(0, 0, 5)))
-
-		mock.ExpectRollback()
-
-		err := voucherRepo.CreateRedeemVoucher(redeem, 50)
-		assert.Error(t, err)
-		assert.EqualError(t, err, "required points (100) do not match provided points (50)")
-	})
-
-	t.Run("Voucher expired", func(t *testing.T) {
-		redeem := &models.Redeem{
-			UserID:    5,
-			VoucherID: 104,
-		}
-
-		mock.ExpectBegin()
-		mock.ExpectQuery(`SELECT \* FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2 ORDER BY "redeems"."id" LIMIT \$3`).
-			WithArgs(redeem.UserID, redeem.VoucherID, 1).
-			WillReturnError(gorm.ErrRecordNotFound)
-
-		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date FROM "vouchers"`).
-			WithArgs(redeem.VoucherID).
-			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date"}).
-				AddRow(10, 50, today.AddDate(0, 0, -10), today.AddDate(0, 0, -1)))
-
-		mock.ExpectRollback()
-
-		err := voucherRepo.CreateRedeemVoucher(redeem, 50)
-		assert.Error(t, err)
-		assert.EqualError(t, err, "voucher expired")
-	})
-}
+package managementvoucher_test
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+	"voucher_system/models"
+	managementvoucher "voucher_system/repository/management_voucher"
+	"voucher_system/utils/codegen"
+	"voucher_system/utils/money"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupTestDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, _ := sqlmock.New()
+	dialector := postgres.New(postgres.Config{
+		Conn:       db,
+		DriverName: "postgres",
+	})
+	gormDB, _ := gorm.Open(dialector, &gorm.Config{})
+	return gormDB, mock
+}
+
+// voucherRow is a voucher row as lockVoucher reads it.
+func voucherRow(id int, name string, version int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "voucher_name", "voucher_code", "discount_value", "payment_methods", "applicable_areas", "quota", "state", "version"}).
+		AddRow(id, name, "DESC2024", "10", `["Credit Card"]`, `["Jawa"]`, 90, models.VoucherLive, version)
+}
+
+// expectResubmit expects a changed voucher to go back to pending approval with actorID
+// as its submitter.
+func expectResubmit(mock sqlmock.Sqlmock, voucherID, actorID int) {
+	mock.ExpectExec(`UPDATE "vouchers" SET "state"=\$1,"submitted_by"=\$2 WHERE id = \$3`).
+		WithArgs(models.VoucherPendingApproval, actorID, voucherID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectVersion expects recordVersion to bump the voucher to version and store a
+// snapshot of the row it reads back.
+func expectVersion(mock sqlmock.Sqlmock, voucherID, version int, row *sqlmock.Rows, args ...driver.Value) {
+	mock.ExpectQuery(`UPDATE vouchers SET version = version \+ 1 WHERE id = \$1 RETURNING version`).
+		WithArgs(voucherID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version))
+	mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers" WHERE id = \$1 LIMIT \$2 FOR UPDATE`).
+		WithArgs(voucherID, 1).
+		WillReturnRows(row)
+	mock.ExpectQuery(`SELECT \* FROM "voucher_tier_prices" WHERE voucher_id = \$1 ORDER BY tier`).
+		WithArgs(voucherID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "voucher_id", "tier", "points_required"}))
+	insert := mock.ExpectQuery(`INSERT INTO "voucher_versions"`)
+	if len(args) > 0 {
+		insert = insert.WithArgs(args...)
+	}
+	insert.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(version))
+}
+
+// expectCodeFree expects a voucher's own code to be checked against the generated codes.
+func expectCodeFree(mock sqlmock.Sqlmock, code string, taken int) {
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "voucher_codes" WHERE code = \$1`).
+		WithArgs(code).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(taken))
+}
+
+// expectLock expects lockVoucher to read the voucher before it is changed.
+func expectLock(mock sqlmock.Sqlmock, voucherID int, row *sqlmock.Rows) {
+	mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers" WHERE id = \$1 LIMIT \$2 FOR UPDATE`).
+		WithArgs(voucherID, 1).
+		WillReturnRows(row)
+	mock.ExpectQuery(`SELECT \* FROM "voucher_tier_prices" WHERE voucher_id = \$1 ORDER BY tier`).
+		WithArgs(voucherID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "voucher_id", "tier", "points_required"}))
+}
+
+// jsonField matches a JSON argument whose field key holds want.
+type jsonField struct {
+	key  string
+	want interface{}
+}
+
+func (j jsonField) Match(value driver.Value) bool {
+	raw, ok := value.(string)
+	if !ok {
+		return false
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(fields[j.key], j.want)
+}
+
+func TestCreateVoucher(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	log := *zap.NewNop()
+
+	customerRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
+
+	t.Run("Succesfully create a voucher", func(t *testing.T) {
+
+		voucher := &models.Voucher{
+			VoucherName:     "Promo December",
+			VoucherCode:     "DESC2024",
+			VoucherType:     "e-commerce",
+			PointsRequired:  0,
+			Description:     "Get more discount on december",
+			VoucherCategory: "discount",
+			DiscountValue:   money.New(10),
+			MinimumPurchase: money.New(200000),
+			PaymentMethods:  []string{"Credit Card", "PayPal"},
+			StartDate:       time.Now().AddDate(0, 0, -5),
+			EndDate:         time.Now().AddDate(0, 0, -1),
+			ApplicableAreas: []string{"US", "Canada"},
+			Quota:           100,
+			Status:          false,
+			State:           models.VoucherLive,
+			Version:         4,
+			CreatedAt:       time.Now().AddDate(0, 0, 1),
+			UpdatedAt:       time.Now().AddDate(0, 0, -1),
+		}
+
+		mock.ExpectBegin()
+		expectCodeFree(mock, voucher.VoucherCode, 0)
+		mock.ExpectQuery(`INSERT INTO "vouchers"`).
+			WithArgs(
+				voucher.VoucherName,
+				voucher.VoucherCode,
+				voucher.VoucherType,
+				voucher.PointsRequired,
+				voucher.Description,
+				voucher.VoucherCategory,
+				voucher.DiscountValue,
+				sqlmock.AnyArg(),
+				sqlmock.AnyArg(),
+				voucher.MinimumPurchase,
+				sqlmock.AnyArg(),
+				sqlmock.AnyArg(),
+				sqlmock.AnyArg(),
+				sqlmock.AnyArg(),
+				sqlmock.AnyArg(),
+				voucher.Quota,
+				voucher.MaxUsesPerUser,
+				voucher.MaxUsesPerUserPerDay,
+				voucher.MaxUsesPerUserPerWeek,
+				voucher.MaxRedemptionsPerUser,
+				voucher.Status,
+				sqlmock.AnyArg(),
+				models.VoucherDraft,
+				0, // submitted_by
+				0, // version
+				sqlmock.AnyArg(),
+				sqlmock.AnyArg(),
+				sqlmock.AnyArg(),
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		expectVersion(mock, 1, 1, voucherRow(1, "Promo December", 1),
+			1, 1, models.VersionCreated, 7, "", jsonField{"voucher_name", "Promo December"}, nil, sqlmock.AnyArg())
+		mock.ExpectCommit()
+
+		err := customerRepo.CreateVoucher(voucher, 7)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, voucher.ID)
+		assert.Equal(t, 1, voucher.Version)
+		assert.Equal(t, models.VoucherDraft, voucher.State)
+		assert.NotEmpty(t, voucher.VoucherName)
+	})
+
+	t.Run("Failed to create a voucher", func(t *testing.T) {
+		voucher := &models.Voucher{
+			VoucherName:     "Promo December",
+			VoucherCode:     "DESC2024",
+			VoucherType:     "e-commerce",
+			PointsRequired:  0,
+			Description:     "Get more discount on december",
+			VoucherCategory: "discount",
+			DiscountValue:   money.New(10),
+			MinimumPurchase: money.New(200000),
+			PaymentMethods:  []string{"Credit Card", "PayPal"},
+			StartDate:       time.Now().AddDate(0, 0, -5),
+			EndDate:         time.Now().AddDate(0, 0, -1),
+			ApplicableAreas: []string{"US", "Canada"},
+			Quota:           100,
+			Status:          false,
+			CreatedAt:       time.Now().AddDate(0, 0, 1),
+			UpdatedAt:       time.Now().AddDate(0, 0, -1),
+		}
+
+		mock.ExpectBegin()
+		expectCodeFree(mock, voucher.VoucherCode, 0)
+		mock.ExpectQuery(`INSERT INTO "vouchers"`).
+			WillReturnError(fmt.Errorf("database error"))
+
+		mock.ExpectRollback()
+		err := customerRepo.CreateVoucher(voucher, 7)
+		assert.Error(t, err)
+		assert.EqualError(t, err, "database error")
+	})
+
+	t.Run("Code taken by a generated code", func(t *testing.T) {
+		voucher := &models.Voucher{VoucherName: "Promo December", VoucherCode: "SUMMER-7KX9QD4M"}
+
+		mock.ExpectBegin()
+		expectCodeFree(mock, voucher.VoucherCode, 1)
+		mock.ExpectRollback()
+
+		err := customerRepo.CreateVoucher(voucher, 7)
+		assert.ErrorIs(t, err, models.ErrCodeTaken)
+	})
+}
+
+func TestSoftDeleteVoucher(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	log := *zap.NewNop()
+
+	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
+
+	t.Run("Successfully soft delete a voucher", func(t *testing.T) {
+		voucherID := 1
+
+		mock.ExpectBegin()
+		expectLock(mock, voucherID, voucherRow(voucherID, "Promo", 2))
+		mock.ExpectExec(`UPDATE "vouchers" SET "deleted_at"=`).
+			WithArgs(sqlmock.AnyArg(), voucherID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectVersion(mock, voucherID, 3, voucherRow(voucherID, "Promo", 3),
+			voucherID, 3, models.VersionDeleted, 7, "", sqlmock.AnyArg(), `{}`, sqlmock.AnyArg())
+		mock.ExpectCommit()
+
+		err := voucherRepo.SoftDeleteVoucher(voucherID, 7)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Failed to soft delete a voucher", func(t *testing.T) {
+		voucherID := 2
+
+		mock.ExpectBegin()
+		expectLock(mock, voucherID, voucherRow(voucherID, "Promo", 1))
+		mock.ExpectExec(`UPDATE "vouchers" SET "deleted_at"=`).
+			WithArgs(sqlmock.AnyArg(), voucherID).
+			WillReturnError(fmt.Errorf("database error"))
+		mock.ExpectRollback()
+
+		err := voucherRepo.SoftDeleteVoucher(voucherID, 7)
+		assert.Error(t, err)
+		assert.EqualError(t, err, "database error")
+	})
+
+	t.Run("Voucher already deleted", func(t *testing.T) {
+		voucherID := 3
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers"`).
+			WithArgs(voucherID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "deleted_at"}).AddRow(voucherID, time.Now()))
+		mock.ExpectQuery(`SELECT \* FROM "voucher_tier_prices"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		err := voucherRepo.SoftDeleteVoucher(voucherID, 7)
+		assert.ErrorIs(t, err, managementvoucher.ErrVoucherNotFound)
+	})
+}
+
+func TestUpdateVoucher(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	log := *zap.NewNop()
+	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
+
+	t.Run("Successfully update a voucher", func(t *testing.T) {
+		voucherID := 1
+		voucher := &models.Voucher{
+			VoucherName:     "Promo Updated",
+			VoucherCode:     "UPDATED2024",
+			VoucherType:     "e-commerce",
+			PointsRequired:  10,
+			Description:     "Updated discount",
+			VoucherCategory: "discount",
+			DiscountValue:   money.New(15),
+			MinimumPurchase: money.New(250000),
+			Quota:           50,
+		}
+
+		mock.ExpectBegin()
+		expectLock(mock, voucherID, voucherRow(voucherID, "Promo", 1))
+		expectCodeFree(mock, voucher.VoucherCode, 0)
+		mock.ExpectExec(`UPDATE "vouchers" SET`).
+			WithArgs(
+				voucher.VoucherName,
+				voucher.VoucherCode,
+				voucher.VoucherType,
+				voucher.PointsRequired,
+				voucher.Description,
+				voucher.VoucherCategory,
+				voucher.DiscountValue,
+				voucher.MinimumPurchase,
+				voucher.Quota,
+				sqlmock.AnyArg(), // updated_at
+				voucherID,
+			).
+			WillReturnResult(sqlmock.NewResult(1, int64(voucherID)))
+		expectResubmit(mock, voucherID, 7)
+		expectVersion(mock, voucherID, 2, voucherRow(voucherID, "Promo Updated", 2),
+			voucherID, 2, models.VersionUpdated, 7, "",
+			jsonField{"voucher_name", "Promo Updated"},
+			jsonField{"voucher_name", map[string]interface{}{"from": "Promo", "to": "Promo Updated"}},
+			sqlmock.AnyArg())
+		mock.ExpectCommit()
+
+		err := voucherRepo.UpdateVoucher(voucher, voucherID, 7)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, voucher.Version)
+	})
+
+	t.Run("Drafts stay drafts", func(t *testing.T) {
+		voucherID := 4
+		voucher := &models.Voucher{
+			VoucherName: "Draft Updated",
+		}
+
+		mock.ExpectBegin()
+		expectLock(mock, voucherID, sqlmock.NewRows([]string{"id", "voucher_name", "state", "version"}).
+			AddRow(voucherID, "Draft", models.VoucherDraft, 1))
+		mock.ExpectExec(`UPDATE "vouchers" SET "voucher_name"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectVersion(mock, voucherID, 2, sqlmock.NewRows([]string{"id", "voucher_name", "state", "version"}).
+			AddRow(voucherID, "Draft Updated", models.VoucherDraft, 2))
+		mock.ExpectCommit()
+
+		err := voucherRepo.UpdateVoucher(voucher, voucherID, 7)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Percentage above 100 is rejected when the type is left out", func(t *testing.T) {
+		voucherID := 5
+		voucher := &models.Voucher{DiscountValue: money.New(500)}
+
+		mock.ExpectBegin()
+		expectLock(mock, voucherID, sqlmock.NewRows([]string{"id", "voucher_name", "discount_type", "discount_value", "state", "version"}).
+			AddRow(voucherID, "Promo", models.DiscountPercentage, "10", models.VoucherLive, 1))
+		mock.ExpectRollback()
+
+		err := voucherRepo.UpdateVoucher(voucher, voucherID, 7)
+		assert.ErrorIs(t, err, models.ErrInvalidDiscount)
+	})
+
+	t.Run("Changing the type checks the stored value", func(t *testing.T) {
+		voucherID := 5
+		voucher := &models.Voucher{DiscountType: models.DiscountPercentage}
+
+		mock.ExpectBegin()
+		expectLock(mock, voucherID, sqlmock.NewRows([]string{"id", "voucher_name", "discount_type", "discount_value", "state", "version"}).
+			AddRow(voucherID, "Promo", models.DiscountFixedAmount, "50000", models.VoucherLive, 1))
+		mock.ExpectRollback()
+
+		err := voucherRepo.UpdateVoucher(voucher, voucherID, 7)
+		assert.ErrorIs(t, err, models.ErrInvalidDiscount)
+	})
+
+	t.Run("Code taken by a generated code", func(t *testing.T) {
+		voucherID := 4
+		voucher := &models.Voucher{VoucherCode: "SUMMER-7KX9QD4M"}
+
+		mock.ExpectBegin()
+		expectLock(mock, voucherID, voucherRow(voucherID, "Promo", 1))
+		expectCodeFree(mock, voucher.VoucherCode, 1)
+		mock.ExpectRollback()
+
+		err := voucherRepo.UpdateVoucher(voucher, voucherID, 7)
+		assert.ErrorIs(t, err, models.ErrCodeTaken)
+	})
+
+	t.Run("Failed to update due to no matching record", func(t *testing.T) {
+		voucherID := 2
+		voucher := &models.Voucher{
+			VoucherName: "Promo Not Found",
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers"`).
+			WithArgs(voucherID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		err := voucherRepo.UpdateVoucher(voucher, voucherID, 7)
+		assert.Error(t, err)
+		assert.EqualError(t, err, "no record found with shipping_id 2")
+	})
+
+	t.Run("Failed to update due to database error", func(t *testing.T) {
+		voucherID := 3
+		voucher := &models.Voucher{
+			VoucherName: "Promo Error",
+		}
+
+		mock.ExpectBegin()
+		expectLock(mock, voucherID, voucherRow(voucherID, "Promo", 1))
+		mock.ExpectExec(`UPDATE "vouchers" SET`).
+			WithArgs(voucher.VoucherName, sqlmock.AnyArg(), voucherID).
+			WillReturnError(fmt.Errorf("database error"))
+		mock.ExpectRollback()
+
+		err := voucherRepo.UpdateVoucher(voucher, voucherID, 7)
+		assert.Error(t, err)
+		assert.EqualError(t, err, "database error")
+	})
+}
+
+func TestFindVersions(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, zap.NewNop())
+
+	mock.ExpectQuery(`SELECT \* FROM "voucher_versions" WHERE voucher_id = \$1 ORDER BY version DESC`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "voucher_id", "version", "action", "actor_id", "snapshot", "changes"}).
+			AddRow(2, 1, 2, models.VersionUpdated, 7, `{"voucher_name":"Promo Updated"}`, `{"voucher_name":{"from":"Promo","to":"Promo Updated"}}`).
+			AddRow(1, 1, 1, models.VersionCreated, 7, `{"voucher_name":"Promo"}`, nil))
+
+	versions, err := voucherRepo.FindVersions(1)
+
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 2) {
+		assert.Equal(t, 2, versions[0].Version)
+		assert.JSONEq(t, `{"voucher_name":{"from":"Promo","to":"Promo Updated"}}`, string(versions[0].Changes))
+		assert.Empty(t, versions[1].Changes)
+	}
+}
+
+func TestRevertVoucher(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, zap.NewNop())
+
+	t.Run("Restores the snapshot as a new version", func(t *testing.T) {
+		snapshot := `{"voucher_name":"Promo","voucher_code":"DESC2024","discount_value":10,"quota":100,
+			"payment_methods":["Credit Card"],"applicable_areas":["Jawa"],
+			"start_date":"2024-12-01T00:00:00Z","end_date":"2024-12-07T00:00:00Z",
+			"tier_prices":[{"tier":"Gold","points_required":180}]}`
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "voucher_versions" WHERE voucher_id = \$1 AND version = \$2`).
+			WithArgs(1, 1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "voucher_id", "version", "snapshot"}).AddRow(1, 1, 1, snapshot))
+		expectLock(mock, 1, voucherRow(1, "Promo Updated", 2))
+		mock.ExpectExec(`UPDATE "vouchers" SET "voucher_name"=\$1,"voucher_code"=\$2,.*"minimum_tier"=\$21,"updated_at"=\$22 WHERE id = \$23`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM "voucher_tier_prices" WHERE voucher_id = \$1`).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO "voucher_tier_prices"`).
+			WithArgs(1, "Gold", 180).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		expectResubmit(mock, 1, 7)
+		expectVersion(mock, 1, 3, voucherRow(1, "Promo", 3),
+			1, 3, models.VersionReverted, 7, "",
+			jsonField{"voucher_name", "Promo"},
+			jsonField{"voucher_name", map[string]interface{}{"from": "Promo Updated", "to": "Promo"}},
+			sqlmock.AnyArg())
+		mock.ExpectCommit()
+
+		reverted, err := voucherRepo.RevertVoucher(1, 1, 7)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, reverted.Version)
+		assert.Equal(t, models.VersionReverted, reverted.Action)
+	})
+
+	t.Run("Restored code is already a generated code", func(t *testing.T) {
+		snapshot := `{"voucher_name":"Promo","voucher_code":"SUMMER-7Q2K","discount_value":10,"quota":100}`
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "voucher_versions" WHERE voucher_id = \$1 AND version = \$2`).
+			WithArgs(1, 1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "voucher_id", "version", "snapshot"}).AddRow(1, 1, 1, snapshot))
+		expectLock(mock, 1, voucherRow(1, "Promo Updated", 2))
+		expectCodeFree(mock, "SUMMER-7Q2K", 1)
+		mock.ExpectRollback()
+
+		reverted, err := voucherRepo.RevertVoucher(1, 1, 7)
+
+		assert.ErrorIs(t, err, models.ErrCodeTaken)
+		assert.Nil(t, reverted)
+	})
+
+	t.Run("Unknown version", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "voucher_versions"`).
+			WithArgs(1, 9, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		reverted, err := voucherRepo.RevertVoucher(1, 9, 7)
+
+		assert.ErrorIs(t, err, models.ErrVersionNotFound)
+		assert.Nil(t, reverted)
+	})
+}
+
+func TestShowRedeemPoints(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	log := *zap.NewNop()
+	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
+
+	t.Run("Successfully show redeem points", func(t *testing.T) {
+
+		mockRows := sqlmock.NewRows([]string{"voucher_name", "discount_value", "points_required"}).
+			AddRow("Promo A", 20.0, 50).
+			AddRow("Promo B", 15.0, 30)
+
+		mock.ExpectQuery(`SELECT v.voucher_name, v.discount_value, v.points_required FROM vouchers as v WHERE`).
+			WithArgs("redeem points", models.VoucherLive).
+			WillReturnRows(mockRows)
+
+		result, err := voucherRepo.ShowRedeemPoints()
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.Len(t, *result, 2)
+
+		assert.Equal(t, "Promo A", (*result)[0].VoucherName)
+		assert.Equal(t, money.New(20), (*result)[0].DiscountValue)
+		assert.Equal(t, 50, (*result)[0].PointsRequired)
+
+		assert.Equal(t, "Promo B", (*result)[1].VoucherName)
+		assert.Equal(t, money.New(15), (*result)[1].DiscountValue)
+		assert.Equal(t, 30, (*result)[1].PointsRequired)
+	})
+
+	t.Run("Failed to show redeem points due to database error", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT v.voucher_name, v.discount_value, v.points_required FROM vouchers as v WHERE`).
+			WithArgs("redeem points", models.VoucherLive).
+			WillReturnError(fmt.Errorf("database error"))
+
+		result, err := voucherRepo.ShowRedeemPoints()
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "database error")
+	})
+}
+
+func TestGetVouchersByQueryParams(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	log := *zap.NewNop()
+	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
+
+	t.Run("Successfully fetch vouchers with all query parameters", func(t *testing.T) {
+		rawPaymentMethods := `["Credit Card","PayPal"]`
+		rawApplicableAreas := `["US","Canada"]`
+
+		mockRows := sqlmock.NewRows([]string{
+			"id", "voucher_name", "voucher_code", "voucher_type", "points_required",
+			"description", "voucher_category", "discount_value", "minimum_purchase",
+			"payment_methods", "start_date", "end_date", "applicable_areas", "quota",
+			"status", "created_at", "updated_at",
+		}).
+			AddRow(1, "Promo A", "CODE123", "e-commerce", 0, "Description A", "discount", 10.0, 200000,
+				rawPaymentMethods, time.Now(), time.Now().AddDate(0, 0, 1), rawApplicableAreas, 100, true, time.Now(), time.Now()).
+			AddRow(2, "Promo B", "CODE456", "retail", 0, "Description B", "voucher", 15.0, 150000,
+				rawPaymentMethods, time.Now(), time.Now().AddDate(0, 0, 1), rawApplicableAreas, 200, true, time.Now(), time.Now())
+
+		mock.ExpectQuery(`SELECT (.+) FROM "vouchers"`).
+			WillReturnRows(mockRows)
+
+		status := "active"
+		area := "US"
+		voucherType := "e-commerce"
+
+		result, err := voucherRepo.GetVouchersByQueryParams(status, area, voucherType)
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.Len(t, *result, 2)
+
+		// Validate first voucher
+		assert.Equal(t, "Promo A", (*result)[0].VoucherName)
+		assert.Equal(t, "CODE123", (*result)[0].VoucherCode)
+		assert.Equal(t, []string{"Credit Card", "PayPal"}, (*result)[0].PaymentMethods)
+		assert.Equal(t, []string{"US", "Canada"}, (*result)[0].ApplicableAreas)
+
+		// Validate second voucher
+		assert.Equal(t, "Promo B", (*result)[1].VoucherName)
+		assert.Equal(t, "CODE456", (*result)[1].VoucherCode)
+	})
+
+	t.Run("No vouchers match query parameters", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM "vouchers"`).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "voucher_name", "voucher_code", "voucher_type", "points_required",
+				"description", "voucher_category", "discount_value", "minimum_purchase",
+				"payment_methods", "start_date", "end_date", "applicable_areas", "quota",
+				"status", "created_at", "updated_at",
+			}))
+
+		status := "non-active"
+		area := "Europe"
+		voucherType := "retail"
+
+		result, err := voucherRepo.GetVouchersByQueryParams(status, area, voucherType)
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.Len(t, *result, 0)
+	})
+
+	t.Run("Drafts and vouchers waiting for approval are not listed", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM "vouchers" WHERE state IN \(\$1,\$2\) AND "vouchers"."deleted_at" IS NULL`).
+			WithArgs(models.VoucherLive, models.VoucherEnded).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "voucher_name", "state"}).
+				AddRow(3, "Promo C", models.VoucherLive))
+
+		result, err := voucherRepo.GetVouchersByQueryParams("", "", "")
+		assert.NoError(t, err)
+		if assert.Len(t, *result, 1) {
+			assert.Equal(t, models.VoucherLive, (*result)[0].State)
+		}
+	})
+
+	t.Run("Database error while fetching vouchers", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM "vouchers"`).
+			WillReturnError(fmt.Errorf("database error"))
+
+		status := "active"
+		area := "Asia"
+		voucherType := "redeem points"
+
+		result, err := voucherRepo.GetVouchersByQueryParams(status, area, voucherType)
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.EqualError(t, err, "database error")
+	})
+}
+
+func TestGetVouchersByQueryParams_PausedVoucherIsHidden(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, zap.NewNop())
+
+	now := time.Now()
+	stateRow := func(state string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "voucher_name", "state", "start_date", "end_date", "version"}).
+			AddRow(1, "Promo", state, now.AddDate(0, 0, -1), now.AddDate(0, 0, 7), 1)
+	}
+
+	mock.ExpectBegin()
+	expectLock(mock, 1, stateRow(models.VoucherLive))
+	mock.ExpectExec(`UPDATE "vouchers" SET "state"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+		WithArgs(models.VoucherPaused, now, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectVersion(mock, 1, 2, stateRow(models.VoucherPaused),
+		1, 2, models.VersionPaused, 8, "fraud spike", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg())
+	mock.ExpectCommit()
+	// The paused voucher does not match the listed states, so nothing comes back.
+	mock.ExpectQuery(`SELECT (.+) FROM "vouchers" WHERE state IN \(\$1,\$2\) AND "vouchers"."deleted_at" IS NULL`).
+		WithArgs(models.VoucherLive, models.VoucherEnded).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "voucher_name", "state"}))
+
+	_, err := voucherRepo.ChangeState(1, 8, models.VersionPaused, "fraud spike", now)
+	assert.NoError(t, err)
+
+	result, err := voucherRepo.GetVouchersByQueryParams("", "", "")
+
+	assert.NoError(t, err)
+	assert.Empty(t, *result)
+}
+
+func TestCreateRedeemVoucher(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	log := *zap.NewNop()
+	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
+
+	today := time.Now()
+
+	t.Run("Successfully create redeem voucher", func(t *testing.T) {
+		redeem := &models.Redeem{
+			UserID:    1,
+			VoucherID: 100,
+		}
+		points := 50
+
+		mock.ExpectBegin()
+		// Mock fetch voucher data
+		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date, minimum_tier, max_redemptions_per_user, state FROM "vouchers" WHERE id = \$1 .* FOR UPDATE`).
+			WithArgs(redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date", "state"}).
+				AddRow(10, 50, today.AddDate(0, 0, -5), today.AddDate(0, 0, 5), models.VoucherLive))
+
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2`).
+			WithArgs(redeem.UserID, redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		mock.ExpectQuery(`SELECT "tier" FROM "users" WHERE id = \$1`).
+			WithArgs(redeem.UserID).
+			WillReturnRows(sqlmock.NewRows([]string{"tier"}).AddRow(""))
+
+		// Mock create redeem
+		mock.ExpectQuery(`INSERT INTO "redeems"`).
+			WithArgs(
+				redeem.UserID,
+				redeem.VoucherID,
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			// WillReturnResult(sqlmock.NewResult(1, 1))
+
+		// Mock debit points
+		mock.ExpectQuery(`INSERT INTO "points_accounts" .* ON CONFLICT \("user_id"\) DO NOTHING`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM "points_accounts" WHERE user_id = \$1 .* FOR UPDATE`).
+			WithArgs(redeem.UserID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "balance"}).AddRow(1, redeem.UserID, 80))
+		mock.ExpectExec(`UPDATE "points_accounts" SET "balance"=\$1`).
+			WithArgs(30, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`INSERT INTO "points_ledger_entries"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM "points_lots" WHERE user_id = \$1 AND remaining > 0`).
+			WithArgs(redeem.UserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "remaining"}).AddRow(2, redeem.UserID, 80))
+		mock.ExpectExec(`UPDATE "points_lots" SET "remaining"=\$1 WHERE id = \$2`).
+			WithArgs(30, 2).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		// Mock decrement quota
+		mock.ExpectExec(`UPDATE "vouchers" SET "quota"=quota - \$1 WHERE id = \$2 AND "vouchers"."deleted_at" IS NULL`).
+			WithArgs(1, redeem.VoucherID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		mock.ExpectCommit()
+
+		err := voucherRepo.CreateRedeemVoucher(redeem, points)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Redeem already exists", func(t *testing.T) {
+		redeem := &models.Redeem{
+			UserID:    2,
+			VoucherID: 101,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date, minimum_tier, max_redemptions_per_user, state FROM "vouchers"`).
+			WithArgs(redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date", "max_redemptions_per_user", "state"}).
+				AddRow(10, 50, today.AddDate(0, 0, -5), today.AddDate(0, 0, 5), 0, models.VoucherLive))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2`).
+			WithArgs(redeem.UserID, redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		mock.ExpectRollback()
+
+		err := voucherRepo.CreateRedeemVoucher(redeem, 50)
+		var limitErr *models.LimitError
+		assert.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, models.LimitRedemptionsPerUser, limitErr.Code)
+		assert.EqualError(t, err, "voucher can be redeemed 1 time(s) per user")
+	})
+
+	t.Run("Redemption limit above one", func(t *testing.T) {
+		redeem := &models.Redeem{
+			UserID:    2,
+			VoucherID: 107,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date, minimum_tier, max_redemptions_per_user, state FROM "vouchers"`).
+			WithArgs(redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date", "max_redemptions_per_user", "state"}).
+				AddRow(10, 50, today.AddDate(0, 0, -5), today.AddDate(0, 0, 5), 3, models.VoucherLive))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "redeems"`).
+			WithArgs(redeem.UserID, redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		mock.ExpectRollback()
+
+		err := voucherRepo.CreateRedeemVoucher(redeem, 50)
+		assert.EqualError(t, err, "voucher can be redeemed 3 time(s) per user")
+	})
+
+	t.Run("Insufficient quota", func(t *testing.T) {
+		redeem := &models.Redeem{
+			UserID:    3,
+			VoucherID: 102,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date, minimum_tier, max_redemptions_per_user, state FROM "vouchers"`).
+			WithArgs(redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date", "state"}).
+				AddRow(0, 50, today.AddDate(0, 0, -5), today.AddDate(0, 0, 5), models.VoucherLive))
+
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2`).
+			WithArgs(redeem.UserID, redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		mock.ExpectRollback()
+
+		err := voucherRepo.CreateRedeemVoucher(redeem, 50)
+		assert.Error(t, err)
+		assert.EqualError(t, err, fmt.Sprintf("quota for voucher ID %d is not sufficient", redeem.VoucherID))
+	})
+
+	t.Run("Paused voucher", func(t *testing.T) {
+		redeem := &models.Redeem{
+			UserID:    3,
+			VoucherID: 102,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date, minimum_tier, max_redemptions_per_user, state FROM "vouchers"`).
+			WithArgs(redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date", "state"}).
+				AddRow(10, 50, today.AddDate(0, 0, -5), today.AddDate(0, 0, 5), models.VoucherPaused))
+
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2`).
+			WithArgs(redeem.UserID, redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		mock.ExpectRollback()
+
+		err := voucherRepo.CreateRedeemVoucher(redeem, 50)
+		assert.ErrorIs(t, err, models.ErrVoucherNotLive)
+	})
+
+	t.Run("Points mismatch", func(t *testing.T) {
+		redeem := &models.Redeem{
+			UserID:    4,
+			VoucherID: 103,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date, minimum_tier, max_redemptions_per_user, state FROM "vouchers"`).
+			WithArgs(redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date", "state"}).
+				AddRow(10, 100, today.AddDate(0, 0, -5), today.AddDate(0, 0, 5), models.VoucherLive))
+
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2`).
+			WithArgs(redeem.UserID, redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		mock.ExpectQuery(`SELECT "tier" FROM "users" WHERE id = \$1`).
+			WithArgs(redeem.UserID).
+			WillReturnRows(sqlmock.NewRows([]string{"tier"}).AddRow(""))
+
+		mock.ExpectRollback()
+
+		err := voucherRepo.CreateRedeemVoucher(redeem, 50)
+		assert.Error(t, err)
+		assert.EqualError(t, err, "required points (100) do not match provided points (50)")
+	})
+
+	t.Run("Voucher expired", func(t *testing.T) {
+		redeem := &models.Redeem{
+			UserID:    5,
+			VoucherID: 104,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date, minimum_tier, max_redemptions_per_user, state FROM "vouchers"`).
+			WithArgs(redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date", "state"}).
+				AddRow(10, 50, today.AddDate(0, 0, -10), today.AddDate(0, 0, -1), models.VoucherLive))
+
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2`).
+			WithArgs(redeem.UserID, redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		mock.ExpectQuery(`SELECT "tier" FROM "users" WHERE id = \$1`).
+			WithArgs(redeem.UserID).
+			WillReturnRows(sqlmock.NewRows([]string{"tier"}).AddRow(""))
+
+		mock.ExpectRollback()
+
+		err := voucherRepo.CreateRedeemVoucher(redeem, 50)
+		assert.Error(t, err)
+		assert.EqualError(t, err, "voucher expired")
+	})
+
+	t.Run("Tier below the voucher minimum", func(t *testing.T) {
+		redeem := &models.Redeem{
+			UserID:    6,
+			VoucherID: 105,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date, minimum_tier, max_redemptions_per_user, state FROM "vouchers"`).
+			WithArgs(redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date", "minimum_tier", "state"}).
+				AddRow(10, 50, today.AddDate(0, 0, -5), today.AddDate(0, 0, 5), models.TierGold, models.VoucherLive))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2`).
+			WithArgs(redeem.UserID, redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(`SELECT "tier" FROM "users"`).
+			WithArgs(redeem.UserID).
+			WillReturnRows(sqlmock.NewRows([]string{"tier"}).AddRow(models.TierSilver))
+		mock.ExpectQuery(`SELECT \* FROM "tiers" WHERE name IN \(\$1,\$2\)`).
+			WithArgs(models.TierSilver, models.TierGold).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "rank"}).AddRow(models.TierSilver, 1).AddRow(models.TierGold, 2))
+		mock.ExpectRollback()
+
+		err := voucherRepo.CreateRedeemVoucher(redeem, 50)
+		assert.EqualError(t, err, "voucher requires the Gold tier")
+	})
+
+	t.Run("Tier price replaces the points required", func(t *testing.T) {
+		redeem := &models.Redeem{
+			UserID:    7,
+			VoucherID: 106,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT quota, points_required, start_date, end_date, minimum_tier, max_redemptions_per_user, state FROM "vouchers"`).
+			WithArgs(redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"quota", "points_required", "start_date", "end_date", "state"}).
+				AddRow(10, 50, today.AddDate(0, 0, -5), today.AddDate(0, 0, 5), models.VoucherLive))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "redeems" WHERE user_id = \$1 AND voucher_id = \$2`).
+			WithArgs(redeem.UserID, redeem.VoucherID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(`SELECT "tier" FROM "users"`).
+			WithArgs(redeem.UserID).
+			WillReturnRows(sqlmock.NewRows([]string{"tier"}).AddRow(models.TierPlatinum))
+		mock.ExpectQuery(`SELECT "points_required" FROM "voucher_tier_prices" WHERE voucher_id = \$1 AND tier = \$2`).
+			WithArgs(redeem.VoucherID, models.TierPlatinum).
+			WillReturnRows(sqlmock.NewRows([]string{"points_required"}).AddRow(40))
+		mock.ExpectRollback()
+
+		err := voucherRepo.CreateRedeemVoucher(redeem, 50)
+		assert.EqualError(t, err, "required points (40) do not match provided points (50)")
+	})
+}
+
+func TestSyncStatus(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	log := *zap.NewNop()
+	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, &log)
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE vouchers SET status = true`).
+		WithArgs(now, now, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "voucher_code", "status", "end_date"}).
+			AddRow(1, "NEWYEAR", true, now.AddDate(0, 0, 7)))
+	mock.ExpectQuery(`UPDATE vouchers SET status = false`).
+		WithArgs(now, now, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "voucher_code", "status", "end_date"}).
+			AddRow(2, "XMAS", false, now.Add(-time.Minute)).
+			AddRow(3, "LATER", false, now.AddDate(0, 1, 0)))
+	mock.ExpectExec(`UPDATE vouchers SET state = \$1, updated_at = \$2\s+WHERE deleted_at IS NULL AND state = \$3 AND start_date <= \$4 AND end_date > \$5`).
+		WithArgs(models.VoucherLive, now, models.VoucherScheduled, now, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE vouchers SET state = \$1, updated_at = \$2\s+WHERE deleted_at IS NULL AND state IN \(\$3,\$4,\$5\) AND end_date <= \$6`).
+		WithArgs(models.VoucherEnded, now, models.VoucherScheduled, models.VoucherLive, models.VoucherPaused, now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	events, err := voucherRepo.SyncStatus(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.VoucherStatusEvent{
+		{Event: models.VoucherActivated, VoucherID: 1, VoucherCode: "NEWYEAR", At: now},
+		{Event: models.VoucherExpired, VoucherID: 2, VoucherCode: "XMAS", At: now},
+		{Event: models.VoucherDeactivated, VoucherID: 3, VoucherCode: "LATER", At: now},
+	}, events)
+}
+
+func TestChangeState(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, zap.NewNop())
+
+	now := time.Now()
+	stateRow := func(state string, submittedBy int) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "voucher_name", "state", "submitted_by", "start_date", "end_date", "version"}).
+			AddRow(1, "Promo", state, submittedBy, now.AddDate(0, 0, 1), now.AddDate(0, 0, 8), 1)
+	}
+
+	t.Run("Submit a draft", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock(mock, 1, stateRow(models.VoucherDraft, 0))
+		mock.ExpectExec(`UPDATE "vouchers" SET "state"=\$1,"submitted_by"=\$2,"updated_at"=\$3 WHERE id = \$4`).
+			WithArgs(models.VoucherPendingApproval, 7, now, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectVersion(mock, 1, 2, stateRow(models.VoucherPendingApproval, 7),
+			1, 2, models.VersionSubmitted, 7, "",
+			jsonField{"state", models.VoucherPendingApproval},
+			jsonField{"state", map[string]interface{}{"from": models.VoucherDraft, "to": models.VoucherPendingApproval}},
+			sqlmock.AnyArg())
+		mock.ExpectCommit()
+
+		changed, err := voucherRepo.ChangeState(1, 7, models.VersionSubmitted, "", now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.VersionSubmitted, changed.Action)
+	})
+
+	t.Run("Approve schedules a voucher that has not started", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock(mock, 1, stateRow(models.VoucherPendingApproval, 7))
+		mock.ExpectExec(`UPDATE "vouchers" SET "state"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+			WithArgs(models.VoucherScheduled, now, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectVersion(mock, 1, 3, stateRow(models.VoucherScheduled, 7),
+			1, 3, models.VersionApproved, 8, "", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg())
+		mock.ExpectCommit()
+
+		changed, err := voucherRepo.ChangeState(1, 8, models.VersionApproved, "", now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, changed.Version)
+	})
+
+	t.Run("Submitter cannot approve", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock(mock, 1, stateRow(models.VoucherPendingApproval, 7))
+		mock.ExpectRollback()
+
+		_, err := voucherRepo.ChangeState(1, 7, models.VersionApproved, "", now)
+
+		assert.ErrorIs(t, err, models.ErrSelfApproval)
+	})
+
+	t.Run("Pause a live voucher", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock(mock, 1, stateRow(models.VoucherLive, 7))
+		mock.ExpectExec(`UPDATE "vouchers" SET "state"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+			WithArgs(models.VoucherPaused, now, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectVersion(mock, 1, 4, stateRow(models.VoucherPaused, 7),
+			1, 4, models.VersionPaused, 8, "fraud spike", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg())
+		mock.ExpectCommit()
+
+		changed, err := voucherRepo.ChangeState(1, 8, models.VersionPaused, "fraud spike", now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.VersionPaused, changed.Action)
+	})
+
+	t.Run("Resume goes back to the schedule", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock(mock, 1, stateRow(models.VoucherPaused, 7))
+		mock.ExpectExec(`UPDATE "vouchers" SET "state"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+			WithArgs(models.VoucherScheduled, now, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectVersion(mock, 1, 5, stateRow(models.VoucherScheduled, 7),
+			1, 5, models.VersionResumed, 8, "resolved", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg())
+		mock.ExpectCommit()
+
+		changed, err := voucherRepo.ChangeState(1, 8, models.VersionResumed, "resolved", now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, changed.Version)
+	})
+
+	t.Run("Only paused vouchers can be resumed", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock(mock, 1, stateRow(models.VoucherLive, 7))
+		mock.ExpectRollback()
+
+		_, err := voucherRepo.ChangeState(1, 8, models.VersionResumed, "resolved", now)
+
+		assert.ErrorIs(t, err, models.ErrInvalidTransition)
+	})
+
+	t.Run("Invalid transition", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock(mock, 1, stateRow(models.VoucherLive, 7))
+		mock.ExpectRollback()
+
+		_, err := voucherRepo.ChangeState(1, 8, models.VersionRejected, "too generous", now)
+
+		assert.ErrorIs(t, err, models.ErrInvalidTransition)
+	})
+}
+
+func TestGenerateCodes(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, zap.NewNop())
+	format := codegen.Format{Alphabet: codegen.DefaultAlphabet, Length: 8, Prefix: "SUMMER-", CheckDigit: true}
+
+	expectExists := func(voucherID int) {
+		mock.ExpectBegin()
+		expectLock(mock, voucherID, voucherRow(voucherID, "Promo", 1))
+		mock.ExpectCommit()
+	}
+	// Each batch is committed on its own.
+	expectBatch := func(inserted int) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT "voucher_code" FROM "vouchers" WHERE voucher_code IN`).
+			WillReturnRows(sqlmock.NewRows([]string{"voucher_code"}))
+		rows := sqlmock.NewRows([]string{"id"})
+		for i := 0; i < inserted; i++ {
+			rows.AddRow(i + 1)
+		}
+		mock.ExpectQuery(`INSERT INTO "voucher_codes" .* ON CONFLICT DO NOTHING RETURNING "id"`).
+			WillReturnRows(rows)
+		mock.ExpectCommit()
+	}
+
+	t.Run("Codes lost to collisions are made up for", func(t *testing.T) {
+		expectExists(1)
+		expectBatch(2)
+		expectBatch(1)
+
+		created, err := voucherRepo.GenerateCodes(1, format, 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, created)
+	})
+
+	t.Run("Gives up when every batch collides", func(t *testing.T) {
+		expectExists(1)
+		expectBatch(0)
+		expectBatch(0)
+		expectBatch(0)
+
+		_, err := voucherRepo.GenerateCodes(1, format, 3)
+
+		assert.ErrorIs(t, err, codegen.ErrSpaceTooSmall)
+	})
+
+	t.Run("Committed batches are kept when a later one fails", func(t *testing.T) {
+		expectExists(1)
+		expectBatch(2)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT "voucher_code" FROM "vouchers"`).
+			WillReturnError(fmt.Errorf("database error"))
+		mock.ExpectRollback()
+
+		created, err := voucherRepo.GenerateCodes(1, format, 3)
+
+		assert.EqualError(t, err, "database error")
+		assert.Equal(t, 2, created)
+	})
+
+	t.Run("Voucher not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers" WHERE id = \$1 LIMIT \$2 FOR UPDATE`).
+			WithArgs(9, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		_, err := voucherRepo.GenerateCodes(9, format, 3)
+
+		assert.ErrorIs(t, err, managementvoucher.ErrVoucherNotFound)
+	})
+}
+
+func TestAssignCode(t *testing.T) {
+	db, mock := setupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	voucherRepo := managementvoucher.NewManagementVoucherRepo(db, zap.NewNop())
+	now := time.Now()
+
+	t.Run("Assigns the oldest unused code", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "voucher_codes" WHERE voucher_id = \$1 AND state = \$2 ORDER BY id LIMIT \$3 FOR UPDATE SKIP LOCKED`).
+			WithArgs(1, models.CodeUnused, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "voucher_id", "code", "state"}).AddRow(12, 1, "SUMMER-7KX9QD4M", models.CodeUnused))
+		mock.ExpectExec(`UPDATE "voucher_codes" SET "assigned_at"=\$1,"state"=\$2,"user_id"=\$3 WHERE id = \$4`).
+			WithArgs(now, models.CodeAssigned, 5, 12).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		code, err := voucherRepo.AssignCode(1, 5, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "SUMMER-7KX9QD4M", code.Code)
+		assert.Equal(t, models.CodeAssigned, code.State)
+		assert.Equal(t, 5, *code.UserID)
+	})
+
+	t.Run("No unused codes left", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "voucher_codes"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		_, err := voucherRepo.AssignCode(1, 5, now)
+
+		assert.ErrorIs(t, err, models.ErrNoUnusedCodes)
+	})
+}
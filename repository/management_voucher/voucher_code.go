@@ -0,0 +1,160 @@
+package managementvoucher
+
+import (
+	"errors"
+	"time"
+	"voucher_system/models"
+	"voucher_system/utils/codegen"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// codeBatchSize is the number of codes generated and inserted per statement.
+const codeBatchSize = 1000
+
+// maxEmptyCodeBatches is how many batches in a row may lose all their codes to
+// collisions before GenerateCodes gives up on the format.
+const maxEmptyCodeBatches = 3
+
+// GenerateCodes adds count new codes in format to the voucher and returns how many were
+// created. Codes are generated in batches that are each committed on their own, so a
+// large run never holds the voucher locked; on error the batches already committed are
+// kept and counted. Codes that collide with a stored code or with another voucher's own
+// code are dropped and made up for by the next batch.
+func (m *ManagementVoucherRepo) GenerateCodes(voucherID int, format codegen.Format, count int) (int, error) {
+	err := m.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := lockLiveVoucher(tx, voucherID)
+		return err
+	})
+	if err != nil {
+		m.Log.Error("Error from repo generating voucher codes:", zap.Int("voucherID", voucherID), zap.Error(err))
+		return 0, err
+	}
+
+	created, empty := 0, 0
+	for created < count {
+		inserted, err := m.insertCodeBatch(voucherID, format, min(count-created, codeBatchSize))
+		if err != nil {
+			m.Log.Error("Error from repo generating voucher codes:", zap.Int("voucherID", voucherID), zap.Int("created", created), zap.Error(err))
+			return created, err
+		}
+
+		if inserted == 0 {
+			empty++
+			if empty >= maxEmptyCodeBatches {
+				m.Log.Error("Error from repo generating voucher codes:", zap.Int("voucherID", voucherID), zap.Int("created", created), zap.Error(codegen.ErrSpaceTooSmall))
+				return created, codegen.ErrSpaceTooSmall
+			}
+			continue
+		}
+		empty = 0
+		created += inserted
+	}
+
+	m.Log.Info("Voucher codes generated", zap.Int("voucherID", voucherID), zap.Int("count", created))
+	return created, nil
+}
+
+// insertCodeBatch generates size codes and stores, in one transaction, those that are
+// not taken yet. It returns how many were stored.
+func (m *ManagementVoucherRepo) insertCodeBatch(voucherID int, format codegen.Format, size int) (int, error) {
+	batch, err := format.Generate(size)
+	if err != nil {
+		return 0, err
+	}
+
+	inserted := 0
+	err = m.DB.Transaction(func(tx *gorm.DB) error {
+		var taken []string
+		err := tx.Unscoped().Model(&models.Voucher{}).
+			Where("voucher_code IN ?", batch).
+			Pluck("voucher_code", &taken).Error
+		if err != nil {
+			return err
+		}
+		skip := make(map[string]bool, len(taken))
+		for _, code := range taken {
+			skip[code] = true
+		}
+
+		codes := make([]models.VoucherCode, 0, len(batch))
+		for _, code := range batch {
+			if !skip[code] {
+				codes = append(codes, models.VoucherCode{VoucherID: voucherID, Code: code, State: models.CodeUnused})
+			}
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&codes)
+		if result.Error != nil {
+			return result.Error
+		}
+		inserted = int(result.RowsAffected)
+		return nil
+	})
+	return inserted, err
+}
+
+// checkCodeFree returns models.ErrCodeTaken when code was generated for a voucher, so
+// a voucher's own code never matches one of the generated codes.
+func checkCodeFree(tx *gorm.DB, code string) error {
+	var taken int64
+	if err := tx.Model(&models.VoucherCode{}).Where("code = ?", code).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return models.ErrCodeTaken
+	}
+	return nil
+}
+
+// AssignCode hands the oldest unused code of the voucher to userID. Codes locked by a
+// concurrent assignment are skipped rather than waited for.
+func (m *ManagementVoucherRepo) AssignCode(voucherID, userID int, now time.Time) (*models.VoucherCode, error) {
+	var code models.VoucherCode
+	err := m.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("voucher_id = ? AND state = ?", voucherID, models.CodeUnused).
+			Order("id").
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Take(&code).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrNoUnusedCodes
+		}
+		if err != nil {
+			return err
+		}
+
+		code.State = models.CodeAssigned
+		code.UserID = &userID
+		code.AssignedAt = &now
+		return tx.Model(&models.VoucherCode{}).
+			Where("id = ?", code.ID).
+			UpdateColumns(map[string]interface{}{"state": code.State, "user_id": userID, "assigned_at": now}).Error
+	})
+	if err != nil {
+		m.Log.Error("Error from repo assigning voucher code:", zap.Int("voucherID", voucherID), zap.Error(err))
+		return nil, err
+	}
+
+	return &code, nil
+}
+
+// FindCodes returns the codes of the voucher in the order they were generated, limited
+// to one state unless state is empty.
+func (m *ManagementVoucherRepo) FindCodes(voucherID int, state string) ([]models.VoucherCode, error) {
+	query := m.DB.Where("voucher_id = ?", voucherID)
+	if state != "" {
+		query = query.Where("state = ?", state)
+	}
+
+	var codes []models.VoucherCode
+	if err := query.Order("id").Find(&codes).Error; err != nil {
+		m.Log.Error("Error from repo fetching voucher codes:", zap.Int("voucherID", voucherID), zap.Error(err))
+		return nil, err
+	}
+	return codes, nil
+}
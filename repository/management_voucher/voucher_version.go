@@ -0,0 +1,163 @@
+package managementvoucher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"voucher_system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrVoucherNotFound = errors.New("voucher not found")
+
+// snapshotOmit are the voucher fields left out of version snapshots: its identity, the
+// version itself and values that are derived or only filled in for responses.
+var snapshotOmit = []string{"id", "version", "status", "remaining_uses", "created_at", "updated_at", "deleted_at"}
+
+// revertColumns are the columns RevertVoucher restores from a snapshot. Quota is left
+// alone because usages kept taking units from it after the snapshot was made.
+var revertColumns = []string{
+	"voucher_name", "voucher_code", "voucher_type", "points_required", "description",
+	"voucher_category", "discount_value", "discount_type", "max_discount", "minimum_purchase",
+	"currency", "payment_methods", "start_date", "end_date", "applicable_areas",
+	"max_uses_per_user", "max_uses_per_user_per_day", "max_uses_per_user_per_week",
+	"max_redemptions_per_user", "status", "minimum_tier",
+}
+
+// lockVoucher loads the voucher with its tier prices and locks its row until tx ends.
+// Soft-deleted vouchers are loaded as well so their final state can be recorded.
+func lockVoucher(tx *gorm.DB, voucherID int) (*models.Voucher, error) {
+	var rawVoucher struct {
+		models.Voucher
+		RawPaymentMethods  []byte `gorm:"column:payment_methods"`
+		RawApplicableAreas []byte `gorm:"column:applicable_areas"`
+	}
+
+	err := tx.Unscoped().
+		Table("vouchers").
+		Select("vouchers.*").
+		Where("id = ?", voucherID).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Take(&rawVoucher).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVoucherNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	voucher := rawVoucher.Voucher
+	if len(rawVoucher.RawPaymentMethods) > 0 {
+		if err := json.Unmarshal(rawVoucher.RawPaymentMethods, &voucher.PaymentMethods); err != nil {
+			return nil, err
+		}
+	}
+	if len(rawVoucher.RawApplicableAreas) > 0 {
+		if err := json.Unmarshal(rawVoucher.RawApplicableAreas, &voucher.ApplicableAreas); err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Where("voucher_id = ?", voucherID).Order("tier").Find(&voucher.TierPrices).Error
+	if err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+// lockLiveVoucher is lockVoucher for vouchers that have not been deleted.
+func lockLiveVoucher(tx *gorm.DB, voucherID int) (*models.Voucher, error) {
+	voucher, err := lockVoucher(tx, voucherID)
+	if err != nil {
+		return nil, err
+	}
+	if voucher.DeletedAt != nil && voucher.DeletedAt.Valid {
+		return nil, ErrVoucherNotFound
+	}
+	return voucher, nil
+}
+
+// snapshotOf returns the versioned fields of voucher keyed by their JSON names. Numbers
+// are kept as json.Number so amounts survive the round trip unchanged.
+func snapshotOf(voucher *models.Voucher) (map[string]interface{}, error) {
+	data, err := json.Marshal(voucher)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+	for _, key := range snapshotOmit {
+		delete(fields, key)
+	}
+	return fields, nil
+}
+
+// diffSnapshots returns the fields whose value differs between before and after.
+func diffSnapshots(before, after map[string]interface{}) map[string]models.FieldChange {
+	changes := map[string]models.FieldChange{}
+	for key, from := range before {
+		if to := after[key]; !reflect.DeepEqual(from, to) {
+			changes[key] = models.FieldChange{From: from, To: to}
+		}
+	}
+	for key, to := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = models.FieldChange{From: nil, To: to}
+		}
+	}
+	return changes
+}
+
+// recordVersion bumps the voucher's version and stores a snapshot of its current state.
+// before is the snapshot taken ahead of the change and is diffed against the new one;
+// it is nil for newly created vouchers.
+func recordVersion(tx *gorm.DB, voucherID int, action string, actorID int, reason string, before map[string]interface{}) (*models.VoucherVersion, error) {
+	var version int
+	err := tx.Raw(`UPDATE vouchers SET version = version + 1 WHERE id = ? RETURNING version`, voucherID).
+		Scan(&version).Error
+	if err != nil {
+		return nil, err
+	}
+
+	voucher, err := lockVoucher(tx, voucherID)
+	if err != nil {
+		return nil, err
+	}
+	after, err := snapshotOf(voucher)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.VoucherVersion{
+		VoucherID: voucherID,
+		Version:   version,
+		Action:    action,
+		ActorID:   actorID,
+		Reason:    reason,
+	}
+	snapshot, err := json.Marshal(after)
+	if err != nil {
+		return nil, err
+	}
+	entry.Snapshot = snapshot
+	if before != nil {
+		changes, err := json.Marshal(diffSnapshots(before, after))
+		if err != nil {
+			return nil, err
+		}
+		entry.Changes = changes
+	}
+
+	if err := tx.Create(entry).Error; err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,308 @@
+package pointsledger
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"voucher_system/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInsufficientPoints = errors.New("insufficient points balance")
+
+type PointsLedgerInterface interface {
+	GetAccount(userID int) (*models.PointsAccount, error)
+	FindEntries(userID, page, limit int) ([]models.PointsLedgerEntry, int64, error)
+	AddEntry(entry *models.PointsLedgerEntry) error
+	HasEntry(entryType, reference string) (bool, error)
+	FindExpiringLots(userID int, until time.Time) ([]models.PointsLot, error)
+	FindExpiredLotIDs(now time.Time, limit int) ([]int, error)
+	ExpireLot(lotID int, now time.Time) (int, error)
+}
+
+type PointsLedgerRepo struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewPointsLedgerRepo(db *gorm.DB, log *zap.Logger) PointsLedgerInterface {
+	return &PointsLedgerRepo{DB: db, Log: log}
+}
+
+// GetAccount returns the points account of userID, or an empty one if the user never
+// had points.
+func (p *PointsLedgerRepo) GetAccount(userID int) (*models.PointsAccount, error) {
+	account := models.PointsAccount{UserID: userID}
+	err := p.DB.Where("user_id = ?", userID).First(&account).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		p.Log.Error("Error from repo fetching points account:", zap.Error(err))
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (p *PointsLedgerRepo) FindEntries(userID, page, limit int) ([]models.PointsLedgerEntry, int64, error) {
+	var (
+		entries []models.PointsLedgerEntry
+		total   int64
+	)
+
+	query := p.DB.Model(&models.PointsLedgerEntry{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		p.Log.Error("Error from repo counting points ledger:", zap.Error(err))
+		return nil, 0, err
+	}
+
+	err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&entries).Error
+	if err != nil {
+		p.Log.Error("Error from repo fetching points ledger:", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+func (p *PointsLedgerRepo) AddEntry(entry *models.PointsLedgerEntry) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		return ApplyEntry(tx, entry)
+	})
+}
+
+func (p *PointsLedgerRepo) HasEntry(entryType, reference string) (bool, error) {
+	var count int64
+	err := p.DB.Model(&models.PointsLedgerEntry{}).
+		Where("entry_type = ? AND reference = ?", entryType, reference).
+		Count(&count).Error
+	if err != nil {
+		p.Log.Error("Error from repo checking points ledger:", zap.Error(err))
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// FindExpiringLots returns the lots of userID with points left that expire before until.
+func (p *PointsLedgerRepo) FindExpiringLots(userID int, until time.Time) ([]models.PointsLot, error) {
+	var lots []models.PointsLot
+	err := p.DB.Where("user_id = ? AND remaining > 0 AND expires_at <= ?", userID, until).
+		Order("expires_at, id").
+		Find(&lots).Error
+	if err != nil {
+		p.Log.Error("Error from repo fetching expiring points:", zap.Error(err))
+		return nil, err
+	}
+	return lots, nil
+}
+
+func (p *PointsLedgerRepo) FindExpiredLotIDs(now time.Time, limit int) ([]int, error) {
+	var ids []int
+	err := p.DB.Model(&models.PointsLot{}).
+		Where("remaining > 0 AND expires_at <= ?", now).
+		Order("expires_at, id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		p.Log.Error("Error from repo fetching expired points lots:", zap.Error(err))
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ExpireLot writes off what is left of an expired lot and returns the points expired.
+// It is a no-op for lots that are used up or not expired yet, so it is safe to retry.
+func (p *PointsLedgerRepo) ExpireLot(lotID int, now time.Time) (int, error) {
+	var expired int
+	err := p.DB.Transaction(func(tx *gorm.DB) error {
+		var lot models.PointsLot
+		if err := tx.First(&lot, lotID).Error; err != nil {
+			return err
+		}
+
+		// Lock the account before the lot, in the same order as ApplyEntry.
+		account, err := lockAccount(tx, lot.UserID)
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&lot, lotID).Error; err != nil {
+			return err
+		}
+		if lot.Remaining == 0 || lot.ExpiresAt == nil || lot.ExpiresAt.After(now) {
+			return nil
+		}
+
+		err = tx.Model(&models.PointsLot{}).Where("id = ?", lot.ID).Update("remaining", 0).Error
+		if err != nil {
+			return fmt.Errorf("failed to expire points lot: %w", err)
+		}
+
+		expired = lot.Remaining
+		return writeEntry(tx, account, &models.PointsLedgerEntry{
+			UserID:      lot.UserID,
+			EntryType:   models.LedgerExpire,
+			Points:      -expired,
+			Reference:   fmt.Sprintf("lot:%d", lot.ID),
+			Description: fmt.Sprintf("Points earned on %s expired", lot.CreatedAt.Format("2006-01-02")),
+		})
+	})
+	if err != nil {
+		p.Log.Error("Error from repo expiring points lot:", zap.Int("lotID", lotID), zap.Error(err))
+		return 0, err
+	}
+	return expired, nil
+}
+
+// ApplyEntry changes the balance of entry.UserID by entry.Points and appends entry to
+// the ledger. Credits open a lot that expires after PointsValidityMonths unless
+// entry.ExpiresAt is set; debits consume the lots that expire first. It must run inside
+// tx so callers can combine it with their own writes; the account row is locked until
+// tx ends.
+func ApplyEntry(tx *gorm.DB, entry *models.PointsLedgerEntry) error {
+	account, err := lockAccount(tx, entry.UserID)
+	if err != nil {
+		return err
+	}
+
+	if entry.Points > 0 && entry.ExpiresAt == nil {
+		expiresAt := time.Now().AddDate(0, models.PointsValidityMonths, 0)
+		entry.ExpiresAt = &expiresAt
+	}
+
+	if err := writeEntry(tx, account, entry); err != nil {
+		return err
+	}
+
+	if entry.Points > 0 {
+		lot := models.PointsLot{
+			UserID:        entry.UserID,
+			LedgerEntryID: entry.ID,
+			Points:        entry.Points,
+			Remaining:     entry.Points,
+			ExpiresAt:     entry.ExpiresAt,
+		}
+		if err := tx.Create(&lot).Error; err != nil {
+			return fmt.Errorf("failed to open points lot: %w", err)
+		}
+	} else if entry.Points < 0 {
+		return consumeLots(tx, entry.UserID, -entry.Points)
+	}
+
+	return nil
+}
+
+// RevokeEarn takes back what is left of the points earned under reference, for when the
+// purchase that earned them is undone. Points already spent stay spent. It must run
+// inside tx and returns the points revoked, zero when nothing was earned.
+func RevokeEarn(tx *gorm.DB, reference, description string) (int, error) {
+	var earned models.PointsLedgerEntry
+	err := tx.Where("entry_type = ? AND reference = ?", models.LedgerEarn, reference).Take(&earned).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Lock the account before the lot, in the same order as ApplyEntry.
+	account, err := lockAccount(tx, earned.UserID)
+	if err != nil {
+		return 0, err
+	}
+	var lot models.PointsLot
+	err = tx.Where("ledger_entry_id = ?", earned.ID).Take(&lot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if lot.Remaining == 0 {
+		return 0, nil
+	}
+
+	err = tx.Model(&models.PointsLot{}).Where("id = ?", lot.ID).Update("remaining", 0).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke points lot: %w", err)
+	}
+
+	revoked := lot.Remaining
+	err = writeEntry(tx, account, &models.PointsLedgerEntry{
+		UserID:      earned.UserID,
+		EntryType:   models.LedgerAdjust,
+		Points:      -revoked,
+		Reference:   reference,
+		Description: description,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return revoked, nil
+}
+
+// lockAccount opens the account of userID if needed and locks it for the rest of tx.
+func lockAccount(tx *gorm.DB, userID int) (*models.PointsAccount, error) {
+	err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}}, DoNothing: true}).
+		Create(&models.PointsAccount{UserID: userID}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to open points account: %w", err)
+	}
+
+	var account models.PointsAccount
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		First(&account).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock points account: %w", err)
+	}
+
+	return &account, nil
+}
+
+func writeEntry(tx *gorm.DB, account *models.PointsAccount, entry *models.PointsLedgerEntry) error {
+	balance := account.Balance + entry.Points
+	if balance < 0 {
+		return ErrInsufficientPoints
+	}
+
+	err := tx.Model(&models.PointsAccount{}).
+		Where("id = ?", account.ID).
+		Update("balance", balance).Error
+	if err != nil {
+		return fmt.Errorf("failed to update points balance: %w", err)
+	}
+	account.Balance = balance
+
+	entry.BalanceAfter = balance
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to write points ledger: %w", err)
+	}
+
+	return nil
+}
+
+func consumeLots(tx *gorm.DB, userID, points int) error {
+	var lots []models.PointsLot
+	err := tx.Where("user_id = ? AND remaining > 0", userID).
+		Order("expires_at NULLS LAST, id").
+		Find(&lots).Error
+	if err != nil {
+		return fmt.Errorf("failed to fetch points lots: %w", err)
+	}
+
+	for _, lot := range lots {
+		if points == 0 {
+			break
+		}
+		used := min(lot.Remaining, points)
+		err := tx.Model(&models.PointsLot{}).
+			Where("id = ?", lot.ID).
+			Update("remaining", lot.Remaining-used).Error
+		if err != nil {
+			return fmt.Errorf("failed to consume points lot: %w", err)
+		}
+		points -= used
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package pointsledger_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"voucher_system/models"
+	pointsledger "voucher_system/repository/points_ledger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func setupTestDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, _ := sqlmock.New()
+	dialector := postgres.New(postgres.Config{
+		Conn:       db,
+		DriverName: "postgres",
+	})
+	gormDB, _ := gorm.Open(dialector, &gorm.Config{})
+	return gormDB, mock
+}
+
+func expectLockedAccount(mock sqlmock.Sqlmock, userID, balance int) {
+	mock.ExpectQuery(`INSERT INTO "points_accounts" .* ON CONFLICT \("user_id"\) DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "points_accounts" WHERE user_id = \$1 .* FOR UPDATE`).
+		WithArgs(userID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "balance"}).AddRow(1, userID, balance))
+}
+
+func TestAddEntry(t *testing.T) {
+	t.Run("Credit updates the balance and appends the entry", func(t *testing.T) {
+		db, mock := setupTestDB()
+		repo := pointsledger.NewPointsLedgerRepo(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		expectLockedAccount(mock, 1, 40)
+		mock.ExpectExec(`UPDATE "points_accounts" SET "balance"=\$1`).
+			WithArgs(140, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`INSERT INTO "points_ledger_entries"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectQuery(`INSERT INTO "points_lots" \("user_id","ledger_entry_id","points","remaining","expires_at","created_at"\)`).
+			WithArgs(1, 9, 100, 100, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectCommit()
+
+		entry := &models.PointsLedgerEntry{UserID: 1, EntryType: models.LedgerAdjust, Points: 100}
+		err := repo.AddEntry(entry)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 140, entry.BalanceAfter)
+		assert.Equal(t, 9, entry.ID)
+		assert.WithinDuration(t, time.Now().AddDate(0, models.PointsValidityMonths, 0), *entry.ExpiresAt, time.Minute)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Debit consumes the lots expiring first", func(t *testing.T) {
+		db, mock := setupTestDB()
+		repo := pointsledger.NewPointsLedgerRepo(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		expectLockedAccount(mock, 1, 70)
+		mock.ExpectExec(`UPDATE "points_accounts" SET "balance"=\$1`).
+			WithArgs(20, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`INSERT INTO "points_ledger_entries"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+		mock.ExpectQuery(`SELECT \* FROM "points_lots" WHERE user_id = \$1 AND remaining > 0 ORDER BY expires_at NULLS LAST, id`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "remaining"}).AddRow(4, 1, 30).AddRow(6, 1, 40))
+		mock.ExpectExec(`UPDATE "points_lots" SET "remaining"=\$1 WHERE id = \$2`).
+			WithArgs(0, 4).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE "points_lots" SET "remaining"=\$1 WHERE id = \$2`).
+			WithArgs(20, 6).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.AddEntry(&models.PointsLedgerEntry{UserID: 1, EntryType: models.LedgerSpend, Points: -50})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Debit below zero is rejected", func(t *testing.T) {
+		db, mock := setupTestDB()
+		repo := pointsledger.NewPointsLedgerRepo(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		expectLockedAccount(mock, 1, 40)
+		mock.ExpectRollback()
+
+		err := repo.AddEntry(&models.PointsLedgerEntry{UserID: 1, EntryType: models.LedgerSpend, Points: -50})
+
+		assert.ErrorIs(t, err, pointsledger.ErrInsufficientPoints)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestLedgerEarnReferenceIndex(t *testing.T) {
+	entrySchema, err := schema.Parse(&models.PointsLedgerEntry{}, &sync.Map{}, schema.NamingStrategy{})
+	assert.NoError(t, err)
+
+	index := entrySchema.LookIndex("idx_ledger_earn_reference")
+	if assert.NotNil(t, index) {
+		assert.Equal(t, "UNIQUE", index.Class)
+		assert.Equal(t, "entry_type = 'earn' AND reference <> ''", index.Where)
+		if assert.Len(t, index.Fields, 2) {
+			assert.Equal(t, "entry_type", index.Fields[0].DBName)
+			assert.Equal(t, "reference", index.Fields[1].DBName)
+		}
+	}
+}
+
+func TestGetAccount(t *testing.T) {
+	db, mock := setupTestDB()
+	repo := pointsledger.NewPointsLedgerRepo(db, zap.NewNop())
+
+	mock.ExpectQuery(`SELECT \* FROM "points_accounts" WHERE user_id = \$1`).
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	account, err := repo.GetAccount(5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, account.UserID)
+	assert.Zero(t, account.Balance)
+}
+
+func TestExpireLot(t *testing.T) {
+	now := time.Now()
+	lotColumns := []string{"id", "user_id", "remaining", "expires_at", "created_at"}
+
+	t.Run("Writes off the remaining points", func(t *testing.T) {
+		db, mock := setupTestDB()
+		repo := pointsledger.NewPointsLedgerRepo(db, zap.NewNop())
+		lot := func() *sqlmock.Rows {
+			return sqlmock.NewRows(lotColumns).AddRow(4, 1, 30, now.Add(-time.Hour), now.AddDate(-1, 0, 0))
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "points_lots" WHERE "points_lots"."id" = \$1`).WithArgs(4, 1).WillReturnRows(lot())
+		expectLockedAccount(mock, 1, 70)
+		mock.ExpectQuery(`SELECT \* FROM "points_lots" WHERE "points_lots"."id" = \$1`).WithArgs(4, 4, 1).WillReturnRows(lot())
+		mock.ExpectExec(`UPDATE "points_lots" SET "remaining"=\$1 WHERE id = \$2`).
+			WithArgs(0, 4).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE "points_accounts" SET "balance"=\$1`).
+			WithArgs(40, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`INSERT INTO "points_ledger_entries"`).
+			WithArgs(1, models.LedgerExpire, -30, 40, "lot:4", sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+		mock.ExpectCommit()
+
+		expired, err := repo.ExpireLot(4, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 30, expired)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Lot that is not due is left alone", func(t *testing.T) {
+		db, mock := setupTestDB()
+		repo := pointsledger.NewPointsLedgerRepo(db, zap.NewNop())
+		lot := func() *sqlmock.Rows {
+			return sqlmock.NewRows(lotColumns).AddRow(4, 1, 30, now.Add(time.Hour), now)
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "points_lots"`).WillReturnRows(lot())
+		expectLockedAccount(mock, 1, 70)
+		mock.ExpectQuery(`SELECT \* FROM "points_lots"`).WillReturnRows(lot())
+		mock.ExpectCommit()
+
+		expired, err := repo.ExpireLot(4, now)
+
+		assert.NoError(t, err)
+		assert.Zero(t, expired)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -1,26 +1,38 @@
-package repository
-
-import (
-	managementvoucher "voucher_system/repository/management_voucher"
-
-	"go.uber.org/zap"
-	"gorm.io/gorm"
-)
-
-type Repository struct {
-	User    UserRepository
-	Manage  managementvoucher.ManagementVoucherInterface
-	Voucher VoucherRepository
-	Redeem  RedeemRepository
-	History HistoryRepository
-}
-
-func NewRepository(db *gorm.DB, log *zap.Logger) Repository {
-	return Repository{
-		User:    NewUserRepository(db, log),
-		Manage:  managementvoucher.NewManagementVoucherRepo(db, log),
-		Voucher: NewVoucherRepository(db, log),
-		Redeem:  NewRedeemRepository(db, log),
-		History: NewHistoryRepository(db, log),
-	}
-}
+package repository
+
+import (
+	managementvoucher "voucher_system/repository/management_voucher"
+	pointsledger "voucher_system/repository/points_ledger"
+	"voucher_system/repository/tier"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	User        UserRepository
+	Manage      managementvoucher.ManagementVoucherInterface
+	Voucher     VoucherRepository
+	Reservation ReservationRepository
+	Redeem      RedeemRepository
+	History     HistoryRepository
+	Points      pointsledger.PointsLedgerInterface
+	Earning     EarningRuleRepository
+	Tier        tier.TierInterface
+	JobRun      JobRunRepository
+}
+
+func NewRepository(db *gorm.DB, log *zap.Logger) Repository {
+	return Repository{
+		User:        NewUserRepository(db, log),
+		Manage:      managementvoucher.NewManagementVoucherRepo(db, log),
+		Voucher:     NewVoucherRepository(db, log),
+		Reservation: NewReservationRepository(db, log),
+		Redeem:      NewRedeemRepository(db, log),
+		History:     NewHistoryRepository(db, log),
+		Points:      pointsledger.NewPointsLedgerRepo(db, log),
+		Earning:     NewEarningRuleRepository(db, log),
+		Tier:        tier.NewTierRepo(db, log),
+		JobRun:      NewJobRunRepository(db, log),
+	}
+}
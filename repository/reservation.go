@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"voucher_system/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOrderReserved      = errors.New("order already has a voucher reservation")
+	ErrReservationNotHeld = errors.New("reservation is no longer held")
+	ErrReservationExpired = errors.New("reservation has expired")
+)
+
+type ReservationRepository interface {
+	Reserve(reservation *models.VoucherReservation, voucherCode string, now time.Time, benefit BenefitFunc) error
+	Confirm(userID, reservationID int, now time.Time) (*models.VoucherReservation, *models.History, error)
+	Release(userID, reservationID int) (*models.VoucherReservation, error)
+	FindExpiredIDs(now time.Time, limit int) ([]int, error)
+	Expire(reservationID int, now time.Time) (bool, error)
+}
+
+type reservationRepository struct {
+	DB       *gorm.DB
+	log      *zap.Logger
+	vouchers *voucherRepository
+}
+
+func NewReservationRepository(db *gorm.DB, log *zap.Logger) ReservationRepository {
+	return &reservationRepository{DB: db, log: log, vouchers: &voucherRepository{DB: db, log: log}}
+}
+
+// Reserve validates voucherCode for the reservation's user and transaction and takes one
+// unit of its quota for the order. The remaining fields of reservation are filled in.
+func (r *reservationRepository) Reserve(reservation *models.VoucherReservation, voucherCode string, now time.Time, benefit BenefitFunc) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		voucher, err := r.vouchers.findValidVoucher(tx, true, reservation.UserID, voucherCode, reservation.Area, reservation.TransactionAmount, reservation.Currency, reservation.PaymentMethod, now)
+		if err != nil {
+			return err
+		}
+		if voucher.VoucherCode != voucherCode {
+			return models.ErrCodeNotReservable
+		}
+
+		var existing int64
+		err = tx.Model(&models.VoucherReservation{}).
+			Where("user_id = ? AND order_id = ? AND status IN ?", reservation.UserID, reservation.OrderID,
+				[]string{models.ReservationHeld, models.ReservationConfirmed}).
+			Count(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrOrderReserved
+		}
+
+		benefitValue, err := benefit(voucher)
+		if err != nil {
+			return err
+		}
+
+		if err := takeQuota(tx, voucher.ID); err != nil {
+			return err
+		}
+
+		reservation.VoucherID = voucher.ID
+		reservation.VoucherVersion = voucher.Version
+		reservation.BenefitValue = benefitValue
+		reservation.Currency = reservation.Currency.OrDefault()
+		reservation.Status = models.ReservationHeld
+		if err := tx.Create(reservation).Error; err != nil {
+			return fmt.Errorf("failed to create reservation: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		r.log.Error("Error reserving voucher", zap.String("voucherCode", voucherCode), zap.String("orderID", reservation.OrderID), zap.Error(err))
+		return err
+	}
+
+	r.log.Info("Voucher reserved", zap.Int("reservationID", reservation.ID), zap.Time("expiresAt", reservation.ExpiresAt))
+	return nil
+}
+
+// Confirm turns a held reservation of userID into a voucher usage. The quota unit was
+// already taken by Reserve.
+func (r *reservationRepository) Confirm(userID, reservationID int, now time.Time) (*models.VoucherReservation, *models.History, error) {
+	var (
+		reservation models.VoucherReservation
+		history     models.History
+	)
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockReservation(tx, &reservation, userID, reservationID); err != nil {
+			return err
+		}
+		if reservation.Status != models.ReservationHeld {
+			return ErrReservationNotHeld
+		}
+		if !now.Before(reservation.ExpiresAt) {
+			return ErrReservationExpired
+		}
+
+		history = models.History{
+			UserID:            reservation.UserID,
+			VoucherID:         reservation.VoucherID,
+			TransactionAmount: reservation.TransactionAmount,
+			BenefitValue:      reservation.BenefitValue,
+			Currency:          reservation.Currency,
+			UsageDate:         now,
+			OrderID:           reservation.OrderID,
+			VoucherVersion:    reservation.VoucherVersion,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return fmt.Errorf("failed to record voucher usage: %w", err)
+		}
+
+		reservation.Status = models.ReservationConfirmed
+		reservation.HistoryID = &history.ID
+		return tx.Model(&reservation).Select("status", "history_id").Updates(&reservation).Error
+	})
+	if err != nil {
+		r.log.Error("Error confirming reservation", zap.Int("reservationID", reservationID), zap.Error(err))
+		return nil, nil, err
+	}
+
+	r.log.Info("Reservation confirmed", zap.Int("reservationID", reservationID), zap.Int("historyID", history.ID))
+	return &reservation, &history, nil
+}
+
+// Release cancels a held reservation of userID and gives its unit back to the quota.
+func (r *reservationRepository) Release(userID, reservationID int) (*models.VoucherReservation, error) {
+	var reservation models.VoucherReservation
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockReservation(tx, &reservation, userID, reservationID); err != nil {
+			return err
+		}
+		if reservation.Status != models.ReservationHeld {
+			return ErrReservationNotHeld
+		}
+		return returnQuota(tx, &reservation, models.ReservationReleased)
+	})
+	if err != nil {
+		r.log.Error("Error releasing reservation", zap.Int("reservationID", reservationID), zap.Error(err))
+		return nil, err
+	}
+
+	r.log.Info("Reservation released", zap.Int("reservationID", reservationID))
+	return &reservation, nil
+}
+
+func (r *reservationRepository) FindExpiredIDs(now time.Time, limit int) ([]int, error) {
+	var ids []int
+	err := r.DB.Model(&models.VoucherReservation{}).
+		Where("status = ? AND expires_at <= ?", models.ReservationHeld, now).
+		Order("expires_at, id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		r.log.Error("Error fetching expired reservations", zap.Error(err))
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Expire gives the unit of an expired held reservation back to the quota and reports
+// whether it did. It is a no-op for reservations that were confirmed, released or have
+// not expired yet, so it is safe to retry.
+func (r *reservationRepository) Expire(reservationID int, now time.Time) (bool, error) {
+	expired := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var reservation models.VoucherReservation
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reservation, reservationID).Error
+		if err != nil {
+			return err
+		}
+		if reservation.Status != models.ReservationHeld || reservation.ExpiresAt.After(now) {
+			return nil
+		}
+
+		expired = true
+		return returnQuota(tx, &reservation, models.ReservationExpired)
+	})
+	if err != nil {
+		r.log.Error("Error expiring reservation", zap.Int("reservationID", reservationID), zap.Error(err))
+		return false, err
+	}
+	return expired, nil
+}
+
+func lockReservation(tx *gorm.DB, reservation *models.VoucherReservation, userID, reservationID int) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ?", reservationID, userID).
+		First(reservation).Error
+}
+
+// takeQuota decrements the quota of voucherID unless it is used up.
+func takeQuota(tx *gorm.DB, voucherID int) error {
+	result := tx.Model(&models.Voucher{}).
+		Where("id = ? AND quota > 0", voucherID).
+		Update("quota", gorm.Expr("quota - 1"))
+	if result.Error != nil {
+		return fmt.Errorf("failed to update voucher quota: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// returnQuota ends reservation with status and gives its unit back to the voucher.
+func returnQuota(tx *gorm.DB, reservation *models.VoucherReservation, status string) error {
+	reservation.Status = status
+	if err := tx.Model(reservation).Update("status", status).Error; err != nil {
+		return fmt.Errorf("failed to update reservation: %w", err)
+	}
+	err := tx.Model(&models.Voucher{}).
+		Where("id = ?", reservation.VoucherID).
+		Update("quota", gorm.Expr("quota + 1")).Error
+	if err != nil {
+		return fmt.Errorf("failed to update voucher quota: %w", err)
+	}
+	return nil
+}
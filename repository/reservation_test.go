@@ -0,0 +1,234 @@
+package repository_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/utils/money"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm/schema"
+)
+
+var reservationColumns = []string{"id", "voucher_id", "user_id", "order_id", "transaction_amount", "benefit_value", "currency", "status", "expires_at"}
+
+func TestReservationRepository_Reserve(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	repo := repository.NewReservationRepository(db, zap.NewNop())
+
+	now := time.Now()
+	voucherRows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas", "version"}).
+		AddRow(1, "VOUCHER1", "e-commerce", 1, now.Add(-time.Hour), now.Add(time.Hour), 50.0, `["credit"]`, `["area1"]`, 2)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers" JOIN redeems .* FOR UPDATE OF "vouchers"`).
+		WillReturnRows(voucherRows)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "voucher_reservations"`).
+		WithArgs(1, "INV-1", models.ReservationHeld, models.ReservationConfirmed).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`UPDATE "vouchers" SET "quota"=quota - 1`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "voucher_reservations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectCommit()
+
+	reservation := &models.VoucherReservation{
+		UserID:            1,
+		OrderID:           "INV-1",
+		TransactionAmount: money.New(100),
+		PaymentMethod:     "credit",
+		Area:              "area1",
+		ExpiresAt:         now.Add(15 * time.Minute),
+	}
+	err := repo.Reserve(reservation, "VOUCHER1", now, func(*models.Voucher) (money.Amount, error) {
+		return money.New(10), nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, reservation.ID)
+	assert.Equal(t, 1, reservation.VoucherID)
+	assert.Equal(t, 2, reservation.VoucherVersion)
+	assert.Equal(t, models.ReservationHeld, reservation.Status)
+	assert.Equal(t, money.IDR, reservation.Currency)
+}
+
+func TestReservationRepository_Reserve_OrderAlreadyReserved(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	repo := repository.NewReservationRepository(db, zap.NewNop())
+
+	now := time.Now()
+	voucherRows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
+		AddRow(1, "VOUCHER1", "e-commerce", 1, now.Add(-time.Hour), now.Add(time.Hour), 50.0, `["credit"]`, `["area1"]`)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers"`).
+		WillReturnRows(voucherRows)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "voucher_reservations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	reservation := &models.VoucherReservation{UserID: 1, OrderID: "INV-1", TransactionAmount: money.New(100), PaymentMethod: "credit", Area: "area1"}
+	err := repo.Reserve(reservation, "VOUCHER1", now, func(*models.Voucher) (money.Amount, error) {
+		return money.New(10), nil
+	})
+
+	assert.ErrorIs(t, err, repository.ErrOrderReserved)
+}
+
+func TestReservationRepository_Reserve_AfterRelease(t *testing.T) {
+	t.Run("Only held and confirmed reservations are unique per order", func(t *testing.T) {
+		reservationSchema, err := schema.Parse(&models.VoucherReservation{}, &sync.Map{}, schema.NamingStrategy{})
+		assert.NoError(t, err)
+
+		index := reservationSchema.LookIndex("idx_reservation_active_order")
+		if assert.NotNil(t, index) {
+			assert.Equal(t, "UNIQUE", index.Class)
+			assert.Equal(t, "status IN ('held','confirmed')", index.Where)
+			assert.Len(t, index.Fields, 2)
+		}
+	})
+
+	t.Run("A released order can be reserved again", func(t *testing.T) {
+		db, mock := SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+		repo := repository.NewReservationRepository(db, zap.NewNop())
+
+		now := time.Now()
+		benefit := func(*models.Voucher) (money.Amount, error) { return money.New(10), nil }
+		expectReserve := func(id int) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers" JOIN redeems .* FOR UPDATE OF "vouchers"`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
+					AddRow(1, "VOUCHER1", "e-commerce", 2, now.Add(-time.Hour), now.Add(time.Hour), 50.0, `["credit"]`, `["area1"]`))
+			mock.ExpectQuery(`SELECT count\(\*\) FROM "voucher_reservations" WHERE user_id = \$1 AND order_id = \$2 AND status IN \(\$3,\$4\)`).
+				WithArgs(1, "INV-1", models.ReservationHeld, models.ReservationConfirmed).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			mock.ExpectExec(`UPDATE "vouchers" SET "quota"=quota - 1`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`INSERT INTO "voucher_reservations"`).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+			mock.ExpectCommit()
+		}
+
+		expectReserve(5)
+		first := &models.VoucherReservation{UserID: 1, OrderID: "INV-1", TransactionAmount: money.New(100), PaymentMethod: "credit", Area: "area1", ExpiresAt: now.Add(15 * time.Minute)}
+		assert.NoError(t, repo.Reserve(first, "VOUCHER1", now, benefit))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "voucher_reservations"`).
+			WillReturnRows(sqlmock.NewRows(reservationColumns).
+				AddRow(5, 1, 1, "INV-1", "100", "10", "IDR", models.ReservationHeld, now.Add(15*time.Minute)))
+		mock.ExpectExec(`UPDATE "voucher_reservations" SET "status"=\$1`).
+			WithArgs(models.ReservationReleased, sqlmock.AnyArg(), 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE "vouchers" SET "quota"=quota \+ 1`).
+			WithArgs(sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		_, err := repo.Release(1, 5)
+		assert.NoError(t, err)
+
+		expectReserve(6)
+		second := &models.VoucherReservation{UserID: 1, OrderID: "INV-1", TransactionAmount: money.New(100), PaymentMethod: "credit", Area: "area1", ExpiresAt: now.Add(15 * time.Minute)}
+		assert.NoError(t, repo.Reserve(second, "VOUCHER1", now, benefit))
+		assert.Equal(t, 6, second.ID)
+		assert.Equal(t, models.ReservationHeld, second.Status)
+	})
+}
+
+func TestReservationRepository_Confirm(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	repo := repository.NewReservationRepository(db, zap.NewNop())
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "voucher_reservations" WHERE id = \$1 AND user_id = \$2 .* FOR UPDATE`).
+		WithArgs(9, 1, 1).
+		WillReturnRows(sqlmock.NewRows(reservationColumns).
+			AddRow(9, 4, 1, "INV-1", "100", "10", "IDR", models.ReservationHeld, now.Add(time.Minute)))
+	mock.ExpectQuery(`INSERT INTO "histories"`).
+		WithArgs(1, 4, "100", "10", "IDR", "INV-1", nil, "", false, 0, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "usage_date"}).AddRow(15, now))
+	mock.ExpectExec(`UPDATE "voucher_reservations" SET "status"=\$1,"history_id"=\$2,"updated_at"=\$3 WHERE "id" = \$4`).
+		WithArgs(models.ReservationConfirmed, 15, sqlmock.AnyArg(), 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	reservation, history, err := repo.Confirm(1, 9, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReservationConfirmed, reservation.Status)
+	assert.Equal(t, 15, *reservation.HistoryID)
+	assert.Equal(t, money.New(10), history.BenefitValue)
+}
+
+func TestReservationRepository_Confirm_Expired(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	repo := repository.NewReservationRepository(db, zap.NewNop())
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "voucher_reservations"`).
+		WillReturnRows(sqlmock.NewRows(reservationColumns).
+			AddRow(9, 4, 1, "INV-1", "100", "10", "IDR", models.ReservationHeld, now.Add(-time.Minute)))
+	mock.ExpectRollback()
+
+	_, _, err := repo.Confirm(1, 9, now)
+
+	assert.ErrorIs(t, err, repository.ErrReservationExpired)
+}
+
+func TestReservationRepository_Release(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	repo := repository.NewReservationRepository(db, zap.NewNop())
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "voucher_reservations"`).
+		WillReturnRows(sqlmock.NewRows(reservationColumns).
+			AddRow(9, 4, 1, "INV-1", "100", "10", "IDR", models.ReservationHeld, now.Add(time.Minute)))
+	mock.ExpectExec(`UPDATE "voucher_reservations" SET "status"=\$1`).
+		WithArgs(models.ReservationReleased, sqlmock.AnyArg(), 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "vouchers" SET "quota"=quota \+ 1`).
+		WithArgs(sqlmock.AnyArg(), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	reservation, err := repo.Release(1, 9)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReservationReleased, reservation.Status)
+}
+
+func TestReservationRepository_Release_AlreadyConfirmed(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	repo := repository.NewReservationRepository(db, zap.NewNop())
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "voucher_reservations"`).
+		WillReturnRows(sqlmock.NewRows(reservationColumns).
+			AddRow(9, 4, 1, "INV-1", "100", "10", "IDR", models.ReservationConfirmed, time.Now().Add(time.Minute)))
+	mock.ExpectRollback()
+
+	_, err := repo.Release(1, 9)
+
+	assert.ErrorIs(t, err, repository.ErrReservationNotHeld)
+}
@@ -0,0 +1,161 @@
+package tier
+
+import (
+	"time"
+	"voucher_system/models"
+	"voucher_system/utils/money"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// UserStats is what a user spent and earned within the tier window. SpendByCurrency is
+// read from the usage history; RollingSpend is its total in the base currency and is
+// filled in by the caller, which knows the exchange rates.
+type UserStats struct {
+	UserID          int           `json:"user_id"`
+	Tier            string        `json:"tier"`
+	RollingSpend    money.Amount  `gorm:"-" json:"rolling_spend" swaggertype:"number"`
+	SpendByCurrency CurrencySpend `gorm:"serializer:json" json:"spend_by_currency,omitempty" swaggertype:"object,number"`
+	RollingPoints   int           `json:"rolling_points"`
+}
+
+// CurrencySpend is what was spent in each currency.
+type CurrencySpend map[money.Currency]money.Amount
+
+type TierInterface interface {
+	FindAll() ([]models.Tier, error)
+	FindStats(userID int, since time.Time) (*UserStats, error)
+	FindStatsBatch(since time.Time, afterUserID, limit int) ([]UserStats, error)
+	ChangeTier(change *models.TierChange) error
+	FindChanges(userID int) ([]models.TierChange, error)
+}
+
+type TierRepo struct {
+	DB  *gorm.DB
+	Log *zap.Logger
+}
+
+func NewTierRepo(db *gorm.DB, log *zap.Logger) TierInterface {
+	return &TierRepo{DB: db, Log: log}
+}
+
+// FindAll returns the tiers from the lowest to the highest rank.
+func (t *TierRepo) FindAll() ([]models.Tier, error) {
+	var tiers []models.Tier
+	err := t.DB.Order("rank").Find(&tiers).Error
+	if err != nil {
+		t.Log.Error("Error from repo fetching tiers:", zap.Error(err))
+	}
+	return tiers, err
+}
+
+func (t *TierRepo) FindStats(userID int, since time.Time) (*UserStats, error) {
+	var stats UserStats
+	err := t.statsQuery(since).Where("users.id = ?", userID).Take(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// FindStatsBatch returns the stats of up to limit users with an ID above afterUserID,
+// ordered by ID so callers can page through all users.
+func (t *TierRepo) FindStatsBatch(since time.Time, afterUserID, limit int) ([]UserStats, error) {
+	var stats []UserStats
+	err := t.statsQuery(since).
+		Where("users.id > ?", afterUserID).
+		Order("users.id").
+		Limit(limit).
+		Scan(&stats).Error
+	if err != nil {
+		t.Log.Error("Error from repo fetching tier stats:", zap.Error(err))
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (t *TierRepo) statsQuery(since time.Time) *gorm.DB {
+	return t.DB.Model(&models.User{}).
+		Select(`users.id AS user_id, users.tier,
+			(SELECT json_object_agg(s.currency, s.spend) FROM (
+				SELECT h.currency, SUM(h.transaction_amount - h.benefit_value) AS spend FROM histories h
+				WHERE h.user_id = users.id AND h.usage_date >= ? AND h.reversed_at IS NULL
+				GROUP BY h.currency) s) AS spend_by_currency,
+			COALESCE((SELECT SUM(e.points) FROM points_ledger_entries e
+				WHERE e.user_id = users.id AND e.entry_type = ? AND e.created_at >= ?), 0) AS rolling_points`,
+			since, models.LedgerEarn, since)
+}
+
+func (t *TierRepo) ChangeTier(change *models.TierChange) error {
+	return t.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", change.UserID).Update("tier", change.ToTier)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(change).Error
+	})
+}
+
+func (t *TierRepo) FindChanges(userID int) ([]models.TierChange, error) {
+	var changes []models.TierChange
+	err := t.DB.Where("user_id = ?", userID).Order("id DESC").Find(&changes).Error
+	if err != nil {
+		t.Log.Error("Error from repo fetching tier changes:", zap.Error(err))
+	}
+	return changes, err
+}
+
+// UserTier returns the tier name of userID, or "" if no tier was assigned yet.
+func UserTier(tx *gorm.DB, userID int) (string, error) {
+	var name string
+	err := tx.Model(&models.User{}).Select("tier").Where("id = ?", userID).Scan(&name).Error
+	return name, err
+}
+
+// Meets reports whether tier ranks at least as high as minimumTier. Every tier, and no
+// tier, meets an empty minimum.
+func Meets(tx *gorm.DB, tier, minimumTier string) (bool, error) {
+	if minimumTier == "" {
+		return true, nil
+	}
+	if tier == "" {
+		return false, nil
+	}
+
+	var tiers []models.Tier
+	if err := tx.Where("name IN ?", []string{tier, minimumTier}).Find(&tiers).Error; err != nil {
+		return false, err
+	}
+
+	ranks := make(map[string]int, len(tiers))
+	for _, t := range tiers {
+		ranks[t.Name] = t.Rank
+	}
+	rank, ok := ranks[tier]
+	minimum, minimumOk := ranks[minimumTier]
+	return ok && minimumOk && rank >= minimum, nil
+}
+
+// PointsPrice returns the points voucherID costs for tier, falling back to the voucher's
+// own price when there is no tier price.
+func PointsPrice(tx *gorm.DB, voucherID int, tier string, fallback int) (int, error) {
+	if tier == "" {
+		return fallback, nil
+	}
+
+	var prices []int
+	err := tx.Model(&models.VoucherTierPrice{}).
+		Where("voucher_id = ? AND tier = ?", voucherID, tier).
+		Pluck("points_required", &prices).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(prices) == 0 {
+		return fallback, nil
+	}
+	return prices[0], nil
+}
@@ -1,37 +1,157 @@
-package repository
-
-import (
-	"voucher_system/models"
-
-	"go.uber.org/zap"
-	"gorm.io/gorm"
-)
-
-type UserRepository interface {
-	Login(email string) (models.User, error)
-	Register(user models.User) error
-}
-
-type userRepository struct {
-	DB  *gorm.DB
-	log *zap.Logger
-}
-
-func NewUserRepository(db *gorm.DB, log *zap.Logger) UserRepository {
-	return &userRepository{DB: db, log: log}
-}
-
-func (r *userRepository) Login(email string) (models.User, error) {
-	var user models.User
-	err := r.DB.Where("email = ?", email).First(&user).Error
-	return user, err
-}
-func (r *userRepository) Register(user models.User) error {
-
-	err := r.DB.Create(&user).Error
-	if err != nil {
-		r.log.Error("Failed to create user", zap.Error(err))
-		return nil
-	}
-	return nil
-}
+package repository
+
+import (
+	"voucher_system/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type UserRepository interface {
+	Login(email string) (models.User, error)
+	Register(user models.User) error
+	GetUserByID(userID int) (models.User, error)
+	UpdateName(userID int, name string) error
+	UpdatePassword(userID int, hashedPassword string) error
+	UpdateEmail(userID int, email string) error
+	DeleteUser(userID int) error
+	SearchUsers(query string, page, limit int) ([]models.User, int64, error)
+	GetUserSummary(userID int) (UserSummary, error)
+	SetDisabled(userID int, disabled bool) error
+	UpdateRole(userID int, role string) error
+	ForcePasswordReset(userID int, hashedPassword string) error
+}
+
+// UserSummary is a user with the number of vouchers they redeemed and used.
+type UserSummary struct {
+	models.User
+	RedeemCount int64 `json:"redeem_count"`
+	UsageCount  int64 `json:"usage_count"`
+}
+
+type userRepository struct {
+	DB  *gorm.DB
+	log *zap.Logger
+}
+
+func NewUserRepository(db *gorm.DB, log *zap.Logger) UserRepository {
+	return &userRepository{DB: db, log: log}
+}
+
+func (r *userRepository) Login(email string) (models.User, error) {
+	var user models.User
+	err := r.DB.Where("email = ?", email).First(&user).Error
+	return user, err
+}
+func (r *userRepository) Register(user models.User) error {
+
+	err := r.DB.Create(&user).Error
+	if err != nil {
+		r.log.Error("Failed to create user", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *userRepository) GetUserByID(userID int) (models.User, error) {
+	var user models.User
+	err := r.DB.Where("id = ?", userID).First(&user).Error
+	if err != nil {
+		r.log.Error("Failed to fetch user", zap.Int("userID", userID), zap.Error(err))
+	}
+	return user, err
+}
+
+func (r *userRepository) UpdateName(userID int, name string) error {
+	return r.updateColumn(userID, "name", name)
+}
+
+func (r *userRepository) UpdatePassword(userID int, hashedPassword string) error {
+	return r.updateColumns(userID, map[string]interface{}{
+		"password":                hashedPassword,
+		"password_reset_required": false,
+	})
+}
+
+func (r *userRepository) UpdateEmail(userID int, email string) error {
+	return r.updateColumn(userID, "email", email)
+}
+
+func (r *userRepository) updateColumn(userID int, column string, value interface{}) error {
+	return r.updateColumns(userID, map[string]interface{}{column: value})
+}
+
+func (r *userRepository) updateColumns(userID int, values map[string]interface{}) error {
+	result := r.DB.Model(&models.User{}).Where("id = ?", userID).Updates(values)
+	if result.Error != nil {
+		r.log.Error("Failed to update user", zap.Int("userID", userID), zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) DeleteUser(userID int) error {
+	err := r.DB.Delete(&models.User{}, userID).Error
+	if err != nil {
+		r.log.Error("Failed to delete user", zap.Int("userID", userID), zap.Error(err))
+	}
+	return err
+}
+
+func (r *userRepository) SearchUsers(query string, page, limit int) ([]models.User, int64, error) {
+	var (
+		users []models.User
+		total int64
+	)
+
+	db := r.DB.Model(&models.User{})
+	if query != "" {
+		pattern := "%" + query + "%"
+		db = db.Where("email ILIKE ? OR name ILIKE ?", pattern, pattern)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		r.log.Error("Failed to count users", zap.Error(err))
+		return nil, 0, err
+	}
+
+	err := db.Order("id").Offset((page - 1) * limit).Limit(limit).Find(&users).Error
+	if err != nil {
+		r.log.Error("Failed to search users", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+func (r *userRepository) GetUserSummary(userID int) (UserSummary, error) {
+	var summary UserSummary
+	err := r.DB.Model(&models.User{}).
+		Select(`users.*,
+			(SELECT COUNT(*) FROM redeems WHERE redeems.user_id = users.id) AS redeem_count,
+			(SELECT COUNT(*) FROM histories WHERE histories.user_id = users.id AND histories.reversed_at IS NULL) AS usage_count`).
+		Where("users.id = ?", userID).
+		Take(&summary).Error
+	if err != nil {
+		r.log.Error("Failed to fetch user summary", zap.Int("userID", userID), zap.Error(err))
+	}
+	return summary, err
+}
+
+func (r *userRepository) SetDisabled(userID int, disabled bool) error {
+	return r.updateColumn(userID, "disabled", disabled)
+}
+
+func (r *userRepository) UpdateRole(userID int, role string) error {
+	return r.updateColumn(userID, "role", role)
+}
+
+func (r *userRepository) ForcePasswordReset(userID int, hashedPassword string) error {
+	return r.updateColumns(userID, map[string]interface{}{
+		"password":                hashedPassword,
+		"password_reset_required": true,
+	})
+}
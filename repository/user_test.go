@@ -0,0 +1,42 @@
+package repository_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"voucher_system/models"
+	"voucher_system/repository"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm/schema"
+)
+
+func TestUserRepository_Register(t *testing.T) {
+	t.Run("Email is only unique among accounts that are not deleted", func(t *testing.T) {
+		userSchema, err := schema.Parse(&models.User{}, &sync.Map{}, schema.NamingStrategy{})
+		assert.NoError(t, err)
+
+		index := userSchema.LookIndex("idx_users_email")
+		if assert.NotNil(t, index) {
+			assert.Equal(t, "UNIQUE", index.Class)
+			assert.Equal(t, "deleted_at IS NULL", index.Where)
+		}
+	})
+
+	t.Run("Insert errors are returned", func(t *testing.T) {
+		db, mock := SetupTestDB()
+		defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+		userRepo := repository.NewUserRepository(db, zap.NewNop())
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "users"`).
+			WillReturnError(errors.New(`duplicate key value violates unique constraint "idx_users_email"`))
+		mock.ExpectRollback()
+
+		err := userRepo.Register(models.User{Name: "Bob Brown", Email: "bob.brown@example.com", Password: "hashed"})
+
+		assert.Error(t, err)
+	})
+}
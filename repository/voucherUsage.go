@@ -1,164 +1,313 @@
-package repository
-
-import (
-	"encoding/json"
-	"fmt"
-	"time"
-	"voucher_system/helper"
-	"voucher_system/models"
-
-	"go.uber.org/zap"
-	"gorm.io/gorm"
-)
-
-type VoucherRepository interface {
-	FindAll(userID int, voucherType string) ([]*models.Voucher, error)
-	FindValidVoucher(userID int, voucherCode, area string, transactionAmount, shippingAmount float64, paymentMethod string, transactionDate time.Time) (*models.Voucher, error)
-	UpdateVoucherQuota(voucherID int, quota int) error
-}
-
-type voucherRepository struct {
-	DB  *gorm.DB
-	log *zap.Logger
-}
-
-func NewVoucherRepository(db *gorm.DB, log *zap.Logger) VoucherRepository {
-	return &voucherRepository{DB: db, log: log}
-}
-
-func (r *voucherRepository) FindAll(userID int, voucherType string) ([]*models.Voucher, error) {
-	r.log.Info("Fetching all vouchers", zap.Int("userID", userID), zap.String("voucherType", voucherType))
-	var rawVouchers []struct {
-		models.Voucher
-		RawPaymentMethods  []byte `gorm:"column:payment_methods"`
-		RawApplicableAreas []byte `gorm:"column:applicable_areas"`
-	}
-
-	query := r.DB.
-		Table("vouchers").
-		Select(`vouchers.*, vouchers.payment_methods AS raw_payment_methods, vouchers.applicable_areas AS raw_applicable_areas`).
-		Joins("JOIN redeems ON redeems.voucher_id = vouchers.id").
-		Where("redeems.user_id = ? AND vouchers.status = ?", userID, true)
-
-	if voucherType != "" {
-		query = query.Where("vouchers.voucher_type = ?", voucherType)
-	}
-
-	err := query.Find(&rawVouchers).Error
-	if err != nil {
-		r.log.Error("Error fetching vouchers", zap.Error(err))
-		return nil, err
-	}
-
-	r.log.Info("Fetched vouchers successfully", zap.Int("voucherCount", len(rawVouchers)))
-	vouchers := make([]*models.Voucher, 0, len(rawVouchers))
-	for _, rawVoucher := range rawVouchers {
-		v := rawVoucher.Voucher
-
-		if len(rawVoucher.RawPaymentMethods) > 0 {
-			if err := json.Unmarshal(rawVoucher.RawPaymentMethods, &v.PaymentMethods); err != nil {
-				r.log.Error("Error unmarshalling payment methods", zap.Error(err))
-				return nil, err
-			}
-		}
-
-		if len(rawVoucher.RawApplicableAreas) > 0 {
-			if err := json.Unmarshal(rawVoucher.RawApplicableAreas, &v.ApplicableAreas); err != nil {
-				r.log.Error("Error unmarshalling applicable areas", zap.Error(err))
-				return nil, err
-			}
-		}
-		vouchers = append(vouchers, &v)
-
-	}
-
-	return vouchers, nil
-}
-
-func (r *voucherRepository) FindValidVoucher(userID int, voucherCode, area string, transactionAmount, shippingAmount float64, paymentMethod string, transactionDate time.Time) (*models.Voucher, error) {
-	r.log.Info("Finding valid voucher", zap.Int("userID", userID), zap.String("voucherCode", voucherCode), zap.String("area", area))
-
-	var rawVoucher struct {
-		models.Voucher
-		RawPaymentMethods  []byte `gorm:"column:payment_methods"`
-		RawApplicableAreas []byte `gorm:"column:applicable_areas"`
-	}
-
-	err := r.DB.Table("vouchers").
-		Select(`vouchers.*`).
-		Joins("JOIN redeems ON redeems.voucher_id = vouchers.id").
-		Where(`
-			redeems.user_id = ? AND
-			vouchers.voucher_code = ? AND 
-			quota > 0`,
-			userID, voucherCode).First(&rawVoucher).Error
-
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("voucher not found")
-		}
-		r.log.Error("Error finding valid voucher", zap.String("voucherCode", voucherCode), zap.Error(err))
-		return nil, err
-	}
-
-	if transactionAmount < rawVoucher.Voucher.MinimumPurchase {
-		return nil, fmt.Errorf("transaction amount must be at least %.2f", rawVoucher.Voucher.MinimumPurchase)
-	}
-
-	if len(rawVoucher.RawApplicableAreas) > 0 {
-		var areas []string
-		if err := json.Unmarshal(rawVoucher.RawApplicableAreas, &areas); err != nil {
-			r.log.Error("Error unmarshalling applicable areas", zap.Error(err))
-			return nil, fmt.Errorf("failed to parse applicable_areas: %w", err)
-		}
-		if !helper.Contains(areas, area) {
-			return nil, fmt.Errorf("area not found")
-		}
-	}
-
-	if len(rawVoucher.RawPaymentMethods) > 0 {
-		var paymentMethods []string
-		if err := json.Unmarshal(rawVoucher.RawPaymentMethods, &paymentMethods); err != nil {
-			r.log.Error("Error unmarshalling payment methods", zap.Error(err))
-			return nil, fmt.Errorf("failed to parse payment_methods: %w", err)
-		}
-		if !helper.Contains(paymentMethods, paymentMethod) {
-			return nil, fmt.Errorf("payment method not found")
-		}
-	}
-
-	if transactionDate.Before(rawVoucher.Voucher.StartDate) {
-		return nil, fmt.Errorf("voucher not available yet")
-	}
-	if transactionDate.After(rawVoucher.Voucher.EndDate) {
-		return nil, fmt.Errorf("voucher expired")
-	}
-
-	if len(rawVoucher.RawPaymentMethods) > 0 {
-		if err := json.Unmarshal(rawVoucher.RawPaymentMethods, &rawVoucher.PaymentMethods); err != nil {
-			r.log.Error("Error unmarshalling payment methods", zap.Error(err))
-			return nil, fmt.Errorf("failed to parse payment_methods: %w", err)
-		}
-	}
-
-	if len(rawVoucher.RawApplicableAreas) > 0 {
-		if err := json.Unmarshal(rawVoucher.RawApplicableAreas, &rawVoucher.ApplicableAreas); err != nil {
-			r.log.Error("Error unmarshalling applicable areas", zap.Error(err))
-			return nil, fmt.Errorf("failed to parse applicable_areas: %w", err)
-		}
-	}
-
-	r.log.Info("Valid voucher found", zap.String("voucherCode", voucherCode))
-	return &rawVoucher.Voucher, nil
-}
-
-func (r *voucherRepository) UpdateVoucherQuota(voucherID int, quota int) error {
-	r.log.Info("Updating voucher quota", zap.Int("voucherID", voucherID), zap.Int("quota", quota))
-	err := r.DB.Model(&models.Voucher{}).Where("id = ?", voucherID).Update("quota", quota).Error
-	if err != nil {
-		r.log.Error("Error updating voucher quota", zap.Int("voucherID", voucherID), zap.Error(err))
-		return err
-	}
-	r.log.Info("Voucher quota updated", zap.Int("voucherID", voucherID), zap.Int("newQuota", quota))
-	return nil
-}
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"voucher_system/helper"
+	"voucher_system/models"
+	"voucher_system/repository/tier"
+	"voucher_system/utils/money"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrQuotaExceeded = errors.New("voucher quota exceeded")
+
+// BenefitFunc computes the benefit a validated voucher gives on the transaction.
+type BenefitFunc func(voucher *models.Voucher) (money.Amount, error)
+
+type VoucherRepository interface {
+	FindAll(userID int, voucherType string) ([]*models.Voucher, error)
+	FindValidVoucher(userID int, voucherCode, area string, transactionAmount, shippingAmount money.Amount, currency money.Currency, paymentMethod string, transactionDate time.Time) (*models.Voucher, error)
+	UseVoucher(userID int, voucherCode, orderID, area string, transactionAmount money.Amount, currency money.Currency, paymentMethod string, usedAt time.Time, benefit BenefitFunc) (*models.History, error)
+}
+
+type voucherRepository struct {
+	DB  *gorm.DB
+	log *zap.Logger
+}
+
+func NewVoucherRepository(db *gorm.DB, log *zap.Logger) VoucherRepository {
+	return &voucherRepository{DB: db, log: log}
+}
+
+func (r *voucherRepository) FindAll(userID int, voucherType string) ([]*models.Voucher, error) {
+	r.log.Info("Fetching all vouchers", zap.Int("userID", userID), zap.String("voucherType", voucherType))
+	var rawVouchers []struct {
+		models.Voucher
+		RawPaymentMethods  []byte `gorm:"column:payment_methods"`
+		RawApplicableAreas []byte `gorm:"column:applicable_areas"`
+	}
+
+	query := r.DB.
+		Table("vouchers").
+		Select(`vouchers.*, vouchers.payment_methods AS raw_payment_methods, vouchers.applicable_areas AS raw_applicable_areas`).
+		Where("EXISTS (SELECT 1 FROM redeems WHERE redeems.voucher_id = vouchers.id AND redeems.user_id = ?) AND vouchers.status = ? AND vouchers.state = ?", userID, true, models.VoucherLive)
+
+	if voucherType != "" {
+		query = query.Where("vouchers.voucher_type = ?", voucherType)
+	}
+
+	err := query.Find(&rawVouchers).Error
+	if err != nil {
+		r.log.Error("Error fetching vouchers", zap.Error(err))
+		return nil, err
+	}
+
+	r.log.Info("Fetched vouchers successfully", zap.Int("voucherCount", len(rawVouchers)))
+	vouchers := make([]*models.Voucher, 0, len(rawVouchers))
+	for _, rawVoucher := range rawVouchers {
+		v := rawVoucher.Voucher
+
+		if len(rawVoucher.RawPaymentMethods) > 0 {
+			if err := json.Unmarshal(rawVoucher.RawPaymentMethods, &v.PaymentMethods); err != nil {
+				r.log.Error("Error unmarshalling payment methods", zap.Error(err))
+				return nil, err
+			}
+		}
+
+		if len(rawVoucher.RawApplicableAreas) > 0 {
+			if err := json.Unmarshal(rawVoucher.RawApplicableAreas, &v.ApplicableAreas); err != nil {
+				r.log.Error("Error unmarshalling applicable areas", zap.Error(err))
+				return nil, err
+			}
+		}
+		vouchers = append(vouchers, &v)
+
+	}
+
+	return vouchers, nil
+}
+
+func (r *voucherRepository) FindValidVoucher(userID int, voucherCode, area string, transactionAmount, shippingAmount money.Amount, currency money.Currency, paymentMethod string, transactionDate time.Time) (*models.Voucher, error) {
+	return r.findValidVoucher(r.DB, false, userID, voucherCode, area, transactionAmount, currency, paymentMethod, transactionDate)
+}
+
+// UseVoucher validates the voucher, records the usage and takes one unit of quota in a
+// single transaction. The voucher row stays locked from the validation until the commit
+// and the quota is only decremented while it is above zero, so concurrent uses of the
+// last unit cannot both succeed. A generated code assigned to the user is marked as used
+// along with it. benefit computes the BenefitValue of the validated voucher.
+func (r *voucherRepository) UseVoucher(userID int, voucherCode, orderID, area string, transactionAmount money.Amount, currency money.Currency, paymentMethod string, usedAt time.Time, benefit BenefitFunc) (*models.History, error) {
+	var history *models.History
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		voucher, err := r.findValidVoucher(tx, true, userID, voucherCode, area, transactionAmount, currency, paymentMethod, usedAt)
+		if err != nil {
+			return err
+		}
+
+		benefitValue, err := benefit(voucher)
+		if err != nil {
+			return err
+		}
+
+		history = &models.History{
+			UserID:            userID,
+			VoucherID:         voucher.ID,
+			TransactionAmount: transactionAmount,
+			BenefitValue:      benefitValue,
+			Currency:          currency.OrDefault(),
+			UsageDate:         usedAt,
+			OrderID:           orderID,
+			VoucherVersion:    voucher.Version,
+		}
+		if err := tx.Create(history).Error; err != nil {
+			return fmt.Errorf("failed to record voucher usage: %w", err)
+		}
+		if voucher.VoucherCode != voucherCode {
+			if err := useCode(tx, userID, voucherCode, history.ID, usedAt); err != nil {
+				return err
+			}
+		}
+
+		return takeQuota(tx, voucher.ID)
+	})
+	if err != nil {
+		r.log.Error("Error using voucher", zap.String("voucherCode", voucherCode), zap.Int("userID", userID), zap.Error(err))
+		return nil, err
+	}
+
+	r.log.Info("Voucher used", zap.String("voucherCode", voucherCode), zap.Int("historyID", history.ID))
+	return history, nil
+}
+
+// findValidVoucher looks up voucherCode for userID through db and checks it against the
+// transaction. With lock set the voucher row is locked until db's transaction ends.
+func (r *voucherRepository) findValidVoucher(db *gorm.DB, lock bool, userID int, voucherCode, area string, transactionAmount money.Amount, currency money.Currency, paymentMethod string, transactionDate time.Time) (*models.Voucher, error) {
+	r.log.Info("Finding valid voucher", zap.Int("userID", userID), zap.String("voucherCode", voucherCode), zap.String("area", area))
+
+	var rawVoucher struct {
+		models.Voucher
+		RawPaymentMethods  []byte `gorm:"column:payment_methods"`
+		RawApplicableAreas []byte `gorm:"column:applicable_areas"`
+	}
+
+	query := db.Table("vouchers").
+		Select(`vouchers.*`).
+		Joins("JOIN redeems ON redeems.voucher_id = vouchers.id").
+		Where(`
+			redeems.user_id = ? AND
+			vouchers.voucher_code = ? AND 
+			vouchers.state = ? AND
+			quota > 0`,
+			userID, voucherCode, models.VoucherLive)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "vouchers"}})
+	}
+	err := query.First(&rawVoucher).Error
+	if err == gorm.ErrRecordNotFound {
+		err = assignedCodeQuery(db, lock, userID, voucherCode).First(&rawVoucher).Error
+	}
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("voucher not found")
+		}
+		r.log.Error("Error finding valid voucher", zap.String("voucherCode", voucherCode), zap.Error(err))
+		return nil, err
+	}
+
+	if voucherCurrency := rawVoucher.Voucher.Currency.OrDefault(); voucherCurrency != currency.OrDefault() {
+		return nil, fmt.Errorf("voucher is only valid for %s transactions", voucherCurrency)
+	}
+
+	if transactionAmount.LessThan(rawVoucher.Voucher.MinimumPurchase) {
+		return nil, fmt.Errorf("transaction amount must be at least %s", rawVoucher.Voucher.MinimumPurchase.StringFixed(2))
+	}
+
+	if len(rawVoucher.RawApplicableAreas) > 0 {
+		var areas []string
+		if err := json.Unmarshal(rawVoucher.RawApplicableAreas, &areas); err != nil {
+			r.log.Error("Error unmarshalling applicable areas", zap.Error(err))
+			return nil, fmt.Errorf("failed to parse applicable_areas: %w", err)
+		}
+		if !helper.Contains(areas, area) {
+			return nil, fmt.Errorf("area not found")
+		}
+	}
+
+	if len(rawVoucher.RawPaymentMethods) > 0 {
+		var paymentMethods []string
+		if err := json.Unmarshal(rawVoucher.RawPaymentMethods, &paymentMethods); err != nil {
+			r.log.Error("Error unmarshalling payment methods", zap.Error(err))
+			return nil, fmt.Errorf("failed to parse payment_methods: %w", err)
+		}
+		if !helper.Contains(paymentMethods, paymentMethod) {
+			return nil, fmt.Errorf("payment method not found")
+		}
+	}
+
+	if rawVoucher.Voucher.MinimumTier != "" {
+		userTier, err := tier.UserTier(db, userID)
+		if err != nil {
+			r.log.Error("Error fetching user tier", zap.Int("userID", userID), zap.Error(err))
+			return nil, err
+		}
+		eligible, err := tier.Meets(db, userTier, rawVoucher.Voucher.MinimumTier)
+		if err != nil {
+			r.log.Error("Error checking user tier", zap.Int("userID", userID), zap.Error(err))
+			return nil, err
+		}
+		if !eligible {
+			return nil, fmt.Errorf("voucher requires the %s tier", rawVoucher.Voucher.MinimumTier)
+		}
+	}
+
+	if transactionDate.Before(rawVoucher.Voucher.StartDate) {
+		return nil, fmt.Errorf("voucher not available yet")
+	}
+	if transactionDate.After(rawVoucher.Voucher.EndDate) {
+		return nil, fmt.Errorf("voucher expired")
+	}
+
+	if rawVoucher.Voucher.HasUsageLimits() {
+		counts, err := usageCounts(db, userID, rawVoucher.Voucher.ID, transactionDate)
+		if err != nil {
+			r.log.Error("Error counting voucher usage", zap.Int("userID", userID), zap.Error(err))
+			return nil, err
+		}
+		if err := rawVoucher.Voucher.CheckUsageLimits(counts); err != nil {
+			return nil, err
+		}
+		rawVoucher.Voucher.RemainingUses = rawVoucher.Voucher.RemainingUsesFor(counts)
+	}
+
+	if len(rawVoucher.RawPaymentMethods) > 0 {
+		if err := json.Unmarshal(rawVoucher.RawPaymentMethods, &rawVoucher.PaymentMethods); err != nil {
+			r.log.Error("Error unmarshalling payment methods", zap.Error(err))
+			return nil, fmt.Errorf("failed to parse payment_methods: %w", err)
+		}
+	}
+
+	if len(rawVoucher.RawApplicableAreas) > 0 {
+		if err := json.Unmarshal(rawVoucher.RawApplicableAreas, &rawVoucher.ApplicableAreas); err != nil {
+			r.log.Error("Error unmarshalling applicable areas", zap.Error(err))
+			return nil, fmt.Errorf("failed to parse applicable_areas: %w", err)
+		}
+	}
+
+	r.log.Info("Valid voucher found", zap.String("voucherCode", voucherCode))
+	return &rawVoucher.Voucher, nil
+}
+
+// assignedCodeQuery looks up the voucher of a generated code that is assigned to userID.
+// Such codes stand in for the voucher's own code and do not need a redemption.
+func assignedCodeQuery(db *gorm.DB, lock bool, userID int, code string) *gorm.DB {
+	query := db.Table("vouchers").
+		Select(`vouchers.*`).
+		Joins("JOIN voucher_codes ON voucher_codes.voucher_id = vouchers.id").
+		Where(`
+			voucher_codes.user_id = ? AND
+			voucher_codes.code = ? AND
+			voucher_codes.state = ? AND
+			vouchers.state = ? AND
+			quota > 0`,
+			userID, code, models.CodeAssigned, models.VoucherLive)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "vouchers"}})
+	}
+	return query
+}
+
+// useCode marks the generated code as used by the usage recorded in historyID. It fails
+// when the code was used by a concurrent request in the meantime.
+func useCode(tx *gorm.DB, userID int, code string, historyID int, usedAt time.Time) error {
+	result := tx.Model(&models.VoucherCode{}).
+		Where("code = ? AND user_id = ? AND state = ?", code, userID, models.CodeAssigned).
+		UpdateColumns(map[string]interface{}{"state": models.CodeUsed, "history_id": historyID, "used_at": usedAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrCodeUsed
+	}
+	return nil
+}
+
+// usageCounts counts the usages of voucherID by userID that were not reversed, in total
+// and since the start of the day and of the week (starting Monday) at. Held reservations
+// count as usages so a user cannot get around the limits by reserving.
+func usageCounts(db *gorm.DB, userID, voucherID int, at time.Time) (models.UsageCounts, error) {
+	dayStart := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	weekStart := dayStart.AddDate(0, 0, -((int(dayStart.Weekday()) + 6) % 7))
+
+	var counts models.UsageCounts
+	err := db.Raw(`
+		SELECT COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE used_at >= ?) AS today,
+			COUNT(*) FILTER (WHERE used_at >= ?) AS this_week
+		FROM (
+			SELECT usage_date AS used_at FROM histories
+			WHERE user_id = ? AND voucher_id = ? AND reversed_at IS NULL
+			UNION ALL
+			SELECT created_at AS used_at FROM voucher_reservations
+			WHERE user_id = ? AND voucher_id = ? AND status = ?
+		) AS uses`,
+		dayStart, weekStart, userID, voucherID, userID, voucherID, models.ReservationHeld).
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,97 @@
+package repository_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+	"voucher_system/database"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/utils/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestVoucherRepository_UseVoucher_Concurrent races several uses of a voucher with one
+// unit of quota left. It needs a real Postgres database, e.g.
+// TEST_DATABASE_DSN="host=localhost user=postgres password=postgres dbname=voucher_test sslmode=disable".
+func TestVoucherRepository_UseVoucher_Concurrent(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(db))
+
+	suffix := time.Now().UnixNano()
+	user := models.User{Name: "Concurrent", Email: fmt.Sprintf("concurrent-%d@example.com", suffix), Password: "password123"}
+	require.NoError(t, db.Create(&user).Error)
+	voucher := models.Voucher{
+		VoucherName:     "Last One",
+		VoucherCode:     fmt.Sprintf("LAST%d", suffix),
+		VoucherType:     "e-commerce",
+		Description:     "Only one use left",
+		VoucherCategory: "Discount",
+		DiscountType:    models.DiscountPercentage,
+		DiscountValue:   money.New(10),
+		StartDate:       time.Now().Add(-time.Hour),
+		EndDate:         time.Now().Add(time.Hour),
+		Quota:           1,
+		Status:          true,
+	}
+	require.NoError(t, db.Create(&voucher).Error)
+	require.NoError(t, db.Create(&models.Redeem{UserID: user.ID, VoucherID: voucher.ID}).Error)
+	t.Cleanup(func() {
+		db.Where("voucher_id = ?", voucher.ID).Delete(&models.History{})
+		db.Where("voucher_id = ?", voucher.ID).Delete(&models.Redeem{})
+		db.Unscoped().Delete(&voucher)
+		db.Unscoped().Delete(&user)
+	})
+
+	repo := repository.NewVoucherRepository(db, zap.NewNop())
+	benefit := func(*models.Voucher) (money.Amount, error) { return money.New(10), nil }
+
+	const attempts = 10
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.UseVoucher(user.ID, voucher.VoucherCode, "", "", money.New(100), money.IDR, "", time.Now(), benefit)
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+				return
+			}
+			if !errors.Is(err, repository.ErrQuotaExceeded) {
+				assert.EqualError(t, err, "voucher not found")
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, succeeded)
+
+	var quota int
+	require.NoError(t, db.Model(&models.Voucher{}).Where("id = ?", voucher.ID).Pluck("quota", &quota).Error)
+	assert.Equal(t, 0, quota)
+
+	var uses int64
+	require.NoError(t, db.Model(&models.History{}).Where("voucher_id = ?", voucher.ID).Count(&uses).Error)
+	assert.Equal(t, int64(1), uses)
+}
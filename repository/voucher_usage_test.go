@@ -1,183 +1,348 @@
-package repository_test
-
-import (
-	"testing"
-	"time"
-	"voucher_system/repository"
-
-	"github.com/DATA-DOG/go-sqlmock"
-	"github.com/stretchr/testify/assert"
-	"go.uber.org/zap"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
-)
-
-func SetupTestDB() (*gorm.DB, sqlmock.Sqlmock) {
-	db, mock, _ := sqlmock.New()
-	dialector := postgres.New(postgres.Config{
-		Conn:       db,
-		DriverName: "postgres",
-	})
-	gormDB, _ := gorm.Open(dialector, &gorm.Config{})
-	return gormDB, mock
-}
-
-func TestVoucherRepository_FindAll(t *testing.T) {
-	db, mock := SetupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-
-	logger := zap.NewNop()
-	repo := repository.NewVoucherRepository(db, logger)
-
-	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
-		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now(), time.Now().Add(24*time.Hour), 50.0, `["credit"]`, `["area1"]`)
-
-	mock.ExpectQuery("SELECT vouchers.*, vouchers.payment_methods AS raw_payment_methods, vouchers.applicable_areas AS raw_applicable_areas").
-		WillReturnRows(rows)
-
-	vouchers, err := repo.FindAll(1, "e-commerce")
-
-	assert.NoError(t, err)
-	assert.Len(t, vouchers, 1)
-	assert.Equal(t, "VOUCHER1", vouchers[0].VoucherCode)
-}
-
-func TestVoucherRepository_FindValidVoucher_VoucherNotFound(t *testing.T) {
-	db, mock := SetupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-
-	logger := zap.NewNop()
-	repo := repository.NewVoucherRepository(db, logger)
-
-	mock.ExpectQuery("SELECT vouchers.*").
-		WillReturnError(gorm.ErrRecordNotFound)
-
-	voucher, err := repo.FindValidVoucher(1, "INVALIDCODE", "area1", 100.0, 10.0, "credit", time.Now())
-
-	assert.Error(t, err)
-	assert.Nil(t, voucher)
-	assert.Equal(t, "voucher not found", err.Error())
-}
-
-func TestVoucherRepository_FindValidVoucher_MinimumPurchaseNotMet(t *testing.T) {
-	db, mock := SetupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-
-	logger := zap.NewNop()
-	repo := repository.NewVoucherRepository(db, logger)
-
-	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
-		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now(), time.Now().Add(24*time.Hour), 50.0, `["credit"]`, `["area1"]`)
-
-	mock.ExpectQuery("SELECT vouchers.*").
-		WillReturnRows(rows)
-
-	voucher, err := repo.FindValidVoucher(1, "VOUCHER1", "area1", 40.0, 10.0, "credit", time.Now())
-
-	assert.Error(t, err)
-	assert.Nil(t, voucher)
-	assert.Equal(t, "transaction amount must be at least 50.00", err.Error())
-}
-
-func TestVoucherRepository_FindValidVoucher_AreaNotApplicable(t *testing.T) {
-	db, mock := SetupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-
-	logger := zap.NewNop()
-	repo := repository.NewVoucherRepository(db, logger)
-
-	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
-		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now(), time.Now().Add(24*time.Hour), 50.0, `["credit"]`, `["area2"]`)
-
-	mock.ExpectQuery("SELECT vouchers.*").
-		WillReturnRows(rows)
-
-	voucher, err := repo.FindValidVoucher(1, "VOUCHER1", "area1", 100.0, 10.0, "credit", time.Now())
-
-	assert.Error(t, err)
-	assert.Nil(t, voucher)
-	assert.Equal(t, "area not found", err.Error())
-}
-
-func TestVoucherRepository_FindValidVoucher_PaymentMethodNotApplicable(t *testing.T) {
-	db, mock := SetupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-
-	logger := zap.NewNop()
-	repo := repository.NewVoucherRepository(db, logger)
-
-	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
-		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now(), time.Now().Add(24*time.Hour), 50.0, `["credit"]`, `["area1"]`)
-
-	mock.ExpectQuery("SELECT vouchers.*").
-		WillReturnRows(rows)
-
-	voucher, err := repo.FindValidVoucher(1, "VOUCHER1", "area1", 100.0, 10.0, "debit", time.Now())
-
-	assert.Error(t, err)
-	assert.Nil(t, voucher)
-	assert.Equal(t, "payment method not found", err.Error())
-}
-
-func TestVoucherRepository_FindValidVoucher_VoucherExpired(t *testing.T) {
-	db, mock := SetupTestDB()
-	defer func() { _ = mock.ExpectationsWereMet() }()
-
-	logger := zap.NewNop()
-	repo := repository.NewVoucherRepository(db, logger)
-
-	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
-		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour), 50.0, `["credit"]`, `["area1"]`)
-
-	mock.ExpectQuery("SELECT vouchers.*").
-		WillReturnRows(rows)
-
-	voucher, err := repo.FindValidVoucher(1, "VOUCHER1", "area1", 100.0, 10.0, "credit", time.Now())
-
-	assert.Error(t, err)
-	assert.Nil(t, voucher)
-	assert.Equal(t, "voucher expired", err.Error())
-}
-
-// func TestVoucherRepository_UpdateVoucherQuota(t *testing.T) {
-//     db, mock := SetupTestDB()
-//     defer func() { _ = mock.ExpectationsWereMet() }()
-
-//     logger := zap.NewNop()
-//     repo := repository.NewVoucherRepository(db, logger)
-
-//     voucherID := 1
-//     newQuota := 50
-
-//     mock.ExpectBegin() // Expect transaction to start
-//     mock.ExpectExec("UPDATE \"vouchers\" SET \"quota\"=$1, \"updated_at\"=$2 WHERE \"id\" = $3 AND \"vouchers\".\"deleted_at\" IS NULL").
-//         WithArgs(newQuota, sqlmock.AnyArg(), voucherID).
-//         WillReturnResult(sqlmock.NewResult(1, 1)) // Simulate successful update
-//     mock.ExpectCommit() // Expect transaction commit
-
-//     err := repo.UpdateVoucherQuota(voucherID, newQuota)
-
-//     assert.NoError(t, err)
-// }
-
-// func TestVoucherRepository_UpdateVoucherQuota_Failed(t *testing.T) {
-//     db, mock := SetupTestDB()
-//     defer func() { _ = mock.ExpectationsWereMet() }()
-
-//     logger := zap.NewNop()
-//     repo := repository.NewVoucherRepository(db, logger)
-
-//     voucherID := 1
-//     newQuota := 50
-
-//     mock.ExpectBegin() // Expect transaction to start
-//     mock.ExpectExec("UPDATE \"vouchers\" SET \"quota\"=$1, \"updated_at\"=$2 WHERE \"id\" = $3 AND \"vouchers\".\"deleted_at\" IS NULL").
-//         WithArgs(newQuota, sqlmock.AnyArg(), voucherID).
-//         WillReturnError(fmt.Errorf("update failed")) // Simulate failure
-//     mock.ExpectRollback() // Expect rollback after failure
-
-//     err := repo.UpdateVoucherQuota(voucherID, newQuota)
-
-//     assert.Error(t, err)
-//     assert.Equal(t, "update failed", err.Error()) // Ensure the error is returned
-// }
+package repository_test
+
+import (
+	"testing"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/utils/money"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func SetupTestDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, _ := sqlmock.New()
+	dialector := postgres.New(postgres.Config{
+		Conn:       db,
+		DriverName: "postgres",
+	})
+	gormDB, _ := gorm.Open(dialector, &gorm.Config{})
+	return gormDB, mock
+}
+
+func TestVoucherRepository_FindAll(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
+		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now(), time.Now().Add(24*time.Hour), 50.0, `["credit"]`, `["area1"]`)
+
+	mock.ExpectQuery("SELECT vouchers.*, vouchers.payment_methods AS raw_payment_methods, vouchers.applicable_areas AS raw_applicable_areas").
+		WillReturnRows(rows)
+
+	vouchers, err := repo.FindAll(1, "e-commerce")
+
+	assert.NoError(t, err)
+	assert.Len(t, vouchers, 1)
+	assert.Equal(t, "VOUCHER1", vouchers[0].VoucherCode)
+}
+
+func TestVoucherRepository_FindValidVoucher_VoucherNotFound(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+
+	mock.ExpectQuery("SELECT vouchers.* FROM \"vouchers\" JOIN redeems").
+		WillReturnError(gorm.ErrRecordNotFound)
+	mock.ExpectQuery("SELECT vouchers.* FROM \"vouchers\" JOIN voucher_codes").
+		WillReturnError(gorm.ErrRecordNotFound)
+
+	voucher, err := repo.FindValidVoucher(1, "INVALIDCODE", "area1", money.New(100), money.New(10), money.IDR, "credit", time.Now())
+
+	assert.Error(t, err)
+	assert.Nil(t, voucher)
+	assert.Equal(t, "voucher not found", err.Error())
+}
+
+func TestVoucherRepository_FindValidVoucher_MinimumPurchaseNotMet(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
+		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now(), time.Now().Add(24*time.Hour), 50.0, `["credit"]`, `["area1"]`)
+
+	mock.ExpectQuery("SELECT vouchers.*").
+		WillReturnRows(rows)
+
+	voucher, err := repo.FindValidVoucher(1, "VOUCHER1", "area1", money.New(40), money.New(10), money.IDR, "credit", time.Now())
+
+	assert.Error(t, err)
+	assert.Nil(t, voucher)
+	assert.Equal(t, "transaction amount must be at least 50.00", err.Error())
+}
+
+func TestVoucherRepository_FindValidVoucher_CurrencyMismatch(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "currency", "payment_methods", "applicable_areas"}).
+		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now(), time.Now().Add(24*time.Hour), 50.0, "SGD", `["credit"]`, `["area1"]`)
+
+	mock.ExpectQuery("SELECT vouchers.*").
+		WillReturnRows(rows)
+
+	voucher, err := repo.FindValidVoucher(1, "VOUCHER1", "area1", money.New(100), money.New(10), money.IDR, "credit", time.Now())
+
+	assert.Error(t, err)
+	assert.Nil(t, voucher)
+	assert.Equal(t, "voucher is only valid for SGD transactions", err.Error())
+}
+
+func TestVoucherRepository_FindValidVoucher_AreaNotApplicable(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
+		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now(), time.Now().Add(24*time.Hour), 50.0, `["credit"]`, `["area2"]`)
+
+	mock.ExpectQuery("SELECT vouchers.*").
+		WillReturnRows(rows)
+
+	voucher, err := repo.FindValidVoucher(1, "VOUCHER1", "area1", money.New(100), money.New(10), money.IDR, "credit", time.Now())
+
+	assert.Error(t, err)
+	assert.Nil(t, voucher)
+	assert.Equal(t, "area not found", err.Error())
+}
+
+func TestVoucherRepository_FindValidVoucher_PaymentMethodNotApplicable(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
+		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now(), time.Now().Add(24*time.Hour), 50.0, `["credit"]`, `["area1"]`)
+
+	mock.ExpectQuery("SELECT vouchers.*").
+		WillReturnRows(rows)
+
+	voucher, err := repo.FindValidVoucher(1, "VOUCHER1", "area1", money.New(100), money.New(10), money.IDR, "debit", time.Now())
+
+	assert.Error(t, err)
+	assert.Nil(t, voucher)
+	assert.Equal(t, "payment method not found", err.Error())
+}
+
+func TestVoucherRepository_FindValidVoucher_VoucherExpired(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
+		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour), 50.0, `["credit"]`, `["area1"]`)
+
+	mock.ExpectQuery("SELECT vouchers.*").
+		WillReturnRows(rows)
+
+	voucher, err := repo.FindValidVoucher(1, "VOUCHER1", "area1", money.New(100), money.New(10), money.IDR, "credit", time.Now())
+
+	assert.Error(t, err)
+	assert.Nil(t, voucher)
+	assert.Equal(t, "voucher expired", err.Error())
+}
+
+func TestVoucherRepository_FindValidVoucher_DailyLimitReached(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas", "max_uses_per_user", "max_uses_per_user_per_day"}).
+		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 50.0, `["credit"]`, `["area1"]`, 5, 1)
+
+	mock.ExpectQuery("SELECT vouchers.*").
+		WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS total`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 1, 1, 1, models.ReservationHeld).
+		WillReturnRows(sqlmock.NewRows([]string{"total", "today", "this_week"}).AddRow(2, 1, 2))
+
+	voucher, err := repo.FindValidVoucher(1, "VOUCHER1", "area1", money.New(100), money.New(10), money.IDR, "credit", time.Now())
+
+	var limitErr *models.LimitError
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, models.LimitUsesPerUserPerDay, limitErr.Code)
+	assert.Nil(t, voucher)
+}
+
+func TestVoucherRepository_FindValidVoucher_RemainingUses(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { _ = mock.ExpectationsWereMet() }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas", "max_uses_per_user", "max_uses_per_user_per_week"}).
+		AddRow(1, "VOUCHER1", "e-commerce", 100, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 50.0, `["credit"]`, `["area1"]`, 5, 3)
+
+	mock.ExpectQuery("SELECT vouchers.*").
+		WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS total`).
+		WillReturnRows(sqlmock.NewRows([]string{"total", "today", "this_week"}).AddRow(2, 0, 2))
+
+	voucher, err := repo.FindValidVoucher(1, "VOUCHER1", "area1", money.New(100), money.New(10), money.IDR, "credit", time.Now())
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, voucher.RemainingUses) {
+		assert.Equal(t, 1, *voucher.RemainingUses)
+	}
+}
+
+func TestVoucherRepository_UseVoucher(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas", "version"}).
+		AddRow(1, "VOUCHER1", "e-commerce", 1, time.Now().Add(-24*time.Hour), time.Now().Add(24*time.Hour), 50.0, `["credit"]`, `["area1"]`, 3)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers" JOIN redeems .* FOR UPDATE OF "vouchers"`).
+		WillReturnRows(rows)
+	mock.ExpectQuery(`INSERT INTO "histories"`).
+		WithArgs(1, 1, "100", "10", "IDR", "INV-1", nil, "", false, 3, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "usage_date"}).AddRow(7, time.Now()))
+	mock.ExpectExec(`UPDATE "vouchers" SET "quota"=quota - 1,"updated_at"=\$1 WHERE \(id = \$2 AND quota > 0\)`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	history, err := repo.UseVoucher(1, "VOUCHER1", "INV-1", "area1", money.New(100), money.IDR, "credit", time.Now(), func(voucher *models.Voucher) (money.Amount, error) {
+		return money.New(10), nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, history.ID)
+	assert.Equal(t, money.New(10), history.BenefitValue)
+	assert.Equal(t, 3, history.VoucherVersion)
+}
+
+func TestVoucherRepository_UseVoucher_GeneratedCode(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+	usedAt := time.Now()
+
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas", "version"}).
+			AddRow(1, "SUMMER", "e-commerce", 100, usedAt.Add(-24*time.Hour), usedAt.Add(24*time.Hour), 50.0, `["credit"]`, `["area1"]`, 3)
+	}
+	expectLookup := func() {
+		mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers" JOIN redeems .* FOR UPDATE OF "vouchers"`).
+			WillReturnError(gorm.ErrRecordNotFound)
+		mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers" JOIN voucher_codes .* FOR UPDATE OF "vouchers"`).
+			WithArgs(1, "SUMMER-7KX9QD4M", models.CodeAssigned, models.VoucherLive, 1).
+			WillReturnRows(rows())
+		mock.ExpectQuery(`INSERT INTO "histories"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "usage_date"}).AddRow(7, usedAt))
+	}
+	benefit := func(voucher *models.Voucher) (money.Amount, error) {
+		return money.New(10), nil
+	}
+
+	t.Run("Code is marked as used", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLookup()
+		mock.ExpectExec(`UPDATE "voucher_codes" SET "history_id"=\$1,"state"=\$2,"used_at"=\$3 WHERE code = \$4 AND user_id = \$5 AND state = \$6`).
+			WithArgs(7, models.CodeUsed, usedAt, "SUMMER-7KX9QD4M", 1, models.CodeAssigned).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE "vouchers" SET "quota"=quota - 1`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		history, err := repo.UseVoucher(1, "SUMMER-7KX9QD4M", "INV-1", "area1", money.New(100), money.IDR, "credit", usedAt, benefit)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 7, history.ID)
+	})
+
+	t.Run("Code used concurrently", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLookup()
+		mock.ExpectExec(`UPDATE "voucher_codes"`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		history, err := repo.UseVoucher(1, "SUMMER-7KX9QD4M", "INV-1", "area1", money.New(100), money.IDR, "credit", usedAt, benefit)
+
+		assert.ErrorIs(t, err, models.ErrCodeUsed)
+		assert.Nil(t, history)
+	})
+}
+
+func TestVoucherRepository_UseVoucher_QuotaExceeded(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+
+	rows := sqlmock.NewRows([]string{"id", "voucher_code", "voucher_type", "quota", "start_date", "end_date", "minimum_purchase", "payment_methods", "applicable_areas"}).
+		AddRow(1, "VOUCHER1", "e-commerce", 1, time.Now().Add(-24*time.Hour), time.Now().Add(24*time.Hour), 50.0, `["credit"]`, `["area1"]`)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers"`).
+		WillReturnRows(rows)
+	mock.ExpectQuery(`INSERT INTO "histories"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "usage_date"}).AddRow(7, time.Now()))
+	mock.ExpectExec(`UPDATE "vouchers" SET "quota"=quota - 1`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	history, err := repo.UseVoucher(1, "VOUCHER1", "INV-1", "area1", money.New(100), money.IDR, "credit", time.Now(), func(voucher *models.Voucher) (money.Amount, error) {
+		return money.New(10), nil
+	})
+
+	assert.ErrorIs(t, err, repository.ErrQuotaExceeded)
+	assert.Nil(t, history)
+}
+
+func TestVoucherRepository_UseVoucher_InvalidVoucher(t *testing.T) {
+	db, mock := SetupTestDB()
+	defer func() { assert.NoError(t, mock.ExpectationsWereMet()) }()
+
+	logger := zap.NewNop()
+	repo := repository.NewVoucherRepository(db, logger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers" JOIN redeems`).
+		WillReturnError(gorm.ErrRecordNotFound)
+	mock.ExpectQuery(`SELECT vouchers.\* FROM "vouchers" JOIN voucher_codes`).
+		WithArgs(1, "INVALIDCODE", models.CodeAssigned, models.VoucherLive, 1).
+		WillReturnError(gorm.ErrRecordNotFound)
+	mock.ExpectRollback()
+
+	history, err := repo.UseVoucher(1, "INVALIDCODE", "", "area1", money.New(100), money.IDR, "credit", time.Now(), func(voucher *models.Voucher) (money.Amount, error) {
+		t.Fatal("benefit must not be calculated for an invalid voucher")
+		return money.Amount{}, nil
+	})
+
+	assert.EqualError(t, err, "voucher not found")
+	assert.Nil(t, history)
+}
@@ -1,45 +1,112 @@
-package router
-
-import (
-	"voucher_system/infra"
-
-	"github.com/gin-gonic/gin"
-
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
-)
-
-func NewRoutes(ctx infra.ServiceContext) *gin.Engine {
-	r := gin.Default()
-
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	// authMiddleware := ctx.Middleware.Authentication()
-	jwtMiddleware := ctx.Middleware.JWTMiddleware()
-	rateLimter := ctx.Middleware.RateLimiter()
-
-	// allowedIPs := []string{"127.0.0.1", "192.168.1.100"}
-	// r.Use(ctx.Middleware.IPWhitelistMiddleware(allowedIPs))
-	
-	r.POST("/login", rateLimter, ctx.Ctl.User.Login)
-	r.POST("/register", ctx.Ctl.User.Register)
-	
-	router := r.Group("/vouchers", jwtMiddleware)
-	{
-		router.POST("/create", ctx.Ctl.Manage.CreateVoucher)
-		router.DELETE("/:id", ctx.Ctl.Manage.SoftDeleteVoucher)
-		router.PUT("/:id", ctx.Ctl.Manage.UpdateVoucher)
-		router.GET("/redeem-points", ctx.Ctl.Manage.ShowRedeemPoints)
-		router.GET("/", ctx.Ctl.Manage.GetVouchersByQueryParams)
-		router.POST("/redeem", ctx.Ctl.Manage.CreateRedeemVoucher)
-		router.GET("/:user_id", ctx.Ctl.Voucher.FindVouchers)
-		router.GET("/:user_id/validate", ctx.Ctl.Voucher.ValidateVoucher)
-		router.POST("/", ctx.Ctl.Voucher.UseVoucher)
-		router.GET("/redeem-history/:user_id", ctx.Ctl.Voucher.GetRedeemHistoryByUser)
-		router.GET("/usage-history/:user_id", ctx.Ctl.Voucher.GetUsageHistoryByUser)
-		router.GET("/users-by-voucher/:voucher_code", ctx.Ctl.Voucher.GetUsersByVoucherCode)
-
-	}
-
-	return r
-}
+package router
+
+import (
+	"time"
+	"voucher_system/infra"
+	"voucher_system/middleware"
+	"voucher_system/models"
+
+	"github.com/gin-gonic/gin"
+
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+func NewRoutes(ctx infra.ServiceContext) *gin.Engine {
+	r := gin.Default()
+
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	authMiddleware := ctx.Middleware.Authenticator(middleware.AuthStateful)
+	stepUp := ctx.Middleware.RequireRecentAuth(time.Duration(ctx.Cfg.StepUpMaxAge) * time.Minute)
+	rateLimter := ctx.Middleware.RateLimiter()
+	idempotent := ctx.Middleware.Idempotency(time.Duration(ctx.Cfg.IdempotencyTTL) * time.Hour)
+
+	// allowedIPs := []string{"127.0.0.1", "192.168.1.100"}
+	// r.Use(ctx.Middleware.IPWhitelistMiddleware(allowedIPs))
+	
+	r.POST("/login", rateLimter, ctx.Ctl.User.Login)
+	r.POST("/register", ctx.Ctl.User.Register)
+	r.POST("/introspect", authMiddleware, ctx.Ctl.User.Introspect)
+	r.GET("/userinfo", authMiddleware, ctx.Ctl.User.UserInfo)
+	r.POST("/reauth", rateLimter, authMiddleware, ctx.Ctl.User.Reauthenticate)
+	r.GET("/tiers", authMiddleware, ctx.Ctl.Tier.ListTiers)
+
+	profile := r.Group("/users/me", authMiddleware)
+	{
+		profile.GET("", ctx.Ctl.User.UserInfo)
+		profile.PUT("", ctx.Ctl.User.UpdateProfile)
+		profile.DELETE("", ctx.Ctl.User.DeleteAccount)
+		profile.PUT("/password", ctx.Ctl.User.ChangePassword)
+		profile.POST("/email", ctx.Ctl.User.RequestEmailChange)
+		profile.POST("/email/verify", ctx.Ctl.User.VerifyEmailChange)
+		profile.GET("/tier", ctx.Ctl.Tier.GetMyTier)
+	}
+	
+	admin := r.Group("/admin", authMiddleware, ctx.Middleware.RequireRole(models.RoleAdmin))
+	{
+		admin.GET("/users", ctx.Ctl.Admin.SearchUsers)
+		admin.GET("/users/:id", ctx.Ctl.Admin.GetUser)
+		admin.PUT("/users/:id/status", stepUp, ctx.Ctl.Admin.SetUserStatus)
+		admin.PUT("/users/:id/role", stepUp, ctx.Ctl.Admin.SetUserRole)
+		admin.POST("/users/:id/reset-password", stepUp, ctx.Ctl.Admin.ResetUserPassword)
+		admin.POST("/users/:id/points", stepUp, ctx.Ctl.Admin.AdjustPoints)
+		admin.GET("/earning-rules", ctx.Ctl.Points.ListEarningRules)
+		admin.POST("/earning-rules", ctx.Ctl.Points.CreateEarningRule)
+		admin.PUT("/earning-rules/:id", ctx.Ctl.Points.UpdateEarningRule)
+		admin.DELETE("/earning-rules/:id", ctx.Ctl.Points.DeleteEarningRule)
+		admin.POST("/points/order-events", ctx.Ctl.Points.PostOrderEvent)
+		admin.POST("/tiers/recalculate", ctx.Ctl.Tier.RecalculateTiers)
+		admin.GET("/reports/usage", ctx.Ctl.Admin.GetUsageReport)
+		admin.POST("/usages/reverse", ctx.Ctl.Voucher.ReverseUsage)
+		admin.GET("/jobs", ctx.Ctl.Job.ListJobs)
+		admin.GET("/jobs/:name/runs", ctx.Ctl.Job.ListJobRuns)
+		admin.POST("/jobs/:name/trigger", ctx.Ctl.Job.TriggerJob)
+		admin.GET("/queue", ctx.Ctl.Job.GetQueueStats)
+		admin.GET("/queue/dead-letters", ctx.Ctl.Job.ListDeadLetters)
+		admin.POST("/queue/dead-letters/:id/requeue", ctx.Ctl.Job.RequeueDeadLetter)
+		admin.GET("/vouchers/:id/versions", ctx.Ctl.Manage.GetVoucherVersions)
+		admin.POST("/vouchers/:id/approve", ctx.Ctl.Manage.ApproveVoucher)
+		admin.POST("/vouchers/:id/reject", ctx.Ctl.Manage.RejectVoucher)
+		admin.POST("/vouchers/:id/pause", ctx.Ctl.Manage.PauseVoucher)
+		admin.POST("/vouchers/:id/resume", ctx.Ctl.Manage.ResumeVoucher)
+		admin.POST("/vouchers/:id/codes", ctx.Ctl.Manage.GenerateVoucherCodes)
+		admin.POST("/vouchers/:id/codes/assign", ctx.Ctl.Manage.AssignVoucherCode)
+		admin.GET("/vouchers/:id/codes/export", ctx.Ctl.Manage.ExportVoucherCodes)
+	}
+
+	points := r.Group("/points", authMiddleware)
+	{
+		points.GET("/balance", ctx.Ctl.Points.GetBalance)
+		points.GET("/ledger", ctx.Ctl.Points.GetLedger)
+		points.GET("/expiring", ctx.Ctl.Points.GetExpiringPoints)
+	}
+
+	manage := r.Group("/vouchers", authMiddleware, ctx.Middleware.RequireRole(models.RoleAdmin))
+	{
+		manage.POST("/create", ctx.Ctl.Manage.CreateVoucher)
+		manage.DELETE("/:id", stepUp, ctx.Ctl.Manage.SoftDeleteVoucher)
+		manage.PUT("/:id", ctx.Ctl.Manage.UpdateVoucher)
+		manage.POST("/:id/versions/:version/revert", ctx.Ctl.Manage.RevertVoucher)
+		manage.POST("/:id/submit", ctx.Ctl.Manage.SubmitVoucher)
+	}
+
+	router := r.Group("/vouchers", authMiddleware)
+	{
+		router.GET("/redeem-points", ctx.Ctl.Manage.ShowRedeemPoints)
+		router.GET("/", ctx.Ctl.Manage.GetVouchersByQueryParams)
+		router.POST("/redeem", idempotent, ctx.Ctl.Manage.CreateRedeemVoucher)
+		router.GET("/:user_id", ctx.Ctl.Voucher.FindVouchers)
+		router.GET("/:user_id/validate", ctx.Ctl.Voucher.ValidateVoucher)
+		router.POST("/", idempotent, ctx.Ctl.Voucher.UseVoucher)
+		router.POST("/reservations", idempotent, ctx.Ctl.Reservation.Reserve)
+		router.POST("/reservations/:id/confirm", idempotent, ctx.Ctl.Reservation.ConfirmReservation)
+		router.POST("/reservations/:id/release", ctx.Ctl.Reservation.ReleaseReservation)
+		router.GET("/redeem-history/:user_id", ctx.Ctl.Voucher.GetRedeemHistoryByUser)
+		router.GET("/usage-history/:user_id", ctx.Ctl.Voucher.GetUsageHistoryByUser)
+		router.GET("/users-by-voucher/:voucher_code", ctx.Ctl.Voucher.GetUsersByVoucherCode)
+
+	}
+
+	return r
+}
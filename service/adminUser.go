@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/utils"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidRole    = errors.New("role must be customer or admin")
+	ErrSelfManagement = errors.New("admins cannot change their own role or status")
+)
+
+type UserPage struct {
+	Users []models.User `json:"users"`
+	Total int64         `json:"total"`
+	Page  int           `json:"page"`
+	Limit int           `json:"limit"`
+}
+
+type AdminUserService interface {
+	SearchUsers(query string, page, limit int) (*UserPage, error)
+	GetUserSummary(userID int) (*repository.UserSummary, error)
+	SetUserDisabled(adminID, userID int, disabled bool) error
+	SetUserRole(adminID, userID int, role string) error
+	ResetPassword(userID int) (string, error)
+}
+
+type adminUserService struct {
+	repo repository.Repository
+	log  *zap.Logger
+}
+
+func NewAdminUserService(repo repository.Repository, log *zap.Logger) AdminUserService {
+	return &adminUserService{repo: repo, log: log}
+}
+
+func (s *adminUserService) SearchUsers(query string, page, limit int) (*UserPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	users, total, err := s.repo.User.SearchUsers(query, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].Password = ""
+	}
+
+	return &UserPage{Users: users, Total: total, Page: page, Limit: limit}, nil
+}
+
+func (s *adminUserService) GetUserSummary(userID int) (*repository.UserSummary, error) {
+	summary, err := s.repo.User.GetUserSummary(userID)
+	if err != nil {
+		return nil, err
+	}
+	summary.Password = ""
+	return &summary, nil
+}
+
+func (s *adminUserService) SetUserDisabled(adminID, userID int, disabled bool) error {
+	if adminID == userID {
+		return ErrSelfManagement
+	}
+	s.log.Info("Changing user status", zap.Int("adminID", adminID), zap.Int("userID", userID), zap.Bool("disabled", disabled))
+	return s.repo.User.SetDisabled(userID, disabled)
+}
+
+func (s *adminUserService) SetUserRole(adminID, userID int, role string) error {
+	if !models.IsValidRole(role) {
+		return ErrInvalidRole
+	}
+	if adminID == userID {
+		return ErrSelfManagement
+	}
+	s.log.Info("Changing user role", zap.Int("adminID", adminID), zap.Int("userID", userID), zap.String("role", role))
+	return s.repo.User.UpdateRole(userID, role)
+}
+
+// ResetPassword replaces the password with a random temporary one that the user
+// has to change after the next login. The temporary password is returned once.
+func (s *adminUserService) ResetPassword(userID int) (string, error) {
+	temporary := utils.GenerateToken()[:16]
+	if err := s.repo.User.ForcePasswordReset(userID, utils.HashPassword(temporary)); err != nil {
+		return "", err
+	}
+	return temporary, nil
+}
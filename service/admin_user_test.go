@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"testing"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/service"
+	"voucher_system/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestAdminUserService_SearchUsers(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	adminService := service.NewAdminUserService(repository.Repository{User: mockUserRepo}, zap.NewNop())
+	mockUserRepo.On("SearchUsers", "john", 1, 20).Return([]models.User{{ID: 1, Email: "john.doe@example.com", Password: "hash"}}, int64(1), nil)
+
+	page, err := adminService.SearchUsers("john", 0, 500)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), page.Total)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, 20, page.Limit)
+	assert.Empty(t, page.Users[0].Password)
+}
+
+func TestAdminUserService_SetUserRole(t *testing.T) {
+	t.Run("Rejects an unknown role", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		adminService := service.NewAdminUserService(repository.Repository{User: mockUserRepo}, zap.NewNop())
+
+		err := adminService.SetUserRole(1, 2, "superuser")
+
+		assert.ErrorIs(t, err, service.ErrInvalidRole)
+	})
+
+	t.Run("Admins cannot demote themselves", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		adminService := service.NewAdminUserService(repository.Repository{User: mockUserRepo}, zap.NewNop())
+
+		err := adminService.SetUserRole(1, 1, models.RoleCustomer)
+
+		assert.ErrorIs(t, err, service.ErrSelfManagement)
+		mockUserRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Assigns the role", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		adminService := service.NewAdminUserService(repository.Repository{User: mockUserRepo}, zap.NewNop())
+		mockUserRepo.On("UpdateRole", 2, models.RoleAdmin).Return(nil)
+
+		assert.NoError(t, adminService.SetUserRole(1, 2, models.RoleAdmin))
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestAdminUserService_ResetPassword(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	adminService := service.NewAdminUserService(repository.Repository{User: mockUserRepo}, zap.NewNop())
+
+	var storedHash string
+	mockUserRepo.On("ForcePasswordReset", 2, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		storedHash = args.String(1)
+	}).Return(nil)
+
+	temporary, err := adminService.ResetPassword(2)
+
+	assert.NoError(t, err)
+	assert.Len(t, temporary, 16)
+	assert.True(t, utils.CheckPassword(temporary, storedHash))
+}
@@ -0,0 +1,52 @@
+package discount
+
+import (
+	"fmt"
+	"voucher_system/models"
+	"voucher_system/utils/money"
+)
+
+// Calculate returns the benefit voucher gives on a purchase of transactionAmount with
+// shippingAmount shipping costs:
+//
+//   - percentage takes DiscountValue percent off the transaction, capped at MaxDiscount
+//   - fixed_amount takes DiscountValue off the transaction
+//   - free_shipping covers the shipping, capped at MaxDiscount
+//
+// A MaxDiscount of zero means no cap. The benefit never exceeds the amount it applies
+// to and is rounded half up to the minor unit of the voucher currency.
+func Calculate(voucher *models.Voucher, transactionAmount, shippingAmount money.Amount) (money.Amount, error) {
+	if err := voucher.ValidateDiscount(); err != nil {
+		return money.Amount{}, err
+	}
+
+	places := voucher.Currency.OrDefault().MinorUnits()
+	var benefit, base money.Amount
+	switch voucher.DiscountType {
+	case models.DiscountPercentage:
+		base = transactionAmount
+		percent, err := transactionAmount.Percent(voucher.DiscountValue, places, money.RoundHalfUp)
+		if err != nil {
+			return money.Amount{}, err
+		}
+		benefit = capped(percent, voucher.MaxDiscount)
+	case models.DiscountFixedAmount:
+		base = transactionAmount
+		benefit = voucher.DiscountValue
+	case models.DiscountFreeShipping:
+		base = shippingAmount
+		benefit = capped(shippingAmount, voucher.MaxDiscount)
+	default:
+		return money.Amount{}, fmt.Errorf("unknown discount type %q", voucher.DiscountType)
+	}
+
+	benefit = money.Min(benefit, money.Max(base, money.Amount{}))
+	return benefit.Round(places, money.RoundHalfUp)
+}
+
+func capped(benefit, maxDiscount money.Amount) money.Amount {
+	if maxDiscount.IsPositive() {
+		return money.Min(benefit, maxDiscount)
+	}
+	return benefit
+}
@@ -0,0 +1,54 @@
+package discount_test
+
+import (
+	"testing"
+	"voucher_system/models"
+	"voucher_system/service/discount"
+	"voucher_system/utils/money"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculate(t *testing.T) {
+	cases := []struct {
+		name        string
+		voucher     models.Voucher
+		transaction money.Amount
+		shipping    money.Amount
+		want        money.Amount
+	}{
+		{"Percentage", models.Voucher{DiscountType: models.DiscountPercentage, DiscountValue: money.New(10)}, money.New(250000), money.Amount{}, money.New(25000)},
+		{"Percentage under the cap", models.Voucher{DiscountType: models.DiscountPercentage, DiscountValue: money.New(10), MaxDiscount: money.New(50000)}, money.New(250000), money.Amount{}, money.New(25000)},
+		{"Percentage over the cap", models.Voucher{DiscountType: models.DiscountPercentage, DiscountValue: money.New(10), MaxDiscount: money.New(20000)}, money.New(250000), money.Amount{}, money.New(20000)},
+		{"Percentage rounds to cents", models.Voucher{DiscountType: models.DiscountPercentage, DiscountValue: money.New(15)}, money.MustParse("33.33"), money.Amount{}, money.New(5)},
+		{"Small fixed amount is not a percentage", models.Voucher{DiscountType: models.DiscountFixedAmount, DiscountValue: money.New(5000)}, money.New(250000), money.Amount{}, money.New(5000)},
+		{"Fixed amount above the transaction", models.Voucher{DiscountType: models.DiscountFixedAmount, DiscountValue: money.New(50000)}, money.New(30000), money.Amount{}, money.New(30000)},
+		{"Free shipping", models.Voucher{DiscountType: models.DiscountFreeShipping}, money.New(250000), money.New(18000), money.New(18000)},
+		{"Free shipping with a cap", models.Voucher{DiscountType: models.DiscountFreeShipping, MaxDiscount: money.New(10000)}, money.New(250000), money.New(18000), money.New(10000)},
+		{"Rounds to the voucher currency", models.Voucher{DiscountType: models.DiscountPercentage, DiscountValue: money.MustParse("12.5"), Currency: money.SGD}, money.MustParse("19.99"), money.Amount{}, money.MustParse("2.5")},
+		{"Free shipping without shipping costs", models.Voucher{DiscountType: models.DiscountFreeShipping}, money.New(250000), money.Amount{}, money.Amount{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			benefit, err := discount.Calculate(&tc.voucher, tc.transaction, tc.shipping)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, benefit)
+		})
+	}
+}
+
+func TestCalculateRejectsInvalidVouchers(t *testing.T) {
+	invalid := []models.Voucher{
+		{DiscountType: "", DiscountValue: money.New(10)},
+		{DiscountType: "bogo", DiscountValue: money.New(10)},
+		{DiscountType: models.DiscountPercentage, DiscountValue: money.New(150)},
+		{DiscountType: models.DiscountFixedAmount, DiscountValue: money.New(-1)},
+		{DiscountType: models.DiscountPercentage, DiscountValue: money.New(10), MaxDiscount: money.New(-5)},
+	}
+
+	for _, voucher := range invalid {
+		_, err := discount.Calculate(&voucher, money.New(100000), money.New(10000))
+		assert.Error(t, err, voucher.DiscountType)
+	}
+}
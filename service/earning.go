@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/utils/money"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidEarningRule = errors.New("earning rule needs spend_per_point (base), multiplier (multiplier) or bonus_points (bonus) above zero")
+	ErrAlreadyEarned      = errors.New("points were already earned for this reference")
+)
+
+// EarningEvent is a purchase that can earn points, either a voucher usage or an order
+// reported by another system. Reference identifies the purchase in the ledger. Amount
+// is in Currency, or the default currency when it is empty.
+type EarningEvent struct {
+	UserID        int
+	Amount        money.Amount
+	Currency      money.Currency
+	PaymentMethod string
+	Area          string
+	At            time.Time
+	Reference     string
+	Description   string
+}
+
+type EarningService interface {
+	ListRules() ([]models.EarningRule, error)
+	CreateRule(rule *models.EarningRule) error
+	UpdateRule(rule *models.EarningRule) error
+	DeleteRule(ruleID int) error
+	Earn(event EarningEvent) (*models.PointsLedgerEntry, error)
+}
+
+type earningService struct {
+	repo  repository.Repository
+	log   *zap.Logger
+	rates money.Rates
+}
+
+func NewEarningService(repo repository.Repository, log *zap.Logger, rates money.Rates) EarningService {
+	return &earningService{repo: repo, log: log, rates: rates}
+}
+
+func (s *earningService) ListRules() ([]models.EarningRule, error) {
+	return s.repo.Earning.FindAll()
+}
+
+func (s *earningService) CreateRule(rule *models.EarningRule) error {
+	if err := validateEarningRule(rule); err != nil {
+		return err
+	}
+	return s.repo.Earning.Create(rule)
+}
+
+func (s *earningService) UpdateRule(rule *models.EarningRule) error {
+	if err := validateEarningRule(rule); err != nil {
+		return err
+	}
+	return s.repo.Earning.Update(rule)
+}
+
+func (s *earningService) DeleteRule(ruleID int) error {
+	return s.repo.Earning.Delete(ruleID)
+}
+
+// Earn credits the points event earns under the currently active rules. It returns a
+// nil entry when no points were earned. A reference earns once: when a concurrent call
+// credited it first, the ledger's unique index rejects the entry and ErrAlreadyEarned
+// is returned.
+func (s *earningService) Earn(event EarningEvent) (*models.PointsLedgerEntry, error) {
+	// Rules are set in the base currency, so the amount is compared in it too.
+	amount, err := s.rates.Convert(event.Amount, event.Currency)
+	if err != nil {
+		return nil, err
+	}
+	event.Amount = amount
+	event.Currency = s.rates.Base
+
+	if event.Reference != "" {
+		earned, err := s.repo.Points.HasEntry(models.LedgerEarn, event.Reference)
+		if err != nil {
+			return nil, err
+		}
+		if earned {
+			return nil, ErrAlreadyEarned
+		}
+	}
+
+	rules, err := s.repo.Earning.FindActive(event.At)
+	if err != nil {
+		return nil, err
+	}
+
+	points := CalculatePoints(rules, event)
+	if points <= 0 {
+		return nil, nil
+	}
+
+	entry := models.PointsLedgerEntry{
+		UserID:      event.UserID,
+		EntryType:   models.LedgerEarn,
+		Points:      points,
+		Reference:   event.Reference,
+		Description: event.Description,
+	}
+	err = s.repo.Points.AddEntry(&entry)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrAlreadyEarned
+	}
+	if err != nil {
+		s.log.Error("Error from service crediting earned points: " + err.Error())
+		return nil, err
+	}
+
+	s.log.Info("Points earned", zap.Int("userID", event.UserID), zap.Int("points", points), zap.String("reference", event.Reference))
+	return &entry, nil
+}
+
+// CalculatePoints applies rules to event, whose amount must be in the base currency.
+// The best matching base rule converts the
+// amount into points, all matching multipliers scale that and matching bonuses are
+// added on top.
+func CalculatePoints(rules []models.EarningRule, event EarningEvent) int {
+	var base float64
+	multiplier := 1.0
+	bonus := 0
+
+	for _, rule := range rules {
+		if !ruleApplies(rule, event) {
+			continue
+		}
+		switch rule.RuleType {
+		case models.EarnRuleBase:
+			if rule.SpendPerPoint.IsPositive() {
+				base = math.Max(base, float64(event.Amount.Quo(rule.SpendPerPoint)))
+			}
+		case models.EarnRuleMultiplier:
+			multiplier *= rule.Multiplier
+		case models.EarnRuleBonus:
+			bonus += rule.BonusPoints
+		}
+	}
+
+	return int(math.Floor(base*multiplier)) + bonus
+}
+
+func ruleApplies(rule models.EarningRule, event EarningEvent) bool {
+	switch {
+	case rule.Disabled:
+		return false
+	case rule.PaymentMethod != "" && !strings.EqualFold(rule.PaymentMethod, event.PaymentMethod):
+		return false
+	case rule.Area != "" && !strings.EqualFold(rule.Area, event.Area):
+		return false
+	case event.Amount.LessThan(rule.MinimumAmount):
+		return false
+	case rule.StartDate != nil && event.At.Before(*rule.StartDate):
+		return false
+	case rule.EndDate != nil && event.At.After(*rule.EndDate):
+		return false
+	}
+	return true
+}
+
+func validateEarningRule(rule *models.EarningRule) error {
+	switch rule.RuleType {
+	case models.EarnRuleBase:
+		if rule.SpendPerPoint.IsPositive() {
+			return nil
+		}
+	case models.EarnRuleMultiplier:
+		if rule.Multiplier > 0 {
+			return nil
+		}
+	case models.EarnRuleBonus:
+		if rule.BonusPoints > 0 {
+			return nil
+		}
+	}
+	return ErrInvalidEarningRule
+}
@@ -0,0 +1,166 @@
+package service_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/service"
+	"voucher_system/utils/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type MockEarningRuleRepository struct {
+	mock.Mock
+}
+
+func (m *MockEarningRuleRepository) FindAll() ([]models.EarningRule, error) {
+	args := m.Called()
+	return args.Get(0).([]models.EarningRule), args.Error(1)
+}
+
+func (m *MockEarningRuleRepository) FindActive(at time.Time) ([]models.EarningRule, error) {
+	args := m.Called(at)
+	return args.Get(0).([]models.EarningRule), args.Error(1)
+}
+
+func (m *MockEarningRuleRepository) Create(rule *models.EarningRule) error {
+	args := m.Called(rule)
+	return args.Error(0)
+}
+
+func (m *MockEarningRuleRepository) Update(rule *models.EarningRule) error {
+	args := m.Called(rule)
+	return args.Error(0)
+}
+
+func (m *MockEarningRuleRepository) Delete(ruleID int) error {
+	args := m.Called(ruleID)
+	return args.Error(0)
+}
+
+func TestCalculatePoints(t *testing.T) {
+	now := time.Now()
+	yesterday, tomorrow := now.AddDate(0, 0, -1), now.AddDate(0, 0, 1)
+	rules := []models.EarningRule{
+		{RuleType: models.EarnRuleBase, SpendPerPoint: money.New(10000)},
+		{RuleType: models.EarnRuleMultiplier, Multiplier: 2, PaymentMethod: "Credit Card"},
+		{RuleType: models.EarnRuleMultiplier, Multiplier: 1.5, Area: "Jawa"},
+		{RuleType: models.EarnRuleBonus, BonusPoints: 50, MinimumAmount: money.New(500000), StartDate: &yesterday, EndDate: &tomorrow},
+		{RuleType: models.EarnRuleBonus, BonusPoints: 1000, Disabled: true},
+	}
+
+	cases := []struct {
+		name  string
+		event service.EarningEvent
+		want  int
+	}{
+		{"Base only", service.EarningEvent{Amount: money.New(255000), PaymentMethod: "Cash", Area: "Bali", At: now}, 25},
+		{"Payment method multiplier", service.EarningEvent{Amount: money.New(255000), PaymentMethod: "credit card", Area: "Bali", At: now}, 50},
+		{"Stacked multipliers", service.EarningEvent{Amount: money.New(100000), PaymentMethod: "Credit Card", Area: "Jawa", At: now}, 30},
+		{"Campaign bonus", service.EarningEvent{Amount: money.New(500000), PaymentMethod: "Cash", Area: "Bali", At: now}, 100},
+		{"Campaign over", service.EarningEvent{Amount: money.New(500000), PaymentMethod: "Cash", Area: "Bali", At: now.AddDate(0, 0, 2)}, 50},
+		{"Below one point", service.EarningEvent{Amount: money.New(9999), At: now}, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, service.CalculatePoints(rules, tc.event))
+		})
+	}
+}
+
+func TestEarningService_Earn(t *testing.T) {
+	event := service.EarningEvent{UserID: 3, Amount: money.New(120000), At: time.Now(), Reference: "order:INV-1"}
+
+	t.Run("Credits the earned points", func(t *testing.T) {
+		earningRepo, pointsRepo := new(MockEarningRuleRepository), new(MockPointsRepository)
+		earningService := service.NewEarningService(repository.Repository{Earning: earningRepo, Points: pointsRepo}, zap.NewNop(), money.NewRates(money.IDR, nil))
+		pointsRepo.On("HasEntry", models.LedgerEarn, "order:INV-1").Return(false, nil)
+		earningRepo.On("FindActive", event.At).Return([]models.EarningRule{{RuleType: models.EarnRuleBase, SpendPerPoint: money.New(10000)}}, nil)
+		pointsRepo.On("AddEntry", mock.MatchedBy(func(e *models.PointsLedgerEntry) bool {
+			return e.UserID == 3 && e.EntryType == models.LedgerEarn && e.Points == 12
+		})).Return(nil)
+
+		entry, err := earningService.Earn(event)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 12, entry.Points)
+		pointsRepo.AssertExpectations(t)
+	})
+
+	t.Run("Converts the amount to the base currency", func(t *testing.T) {
+		earningRepo, pointsRepo := new(MockEarningRuleRepository), new(MockPointsRepository)
+		rates := money.NewRates(money.IDR, map[money.Currency]money.Amount{money.SGD: money.New(11750)})
+		earningService := service.NewEarningService(repository.Repository{Earning: earningRepo, Points: pointsRepo}, zap.NewNop(), rates)
+		sgdEvent := service.EarningEvent{UserID: 3, Amount: money.New(100), Currency: money.SGD, At: event.At, Reference: "order:INV-2"}
+		pointsRepo.On("HasEntry", models.LedgerEarn, "order:INV-2").Return(false, nil)
+		earningRepo.On("FindActive", event.At).Return([]models.EarningRule{{RuleType: models.EarnRuleBase, SpendPerPoint: money.New(10000)}}, nil)
+		pointsRepo.On("AddEntry", mock.MatchedBy(func(e *models.PointsLedgerEntry) bool {
+			return e.Points == 117
+		})).Return(nil)
+
+		entry, err := earningService.Earn(sgdEvent)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 117, entry.Points)
+	})
+
+	t.Run("Currency without a rate is rejected", func(t *testing.T) {
+		earningService := service.NewEarningService(repository.Repository{}, zap.NewNop(), money.NewRates(money.IDR, nil))
+
+		_, err := earningService.Earn(service.EarningEvent{UserID: 3, Amount: money.New(100), Currency: money.SGD, At: event.At})
+
+		assert.ErrorIs(t, err, money.ErrNoRate)
+	})
+
+	t.Run("Same reference earns once", func(t *testing.T) {
+		pointsRepo := new(MockPointsRepository)
+		earningService := service.NewEarningService(repository.Repository{Points: pointsRepo}, zap.NewNop(), money.NewRates(money.IDR, nil))
+		pointsRepo.On("HasEntry", models.LedgerEarn, "order:INV-1").Return(true, nil)
+
+		_, err := earningService.Earn(event)
+
+		assert.ErrorIs(t, err, service.ErrAlreadyEarned)
+		pointsRepo.AssertNotCalled(t, "AddEntry", mock.Anything)
+	})
+
+	t.Run("Concurrent earn for the same reference earns once", func(t *testing.T) {
+		earningRepo, pointsRepo := new(MockEarningRuleRepository), new(MockPointsRepository)
+		earningService := service.NewEarningService(repository.Repository{Earning: earningRepo, Points: pointsRepo}, zap.NewNop(), money.NewRates(money.IDR, nil))
+		pointsRepo.On("HasEntry", models.LedgerEarn, "order:INV-1").Return(false, nil)
+		earningRepo.On("FindActive", event.At).Return([]models.EarningRule{{RuleType: models.EarnRuleBase, SpendPerPoint: money.New(10000)}}, nil)
+		pointsRepo.On("AddEntry", mock.Anything).Return(fmt.Errorf("failed to write points ledger: %w", gorm.ErrDuplicatedKey))
+
+		entry, err := earningService.Earn(event)
+
+		assert.ErrorIs(t, err, service.ErrAlreadyEarned)
+		assert.Nil(t, entry)
+	})
+
+	t.Run("No matching rule earns nothing", func(t *testing.T) {
+		earningRepo, pointsRepo := new(MockEarningRuleRepository), new(MockPointsRepository)
+		earningService := service.NewEarningService(repository.Repository{Earning: earningRepo, Points: pointsRepo}, zap.NewNop(), money.NewRates(money.IDR, nil))
+		pointsRepo.On("HasEntry", models.LedgerEarn, "order:INV-1").Return(false, nil)
+		earningRepo.On("FindActive", event.At).Return([]models.EarningRule{}, nil)
+
+		entry, err := earningService.Earn(event)
+
+		assert.NoError(t, err)
+		assert.Nil(t, entry)
+		pointsRepo.AssertNotCalled(t, "AddEntry", mock.Anything)
+	})
+}
+
+func TestEarningService_CreateRule(t *testing.T) {
+	earningService := service.NewEarningService(repository.Repository{}, zap.NewNop(), money.NewRates(money.IDR, nil))
+
+	err := earningService.CreateRule(&models.EarningRule{Name: "Broken", RuleType: models.EarnRuleMultiplier})
+
+	assert.ErrorIs(t, err, service.ErrInvalidEarningRule)
+}
@@ -1,38 +1,57 @@
-package service
-
-import (
-	"voucher_system/models"
-	"voucher_system/repository"
-
-	"go.uber.org/zap"
-)
-
-type HistoryService interface {
-	GetRedeemHistoryByUser(userID int) ([]models.Redeem, error)
-	GetUsageHistoryByUser(userID int) ([]models.History, error)
-	GetUsersByVoucherCode(voucherCode string) ([]models.Redeem, error)
-}
-
-type historyService struct {
-	repo repository.Repository
-	log  *zap.Logger
-}
-
-func NewHistoryService(repo repository.Repository, log *zap.Logger) HistoryService {
-	return &historyService{
-		repo: repo,
-		log:  log,
-	}
-}
-
-func (s *historyService) GetRedeemHistoryByUser(userID int) ([]models.Redeem, error) {
-    return s.repo.Redeem.FindRedeemHistoryByUser(userID)
-}
-
-func (s *historyService) GetUsageHistoryByUser(userID int) ([]models.History, error) {
-	return s.repo.History.FindUsageHistoryByUser(userID)
-}
-
-func (s *historyService) GetUsersByVoucherCode(voucherCode string) ([]models.Redeem, error) {
-	return s.repo.Redeem.FindUsersByVoucherCode(voucherCode)
-}
+package service
+
+import (
+	"errors"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+
+	"go.uber.org/zap"
+)
+
+var ErrNoUsageToReverse = errors.New("usage_id, or order_id with user_id, is required")
+
+type HistoryService interface {
+	GetRedeemHistoryByUser(userID int) ([]models.Redeem, error)
+	GetUsageHistoryByUser(userID int) ([]models.History, error)
+	GetUsersByVoucherCode(voucherCode string) ([]models.Redeem, error)
+	ReverseUsage(reversal repository.UsageReversal) (*models.History, error)
+}
+
+type historyService struct {
+	repo repository.Repository
+	log  *zap.Logger
+}
+
+func NewHistoryService(repo repository.Repository, log *zap.Logger) HistoryService {
+	return &historyService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+func (s *historyService) GetRedeemHistoryByUser(userID int) ([]models.Redeem, error) {
+    return s.repo.Redeem.FindRedeemHistoryByUser(userID)
+}
+
+func (s *historyService) GetUsageHistoryByUser(userID int) ([]models.History, error) {
+	return s.repo.History.FindUsageHistoryByUser(userID)
+}
+
+func (s *historyService) GetUsersByVoucherCode(voucherCode string) ([]models.Redeem, error) {
+	return s.repo.Redeem.FindUsersByVoucherCode(voucherCode)
+}
+
+// ReverseUsage undoes the usage of a cancelled order. The usage stays in the history,
+// marked as reversed.
+func (s *historyService) ReverseUsage(reversal repository.UsageReversal) (*models.History, error) {
+	if reversal.UsageID == 0 && (reversal.OrderID == "" || reversal.UserID == 0) {
+		return nil, ErrNoUsageToReverse
+	}
+	if reversal.At.IsZero() {
+		reversal.At = time.Now()
+	}
+
+	s.log.Info("Reversing voucher usage", zap.Int("usageID", reversal.UsageID), zap.String("orderID", reversal.OrderID), zap.Bool("restoreQuota", reversal.RestoreQuota))
+	return s.repo.History.Reverse(reversal)
+}
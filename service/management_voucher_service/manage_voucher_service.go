@@ -1,89 +1,196 @@
-package managementvoucherservice
-
-import (
-	"voucher_system/models"
-	"voucher_system/repository"
-	managementvoucher "voucher_system/repository/management_voucher"
-
-	"go.uber.org/zap"
-)
-
-type ManageVoucherService interface {
-	CreateVoucher(voucher *models.Voucher) error
-	SoftDeleteVoucher(voucherID int) error
-	UpdateVoucher(voucher *models.Voucher, voucherID int) error
-	ShowRedeemPoints() (*[]managementvoucher.RedeemPoint, error)
-	GetVouchersByQueryParams(status, area, voucher_type string) (*[]models.Voucher, error)
-	CreateRedeemVoucher(redeem *models.Redeem, points int) error
-}
-
-type ManagementVoucherservice struct {
-	repo repository.Repository
-	log  *zap.Logger
-}
-
-func NewManagementVoucherService(repo repository.Repository, log *zap.Logger) ManageVoucherService {
-	return &ManagementVoucherservice{repo: repo, log: log}
-}
-
-func (ms *ManagementVoucherservice) CreateVoucher(voucher *models.Voucher) error {
-
-	if err := ms.repo.Manage.CreateVoucher(voucher); err != nil {
-		ms.log.Error("Error from service creating voucher: " + err.Error())
-		return err
-	}
-	return nil
-}
-
-func (ms *ManagementVoucherservice) SoftDeleteVoucher(voucherID int) error {
-
-	if err := ms.repo.Manage.SoftDeleteVoucher(voucherID); err != nil {
-		ms.log.Error("Error from service soft-deletes: " + err.Error())
-		return err
-	}
-
-	return nil
-}
-
-func (ms *ManagementVoucherservice) UpdateVoucher(voucher *models.Voucher, voucherID int) error {
-
-	if err := ms.repo.Manage.UpdateVoucher(voucher, voucherID); err != nil {
-		ms.log.Error("Error from service Update Voucher: " + err.Error())
-		return err
-	}
-
-	return nil
-}
-
-func (ms *ManagementVoucherservice) ShowRedeemPoints() (*[]managementvoucher.RedeemPoint, error) {
-
-	vouchers, err := ms.repo.Manage.ShowRedeemPoints()
-	if err != nil {
-		ms.log.Error("Error from service Show redeem points: " + err.Error())
-		return nil, err
-	}
-
-	return vouchers, nil
-}
-
-func (ms *ManagementVoucherservice) GetVouchersByQueryParams(status, area, voucher_type string) (*[]models.Voucher, error) {
-
-	vouchers, err := ms.repo.Manage.GetVouchersByQueryParams(status, area, voucher_type)
-	if err != nil {
-		ms.log.Error("Error from service Show redeem points: " + err.Error())
-		return nil, err
-	}
-
-	return vouchers, nil
-}
-
-func (ms *ManagementVoucherservice) CreateRedeemVoucher(redeem *models.Redeem, points int) error {
-
-	err := ms.repo.Manage.CreateRedeemVoucher(redeem, points)
-	if err != nil {
-		ms.log.Error("Error from service create redeem voucher: " + err.Error())
-		return err
-	}
-
-	return nil
-}
+package managementvoucherservice
+
+import (
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+	managementvoucher "voucher_system/repository/management_voucher"
+	"voucher_system/utils/codegen"
+
+	"go.uber.org/zap"
+)
+
+type ManageVoucherService interface {
+	CreateVoucher(voucher *models.Voucher, actorID int) error
+	SoftDeleteVoucher(voucherID, actorID int) error
+	UpdateVoucher(voucher *models.Voucher, voucherID, actorID int) error
+	FindVersions(voucherID int) ([]models.VoucherVersion, error)
+	RevertVoucher(voucherID, version, actorID int) (*models.VoucherVersion, error)
+	ChangeState(voucherID, actorID int, action, reason string) (*models.VoucherVersion, error)
+	GenerateCodes(voucherID int, req models.GenerateCodesRequest) (int, error)
+	AssignCode(voucherID, userID int) (*models.VoucherCode, error)
+	FindCodes(voucherID int, state string) ([]models.VoucherCode, error)
+	ShowRedeemPoints() (*[]managementvoucher.RedeemPoint, error)
+	GetVouchersByQueryParams(status, area, voucher_type string) (*[]models.Voucher, error)
+	CreateRedeemVoucher(redeem *models.Redeem, points int) error
+	SyncStatus(now time.Time) ([]models.VoucherStatusEvent, error)
+}
+
+type ManagementVoucherservice struct {
+	repo repository.Repository
+	log  *zap.Logger
+}
+
+func NewManagementVoucherService(repo repository.Repository, log *zap.Logger) ManageVoucherService {
+	return &ManagementVoucherservice{repo: repo, log: log}
+}
+
+func (ms *ManagementVoucherservice) CreateVoucher(voucher *models.Voucher, actorID int) error {
+
+	if err := ms.repo.Manage.CreateVoucher(voucher, actorID); err != nil {
+		ms.log.Error("Error from service creating voucher: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+func (ms *ManagementVoucherservice) SoftDeleteVoucher(voucherID, actorID int) error {
+
+	if err := ms.repo.Manage.SoftDeleteVoucher(voucherID, actorID); err != nil {
+		ms.log.Error("Error from service soft-deletes: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ms *ManagementVoucherservice) UpdateVoucher(voucher *models.Voucher, voucherID, actorID int) error {
+
+	if err := ms.repo.Manage.UpdateVoucher(voucher, voucherID, actorID); err != nil {
+		ms.log.Error("Error from service Update Voucher: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ms *ManagementVoucherservice) FindVersions(voucherID int) ([]models.VoucherVersion, error) {
+
+	versions, err := ms.repo.Manage.FindVersions(voucherID)
+	if err != nil {
+		ms.log.Error("Error from service find voucher versions: " + err.Error())
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// RevertVoucher restores the terms a voucher had at version and returns the version
+// recorded for the revert.
+func (ms *ManagementVoucherservice) RevertVoucher(voucherID, version, actorID int) (*models.VoucherVersion, error) {
+
+	reverted, err := ms.repo.Manage.RevertVoucher(voucherID, version, actorID)
+	if err != nil {
+		ms.log.Error("Error from service revert voucher: " + err.Error())
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+// ChangeState applies a lifecycle action (submit, approve, reject, pause or resume) to a
+// voucher and returns the version recorded for it.
+func (ms *ManagementVoucherservice) ChangeState(voucherID, actorID int, action, reason string) (*models.VoucherVersion, error) {
+
+	changed, err := ms.repo.Manage.ChangeState(voucherID, actorID, action, reason, time.Now())
+	if err != nil {
+		ms.log.Error("Error from service change voucher state: " + err.Error())
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+// GenerateCodes generates req.Count unique codes for the voucher in the requested format.
+// The format is checked up front so a bad request never reaches the database.
+func (ms *ManagementVoucherservice) GenerateCodes(voucherID int, req models.GenerateCodesRequest) (int, error) {
+
+	format := codegen.Format{
+		Alphabet:   req.Alphabet,
+		Length:     req.Length,
+		Prefix:     req.Prefix,
+		CheckDigit: req.CheckDigit,
+	}.WithDefaults()
+	if err := format.Validate(); err != nil {
+		return 0, err
+	}
+	if !format.Fits(req.Count) {
+		return 0, codegen.ErrSpaceTooSmall
+	}
+
+	created, err := ms.repo.Manage.GenerateCodes(voucherID, format, req.Count)
+	if err != nil {
+		ms.log.Error("Error from service generate voucher codes: " + err.Error())
+		return created, err
+	}
+
+	return created, nil
+}
+
+func (ms *ManagementVoucherservice) AssignCode(voucherID, userID int) (*models.VoucherCode, error) {
+
+	code, err := ms.repo.Manage.AssignCode(voucherID, userID, time.Now())
+	if err != nil {
+		ms.log.Error("Error from service assign voucher code: " + err.Error())
+		return nil, err
+	}
+
+	return code, nil
+}
+
+func (ms *ManagementVoucherservice) FindCodes(voucherID int, state string) ([]models.VoucherCode, error) {
+
+	codes, err := ms.repo.Manage.FindCodes(voucherID, state)
+	if err != nil {
+		ms.log.Error("Error from service find voucher codes: " + err.Error())
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (ms *ManagementVoucherservice) ShowRedeemPoints() (*[]managementvoucher.RedeemPoint, error) {
+
+	vouchers, err := ms.repo.Manage.ShowRedeemPoints()
+	if err != nil {
+		ms.log.Error("Error from service Show redeem points: " + err.Error())
+		return nil, err
+	}
+
+	return vouchers, nil
+}
+
+func (ms *ManagementVoucherservice) GetVouchersByQueryParams(status, area, voucher_type string) (*[]models.Voucher, error) {
+
+	vouchers, err := ms.repo.Manage.GetVouchersByQueryParams(status, area, voucher_type)
+	if err != nil {
+		ms.log.Error("Error from service Show redeem points: " + err.Error())
+		return nil, err
+	}
+
+	return vouchers, nil
+}
+
+func (ms *ManagementVoucherservice) CreateRedeemVoucher(redeem *models.Redeem, points int) error {
+
+	err := ms.repo.Manage.CreateRedeemVoucher(redeem, points)
+	if err != nil {
+		ms.log.Error("Error from service create redeem voucher: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// SyncStatus brings every voucher's Status in line with its dates at now and returns the
+// changes it made.
+func (ms *ManagementVoucherservice) SyncStatus(now time.Time) ([]models.VoucherStatusEvent, error) {
+
+	events, err := ms.repo.Manage.SyncStatus(now)
+	if err != nil {
+		ms.log.Error("Error from service sync voucher status: " + err.Error())
+		return nil, err
+	}
+
+	return events, nil
+}
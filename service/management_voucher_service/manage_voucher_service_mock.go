@@ -1,48 +1,102 @@
-package managementvoucherservice
-
-import (
-	"voucher_system/models"
-	managementvoucher "voucher_system/repository/management_voucher"
-
-	"github.com/stretchr/testify/mock"
-)
-
-type ManagementVoucherServiceMock struct {
-	mock.Mock
-}
-
-func (m *ManagementVoucherServiceMock) CreateVoucher(voucher *models.Voucher) error {
-	args := m.Called(voucher)
-	return args.Error(0)
-}
-
-func (m *ManagementVoucherServiceMock) SoftDeleteVoucher(voucherID int) error {
-	args := m.Called(voucherID)
-	return args.Error(0)
-}
-
-func (m *ManagementVoucherServiceMock) UpdateVoucher(voucher *models.Voucher, voucherID int) error {
-	args := m.Called(voucher, voucherID)
-	return args.Error(0)
-}
-
-func (m *ManagementVoucherServiceMock) ShowRedeemPoints() (*[]managementvoucher.RedeemPoint, error) {
-	args := m.Called()
-	if points, ok := args.Get(0).(*[]managementvoucher.RedeemPoint); ok {
-		return points, args.Error(1)
-	}
-	return nil, args.Error(1)
-}
-
-func (m *ManagementVoucherServiceMock) GetVouchersByQueryParams(status, area, voucher_type string) (*[]models.Voucher, error) {
-	args := m.Called(status, area, voucher_type)
-	if vouchers := args.Get(0); vouchers != nil {
-		return vouchers.(*[]models.Voucher), args.Error(1)
-	}
-	return nil, args.Error(1)
-}
-
-func (m *ManagementVoucherServiceMock) CreateRedeemVoucher(redeem *models.Redeem, points int) error {
-	args := m.Called(redeem, points)
-	return args.Error(0)
-}
+package managementvoucherservice
+
+import (
+	"time"
+	"voucher_system/models"
+	managementvoucher "voucher_system/repository/management_voucher"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ManagementVoucherServiceMock struct {
+	mock.Mock
+}
+
+func (m *ManagementVoucherServiceMock) CreateVoucher(voucher *models.Voucher, actorID int) error {
+	args := m.Called(voucher, actorID)
+	return args.Error(0)
+}
+
+func (m *ManagementVoucherServiceMock) SoftDeleteVoucher(voucherID, actorID int) error {
+	args := m.Called(voucherID, actorID)
+	return args.Error(0)
+}
+
+func (m *ManagementVoucherServiceMock) UpdateVoucher(voucher *models.Voucher, voucherID, actorID int) error {
+	args := m.Called(voucher, voucherID, actorID)
+	return args.Error(0)
+}
+
+func (m *ManagementVoucherServiceMock) FindVersions(voucherID int) ([]models.VoucherVersion, error) {
+	args := m.Called(voucherID)
+	if versions := args.Get(0); versions != nil {
+		return versions.([]models.VoucherVersion), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherServiceMock) RevertVoucher(voucherID, version, actorID int) (*models.VoucherVersion, error) {
+	args := m.Called(voucherID, version, actorID)
+	if reverted := args.Get(0); reverted != nil {
+		return reverted.(*models.VoucherVersion), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherServiceMock) ShowRedeemPoints() (*[]managementvoucher.RedeemPoint, error) {
+	args := m.Called()
+	if points, ok := args.Get(0).(*[]managementvoucher.RedeemPoint); ok {
+		return points, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherServiceMock) GetVouchersByQueryParams(status, area, voucher_type string) (*[]models.Voucher, error) {
+	args := m.Called(status, area, voucher_type)
+	if vouchers := args.Get(0); vouchers != nil {
+		return vouchers.(*[]models.Voucher), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherServiceMock) CreateRedeemVoucher(redeem *models.Redeem, points int) error {
+	args := m.Called(redeem, points)
+	return args.Error(0)
+}
+
+func (m *ManagementVoucherServiceMock) SyncStatus(now time.Time) ([]models.VoucherStatusEvent, error) {
+	args := m.Called(now)
+	if events := args.Get(0); events != nil {
+		return events.([]models.VoucherStatusEvent), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherServiceMock) ChangeState(voucherID, actorID int, action, reason string) (*models.VoucherVersion, error) {
+	args := m.Called(voucherID, actorID, action, reason)
+	if changed := args.Get(0); changed != nil {
+		return changed.(*models.VoucherVersion), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherServiceMock) GenerateCodes(voucherID int, req models.GenerateCodesRequest) (int, error) {
+	args := m.Called(voucherID, req)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *ManagementVoucherServiceMock) AssignCode(voucherID, userID int) (*models.VoucherCode, error) {
+	args := m.Called(voucherID, userID)
+	if code := args.Get(0); code != nil {
+		return code.(*models.VoucherCode), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ManagementVoucherServiceMock) FindCodes(voucherID int, state string) ([]models.VoucherCode, error) {
+	args := m.Called(voucherID, state)
+	if codes := args.Get(0); codes != nil {
+		return codes.([]models.VoucherCode), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
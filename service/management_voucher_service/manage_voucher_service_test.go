@@ -1,278 +1,330 @@
-package managementvoucherservice_test
-
-import (
-	"errors"
-	"fmt"
-	"testing"
-	"time"
-	"voucher_system/models"
-	"voucher_system/repository"
-	managementvoucher "voucher_system/repository/management_voucher"
-	managementvoucherservice "voucher_system/service/management_voucher_service"
-
-	"github.com/stretchr/testify/assert"
-	"go.uber.org/zap"
-)
-
-func TestCreateVoucher(t *testing.T) {
-	log := *zap.NewNop()
-	mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
-	repo := repository.Repository{
-		Manage: mockRepo,
-	}
-	service := managementvoucherservice.NewManagementVoucherService(repo, &log)
-
-	voucher := &models.Voucher{
-		VoucherName:    "Discount 10%",
-		Quota:          100,
-		PointsRequired: 50,
-		StartDate:      time.Now().AddDate(0, 0, -5),
-		EndDate:        time.Now().AddDate(0, 0, 5),
-	}
-
-	t.Run("Successfully create voucher", func(t *testing.T) {
-
-		mockRepo.On("CreateVoucher", voucher).Return(nil)
-
-		err := service.CreateVoucher(voucher)
-
-		assert.NoError(t, err)
-		mockRepo.AssertCalled(t, "CreateVoucher", voucher)
-	})
-
-	t.Run("Fail to create voucher - insufficient data", func(t *testing.T) {
-
-		invalidVoucher := &models.Voucher{
-			VoucherName: "",
-		}
-
-		mockRepo.On("CreateVoucher", invalidVoucher).Return(fmt.Errorf("voucher data is invalid"))
-
-		err := service.CreateVoucher(invalidVoucher)
-
-		assert.Error(t, err)
-		assert.EqualError(t, err, "voucher data is invalid")
-		mockRepo.AssertCalled(t, "CreateVoucher", invalidVoucher)
-	})
-}
-
-func TestSoftDeleteVoucher(t *testing.T) {
-	log := *zap.NewNop() // Logger yang tidak menghasilkan output apapun untuk testing
-
-	voucherID := 100
-
-	t.Run("Successfully soft delete voucher", func(t *testing.T) {
-		// Mock baru untuk setiap test case
-		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
-		repo := repository.Repository{
-			Manage: mockRepo,
-		}
-		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
-
-		// Setup mock untuk SoftDeleteVoucher yang berhasil (tidak mengembalikan error)
-		mockRepo.On("SoftDeleteVoucher", voucherID).Return(nil)
-
-		// Panggil method SoftDeleteVoucher di service
-		err := service.SoftDeleteVoucher(voucherID)
-
-		// Pastikan tidak ada error
-		assert.NoError(t, err)
-
-		// Pastikan bahwa metode SoftDeleteVoucher dipanggil dengan benar
-		mockRepo.AssertCalled(t, "SoftDeleteVoucher", voucherID)
-	})
-
-	t.Run("Fail to soft delete voucher", func(t *testing.T) {
-		// Mock baru untuk setiap test case
-		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
-		repo := repository.Repository{
-			Manage: mockRepo,
-		}
-		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
-
-		// Setup mock untuk mengembalikan error
-		mockRepo.On("SoftDeleteVoucher", voucherID).Return(errors.New("voucher not found"))
-
-		// Panggil service
-		err := service.SoftDeleteVoucher(voucherID)
-
-		// Verifikasi hasil
-		assert.Error(t, err)
-		assert.Equal(t, "voucher not found", err.Error())
-
-		mockRepo.AssertCalled(t, "SoftDeleteVoucher", voucherID)
-	})
-}
-
-func TestUpdateVoucher(t *testing.T) {
-	log := *zap.NewNop()
-
-	voucherID := 100
-	voucher := &models.Voucher{
-		VoucherName:    "Updated Discount 10%",
-		Quota:          50,
-		PointsRequired: 25,
-	}
-
-	t.Run("Successfully update voucher", func(t *testing.T) {
-		// Mock baru untuk test case ini
-		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
-		repo := repository.Repository{
-			Manage: mockRepo,
-		}
-		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
-
-		// Setup mock
-		mockRepo.On("UpdateVoucher", voucher, voucherID).Return(nil)
-
-		// Panggil service
-		err := service.UpdateVoucher(voucher, voucherID)
-
-		// Verifikasi hasil
-		assert.NoError(t, err)
-		mockRepo.AssertCalled(t, "UpdateVoucher", voucher, voucherID)
-	})
-
-	t.Run("Fail to update voucher", func(t *testing.T) {
-		// Mock baru untuk test case ini
-		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
-		repo := repository.Repository{
-			Manage: mockRepo,
-		}
-		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
-
-		// Setup mock
-		mockRepo.On("UpdateVoucher", voucher, voucherID).Return(fmt.Errorf("voucher update failed"))
-
-		// Panggil service
-		err := service.UpdateVoucher(voucher, voucherID)
-
-		// Verifikasi hasil
-		assert.Error(t, err)
-		assert.EqualError(t, err, "voucher update failed")
-		mockRepo.AssertCalled(t, "UpdateVoucher", voucher, voucherID)
-	})
-}
-
-func TestShowRedeemPoints(t *testing.T) {
-	log := *zap.NewNop()
-
-	expectedRedeemPoints := &[]managementvoucher.RedeemPoint{
-		{VoucherName: "Discount 10%", PointsRequired: 50, DiscountValue: 10.0},
-	}
-
-	t.Run("Successfully show redeem points", func(t *testing.T) {
-		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
-		repo := repository.Repository{
-			Manage: mockRepo,
-		}
-		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
-
-		mockRepo.On("ShowRedeemPoints").Return(expectedRedeemPoints, nil)
-
-		redeemPoints, err := service.ShowRedeemPoints()
-
-		assert.NoError(t, err)
-		assert.Equal(t, expectedRedeemPoints, redeemPoints)
-		mockRepo.AssertCalled(t, "ShowRedeemPoints")
-	})
-
-	t.Run("Fail to show redeem points", func(t *testing.T) {
-		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
-		repo := repository.Repository{
-			Manage: mockRepo,
-		}
-		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
-
-		mockRepo.On("ShowRedeemPoints").Return(nil, fmt.Errorf("failed to fetch redeem points"))
-
-		redeemPoints, err := service.ShowRedeemPoints()
-
-		assert.Error(t, err)
-		assert.Nil(t, redeemPoints)
-		assert.EqualError(t, err, "failed to fetch redeem points")
-		mockRepo.AssertCalled(t, "ShowRedeemPoints")
-	})
-}
-
-func TestGetVouchersByQueryParams(t *testing.T) {
-	log := *zap.NewNop()
-
-	status := "active"
-	area := "Jakarta"
-	voucherType := "discount"
-
-	expectedVouchers := &[]models.Voucher{
-		{VoucherName: "Discount 10%", Quota: 100, PointsRequired: 50},
-	}
-
-	t.Run("Successfully get vouchers by query params", func(t *testing.T) {
-		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
-		repo := repository.Repository{
-			Manage: mockRepo,
-		}
-		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
-		mockRepo.On("GetVouchersByQueryParams", status, area, voucherType).Return(expectedVouchers, nil)
-
-		vouchers, err := service.GetVouchersByQueryParams(status, area, voucherType)
-
-		assert.NoError(t, err)
-		assert.Equal(t, expectedVouchers, vouchers)
-		mockRepo.AssertCalled(t, "GetVouchersByQueryParams", status, area, voucherType)
-	})
-
-	t.Run("Fail to get vouchers by query params", func(t *testing.T) {
-		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
-		repo := repository.Repository{
-			Manage: mockRepo,
-		}
-		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
-		mockRepo.On("GetVouchersByQueryParams", status, area, voucherType).Return(nil, fmt.Errorf("no vouchers found"))
-
-		vouchers, err := service.GetVouchersByQueryParams(status, area, voucherType)
-
-		assert.Error(t, err)
-		assert.Nil(t, vouchers)
-		assert.EqualError(t, err, "no vouchers found")
-		mockRepo.AssertCalled(t, "GetVouchersByQueryParams", status, area, voucherType)
-	})
-}
-
-func TestCreateRedeemVoucher(t *testing.T) {
-	log := *zap.NewNop()
-
-	redeem := &models.Redeem{
-		UserID:    1,
-		VoucherID: 100,
-	}
-	points := 50
-
-	t.Run("Successfully create redeem voucher", func(t *testing.T) {
-		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
-		repo := repository.Repository{
-			Manage: mockRepo,
-		}
-		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
-		mockRepo.On("CreateRedeemVoucher", redeem, points).Return(nil)
-
-		err := service.CreateRedeemVoucher(redeem, points)
-
-		assert.NoError(t, err)
-		mockRepo.AssertCalled(t, "CreateRedeemVoucher", redeem, points)
-	})
-
-	t.Run("Fail to create redeem voucher", func(t *testing.T) {
-		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
-		repo := repository.Repository{
-			Manage: mockRepo,
-		}
-		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
-		mockRepo.On("CreateRedeemVoucher", redeem, points).Return(fmt.Errorf("user already claimed this voucher"))
-
-		err := service.CreateRedeemVoucher(redeem, points)
-
-		assert.Error(t, err)
-		assert.EqualError(t, err, "user already claimed this voucher")
-		mockRepo.AssertCalled(t, "CreateRedeemVoucher", redeem, points)
-	})
-}
+package managementvoucherservice_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+	managementvoucher "voucher_system/repository/management_voucher"
+	managementvoucherservice "voucher_system/service/management_voucher_service"
+	"voucher_system/utils/codegen"
+	"voucher_system/utils/money"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCreateVoucher(t *testing.T) {
+	log := *zap.NewNop()
+	mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+	repo := repository.Repository{
+		Manage: mockRepo,
+	}
+	service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+
+	voucher := &models.Voucher{
+		VoucherName:    "Discount 10%",
+		Quota:          100,
+		PointsRequired: 50,
+		StartDate:      time.Now().AddDate(0, 0, -5),
+		EndDate:        time.Now().AddDate(0, 0, 5),
+	}
+
+	t.Run("Successfully create voucher", func(t *testing.T) {
+
+		mockRepo.On("CreateVoucher", voucher, 1).Return(nil)
+
+		err := service.CreateVoucher(voucher, 1)
+
+		assert.NoError(t, err)
+		mockRepo.AssertCalled(t, "CreateVoucher", voucher, 1)
+	})
+
+	t.Run("Fail to create voucher - insufficient data", func(t *testing.T) {
+
+		invalidVoucher := &models.Voucher{
+			VoucherName: "",
+		}
+
+		mockRepo.On("CreateVoucher", invalidVoucher, 1).Return(fmt.Errorf("voucher data is invalid"))
+
+		err := service.CreateVoucher(invalidVoucher, 1)
+
+		assert.Error(t, err)
+		assert.EqualError(t, err, "voucher data is invalid")
+		mockRepo.AssertCalled(t, "CreateVoucher", invalidVoucher, 1)
+	})
+}
+
+func TestSoftDeleteVoucher(t *testing.T) {
+	log := *zap.NewNop() // Logger yang tidak menghasilkan output apapun untuk testing
+
+	voucherID := 100
+
+	t.Run("Successfully soft delete voucher", func(t *testing.T) {
+		// Mock baru untuk setiap test case
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+
+		// Setup mock untuk SoftDeleteVoucher yang berhasil (tidak mengembalikan error)
+		mockRepo.On("SoftDeleteVoucher", voucherID, 1).Return(nil)
+
+		// Panggil method SoftDeleteVoucher di service
+		err := service.SoftDeleteVoucher(voucherID, 1)
+
+		// Pastikan tidak ada error
+		assert.NoError(t, err)
+
+		// Pastikan bahwa metode SoftDeleteVoucher dipanggil dengan benar
+		mockRepo.AssertCalled(t, "SoftDeleteVoucher", voucherID, 1)
+	})
+
+	t.Run("Fail to soft delete voucher", func(t *testing.T) {
+		// Mock baru untuk setiap test case
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+
+		// Setup mock untuk mengembalikan error
+		mockRepo.On("SoftDeleteVoucher", voucherID, 1).Return(errors.New("voucher not found"))
+
+		// Panggil service
+		err := service.SoftDeleteVoucher(voucherID, 1)
+
+		// Verifikasi hasil
+		assert.Error(t, err)
+		assert.Equal(t, "voucher not found", err.Error())
+
+		mockRepo.AssertCalled(t, "SoftDeleteVoucher", voucherID, 1)
+	})
+}
+
+func TestUpdateVoucher(t *testing.T) {
+	log := *zap.NewNop()
+
+	voucherID := 100
+	voucher := &models.Voucher{
+		VoucherName:    "Updated Discount 10%",
+		Quota:          50,
+		PointsRequired: 25,
+	}
+
+	t.Run("Successfully update voucher", func(t *testing.T) {
+		// Mock baru untuk test case ini
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+
+		// Setup mock
+		mockRepo.On("UpdateVoucher", voucher, voucherID, 1).Return(nil)
+
+		// Panggil service
+		err := service.UpdateVoucher(voucher, voucherID, 1)
+
+		// Verifikasi hasil
+		assert.NoError(t, err)
+		mockRepo.AssertCalled(t, "UpdateVoucher", voucher, voucherID, 1)
+	})
+
+	t.Run("Fail to update voucher", func(t *testing.T) {
+		// Mock baru untuk test case ini
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+
+		// Setup mock
+		mockRepo.On("UpdateVoucher", voucher, voucherID, 1).Return(fmt.Errorf("voucher update failed"))
+
+		// Panggil service
+		err := service.UpdateVoucher(voucher, voucherID, 1)
+
+		// Verifikasi hasil
+		assert.Error(t, err)
+		assert.EqualError(t, err, "voucher update failed")
+		mockRepo.AssertCalled(t, "UpdateVoucher", voucher, voucherID, 1)
+	})
+}
+
+func TestShowRedeemPoints(t *testing.T) {
+	log := *zap.NewNop()
+
+	expectedRedeemPoints := &[]managementvoucher.RedeemPoint{
+		{VoucherName: "Discount 10%", PointsRequired: 50, DiscountValue: money.New(10)},
+	}
+
+	t.Run("Successfully show redeem points", func(t *testing.T) {
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+
+		mockRepo.On("ShowRedeemPoints").Return(expectedRedeemPoints, nil)
+
+		redeemPoints, err := service.ShowRedeemPoints()
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedRedeemPoints, redeemPoints)
+		mockRepo.AssertCalled(t, "ShowRedeemPoints")
+	})
+
+	t.Run("Fail to show redeem points", func(t *testing.T) {
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+
+		mockRepo.On("ShowRedeemPoints").Return(nil, fmt.Errorf("failed to fetch redeem points"))
+
+		redeemPoints, err := service.ShowRedeemPoints()
+
+		assert.Error(t, err)
+		assert.Nil(t, redeemPoints)
+		assert.EqualError(t, err, "failed to fetch redeem points")
+		mockRepo.AssertCalled(t, "ShowRedeemPoints")
+	})
+}
+
+func TestGetVouchersByQueryParams(t *testing.T) {
+	log := *zap.NewNop()
+
+	status := "active"
+	area := "Jakarta"
+	voucherType := "discount"
+
+	expectedVouchers := &[]models.Voucher{
+		{VoucherName: "Discount 10%", Quota: 100, PointsRequired: 50},
+	}
+
+	t.Run("Successfully get vouchers by query params", func(t *testing.T) {
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+		mockRepo.On("GetVouchersByQueryParams", status, area, voucherType).Return(expectedVouchers, nil)
+
+		vouchers, err := service.GetVouchersByQueryParams(status, area, voucherType)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedVouchers, vouchers)
+		mockRepo.AssertCalled(t, "GetVouchersByQueryParams", status, area, voucherType)
+	})
+
+	t.Run("Fail to get vouchers by query params", func(t *testing.T) {
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+		mockRepo.On("GetVouchersByQueryParams", status, area, voucherType).Return(nil, fmt.Errorf("no vouchers found"))
+
+		vouchers, err := service.GetVouchersByQueryParams(status, area, voucherType)
+
+		assert.Error(t, err)
+		assert.Nil(t, vouchers)
+		assert.EqualError(t, err, "no vouchers found")
+		mockRepo.AssertCalled(t, "GetVouchersByQueryParams", status, area, voucherType)
+	})
+}
+
+func TestCreateRedeemVoucher(t *testing.T) {
+	log := *zap.NewNop()
+
+	redeem := &models.Redeem{
+		UserID:    1,
+		VoucherID: 100,
+	}
+	points := 50
+
+	t.Run("Successfully create redeem voucher", func(t *testing.T) {
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+		mockRepo.On("CreateRedeemVoucher", redeem, points).Return(nil)
+
+		err := service.CreateRedeemVoucher(redeem, points)
+
+		assert.NoError(t, err)
+		mockRepo.AssertCalled(t, "CreateRedeemVoucher", redeem, points)
+	})
+
+	t.Run("Fail to create redeem voucher", func(t *testing.T) {
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+		mockRepo.On("CreateRedeemVoucher", redeem, points).Return(fmt.Errorf("user already claimed this voucher"))
+
+		err := service.CreateRedeemVoucher(redeem, points)
+
+		assert.Error(t, err)
+		assert.EqualError(t, err, "user already claimed this voucher")
+		mockRepo.AssertCalled(t, "CreateRedeemVoucher", redeem, points)
+	})
+}
+
+func TestGenerateCodes(t *testing.T) {
+	log := *zap.NewNop()
+
+	t.Run("Empty alphabet uses the default", func(t *testing.T) {
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+
+		format := codegen.Format{Alphabet: codegen.DefaultAlphabet, Length: 8, Prefix: "SUMMER-", CheckDigit: true}
+		mockRepo.On("GenerateCodes", 3, format, 50000).Return(50000, nil)
+
+		created, err := service.GenerateCodes(3, models.GenerateCodesRequest{Count: 50000, Length: 8, Prefix: "SUMMER-", CheckDigit: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 50000, created)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Failure reports the codes already stored", func(t *testing.T) {
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+
+		format := codegen.Format{Alphabet: codegen.DefaultAlphabet, Length: 8}
+		mockRepo.On("GenerateCodes", 3, format, 50000).Return(20000, errors.New("connection reset"))
+
+		created, err := service.GenerateCodes(3, models.GenerateCodesRequest{Count: 50000, Length: 8})
+
+		assert.Error(t, err)
+		assert.Equal(t, 20000, created)
+	})
+
+	t.Run("Format too small for the count", func(t *testing.T) {
+		mockRepo := &managementvoucher.ManagementVoucherRepoMock{}
+		repo := repository.Repository{
+			Manage: mockRepo,
+		}
+		service := managementvoucherservice.NewManagementVoucherService(repo, &log)
+
+		_, err := service.GenerateCodes(3, models.GenerateCodesRequest{Count: 50000, Alphabet: "0123456789", Length: 4})
+
+		assert.ErrorIs(t, err, codegen.ErrSpaceTooSmall)
+		mockRepo.AssertNotCalled(t, "GenerateCodes")
+	})
+}
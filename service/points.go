@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+
+	"go.uber.org/zap"
+)
+
+var ErrZeroAdjustment = errors.New("adjustment must not be zero")
+
+type LedgerPage struct {
+	Entries []models.PointsLedgerEntry `json:"entries"`
+	Total   int64                      `json:"total"`
+	Page    int                        `json:"page"`
+	Limit   int                        `json:"limit"`
+}
+
+type ExpiringPoints struct {
+	Points int                `json:"points"`
+	Until  time.Time          `json:"until"`
+	Lots   []models.PointsLot `json:"lots"`
+}
+
+type PointsService interface {
+	GetBalance(userID int) (*models.PointsAccount, error)
+	GetLedger(userID, page, limit int) (*LedgerPage, error)
+	AdjustPoints(adminID, userID, points int, description string) (*models.PointsLedgerEntry, error)
+	GetExpiringPoints(userID, days int) (*ExpiringPoints, error)
+	ExpirePoints(now time.Time) (int, error)
+}
+
+type pointsService struct {
+	repo repository.Repository
+	log  *zap.Logger
+}
+
+func NewPointsService(repo repository.Repository, log *zap.Logger) PointsService {
+	return &pointsService{repo: repo, log: log}
+}
+
+func (s *pointsService) GetBalance(userID int) (*models.PointsAccount, error) {
+	return s.repo.Points.GetAccount(userID)
+}
+
+func (s *pointsService) GetLedger(userID, page, limit int) (*LedgerPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	entries, total, err := s.repo.Points.FindEntries(userID, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LedgerPage{Entries: entries, Total: total, Page: page, Limit: limit}, nil
+}
+
+// AdjustPoints credits or debits points by hand, e.g. for goodwill or corrections. The
+// ledger keeps which admin made the change.
+func (s *pointsService) AdjustPoints(adminID, userID, points int, description string) (*models.PointsLedgerEntry, error) {
+	if points == 0 {
+		return nil, ErrZeroAdjustment
+	}
+	if _, err := s.repo.User.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	entry := models.PointsLedgerEntry{
+		UserID:      userID,
+		EntryType:   models.LedgerAdjust,
+		Points:      points,
+		Reference:   "admin:" + strconv.Itoa(adminID),
+		Description: description,
+	}
+	if err := s.repo.Points.AddEntry(&entry); err != nil {
+		s.log.Error("Error from service adjusting points: " + err.Error())
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (s *pointsService) GetExpiringPoints(userID, days int) (*ExpiringPoints, error) {
+	if days < 1 || days > 365 {
+		days = 30
+	}
+	until := time.Now().AddDate(0, 0, days)
+
+	lots, err := s.repo.Points.FindExpiringLots(userID, until)
+	if err != nil {
+		return nil, err
+	}
+
+	result := ExpiringPoints{Until: until, Lots: lots}
+	for _, lot := range lots {
+		result.Points += lot.Remaining
+	}
+	return &result, nil
+}
+
+// ExpirePoints writes off every lot that expired by now and returns the points expired.
+// It stops at the first failing batch; the lots left over are picked up by the next run.
+func (s *pointsService) ExpirePoints(now time.Time) (int, error) {
+	const batchSize = 100
+	total := 0
+
+	for {
+		lotIDs, err := s.repo.Points.FindExpiredLotIDs(now, batchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(lotIDs) == 0 {
+			return total, nil
+		}
+
+		for _, lotID := range lotIDs {
+			expired, err := s.repo.Points.ExpireLot(lotID, now)
+			if err != nil {
+				return total, err
+			}
+			total += expired
+		}
+	}
+}
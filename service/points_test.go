@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type MockPointsRepository struct {
+	mock.Mock
+}
+
+func (m *MockPointsRepository) GetAccount(userID int) (*models.PointsAccount, error) {
+	args := m.Called(userID)
+	return args.Get(0).(*models.PointsAccount), args.Error(1)
+}
+
+func (m *MockPointsRepository) FindEntries(userID, page, limit int) ([]models.PointsLedgerEntry, int64, error) {
+	args := m.Called(userID, page, limit)
+	return args.Get(0).([]models.PointsLedgerEntry), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPointsRepository) AddEntry(entry *models.PointsLedgerEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockPointsRepository) HasEntry(entryType, reference string) (bool, error) {
+	args := m.Called(entryType, reference)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPointsRepository) FindExpiringLots(userID int, until time.Time) ([]models.PointsLot, error) {
+	args := m.Called(userID, until)
+	return args.Get(0).([]models.PointsLot), args.Error(1)
+}
+
+func (m *MockPointsRepository) FindExpiredLotIDs(now time.Time, limit int) ([]int, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockPointsRepository) ExpireLot(lotID int, now time.Time) (int, error) {
+	args := m.Called(lotID, now)
+	return args.Int(0), args.Error(1)
+}
+
+func TestPointsService_AdjustPoints(t *testing.T) {
+	t.Run("Records an adjust entry with the admin as reference", func(t *testing.T) {
+		userRepo, pointsRepo := new(MockUserRepository), new(MockPointsRepository)
+		pointsService := service.NewPointsService(repository.Repository{User: userRepo, Points: pointsRepo}, zap.NewNop())
+		userRepo.On("GetUserByID", 3).Return(models.User{ID: 3}, nil)
+		pointsRepo.On("AddEntry", mock.MatchedBy(func(e *models.PointsLedgerEntry) bool {
+			return e.UserID == 3 && e.EntryType == models.LedgerAdjust && e.Points == -20 && e.Reference == "admin:1"
+		})).Return(nil)
+
+		_, err := pointsService.AdjustPoints(1, 3, -20, "Correction")
+
+		assert.NoError(t, err)
+		pointsRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a zero adjustment", func(t *testing.T) {
+		pointsService := service.NewPointsService(repository.Repository{}, zap.NewNop())
+
+		_, err := pointsService.AdjustPoints(1, 3, 0, "Nothing")
+
+		assert.ErrorIs(t, err, service.ErrZeroAdjustment)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		userRepo, pointsRepo := new(MockUserRepository), new(MockPointsRepository)
+		pointsService := service.NewPointsService(repository.Repository{User: userRepo, Points: pointsRepo}, zap.NewNop())
+		userRepo.On("GetUserByID", 99).Return(models.User{}, gorm.ErrRecordNotFound)
+
+		_, err := pointsService.AdjustPoints(1, 99, 10, "Bonus")
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		pointsRepo.AssertNotCalled(t, "AddEntry", mock.Anything)
+	})
+}
+
+func TestPointsService_GetLedger(t *testing.T) {
+	pointsRepo := new(MockPointsRepository)
+	pointsService := service.NewPointsService(repository.Repository{Points: pointsRepo}, zap.NewNop())
+	pointsRepo.On("FindEntries", 3, 1, 20).Return([]models.PointsLedgerEntry{{ID: 1, UserID: 3, Points: 50}}, int64(1), nil)
+
+	page, err := pointsService.GetLedger(3, 0, 500)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, 20, page.Limit)
+	assert.Len(t, page.Entries, 1)
+}
+
+func TestPointsService_ExpirePoints(t *testing.T) {
+	now := time.Now()
+	pointsRepo := new(MockPointsRepository)
+	pointsService := service.NewPointsService(repository.Repository{Points: pointsRepo}, zap.NewNop())
+	pointsRepo.On("FindExpiredLotIDs", now, 100).Return([]int{4, 9}, nil).Once()
+	pointsRepo.On("FindExpiredLotIDs", now, 100).Return([]int{}, nil).Once()
+	pointsRepo.On("ExpireLot", 4, now).Return(30, nil)
+	pointsRepo.On("ExpireLot", 9, now).Return(15, nil)
+
+	expired, err := pointsService.ExpirePoints(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 45, expired)
+	pointsRepo.AssertExpectations(t)
+}
+
+func TestPointsService_GetExpiringPoints(t *testing.T) {
+	pointsRepo := new(MockPointsRepository)
+	pointsService := service.NewPointsService(repository.Repository{Points: pointsRepo}, zap.NewNop())
+	pointsRepo.On("FindExpiringLots", 3, mock.AnythingOfType("time.Time")).
+		Return([]models.PointsLot{{ID: 1, Remaining: 20}, {ID: 2, Remaining: 5}}, nil)
+
+	result, err := pointsService.GetExpiringPoints(3, 30)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 25, result.Points)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), result.Until, time.Minute)
+}
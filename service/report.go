@@ -0,0 +1,65 @@
+package service
+
+import (
+	"time"
+	"voucher_system/repository"
+	"voucher_system/utils/money"
+
+	"go.uber.org/zap"
+)
+
+// UsageReport sums voucher usage per currency and, where an exchange rate is known, in
+// the base currency. Currencies without a rate are listed in MissingRates and left out
+// of the base totals.
+type UsageReport struct {
+	From                  time.Time               `json:"from"`
+	To                    time.Time               `json:"to"`
+	BaseCurrency          money.Currency          `json:"base_currency" swaggertype:"string"`
+	Usages                int                     `json:"usages"`
+	BaseTransactionAmount money.Amount            `json:"base_transaction_amount" swaggertype:"number"`
+	BaseBenefitValue      money.Amount            `json:"base_benefit_value" swaggertype:"number"`
+	ByCurrency            []repository.UsageTotal `json:"by_currency"`
+	MissingRates          []money.Currency        `json:"missing_rates,omitempty" swaggertype:"array,string"`
+}
+
+type ReportService interface {
+	UsageReport(from, to time.Time) (*UsageReport, error)
+}
+
+type reportService struct {
+	repo  repository.Repository
+	log   *zap.Logger
+	rates money.Rates
+}
+
+func NewReportService(repo repository.Repository, log *zap.Logger, rates money.Rates) ReportService {
+	return &reportService{repo: repo, log: log, rates: rates}
+}
+
+// UsageReport reports the usages from from up to, but not including, to.
+func (s *reportService) UsageReport(from, to time.Time) (*UsageReport, error) {
+	totals, err := s.repo.History.SumByCurrency(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := UsageReport{From: from, To: to, BaseCurrency: s.rates.Base.OrDefault(), ByCurrency: totals}
+	for _, total := range totals {
+		transaction, err := s.rates.Convert(total.TransactionAmount, total.Currency)
+		if err != nil {
+			s.log.Warn("No exchange rate for usage report", zap.String("currency", string(total.Currency)))
+			report.MissingRates = append(report.MissingRates, total.Currency)
+			continue
+		}
+		benefit, err := s.rates.Convert(total.BenefitValue, total.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Usages += total.Usages
+		report.BaseTransactionAmount = report.BaseTransactionAmount.Add(transaction)
+		report.BaseBenefitValue = report.BaseBenefitValue.Add(benefit)
+	}
+
+	return &report, nil
+}
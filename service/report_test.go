@@ -0,0 +1,37 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+	"voucher_system/repository"
+	"voucher_system/service"
+	"voucher_system/utils/money"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestReportService_UsageReport(t *testing.T) {
+	from := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	historyRepo := new(MockHistoryRepository)
+	rates := money.NewRates(money.IDR, map[money.Currency]money.Amount{money.SGD: money.New(11750)})
+	reportService := service.NewReportService(repository.Repository{History: historyRepo}, zap.NewNop(), rates)
+
+	historyRepo.On("SumByCurrency", from, to).Return([]repository.UsageTotal{
+		{Currency: money.IDR, Usages: 3, TransactionAmount: money.New(750000), BenefitValue: money.New(75000)},
+		{Currency: money.MYR, Usages: 1, TransactionAmount: money.New(120), BenefitValue: money.New(12)},
+		{Currency: money.SGD, Usages: 2, TransactionAmount: money.MustParse("80.5"), BenefitValue: money.New(8)},
+	}, nil)
+
+	report, err := reportService.UsageReport(from, to)
+
+	assert.NoError(t, err)
+	assert.Equal(t, money.IDR, report.BaseCurrency)
+	assert.Equal(t, 5, report.Usages)
+	assert.Equal(t, money.MustParse("1695875"), report.BaseTransactionAmount)
+	assert.Equal(t, money.New(169000), report.BaseBenefitValue)
+	assert.Equal(t, []money.Currency{money.MYR}, report.MissingRates)
+	assert.Len(t, report.ByCurrency, 3)
+	historyRepo.AssertExpectations(t)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/service/discount"
+	"voucher_system/utils/money"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultReservationTTL is how long a reservation holds quota when the client does
+	// not ask for a different time.
+	DefaultReservationTTL = 15 * time.Minute
+	// MaxReservationTTL is the longest a reservation may hold quota.
+	MaxReservationTTL = time.Hour
+)
+
+var ErrInvalidReservationTTL = errors.New("reservation ttl must be between 1 second and 1 hour")
+
+// ReservationRequest is an order that wants to hold a voucher while it is being paid.
+type ReservationRequest struct {
+	UserID            int
+	OrderID           string
+	VoucherCode       string
+	TransactionAmount money.Amount
+	Currency          money.Currency
+	PaymentMethod     string
+	Area              string
+	// TTL is how long the reservation holds quota; zero means DefaultReservationTTL.
+	TTL time.Duration
+}
+
+type ReservationService interface {
+	Reserve(request ReservationRequest) (*models.VoucherReservation, error)
+	Confirm(userID, reservationID int) (*models.VoucherReservation, error)
+	Release(userID, reservationID int) (*models.VoucherReservation, error)
+	ExpireReservations(now time.Time) (int, error)
+}
+
+type reservationService struct {
+	repo    repository.Repository
+	log     *zap.Logger
+	earning EarningService
+}
+
+func NewReservationService(repo repository.Repository, log *zap.Logger, rates money.Rates) ReservationService {
+	return &reservationService{repo: repo, log: log, earning: NewEarningService(repo, log, rates)}
+}
+
+// Reserve validates the voucher for the order and holds one unit of its quota until the
+// reservation is confirmed, released or expires.
+func (s *reservationService) Reserve(request ReservationRequest) (*models.VoucherReservation, error) {
+	ttl := request.TTL
+	if ttl == 0 {
+		ttl = DefaultReservationTTL
+	}
+	if ttl < time.Second || ttl > MaxReservationTTL {
+		return nil, ErrInvalidReservationTTL
+	}
+
+	now := time.Now()
+	reservation := &models.VoucherReservation{
+		UserID:            request.UserID,
+		OrderID:           request.OrderID,
+		TransactionAmount: request.TransactionAmount,
+		Currency:          request.Currency,
+		PaymentMethod:     request.PaymentMethod,
+		Area:              request.Area,
+		ExpiresAt:         now.Add(ttl),
+	}
+	err := s.repo.Reservation.Reserve(reservation, request.VoucherCode, now, func(voucher *models.Voucher) (money.Amount, error) {
+		return discount.Calculate(voucher, request.TransactionAmount, money.Amount{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// Confirm records the usage of a held reservation and credits the points it earns.
+func (s *reservationService) Confirm(userID, reservationID int) (*models.VoucherReservation, error) {
+	reservation, history, err := s.repo.Reservation.Confirm(userID, reservationID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	earnForUsage(s.earning, s.log, history, reservation.PaymentMethod, reservation.Area, fmt.Sprintf("Purchase for order %s", reservation.OrderID))
+	return reservation, nil
+}
+
+func (s *reservationService) Release(userID, reservationID int) (*models.VoucherReservation, error) {
+	return s.repo.Reservation.Release(userID, reservationID)
+}
+
+// ExpireReservations gives the quota of every reservation that expired by now back and
+// returns how many it expired. It stops at the first failing batch; the rest is picked up
+// by the next run.
+func (s *reservationService) ExpireReservations(now time.Time) (int, error) {
+	const batchSize = 100
+	total := 0
+
+	for {
+		ids, err := s.repo.Reservation.FindExpiredIDs(now, batchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		for _, id := range ids {
+			expired, err := s.repo.Reservation.Expire(id, now)
+			if err != nil {
+				return total, err
+			}
+			if expired {
+				total++
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/service"
+	"voucher_system/utils/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockReservationRepository struct {
+	mock.Mock
+}
+
+func (m *MockReservationRepository) Reserve(reservation *models.VoucherReservation, voucherCode string, now time.Time, benefit repository.BenefitFunc) error {
+	args := m.Called(reservation, voucherCode, now, benefit)
+	return args.Error(0)
+}
+
+func (m *MockReservationRepository) Confirm(userID, reservationID int, now time.Time) (*models.VoucherReservation, *models.History, error) {
+	args := m.Called(userID, reservationID, now)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.VoucherReservation), args.Get(1).(*models.History), args.Error(2)
+}
+
+func (m *MockReservationRepository) Release(userID, reservationID int) (*models.VoucherReservation, error) {
+	args := m.Called(userID, reservationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.VoucherReservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) FindExpiredIDs(now time.Time, limit int) ([]int, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockReservationRepository) Expire(reservationID int, now time.Time) (bool, error) {
+	args := m.Called(reservationID, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestReservationService_Reserve(t *testing.T) {
+	t.Run("Holds the voucher with the default ttl and its benefit", func(t *testing.T) {
+		reservationRepo := new(MockReservationRepository)
+		reservationService := service.NewReservationService(repository.Repository{Reservation: reservationRepo}, zap.NewNop(), money.NewRates(money.IDR, nil))
+
+		voucher := &models.Voucher{ID: 4, DiscountType: models.DiscountPercentage, DiscountValue: money.New(10)}
+		reservationRepo.On("Reserve", mock.AnythingOfType("*models.VoucherReservation"), "PROMO", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				benefitValue, err := args.Get(3).(repository.BenefitFunc)(voucher)
+				assert.NoError(t, err)
+				assert.Equal(t, money.New(25000), benefitValue)
+			}).
+			Return(nil)
+
+		before := time.Now()
+		reservation, err := reservationService.Reserve(service.ReservationRequest{
+			UserID:            1,
+			OrderID:           "INV-1",
+			VoucherCode:       "PROMO",
+			TransactionAmount: money.New(250000),
+			Currency:          money.IDR,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "INV-1", reservation.OrderID)
+		assert.WithinDuration(t, before.Add(service.DefaultReservationTTL), reservation.ExpiresAt, time.Second)
+		reservationRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a ttl above the maximum", func(t *testing.T) {
+		reservationRepo := new(MockReservationRepository)
+		reservationService := service.NewReservationService(repository.Repository{Reservation: reservationRepo}, zap.NewNop(), money.NewRates(money.IDR, nil))
+
+		_, err := reservationService.Reserve(service.ReservationRequest{UserID: 1, OrderID: "INV-1", TTL: 2 * time.Hour})
+
+		assert.ErrorIs(t, err, service.ErrInvalidReservationTTL)
+		reservationRepo.AssertNotCalled(t, "Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestReservationService_Confirm(t *testing.T) {
+	t.Run("Credits points for the confirmed usage", func(t *testing.T) {
+		reservationRepo, pointsRepo, earningRepo := new(MockReservationRepository), new(MockPointsRepository), new(MockEarningRuleRepository)
+		reservationService := service.NewReservationService(repository.Repository{Reservation: reservationRepo, Points: pointsRepo, Earning: earningRepo}, zap.NewNop(), money.NewRates(money.IDR, nil))
+
+		usedAt := time.Now()
+		reservation := &models.VoucherReservation{ID: 9, UserID: 1, OrderID: "INV-1", Status: models.ReservationConfirmed}
+		history := &models.History{ID: 15, UserID: 1, TransactionAmount: money.New(250000), BenefitValue: money.New(25000), UsageDate: usedAt}
+		reservationRepo.On("Confirm", 1, 9, mock.Anything).Return(reservation, history, nil)
+		pointsRepo.On("HasEntry", models.LedgerEarn, "history:15").Return(false, nil)
+		earningRepo.On("FindActive", usedAt).Return([]models.EarningRule{{RuleType: models.EarnRuleBase, SpendPerPoint: money.New(10000)}}, nil)
+		pointsRepo.On("AddEntry", mock.MatchedBy(func(e *models.PointsLedgerEntry) bool {
+			return e.UserID == 1 && e.Points == 22 && e.Reference == "history:15"
+		})).Return(nil)
+
+		confirmed, err := reservationService.Confirm(1, 9)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.ReservationConfirmed, confirmed.Status)
+		pointsRepo.AssertExpectations(t)
+	})
+
+	t.Run("Expired reservations are not confirmed", func(t *testing.T) {
+		reservationRepo := new(MockReservationRepository)
+		reservationService := service.NewReservationService(repository.Repository{Reservation: reservationRepo}, zap.NewNop(), money.NewRates(money.IDR, nil))
+		reservationRepo.On("Confirm", 1, 9, mock.Anything).Return(nil, nil, repository.ErrReservationExpired)
+
+		_, err := reservationService.Confirm(1, 9)
+
+		assert.ErrorIs(t, err, repository.ErrReservationExpired)
+	})
+}
+
+func TestReservationService_ExpireReservations(t *testing.T) {
+	reservationRepo := new(MockReservationRepository)
+	reservationService := service.NewReservationService(repository.Repository{Reservation: reservationRepo}, zap.NewNop(), money.NewRates(money.IDR, nil))
+
+	now := time.Now()
+	reservationRepo.On("FindExpiredIDs", now, 100).Return([]int{3, 4}, nil).Once()
+	reservationRepo.On("FindExpiredIDs", now, 100).Return([]int{}, nil).Once()
+	reservationRepo.On("Expire", 3, now).Return(true, nil)
+	reservationRepo.On("Expire", 4, now).Return(false, nil)
+
+	expired, err := reservationService.ExpireReservations(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	reservationRepo.AssertExpectations(t)
+}
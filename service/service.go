@@ -1,24 +1,37 @@
-package service
-
-import (
-	"voucher_system/repository"
-	managementvoucherservice "voucher_system/service/management_voucher_service"
-
-	"go.uber.org/zap"
-)
-
-type Service struct {
-	User    UserService
-	Manage  managementvoucherservice.ManageVoucherService
-	Voucher VoucherService
-	History HistoryService
-}
-
-func NewService(repo repository.Repository, log *zap.Logger) Service {
-	return Service{
-		User:    NewUserService(repo, log),
-		Manage:  managementvoucherservice.NewManagementVoucherService(repo, log),
-		Voucher: NewVoucherService(repo, log),
-		History: NewHistoryService(repo, log),
-	}
-}
+package service
+
+import (
+	"voucher_system/repository"
+	managementvoucherservice "voucher_system/service/management_voucher_service"
+	"voucher_system/utils/money"
+
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	User        UserService
+	Manage      managementvoucherservice.ManageVoucherService
+	Voucher     VoucherService
+	Reservation ReservationService
+	History     HistoryService
+	Admin       AdminUserService
+	Points      PointsService
+	Earning     EarningService
+	Tier        TierService
+	Report      ReportService
+}
+
+func NewService(repo repository.Repository, log *zap.Logger, rates money.Rates) Service {
+	return Service{
+		User:        NewUserService(repo, log),
+		Manage:      managementvoucherservice.NewManagementVoucherService(repo, log),
+		Voucher:     NewVoucherService(repo, log, rates),
+		Reservation: NewReservationService(repo, log, rates),
+		History:     NewHistoryService(repo, log),
+		Admin:       NewAdminUserService(repo, log),
+		Points:      NewPointsService(repo, log),
+		Earning:     NewEarningService(repo, log, rates),
+		Tier:        NewTierService(repo, log, rates),
+		Report:      NewReportService(repo, log, rates),
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/repository/tier"
+	"voucher_system/utils/money"
+
+	"go.uber.org/zap"
+)
+
+type TierStatus struct {
+	tier.UserStats
+	NextTier *models.Tier        `json:"next_tier,omitempty"`
+	Changes  []models.TierChange `json:"changes"`
+}
+
+type TierService interface {
+	ListTiers() ([]models.Tier, error)
+	GetTierStatus(userID int) (*TierStatus, error)
+	RecalculateTiers(now time.Time) (int, error)
+}
+
+type tierService struct {
+	repo  repository.Repository
+	log   *zap.Logger
+	rates money.Rates
+}
+
+func NewTierService(repo repository.Repository, log *zap.Logger, rates money.Rates) TierService {
+	return &tierService{repo: repo, log: log, rates: rates}
+}
+
+func (s *tierService) ListTiers() ([]models.Tier, error) {
+	return s.repo.Tier.FindAll()
+}
+
+func (s *tierService) GetTierStatus(userID int) (*TierStatus, error) {
+	stats, err := s.repo.Tier.FindStats(userID, tierWindowStart(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillRollingSpend(stats); err != nil {
+		return nil, err
+	}
+
+	tiers, err := s.repo.Tier.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.repo.Tier.FindChanges(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := TierStatus{UserStats: *stats, Changes: changes}
+	current := rankOf(tiers, stats.Tier)
+	for i := range tiers {
+		if tiers[i].Rank > current {
+			status.NextTier = &tiers[i]
+			break
+		}
+	}
+	return &status, nil
+}
+
+// RecalculateTiers moves every user to the tier their rolling spend or points qualify
+// for and returns how many users changed tier.
+func (s *tierService) RecalculateTiers(now time.Time) (int, error) {
+	const batchSize = 500
+
+	tiers, err := s.repo.Tier.FindAll()
+	if err != nil || len(tiers) == 0 {
+		return 0, err
+	}
+
+	since := tierWindowStart(now)
+	changed, afterUserID := 0, 0
+	for {
+		batch, err := s.repo.Tier.FindStatsBatch(since, afterUserID, batchSize)
+		if err != nil {
+			return changed, err
+		}
+		if len(batch) == 0 {
+			return changed, nil
+		}
+
+		for _, stats := range batch {
+			afterUserID = stats.UserID
+			if err := s.fillRollingSpend(&stats); err != nil {
+				return changed, err
+			}
+
+			target := QualifyingTier(tiers, stats.RollingSpend, stats.RollingPoints)
+			if target == stats.Tier {
+				continue
+			}
+
+			direction := models.TierUpgrade
+			if rankOf(tiers, target) < rankOf(tiers, stats.Tier) {
+				direction = models.TierDowngrade
+			}
+
+			err := s.repo.Tier.ChangeTier(&models.TierChange{
+				UserID:        stats.UserID,
+				FromTier:      stats.Tier,
+				ToTier:        target,
+				Direction:     direction,
+				RollingSpend:  stats.RollingSpend,
+				RollingPoints: stats.RollingPoints,
+			})
+			if err != nil {
+				return changed, err
+			}
+			changed++
+		}
+	}
+}
+
+// fillRollingSpend sets RollingSpend to the spend in every currency converted to the
+// base currency, which tier thresholds are set in. Spend in a currency without an
+// exchange rate cannot be compared and is left out.
+func (s *tierService) fillRollingSpend(stats *tier.UserStats) error {
+	total := money.Amount{}
+	for currency, spend := range stats.SpendByCurrency {
+		converted, err := s.rates.Convert(spend, currency)
+		if errors.Is(err, money.ErrNoRate) {
+			s.log.Warn("Spend left out of the tier window", zap.Int("userID", stats.UserID), zap.String("currency", string(currency)))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		total = total.Add(converted)
+	}
+	stats.RollingSpend = total
+	return nil
+}
+
+// QualifyingTier returns the highest of tiers, ordered by rank, whose spend or points
+// threshold is met. A zero threshold is not set, and a tier without any threshold is
+// open to everyone.
+func QualifyingTier(tiers []models.Tier, spend money.Amount, points int) string {
+	qualified := ""
+	for _, t := range tiers {
+		open := t.MinSpend.IsZero() && t.MinPoints == 0
+		bySpend := t.MinSpend.IsPositive() && !spend.LessThan(t.MinSpend)
+		byPoints := t.MinPoints > 0 && points >= t.MinPoints
+		if open || bySpend || byPoints {
+			qualified = t.Name
+		}
+	}
+	return qualified
+}
+
+func rankOf(tiers []models.Tier, name string) int {
+	for _, t := range tiers {
+		if t.Name == name {
+			return t.Rank
+		}
+	}
+	return 0
+}
+
+func tierWindowStart(now time.Time) time.Time {
+	return now.AddDate(0, -models.TierWindowMonths, 0)
+}
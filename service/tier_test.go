@@ -0,0 +1,103 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/repository/tier"
+	"voucher_system/service"
+	"voucher_system/utils/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockTierRepository struct {
+	mock.Mock
+}
+
+func (m *MockTierRepository) FindAll() ([]models.Tier, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Tier), args.Error(1)
+}
+
+func (m *MockTierRepository) FindStats(userID int, since time.Time) (*tier.UserStats, error) {
+	args := m.Called(userID, since)
+	return args.Get(0).(*tier.UserStats), args.Error(1)
+}
+
+func (m *MockTierRepository) FindStatsBatch(since time.Time, afterUserID, limit int) ([]tier.UserStats, error) {
+	args := m.Called(since, afterUserID, limit)
+	return args.Get(0).([]tier.UserStats), args.Error(1)
+}
+
+func (m *MockTierRepository) ChangeTier(change *models.TierChange) error {
+	args := m.Called(change)
+	return args.Error(0)
+}
+
+func (m *MockTierRepository) FindChanges(userID int) ([]models.TierChange, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.TierChange), args.Error(1)
+}
+
+func TestQualifyingTier(t *testing.T) {
+	tiers := models.TierSeed()
+
+	assert.Equal(t, models.TierSilver, service.QualifyingTier(tiers, money.New(0), 0))
+	assert.Equal(t, models.TierGold, service.QualifyingTier(tiers, money.New(5000000), 0))
+	assert.Equal(t, models.TierGold, service.QualifyingTier(tiers, money.New(0), 500))
+	assert.Equal(t, models.TierPlatinum, service.QualifyingTier(tiers, money.New(1000), 2500))
+	assert.Equal(t, models.TierSilver, service.QualifyingTier([]models.Tier{
+		{Name: models.TierSilver, Rank: 1},
+		{Name: models.TierGold, Rank: 2, MinSpend: money.New(5000000)},
+	}, money.New(100), 100))
+}
+
+func TestTierService_RecalculateTiers(t *testing.T) {
+	now := time.Now()
+	since := now.AddDate(0, -models.TierWindowMonths, 0)
+	tierRepo := new(MockTierRepository)
+	rates := money.NewRates(money.IDR, map[money.Currency]money.Amount{money.SGD: money.New(11750)})
+	tierService := service.NewTierService(repository.Repository{Tier: tierRepo}, zap.NewNop(), rates)
+
+	tierRepo.On("FindAll").Return(models.TierSeed(), nil)
+	tierRepo.On("FindStatsBatch", since, 0, 500).Return([]tier.UserStats{
+		{UserID: 1, Tier: ""},
+		{UserID: 2, Tier: models.TierGold, SpendByCurrency: tier.CurrencySpend{money.IDR: money.New(6000000)}},
+		{UserID: 3, Tier: models.TierPlatinum, RollingPoints: 600},
+		{UserID: 4, Tier: models.TierSilver, SpendByCurrency: tier.CurrencySpend{money.SGD: money.New(500), money.MYR: money.New(100)}},
+	}, nil)
+	tierRepo.On("FindStatsBatch", since, 4, 500).Return([]tier.UserStats{}, nil)
+	tierRepo.On("ChangeTier", mock.MatchedBy(func(c *models.TierChange) bool {
+		return c.UserID == 1 && c.ToTier == models.TierSilver && c.Direction == models.TierUpgrade
+	})).Return(nil)
+	tierRepo.On("ChangeTier", mock.MatchedBy(func(c *models.TierChange) bool {
+		return c.UserID == 3 && c.FromTier == models.TierPlatinum && c.ToTier == models.TierGold && c.Direction == models.TierDowngrade
+	})).Return(nil)
+	// SGD 500 is IDR 5,875,000; MYR has no rate and is left out.
+	tierRepo.On("ChangeTier", mock.MatchedBy(func(c *models.TierChange) bool {
+		return c.UserID == 4 && c.ToTier == models.TierGold && c.RollingSpend.Cmp(money.New(5875000)) == 0
+	})).Return(nil)
+
+	changed, err := tierService.RecalculateTiers(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, changed)
+	tierRepo.AssertExpectations(t)
+}
+
+func TestTierService_GetTierStatus(t *testing.T) {
+	tierRepo := new(MockTierRepository)
+	tierService := service.NewTierService(repository.Repository{Tier: tierRepo}, zap.NewNop(), money.NewRates(money.IDR, nil))
+	tierRepo.On("FindStats", 3, mock.AnythingOfType("time.Time")).Return(&tier.UserStats{UserID: 3, Tier: models.TierSilver}, nil)
+	tierRepo.On("FindAll").Return(models.TierSeed(), nil)
+	tierRepo.On("FindChanges", 3).Return([]models.TierChange{}, nil)
+
+	status, err := tierService.GetTierStatus(3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.TierGold, status.NextTier.Name)
+}
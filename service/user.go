@@ -1,29 +1,124 @@
-package service
-
-import (
-	"voucher_system/models"
-	"voucher_system/repository"
-
-	"go.uber.org/zap"
-)
-
-type UserService interface {
-	Login(email string) (models.User, error)
-	Register(user models.User) error
-}
-
-type userService struct {
-	Repo repository.Repository
-	log  *zap.Logger
-}
-
-func NewUserService(repo repository.Repository, log *zap.Logger) UserService {
-	return &userService{Repo: repo, log: log}
-}
-
-func (s *userService) Login(email string) (models.User, error) {
-	return s.Repo.User.Login(email)
-}
-func (s *userService) Register(user models.User) error {
-	return s.Repo.User.Register(user)
-}
+package service
+
+import (
+	"errors"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPassword = errors.New("password is incorrect")
+	ErrWeakPassword    = errors.New("password must be at least 8 characters")
+	ErrEmailTaken      = errors.New("email is already registered")
+)
+
+type UserService interface {
+	Login(email string) (models.User, error)
+	Register(user models.User) error
+	GetUserByID(userID int) (models.User, error)
+	UpdateProfile(userID int, name string) (models.User, error)
+	ChangePassword(userID int, currentPassword, newPassword string) error
+	CheckEmailChange(userID int, password, newEmail string) error
+	ChangeEmail(userID int, newEmail string) error
+	DeleteAccount(userID int, password string) error
+	ConfirmPassword(userID int, password string) error
+}
+
+type userService struct {
+	Repo repository.Repository
+	log  *zap.Logger
+}
+
+func NewUserService(repo repository.Repository, log *zap.Logger) UserService {
+	return &userService{Repo: repo, log: log}
+}
+
+func (s *userService) Login(email string) (models.User, error) {
+	return s.Repo.User.Login(email)
+}
+func (s *userService) Register(user models.User) error {
+	if err := s.checkEmailAvailable(user.Email); err != nil {
+		return err
+	}
+	return s.Repo.User.Register(user)
+}
+
+func (s *userService) GetUserByID(userID int) (models.User, error) {
+	user, err := s.Repo.User.GetUserByID(userID)
+	if err != nil {
+		return models.User{}, err
+	}
+	user.Password = ""
+	return user, nil
+}
+
+func (s *userService) UpdateProfile(userID int, name string) (models.User, error) {
+	if err := s.Repo.User.UpdateName(userID, name); err != nil {
+		return models.User{}, err
+	}
+	return s.GetUserByID(userID)
+}
+
+func (s *userService) ChangePassword(userID int, currentPassword, newPassword string) error {
+	if err := s.verifyPassword(userID, currentPassword); err != nil {
+		return err
+	}
+	if len(newPassword) < 8 {
+		return ErrWeakPassword
+	}
+	return s.Repo.User.UpdatePassword(userID, utils.HashPassword(newPassword))
+}
+
+// CheckEmailChange verifies the password and that newEmail is free before a
+// verification code is sent to it.
+func (s *userService) CheckEmailChange(userID int, password, newEmail string) error {
+	if err := s.verifyPassword(userID, password); err != nil {
+		return err
+	}
+	return s.checkEmailAvailable(newEmail)
+}
+
+func (s *userService) ChangeEmail(userID int, newEmail string) error {
+	if err := s.checkEmailAvailable(newEmail); err != nil {
+		return err
+	}
+	return s.Repo.User.UpdateEmail(userID, newEmail)
+}
+
+func (s *userService) DeleteAccount(userID int, password string) error {
+	if err := s.verifyPassword(userID, password); err != nil {
+		return err
+	}
+	return s.Repo.User.DeleteUser(userID)
+}
+
+func (s *userService) ConfirmPassword(userID int, password string) error {
+	return s.verifyPassword(userID, password)
+}
+
+func (s *userService) verifyPassword(userID int, password string) error {
+	user, err := s.Repo.User.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !utils.CheckPassword(password, user.Password) {
+		s.log.Warn("Password confirmation failed", zap.Int("userID", userID))
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
+func (s *userService) checkEmailAvailable(email string) error {
+	_, err := s.Repo.User.Login(email)
+	if err == nil {
+		return ErrEmailTaken
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,168 @@
+package service_test
+
+import (
+	"testing"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/service"
+	"voucher_system/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Login(email string) (models.User, error) {
+	args := m.Called(email)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Register(user models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetUserByID(userID int) (models.User, error) {
+	args := m.Called(userID)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateName(userID int, name string) error {
+	args := m.Called(userID, name)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(userID int, hashedPassword string) error {
+	args := m.Called(userID, hashedPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateEmail(userID int, email string) error {
+	args := m.Called(userID, email)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DeleteUser(userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SearchUsers(query string, page, limit int) ([]models.User, int64, error) {
+	args := m.Called(query, page, limit)
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) GetUserSummary(userID int) (repository.UserSummary, error) {
+	args := m.Called(userID)
+	return args.Get(0).(repository.UserSummary), args.Error(1)
+}
+
+func (m *MockUserRepository) SetDisabled(userID int, disabled bool) error {
+	args := m.Called(userID, disabled)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateRole(userID int, role string) error {
+	args := m.Called(userID, role)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ForcePasswordReset(userID int, hashedPassword string) error {
+	args := m.Called(userID, hashedPassword)
+	return args.Error(0)
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	stored := models.User{ID: 1, Email: "john.doe@example.com", Password: utils.HashPassword("password1234")}
+
+	t.Run("Rejects a wrong current password", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		userService := service.NewUserService(repository.Repository{User: mockUserRepo}, zap.NewNop())
+		mockUserRepo.On("GetUserByID", 1).Return(stored, nil)
+
+		err := userService.ChangePassword(1, "wrong-password", "newpassword1234")
+
+		assert.ErrorIs(t, err, service.ErrInvalidPassword)
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects a short new password", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		userService := service.NewUserService(repository.Repository{User: mockUserRepo}, zap.NewNop())
+		mockUserRepo.On("GetUserByID", 1).Return(stored, nil)
+
+		err := userService.ChangePassword(1, "password1234", "short")
+
+		assert.ErrorIs(t, err, service.ErrWeakPassword)
+	})
+
+	t.Run("Stores a hash of the new password", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		userService := service.NewUserService(repository.Repository{User: mockUserRepo}, zap.NewNop())
+		mockUserRepo.On("GetUserByID", 1).Return(stored, nil)
+		mockUserRepo.On("UpdatePassword", 1, mock.MatchedBy(func(hash string) bool {
+			return utils.CheckPassword("newpassword1234", hash)
+		})).Return(nil)
+
+		err := userService.ChangePassword(1, "password1234", "newpassword1234")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserService_ChangeEmail(t *testing.T) {
+	t.Run("Rejects an email used by another account", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		userService := service.NewUserService(repository.Repository{User: mockUserRepo}, zap.NewNop())
+		mockUserRepo.On("Login", "jane.smith@example.com").Return(models.User{ID: 2}, nil)
+
+		err := userService.ChangeEmail(1, "jane.smith@example.com")
+
+		assert.ErrorIs(t, err, service.ErrEmailTaken)
+	})
+
+	t.Run("Updates a free email", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		userService := service.NewUserService(repository.Repository{User: mockUserRepo}, zap.NewNop())
+		mockUserRepo.On("Login", "john.new@example.com").Return(models.User{}, gorm.ErrRecordNotFound)
+		mockUserRepo.On("UpdateEmail", 1, "john.new@example.com").Return(nil)
+
+		err := userService.ChangeEmail(1, "john.new@example.com")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserService_Register(t *testing.T) {
+	t.Run("Rejects an email used by another account", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		userService := service.NewUserService(repository.Repository{User: mockUserRepo}, zap.NewNop())
+		mockUserRepo.On("Login", "jane.smith@example.com").Return(models.User{ID: 2}, nil)
+
+		err := userService.Register(models.User{Name: "Jane", Email: "jane.smith@example.com"})
+
+		assert.ErrorIs(t, err, service.ErrEmailTaken)
+		mockUserRepo.AssertNotCalled(t, "Register")
+	})
+
+	t.Run("Registers again with the email of a deleted account", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		userService := service.NewUserService(repository.Repository{User: mockUserRepo}, zap.NewNop())
+		// Login does not find soft-deleted accounts.
+		user := models.User{Name: "Bob", Email: "bob.brown@example.com"}
+		mockUserRepo.On("Login", "bob.brown@example.com").Return(models.User{}, gorm.ErrRecordNotFound)
+		mockUserRepo.On("Register", user).Return(nil)
+
+		err := userService.Register(user)
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
@@ -1,351 +1,392 @@
-package service_test
-
-import (
-	"testing"
-	"time"
-	"voucher_system/models"
-	"voucher_system/repository"
-	"voucher_system/service"
-
-	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
-	"go.uber.org/zap"
-)
-
-type MockVoucherRepository struct {
-	mock.Mock
-}
-
-func (m *MockVoucherRepository) FindAll(userID int, voucherType string) ([]*models.Voucher, error) {
-	args := m.Called(userID, voucherType)
-	return args.Get(0).([]*models.Voucher), args.Error(1)
-}
-
-func (m *MockVoucherRepository) FindValidVoucher(userID int, voucherCode, area string, transactionAmount, shippingAmount float64, paymentMethod string, transactionDate time.Time) (*models.Voucher, error) {
-	args := m.Called(userID, voucherCode, area, transactionAmount, shippingAmount, paymentMethod, transactionDate)
-	return args.Get(0).(*models.Voucher), args.Error(1)
-}
-func (m *MockVoucherRepository) UpdateVoucherQuota(voucherID int, quota int) error {
-	args := m.Called(voucherID, quota)
-	return args.Error(0)
-}
-
-type MockHistoryRepository struct {
-	mock.Mock
-}
-
-func (m *MockHistoryRepository) CreateHistory(history *models.History) error {
-	args := m.Called(history)
-	return args.Error(0)
-}
-func (m *MockHistoryRepository) FindUsageHistoryByUser(userID int) ([]models.History, error) {
-	args := m.Called(userID)
-	return args.Get(0).([]models.History), args.Error(1)
-}
-
-type MockRedeemRepository struct {
-	mock.Mock
-}
-
-func (m *MockRedeemRepository) FindUsersByVoucherCode(voucherCode string) ([]models.Redeem, error) {
-	args := m.Called(voucherCode)
-	return args.Get(0).([]models.Redeem), args.Error(1)
-}
-func (m *MockRedeemRepository) FindRedeemHistoryByUser(userID int) ([]models.Redeem, error) {
-	args := m.Called(userID)
-	return args.Get(0).([]models.Redeem), args.Error(1)
-}
-
-type MockRepository struct {
-	mock.Mock
-	VoucherRepo repository.VoucherRepository
-	HistoryRepo repository.HistoryRepository
-	RedeemRepo  repository.RedeemRepository
-}
-
-func (m *MockRepository) Voucher() repository.VoucherRepository {
-	return m.VoucherRepo
-}
-
-func (m *MockRepository) Redeem() repository.RedeemRepository {
-	return m.RedeemRepo
-}
-func (m *MockRepository) History() repository.HistoryRepository {
-	return m.HistoryRepo
-}
-
-func TestVoucherService_FindVouchers(t *testing.T) {
-	mockVoucherRepo := new(MockVoucherRepository)
-	mockHistoryRepo := new(MockHistoryRepository)
-
-	mockRepo := &repository.Repository{
-		Voucher: mockVoucherRepo,
-		History: mockHistoryRepo,
-	}
-
-	logger := zap.NewNop()
-	service := service.NewVoucherService(*mockRepo, logger)
-
-	userID := 1
-	voucherType := "e-commerce"
-
-	mockVoucher := &models.Voucher{
-		ID:            1,
-		VoucherCode:   "VOUCHER1",
-		VoucherType:   voucherType,
-		Quota:         100,
-		DiscountValue: 10,
-	}
-
-	mockVoucherRepo.On("FindAll", userID, voucherType).Return([]*models.Voucher{mockVoucher}, nil)
-
-	vouchers, err := service.FindVouchers(userID, voucherType)
-
-	mockVoucherRepo.AssertExpectations(t)
-	mockHistoryRepo.AssertExpectations(t)
-
-	assert.NoError(t, err)
-	assert.Len(t, vouchers, 1)
-	assert.Equal(t, "VOUCHER1", vouchers[0].VoucherCode)
-}
-
-func TestVoucherService_FindVouchers_NoVouchers(t *testing.T) {
-	mockVoucherRepo := new(MockVoucherRepository)
-	mockHistoryRepo := new(MockHistoryRepository)
-
-	mockRepo := &repository.Repository{
-		Voucher: mockVoucherRepo,
-		History: mockHistoryRepo,
-	}
-
-	logger := zap.NewNop()
-	service := service.NewVoucherService(*mockRepo, logger)
-
-	userID := 1
-	voucherType := "e-commerce"
-
-	mockVoucherRepo.On("FindAll", userID, voucherType).Return([]*models.Voucher{}, nil)
-
-	vouchers, err := service.FindVouchers(userID, voucherType)
-
-	mockVoucherRepo.AssertExpectations(t)
-	mockHistoryRepo.AssertExpectations(t)
-
-	assert.Error(t, err)
-	assert.Nil(t, vouchers)
-	assert.Equal(t, "no vouchers available", err.Error())
-}
-
-func TestVoucherService_ValidateVoucher_Success(t *testing.T) {
-	mockVoucherRepo := new(MockVoucherRepository)
-	mockHistoryRepo := new(MockHistoryRepository)
-
-	mockRepo := &repository.Repository{
-		Voucher: mockVoucherRepo,
-		History: mockHistoryRepo,
-	}
-
-	logger := zap.NewNop()
-	service := service.NewVoucherService(*mockRepo, logger)
-
-	userID := 1
-	voucherCode := "VOUCHER1"
-	transactionAmount := 100.0
-	shippingAmount := 20.0
-	area := "area1"
-	paymentMethod := "credit"
-	transactionDate := time.Now()
-
-	mockVoucher := &models.Voucher{
-		ID:              1,
-		VoucherCode:     voucherCode,
-		VoucherCategory: "Discount",
-		DiscountValue:   10.0,
-		Quota:           100,
-	}
-
-	mockVoucherRepo.On("FindValidVoucher", userID, voucherCode, area, transactionAmount, shippingAmount, paymentMethod, transactionDate).
-		Return(mockVoucher, nil)
-
-	voucher, benefitValue, err := service.ValidateVoucher(userID, voucherCode, transactionAmount, shippingAmount, area, paymentMethod, transactionDate)
-
-	mockVoucherRepo.AssertExpectations(t)
-	mockHistoryRepo.AssertExpectations(t)
-
-	assert.NoError(t, err)
-	assert.NotNil(t, voucher)
-	assert.Equal(t, 10.0, benefitValue)
-}
-
-// func TestVoucherService_UseVoucher_Success(t *testing.T) {
-// 	mockVoucherRepo := new(MockVoucherRepository)
-// 	mockHistoryRepo := new(MockHistoryRepository)
-// 	mockRedeemRepo := new(repository.MockRedeemRepository)
-
-// 	mockRepo := &repository.Repository{
-// 		Voucher: mockVoucherRepo,
-// 		History: mockHistoryRepo,
-// 		Redeem:  mockRedeemRepo,
-// 	}
-
-// 	logger := zap.NewNop()
-// 	service := service.NewVoucherService(*mockRepo, logger)
-
-// 	userID := 1
-// 	voucherCode := "VOUCHER1"
-// 	transactionAmount := 100.0
-// 	paymentMethod := "credit"
-// 	area := "area1"
-
-// 	mockVoucher := &models.Voucher{
-// 		ID:              1,
-// 		VoucherCode:     voucherCode,
-// 		VoucherCategory: "Discount",
-// 		DiscountValue:   10.0,
-// 		Quota:           100,
-// 	}
-
-// 	benefitValue := 10.0
-
-// 	mockVoucherRepo.On("FindValidVoucher", userID, voucherCode, area, transactionAmount, 0.0, paymentMethod, mock.Anything).
-// 		Return(mockVoucher, benefitValue, nil)
-
-// 	mockHistoryRepo.On("CreateHistory", mock.AnythingOfType("*models.History")).Return(nil)
-
-// 	mockVoucherRepo.On("UpdateVoucherQuota", mock.Anything, mock.Anything).Return(nil)
-
-// 	err := service.UseVoucher(userID, voucherCode, transactionAmount, paymentMethod, area)
-
-// 	mockVoucherRepo.AssertExpectations(t)
-// 	mockHistoryRepo.AssertExpectations(t)
-// 	mockRedeemRepo.AssertExpectations(t)
-
-// 	assert.NoError(t, err)
-// }
-
-// func TestVoucherService_UseVoucher_QuotaExceeded(t *testing.T) {
-// 	mockVoucherRepo := new(MockVoucherRepository)
-// 	mockHistoryRepo := new(MockHistoryRepository)
-// 	mockRedeemRepo := new(repository.MockRedeemRepository)
-
-// 	mockRepo := &repository.Repository{
-// 		Voucher: mockVoucherRepo,
-// 		History: mockHistoryRepo,
-// 		Redeem:  mockRedeemRepo,
-// 	}
-
-// 	logger := zap.NewNop()
-// 	service := service.NewVoucherService(*mockRepo, logger)
-
-// 	userID := 1
-// 	voucherCode := "VOUCHER1"
-// 	transactionAmount := 100.0
-// 	paymentMethod := "credit"
-// 	area := "area1"
-
-// 	mockVoucher := &models.Voucher{
-// 		ID:              1,
-// 		VoucherCode:     voucherCode,
-// 		VoucherCategory: "Discount",
-// 		DiscountValue:   10.0,
-// 		Quota:           0, // This will trigger the "quota exceeded" scenario
-// 	}
-
-// 	mockVoucherRepo.On("FindValidVoucher", userID, voucherCode, area, transactionAmount, 0.0, paymentMethod, mock.Anything).
-//     Return(mockVoucher, 10.0, nil)
-
-// 	mockHistoryRepo.On("CreateHistory", mock.AnythingOfType("*models.History")).Return(nil)
-
-// 	mockVoucherRepo.On("UpdateVoucherQuota", mock.Anything, mock.Anything).Return(nil)
-
-// 	err := service.UseVoucher(userID, voucherCode, transactionAmount, paymentMethod, area)
-
-// 	mockVoucherRepo.AssertExpectations(t)
-// 	mockHistoryRepo.AssertExpectations(t)
-// 	mockRedeemRepo.AssertExpectations(t)
-
-// 	assert.Error(t, err)
-// 	assert.Equal(t, "voucher quota exceeded", err.Error())
-// }
-
-func TestHistoryService_GetRedeemHistoryByUser(t *testing.T) {
-	mockRedeemRepo := new(MockRedeemRepository)
-	mockHistoryRepo := new(MockHistoryRepository)
-
-	mockRepo := &repository.Repository{
-		Redeem:  mockRedeemRepo,
-		History: mockHistoryRepo,
-	}
-
-	logger := zap.NewNop()
-	service := service.NewHistoryService(*mockRepo, logger)
-
-	userID := 1
-	mockRedeemHistory := []models.Redeem{
-		{ID: 1, VoucherID: 1, UserID: userID},
-	}
-
-	mockRedeemRepo.On("FindRedeemHistoryByUser", userID).Return(mockRedeemHistory, nil)
-
-	history, err := service.GetRedeemHistoryByUser(userID)
-
-	mockRedeemRepo.AssertExpectations(t)
-	assert.NoError(t, err)
-	assert.Len(t, history, 1)
-	assert.Equal(t, 1, history[0].VoucherID)
-}
-
-func TestHistoryService_GetUsageHistoryByUser(t *testing.T) {
-	mockRedeemRepo := new(MockRedeemRepository)
-	mockHistoryRepo := new(MockHistoryRepository)
-
-	mockRepo := &repository.Repository{
-		Redeem:  mockRedeemRepo,
-		History: mockHistoryRepo,
-	}
-
-	logger := zap.NewNop()
-	service := service.NewHistoryService(*mockRepo, logger)
-
-	userID := 1
-	mockUsageHistory := []models.History{
-		{ID: 1, UserID: userID, VoucherID: 1, UsageDate: time.Now()},
-	}
-
-	mockHistoryRepo.On("FindUsageHistoryByUser", userID).Return(mockUsageHistory, nil)
-
-	history, err := service.GetUsageHistoryByUser(userID)
-
-	mockHistoryRepo.AssertExpectations(t)
-	assert.NoError(t, err)
-	assert.Len(t, history, 1)
-	assert.Equal(t, 1, history[0].VoucherID)
-}
-
-func TestHistoryService_GetUsersByVoucherCode(t *testing.T) {
-	mockRedeemRepo := new(MockRedeemRepository)
-	mockHistoryRepo := new(MockHistoryRepository)
-
-	mockRepo := &repository.Repository{
-		Redeem:  mockRedeemRepo,
-		History: mockHistoryRepo,
-	}
-
-	logger := zap.NewNop()
-	service := service.NewHistoryService(*mockRepo, logger)
-
-	voucherCode := "VOUCHER1"
-	mockUsers := []models.Redeem{
-		{ID: 1, UserID: 1, VoucherID: 1, RedeemDate: time.Now()},
-	}
-
-	mockRedeemRepo.On("FindUsersByVoucherCode", voucherCode).Return(mockUsers, nil)
-
-	users, err := service.GetUsersByVoucherCode(voucherCode)
-
-	mockRedeemRepo.AssertExpectations(t)
-	assert.NoError(t, err)
-	assert.Len(t, users, 1)
-	assert.Equal(t, 1, users[0].VoucherID)
-	assert.Equal(t, 1, users[0].UserID)
-}
+package service_test
+
+import (
+	"testing"
+	"time"
+	"voucher_system/models"
+	"voucher_system/repository"
+	"voucher_system/service"
+	"voucher_system/utils/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockVoucherRepository struct {
+	mock.Mock
+}
+
+func (m *MockVoucherRepository) FindAll(userID int, voucherType string) ([]*models.Voucher, error) {
+	args := m.Called(userID, voucherType)
+	return args.Get(0).([]*models.Voucher), args.Error(1)
+}
+
+func (m *MockVoucherRepository) FindValidVoucher(userID int, voucherCode, area string, transactionAmount, shippingAmount money.Amount, currency money.Currency, paymentMethod string, transactionDate time.Time) (*models.Voucher, error) {
+	args := m.Called(userID, voucherCode, area, transactionAmount, shippingAmount, currency, paymentMethod, transactionDate)
+	return args.Get(0).(*models.Voucher), args.Error(1)
+}
+func (m *MockVoucherRepository) UseVoucher(userID int, voucherCode, orderID, area string, transactionAmount money.Amount, currency money.Currency, paymentMethod string, usedAt time.Time, benefit repository.BenefitFunc) (*models.History, error) {
+	args := m.Called(userID, voucherCode, orderID, area, transactionAmount, currency, paymentMethod, usedAt, benefit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.History), args.Error(1)
+}
+
+type MockHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockHistoryRepository) CreateHistory(history *models.History) error {
+	args := m.Called(history)
+	return args.Error(0)
+}
+func (m *MockHistoryRepository) FindUsageHistoryByUser(userID int) ([]models.History, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.History), args.Error(1)
+}
+func (m *MockHistoryRepository) SumByCurrency(from, to time.Time) ([]repository.UsageTotal, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]repository.UsageTotal), args.Error(1)
+}
+func (m *MockHistoryRepository) Reverse(reversal repository.UsageReversal) (*models.History, error) {
+	args := m.Called(reversal)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.History), args.Error(1)
+}
+
+type MockRedeemRepository struct {
+	mock.Mock
+}
+
+func (m *MockRedeemRepository) FindUsersByVoucherCode(voucherCode string) ([]models.Redeem, error) {
+	args := m.Called(voucherCode)
+	return args.Get(0).([]models.Redeem), args.Error(1)
+}
+func (m *MockRedeemRepository) FindRedeemHistoryByUser(userID int) ([]models.Redeem, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Redeem), args.Error(1)
+}
+
+type MockRepository struct {
+	mock.Mock
+	VoucherRepo repository.VoucherRepository
+	HistoryRepo repository.HistoryRepository
+	RedeemRepo  repository.RedeemRepository
+}
+
+func (m *MockRepository) Voucher() repository.VoucherRepository {
+	return m.VoucherRepo
+}
+
+func (m *MockRepository) Redeem() repository.RedeemRepository {
+	return m.RedeemRepo
+}
+func (m *MockRepository) History() repository.HistoryRepository {
+	return m.HistoryRepo
+}
+
+func TestVoucherService_FindVouchers(t *testing.T) {
+	mockVoucherRepo := new(MockVoucherRepository)
+	mockHistoryRepo := new(MockHistoryRepository)
+
+	mockRepo := &repository.Repository{
+		Voucher: mockVoucherRepo,
+		History: mockHistoryRepo,
+	}
+
+	logger := zap.NewNop()
+	service := service.NewVoucherService(*mockRepo, logger, money.NewRates(money.IDR, nil))
+
+	userID := 1
+	voucherType := "e-commerce"
+
+	mockVoucher := &models.Voucher{
+		ID:            1,
+		VoucherCode:   "VOUCHER1",
+		VoucherType:   voucherType,
+		Quota:         100,
+		DiscountValue: money.New(10),
+	}
+
+	mockVoucherRepo.On("FindAll", userID, voucherType).Return([]*models.Voucher{mockVoucher}, nil)
+
+	vouchers, err := service.FindVouchers(userID, voucherType)
+
+	mockVoucherRepo.AssertExpectations(t)
+	mockHistoryRepo.AssertExpectations(t)
+
+	assert.NoError(t, err)
+	assert.Len(t, vouchers, 1)
+	assert.Equal(t, "VOUCHER1", vouchers[0].VoucherCode)
+}
+
+func TestVoucherService_FindVouchers_NoVouchers(t *testing.T) {
+	mockVoucherRepo := new(MockVoucherRepository)
+	mockHistoryRepo := new(MockHistoryRepository)
+
+	mockRepo := &repository.Repository{
+		Voucher: mockVoucherRepo,
+		History: mockHistoryRepo,
+	}
+
+	logger := zap.NewNop()
+	service := service.NewVoucherService(*mockRepo, logger, money.NewRates(money.IDR, nil))
+
+	userID := 1
+	voucherType := "e-commerce"
+
+	mockVoucherRepo.On("FindAll", userID, voucherType).Return([]*models.Voucher{}, nil)
+
+	vouchers, err := service.FindVouchers(userID, voucherType)
+
+	mockVoucherRepo.AssertExpectations(t)
+	mockHistoryRepo.AssertExpectations(t)
+
+	assert.Error(t, err)
+	assert.Nil(t, vouchers)
+	assert.Equal(t, "no vouchers available", err.Error())
+}
+
+func TestVoucherService_ValidateVoucher_Success(t *testing.T) {
+	mockVoucherRepo := new(MockVoucherRepository)
+	mockHistoryRepo := new(MockHistoryRepository)
+
+	mockRepo := &repository.Repository{
+		Voucher: mockVoucherRepo,
+		History: mockHistoryRepo,
+	}
+
+	logger := zap.NewNop()
+	service := service.NewVoucherService(*mockRepo, logger, money.NewRates(money.IDR, nil))
+
+	userID := 1
+	voucherCode := "VOUCHER1"
+	transactionAmount := money.New(100)
+	shippingAmount := money.New(20)
+	area := "area1"
+	paymentMethod := "credit"
+	transactionDate := time.Now()
+
+	mockVoucher := &models.Voucher{
+		ID:              1,
+		VoucherCode:     voucherCode,
+		VoucherCategory: "Discount",
+		DiscountType:    models.DiscountPercentage,
+		DiscountValue:   money.New(10),
+		Quota:           100,
+	}
+
+	mockVoucherRepo.On("FindValidVoucher", userID, voucherCode, area, transactionAmount, shippingAmount, money.IDR, paymentMethod, transactionDate).
+		Return(mockVoucher, nil)
+
+	voucher, benefitValue, err := service.ValidateVoucher(userID, voucherCode, transactionAmount, shippingAmount, money.IDR, area, paymentMethod, transactionDate)
+
+	mockVoucherRepo.AssertExpectations(t)
+	mockHistoryRepo.AssertExpectations(t)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, voucher)
+	assert.Equal(t, money.New(10), benefitValue)
+}
+
+func TestVoucherService_UseVoucher_Success(t *testing.T) {
+	mockVoucherRepo := new(MockVoucherRepository)
+	mockPointsRepo := new(MockPointsRepository)
+	mockEarningRepo := new(MockEarningRuleRepository)
+
+	mockRepo := &repository.Repository{
+		Voucher: mockVoucherRepo,
+		Points:  mockPointsRepo,
+		Earning: mockEarningRepo,
+	}
+
+	logger := zap.NewNop()
+	service := service.NewVoucherService(*mockRepo, logger, money.NewRates(money.IDR, nil))
+
+	userID := 1
+	voucherCode := "VOUCHER1"
+	transactionAmount := money.New(100)
+	paymentMethod := "credit"
+	area := "area1"
+
+	mockVoucher := &models.Voucher{
+		ID:              1,
+		VoucherCode:     voucherCode,
+		VoucherCategory: "Discount",
+		DiscountType:    models.DiscountPercentage,
+		DiscountValue:   money.New(10),
+		Quota:           100,
+	}
+
+	mockVoucherRepo.On("UseVoucher", userID, voucherCode, "INV-1", area, transactionAmount, money.IDR, paymentMethod, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			benefitValue, err := args.Get(8).(repository.BenefitFunc)(mockVoucher)
+			assert.NoError(t, err)
+			assert.Equal(t, money.New(10), benefitValue)
+		}).
+		Return(&models.History{ID: 7, UserID: userID, VoucherID: mockVoucher.ID, TransactionAmount: transactionAmount, BenefitValue: money.New(10)}, nil)
+	mockPointsRepo.On("HasEntry", models.LedgerEarn, "history:7").Return(false, nil)
+	mockEarningRepo.On("FindActive", mock.Anything).Return([]models.EarningRule{}, nil)
+
+	err := service.UseVoucher(userID, voucherCode, "INV-1", transactionAmount, money.IDR, paymentMethod, area)
+
+	mockVoucherRepo.AssertExpectations(t)
+	mockPointsRepo.AssertExpectations(t)
+	mockEarningRepo.AssertExpectations(t)
+
+	assert.NoError(t, err)
+}
+
+func TestVoucherService_UseVoucher_QuotaExceeded(t *testing.T) {
+	mockVoucherRepo := new(MockVoucherRepository)
+
+	mockRepo := &repository.Repository{
+		Voucher: mockVoucherRepo,
+	}
+
+	logger := zap.NewNop()
+	service := service.NewVoucherService(*mockRepo, logger, money.NewRates(money.IDR, nil))
+
+	userID := 1
+	voucherCode := "VOUCHER1"
+	transactionAmount := money.New(100)
+	paymentMethod := "credit"
+	area := "area1"
+
+	mockVoucherRepo.On("UseVoucher", userID, voucherCode, "INV-1", area, transactionAmount, money.IDR, paymentMethod, mock.Anything, mock.Anything).
+		Return(nil, repository.ErrQuotaExceeded)
+
+	err := service.UseVoucher(userID, voucherCode, "INV-1", transactionAmount, money.IDR, paymentMethod, area)
+
+	mockVoucherRepo.AssertExpectations(t)
+
+	assert.ErrorIs(t, err, repository.ErrQuotaExceeded)
+	assert.Equal(t, "voucher quota exceeded", err.Error())
+}
+
+func TestHistoryService_GetRedeemHistoryByUser(t *testing.T) {
+	mockRedeemRepo := new(MockRedeemRepository)
+	mockHistoryRepo := new(MockHistoryRepository)
+
+	mockRepo := &repository.Repository{
+		Redeem:  mockRedeemRepo,
+		History: mockHistoryRepo,
+	}
+
+	logger := zap.NewNop()
+	service := service.NewHistoryService(*mockRepo, logger)
+
+	userID := 1
+	mockRedeemHistory := []models.Redeem{
+		{ID: 1, VoucherID: 1, UserID: userID},
+	}
+
+	mockRedeemRepo.On("FindRedeemHistoryByUser", userID).Return(mockRedeemHistory, nil)
+
+	history, err := service.GetRedeemHistoryByUser(userID)
+
+	mockRedeemRepo.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, 1, history[0].VoucherID)
+}
+
+func TestHistoryService_GetUsageHistoryByUser(t *testing.T) {
+	mockRedeemRepo := new(MockRedeemRepository)
+	mockHistoryRepo := new(MockHistoryRepository)
+
+	mockRepo := &repository.Repository{
+		Redeem:  mockRedeemRepo,
+		History: mockHistoryRepo,
+	}
+
+	logger := zap.NewNop()
+	service := service.NewHistoryService(*mockRepo, logger)
+
+	userID := 1
+	mockUsageHistory := []models.History{
+		{ID: 1, UserID: userID, VoucherID: 1, UsageDate: time.Now()},
+	}
+
+	mockHistoryRepo.On("FindUsageHistoryByUser", userID).Return(mockUsageHistory, nil)
+
+	history, err := service.GetUsageHistoryByUser(userID)
+
+	mockHistoryRepo.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, 1, history[0].VoucherID)
+}
+
+func TestHistoryService_GetUsersByVoucherCode(t *testing.T) {
+	mockRedeemRepo := new(MockRedeemRepository)
+	mockHistoryRepo := new(MockHistoryRepository)
+
+	mockRepo := &repository.Repository{
+		Redeem:  mockRedeemRepo,
+		History: mockHistoryRepo,
+	}
+
+	logger := zap.NewNop()
+	service := service.NewHistoryService(*mockRepo, logger)
+
+	voucherCode := "VOUCHER1"
+	mockUsers := []models.Redeem{
+		{ID: 1, UserID: 1, VoucherID: 1, RedeemDate: time.Now()},
+	}
+
+	mockRedeemRepo.On("FindUsersByVoucherCode", voucherCode).Return(mockUsers, nil)
+
+	users, err := service.GetUsersByVoucherCode(voucherCode)
+
+	mockRedeemRepo.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, 1, users[0].VoucherID)
+	assert.Equal(t, 1, users[0].UserID)
+}
+
+func TestHistoryService_ReverseUsage(t *testing.T) {
+	mockHistoryRepo := new(MockHistoryRepository)
+
+	mockRepo := &repository.Repository{
+		History: mockHistoryRepo,
+	}
+
+	logger := zap.NewNop()
+	service := service.NewHistoryService(*mockRepo, logger)
+
+	reversedAt := time.Now()
+	mockHistoryRepo.On("Reverse", mock.MatchedBy(func(r repository.UsageReversal) bool {
+		return r.OrderID == "INV-1" && r.UserID == 1 && r.RestoreQuota && !r.At.IsZero()
+	})).Return(&models.History{ID: 15, OrderID: "INV-1", ReversedAt: &reversedAt, QuotaRestored: true}, nil)
+
+	history, err := service.ReverseUsage(repository.UsageReversal{OrderID: "INV-1", UserID: 1, RestoreQuota: true})
+
+	mockHistoryRepo.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.NotNil(t, history.ReversedAt)
+}
+
+func TestHistoryService_ReverseUsage_NoUsage(t *testing.T) {
+	mockHistoryRepo := new(MockHistoryRepository)
+
+	mockRepo := &repository.Repository{
+		History: mockHistoryRepo,
+	}
+
+	logger := zap.NewNop()
+	historyService := service.NewHistoryService(*mockRepo, logger)
+
+	_, err := historyService.ReverseUsage(repository.UsageReversal{Reason: "cancelled"})
+	assert.ErrorIs(t, err, service.ErrNoUsageToReverse)
+
+	_, err = historyService.ReverseUsage(repository.UsageReversal{OrderID: "INV-1", Reason: "cancelled"})
+	assert.ErrorIs(t, err, service.ErrNoUsageToReverse)
+
+	mockHistoryRepo.AssertNotCalled(t, "Reverse", mock.Anything)
+}
@@ -1,27 +1,27 @@
-package utils
-
-import (
-	"crypto/rand"
-	"encoding/hex"
-
-	"golang.org/x/crypto/bcrypt"
-)
-
-func CheckPassword(inputPassword, storedPassword string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(inputPassword))
-	return err == nil
-}
-
-func HashPassword(password string) string {
-	bytes, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes)
-}
-
-func GenerateToken() string {
-	token := make([]byte, 32)
-	_, err := rand.Read(token)
-	if err != nil {
-		panic("failed to generate token")
-	}
-	return hex.EncodeToString(token)
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func CheckPassword(inputPassword, storedPassword string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(inputPassword))
+	return err == nil
+}
+
+func HashPassword(password string) string {
+	bytes, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(bytes)
+}
+
+func GenerateToken() string {
+	token := make([]byte, 32)
+	_, err := rand.Read(token)
+	if err != nil {
+		panic("failed to generate token")
+	}
+	return hex.EncodeToString(token)
 }
\ No newline at end of file
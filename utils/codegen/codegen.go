@@ -0,0 +1,155 @@
+package codegen
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// DefaultAlphabet leaves out characters that are easily mistaken for one another when
+// codes are printed or read out: 0/O, 1/I/L and U/V.
+const DefaultAlphabet = "23456789ABCDEFGHJKMNPQRSTWXYZ"
+
+// MaxLength is the longest random part a Format may have.
+const MaxLength = 32
+
+var (
+	ErrInvalidFormat = errors.New("codegen: invalid code format")
+	// ErrSpaceTooSmall means the format cannot produce enough distinct codes to generate
+	// the requested number without constant collisions.
+	ErrSpaceTooSmall = errors.New("codegen: code format has too few combinations")
+)
+
+// Format describes the codes of a batch: Prefix followed by Length characters drawn from
+// Alphabet and, with CheckDigit set, one more character computed from them with the
+// Luhn mod N algorithm so that any single mistyped character and most swaps of two
+// adjacent characters are detected.
+type Format struct {
+	Alphabet   string
+	Length     int
+	Prefix     string
+	CheckDigit bool
+}
+
+// WithDefaults returns f with DefaultAlphabet filled in when no alphabet is set.
+func (f Format) WithDefaults() Format {
+	if f.Alphabet == "" {
+		f.Alphabet = DefaultAlphabet
+	}
+	return f
+}
+
+// Validate checks that the alphabet has at least two distinct printable characters and
+// that the length is within bounds.
+func (f Format) Validate() error {
+	if len(f.Alphabet) < 2 {
+		return fmt.Errorf("%w: alphabet needs at least 2 characters", ErrInvalidFormat)
+	}
+	seen := make(map[rune]bool, len(f.Alphabet))
+	for _, c := range f.Alphabet {
+		if c <= ' ' || c > '~' {
+			return fmt.Errorf("%w: alphabet may only contain printable ASCII characters", ErrInvalidFormat)
+		}
+		if seen[c] {
+			return fmt.Errorf("%w: alphabet repeats %q", ErrInvalidFormat, c)
+		}
+		seen[c] = true
+	}
+	if f.Length < 1 || f.Length > MaxLength {
+		return fmt.Errorf("%w: length must be between 1 and %d", ErrInvalidFormat, MaxLength)
+	}
+	for _, c := range f.Prefix {
+		if c <= ' ' || c > '~' {
+			return fmt.Errorf("%w: prefix may only contain printable ASCII characters", ErrInvalidFormat)
+		}
+	}
+	return nil
+}
+
+// Fits reports whether the format has room for count codes. At least ten times as many
+// combinations as codes are required so random draws rarely collide.
+func (f Format) Fits(count int) bool {
+	space := new(big.Int).Exp(big.NewInt(int64(len(f.Alphabet))), big.NewInt(int64(f.Length)), nil)
+	return space.Cmp(new(big.Int).Mul(big.NewInt(int64(count)), big.NewInt(10))) >= 0
+}
+
+// Generate returns count distinct random codes in the format. Codes generated by
+// separate calls may still collide and have to be checked against the ones stored.
+func (f Format) Generate(count int) ([]string, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	if !f.Fits(count) {
+		return nil, ErrSpaceTooSmall
+	}
+
+	size := big.NewInt(int64(len(f.Alphabet)))
+	seen := make(map[string]bool, count)
+	codes := make([]string, 0, count)
+	payload := make([]byte, f.Length)
+	for len(codes) < count {
+		for i := range payload {
+			n, err := rand.Int(rand.Reader, size)
+			if err != nil {
+				return nil, err
+			}
+			payload[i] = f.Alphabet[n.Int64()]
+		}
+
+		code := f.Prefix + string(payload)
+		if f.CheckDigit {
+			code += string(checkChar(f.Alphabet, payload))
+		}
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// Valid reports whether code has the prefix, length, alphabet and check character of the
+// format. It does not say whether the code was ever generated.
+func (f Format) Valid(code string) bool {
+	if !strings.HasPrefix(code, f.Prefix) {
+		return false
+	}
+	rest := code[len(f.Prefix):]
+
+	want := f.Length
+	if f.CheckDigit {
+		want++
+	}
+	if len(rest) != want {
+		return false
+	}
+	for i := 0; i < len(rest); i++ {
+		if strings.IndexByte(f.Alphabet, rest[i]) < 0 {
+			return false
+		}
+	}
+	if f.CheckDigit {
+		return checkChar(f.Alphabet, []byte(rest[:f.Length])) == rest[f.Length]
+	}
+	return true
+}
+
+// checkChar computes the Luhn mod N check character of payload over alphabet.
+func checkChar(alphabet string, payload []byte) byte {
+	n := len(alphabet)
+	factor := 2
+	sum := 0
+	for i := len(payload) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(alphabet, payload[i])
+		sum += addend/n + addend%n
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+	}
+	return alphabet[(n-sum%n)%n]
+}
@@ -0,0 +1,57 @@
+package codegen_test
+
+import (
+	"strings"
+	"testing"
+	"voucher_system/utils/codegen"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	format := codegen.Format{Alphabet: "ABCDEFGH", Length: 6, Prefix: "SUMMER-", CheckDigit: true}
+
+	codes, err := format.Generate(500)
+
+	assert.NoError(t, err)
+	assert.Len(t, codes, 500)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		assert.True(t, strings.HasPrefix(code, "SUMMER-"), code)
+		assert.Len(t, code, len("SUMMER-")+7)
+		assert.True(t, format.Valid(code), code)
+		assert.False(t, seen[code], "duplicate code %s", code)
+		seen[code] = true
+	}
+}
+
+func TestGenerateRejectsSmallSpace(t *testing.T) {
+	_, err := codegen.Format{Alphabet: "AB", Length: 3}.Generate(2)
+	assert.ErrorIs(t, err, codegen.ErrSpaceTooSmall)
+
+	_, err = codegen.Format{Alphabet: "AAB", Length: 8}.Generate(1)
+	assert.ErrorIs(t, err, codegen.ErrInvalidFormat)
+
+	_, err = codegen.Format{Alphabet: "AB", Length: 0}.Generate(1)
+	assert.ErrorIs(t, err, codegen.ErrInvalidFormat)
+}
+
+func TestCheckDigit(t *testing.T) {
+	// Luhn mod 10 over the digits gives the familiar Luhn check digit.
+	format := codegen.Format{Alphabet: "0123456789", Length: 10, CheckDigit: true}
+	assert.True(t, format.Valid("79927398713"))
+	assert.False(t, format.Valid("79927398710"))
+
+	// A changed character and a swap of adjacent characters are detected.
+	assert.False(t, format.Valid("79927398723"))
+	assert.False(t, format.Valid("97927398713"))
+
+	assert.False(t, format.Valid("7992739871"))
+	assert.False(t, format.Valid("7992739871X"))
+}
+
+func TestWithDefaults(t *testing.T) {
+	format := codegen.Format{Length: 8}.WithDefaults()
+	assert.Equal(t, codegen.DefaultAlphabet, format.Alphabet)
+	assert.NoError(t, format.Validate())
+}
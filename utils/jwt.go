@@ -1,32 +1,86 @@
-package utils
-
-import (
-	"errors"
-	"time"
-	"voucher_system/config"
-
-	"github.com/golang-jwt/jwt/v4"
-)
-
-var JwtKey []byte
-
-// InitJwtKey membaca JWT_KEY dari environment sekali saja
-func InitJwtKey(config config.Configuration) error {
-	key := config.JwtKey
-	if key == "" {
-		return errors.New("JWT_KEY is not set in the environment")
-	}
-	JwtKey = []byte(key)
-	return nil
-}
-
-func GenerateJWT(userID int) (string, error) {
-	expirationTime := time.Now().Add(15 * time.Minute)
-	claims := &jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(expirationTime),
-		Subject:   string(rune(userID)),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(JwtKey)
-}
\ No newline at end of file
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"voucher_system/config"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var JwtKey []byte
+
+// InitJwtKey membaca JWT_KEY dari environment sekali saja
+func InitJwtKey(config config.Configuration) error {
+	key := config.JwtKey
+	if key == "" {
+		return errors.New("JWT_KEY is not set in the environment")
+	}
+	JwtKey = []byte(key)
+	return nil
+}
+
+// GenerateJWT issues an access token for userID with the configured token format.
+// The name is kept from when JWT was the only format.
+func GenerateJWT(userID int) (string, error) {
+	return tokenIssuer.Issue(NewTokenClaims(userID, time.Now()))
+}
+
+// jwtProvider signs and verifies HS256 JWTs with JwtKey.
+type jwtProvider struct{}
+
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+}
+
+func (jwtProvider) Format() string {
+	return TokenFormatJWT
+}
+
+func (jwtProvider) Owns(token string) bool {
+	return strings.HasPrefix(token, "eyJ") && strings.Count(token, ".") == 2
+}
+
+func (jwtProvider) Issue(claims TokenClaims) (string, error) {
+	registered := &jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(claims.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(claims.ExpiresAt),
+			Subject:   claims.Subject,
+		},
+	}
+	if !claims.AuthTime.IsZero() {
+		registered.AuthTime = jwt.NewNumericDate(claims.AuthTime)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, registered)
+	return token.SignedString(JwtKey)
+}
+
+func (jwtProvider) Verify(tokenString string) (*TokenClaims, error) {
+	claims := &jwtClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return JwtKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.ExpiresAt == nil {
+		return nil, errors.New("invalid token claims")
+	}
+
+	result := &TokenClaims{Subject: claims.Subject, ExpiresAt: claims.ExpiresAt.Time}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = claims.IssuedAt.Time
+	}
+	if claims.AuthTime != nil {
+		result.AuthTime = claims.AuthTime.Time
+	}
+	return result, nil
+}
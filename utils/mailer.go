@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"voucher_system/config"
+)
+
+var ErrMailerNotConfigured = errors.New("mail sender is not configured")
+
+// Mailer sends plain text emails.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// NewMailer returns an SMTP mailer for cfg. Without a host every Send fails with
+// ErrMailerNotConfigured, so messages are never silently dropped.
+func NewMailer(cfg config.MailConfig) Mailer {
+	if cfg.Host == "" {
+		return unconfiguredMailer{}
+	}
+	return smtpMailer{cfg: cfg}
+}
+
+type unconfiguredMailer struct{}
+
+func (unconfiguredMailer) Send(to, subject, body string) error {
+	return ErrMailerNotConfigured
+}
+
+type smtpMailer struct {
+	cfg config.MailConfig
+}
+
+func (m smtpMailer) Send(to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return fmt.Errorf("invalid mail header")
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	msg := "From: " + m.cfg.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+	return smtp.SendMail(net.JoinHostPort(m.cfg.Host, m.cfg.Port), auth, m.cfg.From, []string{to}, []byte(msg))
+}
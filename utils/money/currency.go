@@ -0,0 +1,63 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnsupportedCurrency = errors.New("money: unsupported currency")
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const (
+	IDR Currency = "IDR"
+	SGD Currency = "SGD"
+	MYR Currency = "MYR"
+)
+
+// DefaultCurrency is the currency amounts are in when nothing else is said.
+const DefaultCurrency = IDR
+
+var minorUnits = map[Currency]int32{
+	IDR: 2,
+	SGD: 2,
+	MYR: 2,
+}
+
+// ParseCurrency reads a currency code case-insensitively. An empty code is the
+// DefaultCurrency.
+func ParseCurrency(code string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if c == "" {
+		return DefaultCurrency, nil
+	}
+	if !c.IsSupported() {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedCurrency, code)
+	}
+	return c, nil
+}
+
+// IsSupported reports whether vouchers and transactions can be in c.
+func (c Currency) IsSupported() bool {
+	_, ok := minorUnits[c]
+	return ok
+}
+
+// OrDefault returns c, or DefaultCurrency when c is empty.
+func (c Currency) OrDefault() Currency {
+	if c == "" {
+		return DefaultCurrency
+	}
+	return c
+}
+
+// MinorUnits returns the number of fractional digits amounts in c are rounded to. Unknown
+// currencies use two.
+func (c Currency) MinorUnits() int32 {
+	if units, ok := minorUnits[c]; ok {
+		return units
+	}
+	return 2
+}
@@ -0,0 +1,313 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of fractional digits an Amount keeps.
+const Scale = 4
+
+var (
+	ErrInvalidAmount = errors.New("money: invalid amount")
+	ErrTooPrecise    = errors.New("money: amount has more than 4 decimal places")
+	ErrOverflow      = errors.New("money: amount out of range")
+)
+
+// Amount is a fixed-point decimal number with Scale fractional digits. The zero value is
+// zero; use New or Parse to build other amounts.
+//
+// Amounts are written to JSON as plain numbers and to numeric columns as exact
+// decimal strings, so no value ever passes through a float.
+type Amount struct {
+	units int64
+}
+
+const unit = 10000
+
+// RoundingMode decides what happens to the digits dropped when rounding.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds to the nearest value and ties away from zero.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds to the nearest value and ties to the even neighbour.
+	RoundHalfEven
+	// RoundDown rounds towards zero.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+)
+
+// New returns an Amount of whole units.
+func New(units int64) Amount {
+	return Amount{units * unit}
+}
+
+// decimalPattern matches plain decimal numbers with an optional short exponent. It keeps
+// out the fractions, hexadecimal and huge exponents big.Rat would otherwise accept.
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d{1,2})?$`)
+
+// Parse reads a decimal number such as "250000", "12.5" or "1e3". It fails rather than
+// round when s has more than Scale fractional digits.
+func Parse(s string) (Amount, error) {
+	trimmed := strings.TrimSpace(s)
+	if !decimalPattern.MatchString(trimmed) {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	r, ok := new(big.Rat).SetString(trimmed)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(unit))
+	if !r.IsInt() {
+		return Amount{}, fmt.Errorf("%w: %q", ErrTooPrecise, s)
+	}
+	return fromBig(r.Num())
+}
+
+// MustParse is like Parse but panics on invalid input. It is meant for constants and
+// seed data.
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// String formats the amount without trailing zeros, e.g. "10", "12.5" or "-0.25".
+func (a Amount) String() string {
+	sign := ""
+	v := uint64(a.units)
+	if a.units < 0 {
+		sign = "-"
+		v = uint64(-a.units)
+	}
+	whole, frac := v/unit, v%unit
+	if frac == 0 {
+		return sign + strconv.FormatUint(whole, 10)
+	}
+	digits := strings.TrimRight(fmt.Sprintf("%04d", frac), "0")
+	return sign + strconv.FormatUint(whole, 10) + "." + digits
+}
+
+// StringFixed formats the amount with exactly places fractional digits, rounding half up
+// when it has more. An amount too close to the limit to round is printed unrounded.
+func (a Amount) StringFixed(places int32) string {
+	rounded, err := a.Round(places, RoundHalfUp)
+	if err != nil {
+		rounded = a
+	}
+	s := rounded.String()
+	if places <= 0 {
+		return s
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	return whole + "." + frac + strings.Repeat("0", int(places)-len(frac))
+}
+
+// Add returns a + b.
+func (a Amount) Add(b Amount) Amount {
+	return Amount{a.units + b.units}
+}
+
+// Sub returns a - b.
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{a.units - b.units}
+}
+
+// Cmp returns -1, 0 or +1 depending on whether a is less than, equal to or greater
+// than b.
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a.units < b.units:
+		return -1
+	case a.units > b.units:
+		return 1
+	}
+	return 0
+}
+
+// LessThan reports whether a < b.
+func (a Amount) LessThan(b Amount) bool {
+	return a.units < b.units
+}
+
+// GreaterThan reports whether a > b.
+func (a Amount) GreaterThan(b Amount) bool {
+	return a.units > b.units
+}
+
+// IsZero reports whether a is zero.
+func (a Amount) IsZero() bool {
+	return a.units == 0
+}
+
+// IsPositive reports whether a is above zero.
+func (a Amount) IsPositive() bool {
+	return a.units > 0
+}
+
+// IsNegative reports whether a is below zero.
+func (a Amount) IsNegative() bool {
+	return a.units < 0
+}
+
+// Round returns a rounded to places fractional digits using mode. It returns
+// ErrOverflow when the rounded amount is out of range.
+func (a Amount) Round(places int32, mode RoundingMode) (Amount, error) {
+	if places >= Scale {
+		return a, nil
+	}
+	step := pow10(Scale - places)
+	q := roundQuo(big.NewInt(a.units), step, mode)
+	return fromBig(q.Mul(q, step))
+}
+
+// RoundFor rounds a to the minor unit of currency using mode.
+func (a Amount) RoundFor(currency Currency, mode RoundingMode) (Amount, error) {
+	return a.Round(currency.MinorUnits(), mode)
+}
+
+// Percent returns pct percent of a, rounded once to places fractional digits using mode.
+// It returns ErrOverflow when the result is out of range.
+func (a Amount) Percent(pct Amount, places int32, mode RoundingMode) (Amount, error) {
+	return mulDiv(a, pct, 100, places, mode)
+}
+
+// Mul returns a * b, rounded once to places fractional digits using mode. It returns
+// ErrOverflow when the result is out of range.
+func (a Amount) Mul(b Amount, places int32, mode RoundingMode) (Amount, error) {
+	return mulDiv(a, b, 1, places, mode)
+}
+
+// Quo returns how many whole times b fits into a, rounded towards zero. It panics when b
+// is zero.
+func (a Amount) Quo(b Amount) int64 {
+	return a.units / b.units
+}
+
+// Min returns the smaller of a and b.
+func Min(a, b Amount) Amount {
+	if a.LessThan(b) {
+		return a
+	}
+	return b
+}
+
+// Max returns the larger of a and b.
+func Max(a, b Amount) Amount {
+	if a.GreaterThan(b) {
+		return a
+	}
+	return b
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts both JSON numbers and decimal strings.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value stores the amount as an exact decimal string, which numeric columns accept
+// without loss.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+func (a *Amount) Scan(src interface{}) error {
+	var err error
+	switch v := src.(type) {
+	case nil:
+		*a = Amount{}
+	case int64:
+		*a = New(v)
+	case float64:
+		*a, err = Parse(strconv.FormatFloat(v, 'f', Scale, 64))
+	case []byte:
+		*a, err = Parse(string(v))
+	case string:
+		*a, err = Parse(v)
+	default:
+		err = fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+	return err
+}
+
+// mulDiv returns a * b / div rounded to places fractional digits using mode.
+func mulDiv(a, b Amount, div int64, places int32, mode RoundingMode) (Amount, error) {
+	if places > Scale {
+		places = Scale
+	}
+	n := new(big.Int).Mul(big.NewInt(a.units), big.NewInt(b.units))
+	n.Mul(n, pow10(places))
+	d := new(big.Int).Mul(big.NewInt(div*unit), pow10(Scale))
+	q := roundQuo(n, d, mode)
+	return fromBig(q.Mul(q, pow10(Scale-places)))
+}
+
+// roundQuo divides n by d and rounds the quotient to an integer using mode.
+func roundQuo(n, d *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	away := false
+	switch mode {
+	case RoundUp:
+		away = true
+	case RoundDown:
+		away = false
+	default:
+		cmp := new(big.Int).Abs(r)
+		cmp.Lsh(cmp, 1)
+		switch cmp.Cmp(new(big.Int).Abs(d)) {
+		case 1:
+			away = true
+		case 0:
+			away = mode == RoundHalfUp || q.Bit(0) == 1
+		}
+	}
+
+	if away {
+		if n.Sign()*d.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func fromBig(v *big.Int) (Amount, error) {
+	if !v.IsInt64() {
+		return Amount{}, ErrOverflow
+	}
+	return Amount{v.Int64()}, nil
+}
@@ -0,0 +1,127 @@
+package money_test
+
+import (
+	"encoding/json"
+	"testing"
+	"voucher_system/utils/money"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAndString(t *testing.T) {
+	cases := map[string]string{
+		"250000":    "250000",
+		"12.50":     "12.5",
+		"0.0001":    "0.0001",
+		"-3.25":     "-3.25",
+		"1e3":       "1000",
+		"200000.00": "200000",
+	}
+	for in, want := range cases {
+		amount, err := money.Parse(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, amount.String(), in)
+	}
+
+	assert.Equal(t, "50.00", money.New(50).StringFixed(2))
+	assert.Equal(t, "12.35", money.MustParse("12.345").StringFixed(2))
+
+	_, err := money.Parse("0.00001")
+	assert.ErrorIs(t, err, money.ErrTooPrecise)
+	for _, in := range []string{"ten", "1/3", "0x10", "1e1000000", ""} {
+		_, err = money.Parse(in)
+		assert.ErrorIs(t, err, money.ErrInvalidAmount, in)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	huge := money.MustParse("900000000000000")
+
+	_, err := huge.Mul(huge, 2, money.RoundHalfUp)
+	assert.ErrorIs(t, err, money.ErrOverflow)
+	_, err = huge.Percent(money.New(1000), 2, money.RoundHalfUp)
+	assert.ErrorIs(t, err, money.ErrOverflow)
+	_, err = money.MustParse("922337203685477.5807").Round(2, money.RoundUp)
+	assert.ErrorIs(t, err, money.ErrOverflow)
+
+	_, err = money.NewRates(money.IDR, map[money.Currency]money.Amount{money.SGD: money.New(11750)}).Convert(huge, money.SGD)
+	assert.ErrorIs(t, err, money.ErrOverflow)
+}
+
+func TestRound(t *testing.T) {
+	cases := []struct {
+		amount string
+		mode   money.RoundingMode
+		want   string
+	}{
+		{"2.345", money.RoundHalfUp, "2.35"},
+		{"2.345", money.RoundHalfEven, "2.34"},
+		{"2.355", money.RoundHalfEven, "2.36"},
+		{"2.349", money.RoundDown, "2.34"},
+		{"2.341", money.RoundUp, "2.35"},
+		{"-2.345", money.RoundHalfUp, "-2.35"},
+		{"-2.341", money.RoundDown, "-2.34"},
+	}
+	for _, tc := range cases {
+		got, err := money.MustParse(tc.amount).Round(2, tc.mode)
+		assert.NoError(t, err, tc.amount)
+		assert.Equal(t, money.MustParse(tc.want), got, tc.amount)
+	}
+}
+
+func TestPercentRoundsOnce(t *testing.T) {
+	// 15% of 33.33 is 4.9995, which must round to 5 and not drift.
+	percent, err := money.MustParse("33.33").Percent(money.New(15), 2, money.RoundHalfUp)
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(5), percent)
+	// 0.1 + 0.2 style drift does not exist in fixed-point.
+	assert.Equal(t, money.MustParse("0.3"), money.MustParse("0.1").Add(money.MustParse("0.2")))
+	percent, err = money.New(250000).Percent(money.New(10), 2, money.RoundHalfUp)
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(25000), percent)
+}
+
+func TestJSONAndSQL(t *testing.T) {
+	var body struct {
+		Amount money.Amount `json:"amount"`
+		Quoted money.Amount `json:"quoted"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": 250000.75, "quoted": "12.5"}`), &body))
+	assert.Equal(t, money.MustParse("250000.75"), body.Amount)
+	assert.Equal(t, money.MustParse("12.5"), body.Quoted)
+
+	out, err := json.Marshal(body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount": 250000.75, "quoted": 12.5}`, string(out))
+
+	value, err := body.Amount.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "250000.75", value)
+
+	var scanned money.Amount
+	assert.NoError(t, scanned.Scan([]byte("99999999.99")))
+	assert.Equal(t, money.MustParse("99999999.99"), scanned)
+	assert.NoError(t, scanned.Scan(10.5))
+	assert.Equal(t, money.MustParse("10.5"), scanned)
+}
+
+func TestRatesConvert(t *testing.T) {
+	rates, err := money.ParseRates("idr", []string{"SGD=11750.5", "MYR=3550"})
+	assert.NoError(t, err)
+
+	converted, err := rates.Convert(money.MustParse("12.34"), money.SGD)
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("145001.17"), converted)
+
+	converted, err = rates.Convert(money.New(250000), money.IDR)
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(250000), converted)
+
+	_, err = money.NewRates(money.IDR, nil).Convert(money.New(10), money.MYR)
+	assert.ErrorIs(t, err, money.ErrNoRate)
+
+	_, err = money.ParseRates("IDR", []string{"USD=15000"})
+	assert.ErrorIs(t, err, money.ErrUnsupportedCurrency)
+	_, err = money.ParseRates("IDR", []string{"SGD"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrNoRate = errors.New("money: no exchange rate")
+
+// Rates is a static exchange rate table used to report amounts in one base currency.
+// Each rate is how much of the base currency one unit of the other currency is worth.
+type Rates struct {
+	Base  Currency
+	rates map[Currency]Amount
+}
+
+// NewRates returns a table converting into base.
+func NewRates(base Currency, rates map[Currency]Amount) Rates {
+	table := Rates{Base: base.OrDefault(), rates: map[Currency]Amount{}}
+	for currency, rate := range rates {
+		table.rates[currency] = rate
+	}
+	return table
+}
+
+// ParseRates builds a table from items such as "SGD=11750.5". An empty list gives a
+// table that only knows the base currency.
+func ParseRates(base string, items []string) (Rates, error) {
+	baseCurrency, err := ParseCurrency(base)
+	if err != nil {
+		return Rates{}, err
+	}
+
+	rates := map[Currency]Amount{}
+	for _, item := range items {
+		code, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return Rates{}, fmt.Errorf("money: invalid exchange rate %q, want CODE=RATE", item)
+		}
+		currency, err := ParseCurrency(code)
+		if err != nil {
+			return Rates{}, err
+		}
+		rate, err := Parse(value)
+		if err != nil {
+			return Rates{}, err
+		}
+		if !rate.IsPositive() {
+			return Rates{}, fmt.Errorf("money: exchange rate for %s must be above zero", currency)
+		}
+		rates[currency] = rate
+	}
+	return NewRates(baseCurrency, rates), nil
+}
+
+// Convert returns amount in from as the base currency, rounded half up to its minor
+// unit.
+func (r Rates) Convert(amount Amount, from Currency) (Amount, error) {
+	from = from.OrDefault()
+	if from == r.Base.OrDefault() {
+		return amount, nil
+	}
+	rate, ok := r.rates[from]
+	if !ok {
+		return Amount{}, fmt.Errorf("%w for %s", ErrNoRate, from)
+	}
+	return amount.Mul(rate, r.Base.OrDefault().MinorUnits(), RoundHalfUp)
+}
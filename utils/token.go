@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+	"voucher_system/config"
+)
+
+const (
+	TokenFormatJWT          = "jwt"
+	TokenFormatPasetoPublic = "paseto-v4-public"
+	TokenFormatPasetoLocal  = "paseto-v4-local"
+)
+
+// AccessTokenTTL is how long an issued access token stays valid.
+const AccessTokenTTL = 15 * time.Minute
+
+// TokenClaims is the format independent content of an access token.
+// AuthTime is when the user last proved their password, used for step-up checks.
+type TokenClaims struct {
+	Subject   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	AuthTime  time.Time
+}
+
+// NewTokenClaims builds the claims of an access token for userID issued at now,
+// right after the user authenticated with their password.
+func NewTokenClaims(userID int, now time.Time) TokenClaims {
+	return TokenClaims{
+		Subject:   strconv.Itoa(userID),
+		IssuedAt:  now,
+		ExpiresAt: now.Add(AccessTokenTTL),
+		AuthTime:  now,
+	}
+}
+
+// TokenProvider issues and verifies access tokens of one format.
+type TokenProvider interface {
+	Format() string
+	// Owns reports whether token looks like it was produced by this format.
+	Owns(token string) bool
+	Issue(claims TokenClaims) (string, error)
+	Verify(token string) (*TokenClaims, error)
+}
+
+var (
+	tokenIssuer    TokenProvider   = jwtProvider{}
+	tokenVerifiers []TokenProvider = []TokenProvider{jwtProvider{}}
+)
+
+// InitTokenProvider selects the issuing format and the formats accepted by VerifyToken.
+// Accepting more than one format allows migrating clients without forcing a re-login.
+// The issuing format defaults to JWT and the accepted formats to the issuing one; an
+// issuing format that is not accepted is an error, as its tokens could never be used.
+func InitTokenProvider(cfg config.Configuration) error {
+	format := cfg.TokenConfig.Format
+	if format == "" {
+		format = TokenFormatJWT
+	}
+
+	accepted := cfg.TokenConfig.AcceptFormats
+	if len(accepted) == 0 {
+		accepted = []string{format}
+	}
+	if !slices.Contains(accepted, format) {
+		return fmt.Errorf("token format %q is not one of the accepted formats %v", format, accepted)
+	}
+
+	providers := map[string]TokenProvider{}
+	for _, f := range append([]string{format}, accepted...) {
+		if _, ok := providers[f]; ok {
+			continue
+		}
+		provider, err := newTokenProvider(f, cfg)
+		if err != nil {
+			return err
+		}
+		providers[f] = provider
+	}
+
+	verifiers := make([]TokenProvider, 0, len(accepted))
+	for _, f := range accepted {
+		verifiers = append(verifiers, providers[f])
+	}
+
+	tokenIssuer = providers[format]
+	tokenVerifiers = verifiers
+	return nil
+}
+
+func newTokenProvider(format string, cfg config.Configuration) (TokenProvider, error) {
+	switch format {
+	case TokenFormatJWT:
+		if err := InitJwtKey(cfg); err != nil {
+			return nil, err
+		}
+		return jwtProvider{}, nil
+	case TokenFormatPasetoPublic:
+		return newPasetoPublicProvider(cfg.TokenConfig.PasetoSecretKey, cfg.TokenConfig.PasetoPublicKey)
+	case TokenFormatPasetoLocal:
+		return newPasetoLocalProvider(cfg.TokenConfig.PasetoLocalKey)
+	}
+	return nil, fmt.Errorf("unsupported token format %q", format)
+}
+
+// VerifyToken checks token with the first accepted format that recognises it.
+func VerifyToken(token string) (*TokenClaims, error) {
+	for _, verifier := range tokenVerifiers {
+		if !verifier.Owns(token) {
+			continue
+		}
+		claims, err := verifier.Verify(token)
+		if err != nil {
+			return nil, err
+		}
+		if claims.Subject == "" {
+			return nil, errors.New("token has no subject")
+		}
+		if claims.ExpiresAt.Before(time.Now()) {
+			return nil, errors.New("token has expired")
+		}
+		return claims, nil
+	}
+	return nil, errors.New("token format is not accepted")
+}
@@ -0,0 +1,92 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"voucher_system/config"
+	"voucher_system/utils"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/stretchr/testify/assert"
+)
+
+func tokenConfig(format string, accept ...string) config.Configuration {
+	secretKey := paseto.NewV4AsymmetricSecretKey()
+	return config.Configuration{
+		JwtKey: "test-secret",
+		TokenConfig: config.TokenConfig{
+			Format:          format,
+			AcceptFormats:   accept,
+			PasetoSecretKey: secretKey.ExportHex(),
+			PasetoLocalKey:  paseto.NewV4SymmetricKey().ExportHex(),
+		},
+	}
+}
+
+func TestTokenProviders(t *testing.T) {
+	cases := []struct {
+		format string
+		prefix string
+	}{
+		{utils.TokenFormatJWT, "eyJ"},
+		{utils.TokenFormatPasetoPublic, "v4.public."},
+		{utils.TokenFormatPasetoLocal, "v4.local."},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			assert.NoError(t, utils.InitTokenProvider(tokenConfig(tc.format)))
+
+			token, err := utils.GenerateJWT(42)
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(token, tc.prefix))
+
+			claims, err := utils.VerifyToken(token)
+			assert.NoError(t, err)
+			assert.Equal(t, "42", claims.Subject)
+			assert.WithinDuration(t, time.Now(), claims.AuthTime, time.Minute)
+
+			_, err = utils.VerifyToken(token[:len(token)-2] + "xx")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTokenMigrationWindow(t *testing.T) {
+	cfg := tokenConfig(utils.TokenFormatJWT)
+	assert.NoError(t, utils.InitTokenProvider(cfg))
+	oldToken, err := utils.GenerateJWT(1)
+	assert.NoError(t, err)
+
+	cfg.TokenConfig.Format = utils.TokenFormatPasetoPublic
+	cfg.TokenConfig.AcceptFormats = []string{utils.TokenFormatPasetoPublic, utils.TokenFormatJWT}
+	assert.NoError(t, utils.InitTokenProvider(cfg))
+	newToken, err := utils.GenerateJWT(1)
+	assert.NoError(t, err)
+
+	_, err = utils.VerifyToken(oldToken)
+	assert.NoError(t, err)
+	_, err = utils.VerifyToken(newToken)
+	assert.NoError(t, err)
+
+	cfg.TokenConfig.AcceptFormats = []string{utils.TokenFormatPasetoPublic}
+	assert.NoError(t, utils.InitTokenProvider(cfg))
+	_, err = utils.VerifyToken(oldToken)
+	assert.Error(t, err)
+}
+
+func TestInitTokenProviderRequiresKeys(t *testing.T) {
+	assert.Error(t, utils.InitTokenProvider(config.Configuration{TokenConfig: config.TokenConfig{Format: utils.TokenFormatPasetoLocal}}))
+	assert.Error(t, utils.InitTokenProvider(config.Configuration{TokenConfig: config.TokenConfig{Format: "rot13"}}))
+}
+
+func TestInitTokenProviderRequiresIssuingFormatAccepted(t *testing.T) {
+	cfg := tokenConfig(utils.TokenFormatPasetoPublic, utils.TokenFormatJWT)
+	assert.Error(t, utils.InitTokenProvider(cfg))
+
+	cfg = tokenConfig("", utils.TokenFormatJWT)
+	assert.NoError(t, utils.InitTokenProvider(cfg))
+
+	assert.NoError(t, utils.InitTokenProvider(config.Configuration{JwtKey: "test-secret"}))
+}